@@ -15,9 +15,10 @@ import (
 	"os/signal"
 	"syscall"
 	"time"
+	// 内置时区数据库，精简镜像中没有 zoneinfo 时情侣时区设置也能生效
+	_ "time/tzdata"
 
 	"github.com/gin-gonic/gin"
-	"github.com/robfig/cron/v3"
 )
 
 func main() {
@@ -69,49 +70,30 @@ func main() {
 	defer cancel()
 
 	// Start email queue processor if enabled
+	emailDone := make(chan struct{})
 	if cfg.Email.Enabled {
 		logger.Info("启动邮件队列处理")
-		go serviceFactory.Email().ProcessEmailQueue(ctx)
+		go func() {
+			defer close(emailDone)
+			serviceFactory.Email().ProcessEmailQueue(ctx)
+		}()
+	} else {
+		close(emailDone)
 	}
 
-	// 设置定时任务
-	if cfg.Email.Enabled {
-		logger.Info("设置纪念日和节日提醒定时任务")
-		c := cron.New()
-
-		// 每天早上9点检查纪念日
-		_, err := c.AddFunc("15 16 * * *", func() {
-			logger.Info("执行纪念日检查任务")
-			err := serviceFactory.CoupleReminder().CheckAndSendAnniversaryReminders(context.Background())
-			if err != nil {
-				logger.Error(err, "纪念日检查任务失败")
-			}
-		})
-		if err != nil {
-			logger.Error(err, "添加纪念日检查任务失败")
-		}
-
-		// 每天早上10点检查节日
-		_, err = c.AddFunc("0 10 * * *", func() {
-			logger.Info("执行节日检查任务")
-			err := serviceFactory.CoupleReminder().CheckAndSendFestivalReminders(context.Background())
-			if err != nil {
-				logger.Error(err, "节日检查任务失败")
-			}
-		})
-		if err != nil {
-			logger.Error(err, "添加节日检查任务失败")
-		}
-
-		// 启动定时任务
-		c.Start()
+	// 启动定时任务调度，多个实例通过Redis锁保证每个任务只在一个实例上执行
+	scheduler := serviceFactory.Scheduler()
+	scheduler.Start()
 
-		// 确保在程序退出时停止定时任务
-		defer c.Stop()
-	}
+	// 程序退出时停止调度，并等待正在执行的任务结束
+	defer func() {
+		<-scheduler.Stop().Done()
+	}()
 
 	// Setup Gin router
 	router := gin.New()
+	// 直接把 gin.Context 传给服务层的处理器也能取到请求上下文中的值（如审计信息）
+	router.ContextWithFallback = true
 
 	// Register API routes
 	api.RegisterRoutes(router, serviceFactory, dbConn, cfg)
@@ -139,6 +121,9 @@ func main() {
 	<-quit
 	logger.Info("Shutting down server...")
 
+	// 停止取新的邮件任务，正在发送的邮件会处理完；来不及处理完的任务留在处理中队列，由其他实例重新放回队列
+	cancel()
+
 	// Create timeout context for shutdown
 	ctx, cancel = context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
@@ -148,6 +133,12 @@ func main() {
 		logger.Fatal(err, "Server forced to shutdown")
 	}
 
+	select {
+	case <-emailDone:
+	case <-ctx.Done():
+		logger.Warn("邮件队列未能在超时前停止")
+	}
+
 	// Close database connection
 	if err := sqlDB.Close(); err != nil {
 		logger.Fatal(err, "Error closing database connection")
@@ -1,21 +1,28 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"memoir-api/internal/config"
 	"memoir-api/internal/db"
+	"memoir-api/internal/email"
 	"memoir-api/internal/logger"
 	"memoir-api/internal/models"
+	"memoir-api/internal/repository"
 	"os"
+	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 func main() {
 	// 定义命令行参数
 	var (
-		action = flag.String("action", "", "Migration action: up, down, status")
+		action = flag.String("action", "", "Migration action: up, down, status, grant-admin, revoke-admin, grandfather-verified")
+		email  = flag.String("email", "", "User email for grant-admin / revoke-admin")
+		before = flag.String("before", "", "Cutoff date (2006-01-02) for grandfather-verified, defaults to now")
 		help   = flag.Bool("help", false, "Show help message")
 	)
 	flag.Parse()
@@ -70,6 +77,18 @@ func main() {
 		if err := migrateStatus(dbConn); err != nil {
 			logger.Fatal(err, "Migration status check failed")
 		}
+	case "grant-admin":
+		if err := setUserRole(dbConn, *email, models.RoleAdmin); err != nil {
+			logger.Fatal(err, "Grant admin failed")
+		}
+	case "revoke-admin":
+		if err := setUserRole(dbConn, *email, models.RoleUser); err != nil {
+			logger.Fatal(err, "Revoke admin failed")
+		}
+	case "grandfather-verified":
+		if err := grandfatherVerifiedEmails(dbConn, *before); err != nil {
+			logger.Fatal(err, "Grandfather verified emails failed")
+		}
 	default:
 		fmt.Printf("Unknown action: %s\n", *action)
 		showHelp()
@@ -77,10 +96,40 @@ func main() {
 	}
 }
 
+// auditLogAppendOnlySQL 审计日志表的触发器，任何 UPDATE/DELETE 都会报错
+const auditLogAppendOnlySQL = `
+CREATE OR REPLACE FUNCTION audit_logs_append_only() RETURNS trigger AS $$
+BEGIN
+	RAISE EXCEPTION 'audit_logs is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS audit_logs_append_only ON audit_logs;
+CREATE TRIGGER audit_logs_append_only
+	BEFORE UPDATE OR DELETE ON audit_logs
+	FOR EACH ROW EXECUTE FUNCTION audit_logs_append_only();
+`
+
+// pastCoupleMembersBackfillSQL 根据 previous_couple_id 补写已解除情侣关系的原成员，重复执行不会重复插入
+const pastCoupleMembersBackfillSQL = `
+INSERT INTO past_couple_members (user_id, couple_id, dissolved_at)
+SELECT u.id, u.previous_couple_id, COALESCE(c.dissolved_at, now())
+FROM users u
+JOIN couples c ON c.id = u.previous_couple_id
+WHERE u.previous_couple_id <> 0
+ON CONFLICT DO NOTHING;
+`
+
 // migrateUp 执行数据库迁移（创建/更新表）
 func migrateUp(db *gorm.DB) error {
 	logger.Info("Running database migrations...")
 
+	// 邮箱验证状态是后来加的列，升级时已有的账号在加列之前注册，视为已验证
+	addingEmailVerifiedAt := db.Migrator().HasTable(&models.User{}) &&
+		!db.Migrator().HasColumn(&models.User{}, "EmailVerifiedAt")
+	// 提醒邮件总开关已并入按类型的邮件偏好，旧列还在时需要迁移数据
+	hasReminderEmailOptIn := db.Migrator().HasColumn(&models.User{}, "reminder_email_opt_in")
+
 	// 使用AutoMigrate进行增量迁移（添加表/列，但不删除）
 	if err := db.AutoMigrate(
 		&models.User{},
@@ -95,10 +144,43 @@ func migrateUp(db *gorm.DB) error {
 		&models.TimelineEventLocation{},
 		&models.Attachment{},
 		&models.WishlistAttachment{},
+		&models.MFARecoveryCode{},
+		&models.PersonalAccessToken{},
+		&models.AuditLog{},
+		&models.CoupleAnniversary{},
+		&models.CoupleFestivalSetting{},
+		&models.ReminderDelivery{},
+		&models.JobRun{},
+		&models.Reminder{},
+		&models.EmailLog{},
+		&models.EmailPreference{},
+		&models.PastCoupleMember{},
 	); err != nil {
 		return fmt.Errorf("failed to migrate tables: %w", err)
 	}
 
+	// 审计日志只允许追加，在数据库层面拒绝修改和删除
+	if err := db.Exec(auditLogAppendOnlySQL).Error; err != nil {
+		return fmt.Errorf("failed to protect audit_logs: %w", err)
+	}
+
+	if addingEmailVerifiedAt {
+		if err := markEmailsVerified(db, time.Now()); err != nil {
+			return err
+		}
+	}
+
+	// 之前只在 previous_couple_id 记录最近一次解除的情侣关系，补写到 past_couple_members
+	if err := db.Exec(pastCoupleMembersBackfillSQL).Error; err != nil {
+		return fmt.Errorf("failed to backfill past_couple_members: %w", err)
+	}
+
+	if hasReminderEmailOptIn {
+		if err := migrateReminderEmailOptIn(db); err != nil {
+			return err
+		}
+	}
+
 	logger.Info("Database migrations completed successfully")
 	return nil
 }
@@ -109,6 +191,17 @@ func migrateDown(db *gorm.DB) error {
 
 	// 按依赖关系逆序删除表
 	tables := []interface{}{
+		&models.PastCoupleMember{},
+		&models.EmailPreference{},
+		&models.EmailLog{},
+		&models.Reminder{},
+		&models.JobRun{},
+		&models.ReminderDelivery{},
+		&models.CoupleFestivalSetting{},
+		&models.CoupleAnniversary{},
+		&models.AuditLog{},
+		&models.PersonalAccessToken{},
+		&models.MFARecoveryCode{},
 		&models.WishlistAttachment{},
 		&models.CoupleAlbum{},
 		&models.PersonalMedia{},
@@ -150,6 +243,17 @@ func migrateStatus(db *gorm.DB) error {
 		{&models.Wishlist{}, "wishlists"},
 		{&models.PersonalMedia{}, "personal_media"},
 		{&models.CoupleAlbum{}, "couple_albums"},
+		{&models.MFARecoveryCode{}, "mfa_recovery_codes"},
+		{&models.PersonalAccessToken{}, "personal_access_tokens"},
+		{&models.AuditLog{}, "audit_logs"},
+		{&models.CoupleAnniversary{}, "couple_anniversaries"},
+		{&models.CoupleFestivalSetting{}, "couple_festival_settings"},
+		{&models.ReminderDelivery{}, "reminder_deliveries"},
+		{&models.JobRun{}, "job_runs"},
+		{&models.Reminder{}, "reminders"},
+		{&models.EmailLog{}, "email_logs"},
+		{&models.EmailPreference{}, "email_preferences"},
+		{&models.PastCoupleMember{}, "past_couple_members"},
 	}
 
 	for _, info := range modelInfo {
@@ -163,6 +267,81 @@ func migrateStatus(db *gorm.DB) error {
 	return nil
 }
 
+// grandfatherVerifiedEmails 把截止日期前注册、还没有验证邮箱的账号标记为已验证。
+// 开启 AUTH_REQUIRE_EMAIL_VERIFICATION 前执行，避免老用户被拦截
+func grandfatherVerifiedEmails(db *gorm.DB, before string) error {
+	cutoff := time.Now()
+	if before != "" {
+		parsed, err := time.Parse("2006-01-02", before)
+		if err != nil {
+			return fmt.Errorf("invalid -before date %q: %w", before, err)
+		}
+		cutoff = parsed
+	}
+	return markEmailsVerified(db, cutoff)
+}
+
+// markEmailsVerified 把 cutoff 之前注册、还没有验证邮箱的账号标记为已验证，验证时间记为注册时间
+func markEmailsVerified(db *gorm.DB, cutoff time.Time) error {
+	result := db.Model(&models.User{}).
+		Where("email_verified_at IS NULL AND created_at < ?", cutoff).
+		Update("email_verified_at", gorm.Expr("created_at"))
+	if result.Error != nil {
+		return fmt.Errorf("failed to backfill email_verified_at: %w", result.Error)
+	}
+	logger.Info("Marked existing users as email verified", "count", result.RowsAffected, "before", cutoff)
+	return nil
+}
+
+// migrateReminderEmailOptIn 把 users.reminder_email_opt_in 关闭的用户迁移为各可退订类型的关闭记录，
+// 用户已单独设置过的类型保持不变，迁移完成后删除旧列
+func migrateReminderEmailOptIn(db *gorm.DB) error {
+	var userIDs []int64
+	if err := db.Table("users").Where("reminder_email_opt_in = ?", false).Pluck("id", &userIDs).Error; err != nil {
+		return fmt.Errorf("failed to read reminder_email_opt_in: %w", err)
+	}
+
+	for _, userID := range userIDs {
+		preferences := make([]models.EmailPreference, 0, len(email.OptionalTypes))
+		for _, emailType := range email.OptionalTypes {
+			preferences = append(preferences, models.EmailPreference{
+				UserID:    userID,
+				EmailType: string(emailType),
+				Enabled:   false,
+			})
+		}
+		if err := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&preferences).Error; err != nil {
+			return fmt.Errorf("failed to migrate reminder_email_opt_in for user %d: %w", userID, err)
+		}
+	}
+
+	if err := db.Migrator().DropColumn(&models.User{}, "reminder_email_opt_in"); err != nil {
+		return fmt.Errorf("failed to drop reminder_email_opt_in: %w", err)
+	}
+	logger.Info("Migrated reminder_email_opt_in to email_preferences", "users", len(userIDs))
+	return nil
+}
+
+// setUserRole 设置指定邮箱用户的角色，用于初始化管理员账号
+func setUserRole(db *gorm.DB, email, role string) error {
+	if email == "" {
+		return fmt.Errorf("-email is required")
+	}
+
+	ctx := context.Background()
+	userRepo := repository.NewUserRepository(db)
+	user, err := userRepo.GetByEmail(ctx, email)
+	if err != nil {
+		return fmt.Errorf("failed to find user %s: %w", email, err)
+	}
+	if err := userRepo.SetRole(ctx, user.ID, role); err != nil {
+		return fmt.Errorf("failed to update role: %w", err)
+	}
+
+	logger.Info("User role updated", "email", email, "role", role)
+	return nil
+}
+
 // showHelp 显示帮助信息
 func showHelp() {
 	fmt.Println("Database Migration Tool")
@@ -172,7 +351,11 @@ func showHelp() {
 	fmt.Println()
 	fmt.Println("Options:")
 	fmt.Println("  -action string")
-	fmt.Println("        Migration action: up, down, status (required)")
+	fmt.Println("        Migration action: up, down, status, grant-admin, revoke-admin, grandfather-verified (required)")
+	fmt.Println("  -email string")
+	fmt.Println("        User email, required by grant-admin and revoke-admin")
+	fmt.Println("  -before string")
+	fmt.Println("        Cutoff date (2006-01-02) for grandfather-verified, defaults to now")
 	fmt.Println("  -help")
 	fmt.Println("        Show this help message")
 	fmt.Println()
@@ -180,4 +363,6 @@ func showHelp() {
 	fmt.Println("  go run cmd/migrate/main.go -action=up     # Run migrations")
 	fmt.Println("  go run cmd/migrate/main.go -action=down   # Rollback migrations")
 	fmt.Println("  go run cmd/migrate/main.go -action=status # Check migration status")
+	fmt.Println("  go run cmd/migrate/main.go -action=grant-admin -email=a@b.com # Grant admin role")
+	fmt.Println("  go run cmd/migrate/main.go -action=grandfather-verified -before=2024-06-01 # Treat older accounts as verified")
 }
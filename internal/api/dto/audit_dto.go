@@ -0,0 +1,11 @@
+package dto
+
+// AuditLogQueryRequest 管理员查询审计日志的条件，未填写的条件不参与过滤
+type AuditLogQueryRequest struct {
+	PaginationRequest
+	ActorID    int64  `form:"actor_id"`
+	CoupleID   int64  `form:"couple_id"`
+	Action     string `form:"action"`
+	EntityType string `form:"entity_type"`
+	EntityID   string `form:"entity_id"`
+}
@@ -1,18 +1,22 @@
 package dto
 
+import "time"
+
 // RegisterRequest 注册请求
 type RegisterRequest struct {
-	Username  string `json:"username" binding:"required,min=3,max=50"`
-	Email     string `json:"email" binding:"required,email"`
-	Password  string `json:"password" binding:"required,min=6"`
-	PairToken string `json:"pair_token"`
+	Username   string `json:"username" binding:"required,min=3,max=50"`
+	Email      string `json:"email" binding:"required,email"`
+	Password   string `json:"password" binding:"required,min=6"`
+	PairToken  string `json:"pair_token"`
+	DeviceName string `json:"device_name" binding:"max=100"`
 }
 
 // LoginRequest 登录请求
 type LoginRequest struct {
-	Username string `json:"username"`
-	Email    string `json:"email"`
-	Password string `json:"password" binding:"required"`
+	Username   string `json:"username"`
+	Email      string `json:"email"`
+	Password   string `json:"password" binding:"required"`
+	DeviceName string `json:"device_name" binding:"max=100"`
 }
 
 // TokenResponse 令牌响应
@@ -22,3 +26,63 @@ type TokenResponse struct {
 	ExpiresIn    int64  `json:"expires_in"`
 	TokenType    string `json:"token_type"`
 }
+
+// SessionResponse 登录会话（设备）信息
+type SessionResponse struct {
+	ID         string    `json:"id"`
+	DeviceName string    `json:"device_name"`
+	IP         string    `json:"ip"`
+	UserAgent  string    `json:"user_agent"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	Current    bool      `json:"current"`
+}
+
+// MFAChallengeResponse 需要二次验证时登录接口返回的挑战
+type MFAChallengeResponse struct {
+	MFARequired bool   `json:"mfa_required"`
+	MFAToken    string `json:"mfa_token"`
+	ExpiresIn   int64  `json:"expires_in"`
+}
+
+// MFAVerifyRequest 二次验证请求
+type MFAVerifyRequest struct {
+	MFAToken   string `json:"mfa_token" binding:"required"`
+	Code       string `json:"code" binding:"required"`
+	DeviceName string `json:"device_name" binding:"max=100"`
+}
+
+// MFACodeRequest 提交二次验证码（TOTP验证码或恢复码）
+type MFACodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// MFARecoveryCodesResponse 开启二次验证后返回的恢复码，只会展示一次
+type MFARecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// CreateAccessTokenRequest 创建个人访问令牌请求
+type CreateAccessTokenRequest struct {
+	Name          string   `json:"name" binding:"required,max=100"`
+	Scopes        []string `json:"scopes" binding:"required,min=1"`
+	ExpiresInDays int      `json:"expires_in_days" binding:"omitempty,min=1,max=365"`
+}
+
+// AccessTokenResponse 个人访问令牌信息（不含令牌明文）
+type AccessTokenResponse struct {
+	ID          string     `json:"id"`
+	Name        string     `json:"name"`
+	TokenPrefix string     `json:"token_prefix"`
+	Scopes      []string   `json:"scopes"`
+	ExpiresAt   time.Time  `json:"expires_at"`
+	LastUsedAt  *time.Time `json:"last_used_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// CreatedAccessTokenResponse 创建令牌后的响应，令牌明文只会返回这一次
+type CreatedAccessTokenResponse struct {
+	AccessTokenResponse
+	Token string `json:"token"`
+}
@@ -0,0 +1,100 @@
+package dto
+
+import (
+	"time"
+
+	"memoir-api/internal/models"
+)
+
+// CreateCoupleAnniversaryRequest 创建纪念日请求
+type CreateCoupleAnniversaryRequest struct {
+	Title              string `json:"title" binding:"required,max=100"`
+	Date               string `json:"date" binding:"required,datetime=2006-01-02"`
+	Type               string `json:"type" binding:"omitempty,oneof=together first_meet engagement wedding move_in custom"`
+	Recurrence         string `json:"recurrence" binding:"omitempty,oneof=none yearly monthly"`
+	ReminderDaysBefore int    `json:"reminder_days_before" binding:"omitempty,min=0,max=30"`
+}
+
+// ToModel 将创建请求转换为模型对象，未填写的类型和重复方式使用默认值
+func (r *CreateCoupleAnniversaryRequest) ToModel(coupleID int64) (*models.CoupleAnniversary, error) {
+	date, err := time.Parse("2006-01-02", r.Date)
+	if err != nil {
+		return nil, err
+	}
+
+	anniversary := &models.CoupleAnniversary{
+		CoupleID:           coupleID,
+		Title:              r.Title,
+		Date:               date,
+		Type:               r.Type,
+		Recurrence:         r.Recurrence,
+		ReminderDaysBefore: r.ReminderDaysBefore,
+	}
+	if anniversary.Type == "" {
+		anniversary.Type = models.AnniversaryTypeCustom
+	}
+	if anniversary.Recurrence == "" {
+		anniversary.Recurrence = models.AnniversaryRecurrenceYearly
+	}
+	return anniversary, nil
+}
+
+// UpdateCoupleAnniversaryRequest 更新纪念日请求，未填写的字段保持不变
+type UpdateCoupleAnniversaryRequest struct {
+	Title              *string `json:"title" binding:"omitempty,max=100"`
+	Date               *string `json:"date" binding:"omitempty,datetime=2006-01-02"`
+	Type               *string `json:"type" binding:"omitempty,oneof=together first_meet engagement wedding move_in custom"`
+	Recurrence         *string `json:"recurrence" binding:"omitempty,oneof=none yearly monthly"`
+	ReminderDaysBefore *int    `json:"reminder_days_before" binding:"omitempty,min=0,max=30"`
+}
+
+// ApplyToModel 将更新请求应用到模型对象
+func (r *UpdateCoupleAnniversaryRequest) ApplyToModel(anniversary *models.CoupleAnniversary) error {
+	if r.Title != nil {
+		anniversary.Title = *r.Title
+	}
+	if r.Date != nil {
+		date, err := time.Parse("2006-01-02", *r.Date)
+		if err != nil {
+			return err
+		}
+		anniversary.Date = date
+	}
+	if r.Type != nil {
+		anniversary.Type = *r.Type
+	}
+	if r.Recurrence != nil {
+		anniversary.Recurrence = *r.Recurrence
+	}
+	if r.ReminderDaysBefore != nil {
+		anniversary.ReminderDaysBefore = *r.ReminderDaysBefore
+	}
+	return nil
+}
+
+// CoupleAnniversaryDTO 纪念日响应，附带已经过的天数和下一次的倒计时
+type CoupleAnniversaryDTO struct {
+	ID                 int64  `json:"id,string"`
+	Title              string `json:"title"`
+	Date               string `json:"date"`
+	Type               string `json:"type"`
+	Recurrence         string `json:"recurrence"`
+	ReminderDaysBefore int    `json:"reminder_days_before"`
+	// DaysSince 从纪念日到今天的天数
+	DaysSince int `json:"days_since"`
+	// NextDate 下一次纪念日，不再重复时为空
+	NextDate  string `json:"next_date,omitempty"`
+	DaysUntil *int   `json:"days_until,omitempty"`
+}
+
+// MilestoneDTO 即将到来的纪念日或恋爱天数里程碑
+type MilestoneDTO struct {
+	// AnniversaryID 来自自定义纪念日时不为0，恋爱天数里程碑为0
+	AnniversaryID int64  `json:"anniversary_id,string,omitempty"`
+	Title         string `json:"title"`
+	Type          string `json:"type"`
+	Date          string `json:"date"`
+	DaysUntil     int    `json:"days_until"`
+	// Count 第几周年/第几个月，恋爱天数里程碑为天数
+	Count int `json:"count,omitempty"`
+}
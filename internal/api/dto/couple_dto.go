@@ -16,6 +16,9 @@ type CoupleInfoDTO struct {
 	CoupleName      string `json:"couple_name"`
 	CoupleDays      int    `json:"couple_days"`
 	AnniversaryDate string `json:"anniversary_date"`
+	// Status 情侣关系状态，dissolving 表示处于解除冷静期
+	Status     string     `json:"status"`
+	DissolveAt *time.Time `json:"dissolve_at,omitempty"`
 }
 
 func (r *CreateCoupleRequest) ToCouple() (models.Couple, error) {
@@ -29,3 +32,73 @@ func (r *CreateCoupleRequest) ToCouple() (models.Couple, error) {
 		AnniversaryDate: anniversaryDate,
 	}, nil
 }
+
+// AdminCoupleResponse 管理员查看的情侣关系信息（不包含配对令牌）
+type AdminCoupleResponse struct {
+	ID              int64               `json:"id,string"`
+	AnniversaryDate time.Time           `json:"anniversary_date"`
+	CreatedAt       time.Time           `json:"created_at"`
+	Users           []AdminUserResponse `json:"users"`
+}
+
+// CreateCoupleInviteRequest 生成配对邀请的请求，填写邮箱时会发送邀请邮件
+type CreateCoupleInviteRequest struct {
+	Email string `json:"email" binding:"omitempty,email"`
+}
+
+// JoinCoupleRequest 使用邀请码加入情侣关系的请求
+type JoinCoupleRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// LeaveCoupleRequest 申请解除情侣关系请求
+type LeaveCoupleRequest struct {
+	// Handoff 冷静期结束后共享数据的处理方式：archive 归档只读（默认），copy 复制到双方的个人空间
+	Handoff string `json:"handoff" binding:"omitempty,oneof=archive copy"`
+}
+
+// CoupleMemberSettings 情侣成员各自的设置
+type CoupleMemberSettings struct {
+	UserID   int64  `json:"user_id,string"`
+	Username string `json:"username"`
+	// ReminderEmailOptIn 是否接收全部可退订的提醒邮件，在邮件偏好中关闭任意一类时为 false
+	ReminderEmailOptIn bool `json:"reminder_email_opt_in"`
+}
+
+// CoupleSettingsResponse 情侣设置
+type CoupleSettingsResponse struct {
+	AutoGenerateVideo     bool                   `json:"auto_generate_video"`
+	ReminderNotifications bool                   `json:"reminder_notifications"`
+	AnniversaryDate       string                 `json:"anniversary_date"`
+	Timezone              string                 `json:"timezone"`
+	ReminderHour          int                    `json:"reminder_hour"`
+	Language              string                 `json:"language"`
+	Members               []CoupleMemberSettings `json:"members"`
+}
+
+// UpdateCoupleSettingsRequest 更新情侣设置请求，未填写的字段保持不变
+type UpdateCoupleSettingsRequest struct {
+	AutoGenerateVideo     *bool   `json:"auto_generate_video"`
+	ReminderNotifications *bool   `json:"reminder_notifications"`
+	AnniversaryDate       *string `json:"anniversary_date" binding:"omitempty,datetime=2006-01-02"`
+	Timezone              *string `json:"timezone" binding:"omitempty,max=64"`
+	ReminderHour          *int    `json:"reminder_hour" binding:"omitempty,min=0,max=23"`
+	Language              *string `json:"language" binding:"omitempty,oneof=zh-CN en-US"`
+	// ReminderEmailOptIn 一次开关当前用户全部可退订的提醒邮件，等同于逐个修改邮件偏好
+	ReminderEmailOptIn *bool `json:"reminder_email_opt_in"`
+}
+
+// CoupleFestivalDTO 节日及情侣对它的提醒开关
+type CoupleFestivalDTO struct {
+	Key     string `json:"key"`
+	Name    string `json:"name"`
+	Kind    string `json:"kind"`
+	Enabled bool   `json:"enabled"`
+	// NextDate 下一次节日的公历日期（按情侣时区）
+	NextDate string `json:"next_date,omitempty"`
+}
+
+// UpdateCoupleFestivalRequest 开关某个节日的提醒
+type UpdateCoupleFestivalRequest struct {
+	Enabled *bool `json:"enabled" binding:"required"`
+}
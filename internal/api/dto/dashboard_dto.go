@@ -8,4 +8,8 @@ type DashboardDTO struct {
 	AlbumCount int                `json:"album_count"`
 	CoupleDays int                `json:"couple_days"`
 	Locations  []*models.Location `json:"locations,omitempty"`
+	// Anniversaries 所有自定义纪念日，附带各自经过的天数
+	Anniversaries []CoupleAnniversaryDTO `json:"anniversaries"`
+	// UpcomingMilestones 即将到来的纪念日和恋爱天数里程碑，按倒计时排序
+	UpcomingMilestones []MilestoneDTO `json:"upcoming_milestones"`
 }
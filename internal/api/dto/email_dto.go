@@ -0,0 +1,79 @@
+package dto
+
+import (
+	"memoir-api/internal/models"
+	"time"
+)
+
+// 邮件预览的输出格式
+const (
+	EmailPreviewFormatHTML = "html"
+	EmailPreviewFormatText = "text"
+	EmailPreviewFormatJSON = "json"
+)
+
+// EmailPreviewRequest 邮件模板预览参数，语言为空时使用默认语言，格式为空时输出HTML
+type EmailPreviewRequest struct {
+	Locale string `form:"locale" binding:"omitempty,oneof=zh-CN en-US"`
+	Format string `form:"format" binding:"omitempty,oneof=html text json"`
+}
+
+// EmailLogQueryRequest 管理员查询邮件投递记录的条件，未填写的条件不参与过滤
+type EmailLogQueryRequest struct {
+	PaginationRequest
+	UserID    int64      `form:"user_id"`
+	ToAddress string     `form:"to_address"`
+	Type      string     `form:"type"`
+	Status    string     `form:"status" binding:"omitempty,oneof=queued sent failed dead discarded"`
+	Since     *time.Time `form:"since" time_format:"2006-01-02T15:04:05Z07:00"`
+	Until     *time.Time `form:"until" time_format:"2006-01-02T15:04:05Z07:00"`
+}
+
+// UserEmailLogDTO 用户查看的邮件记录，不包含发送服务的ID和错误详情
+type UserEmailLogDTO struct {
+	ID        int64      `json:"id,string"`
+	Type      string     `json:"type"`
+	ToAddress string     `json:"to_address"`
+	Subject   string     `json:"subject"`
+	Status    string     `json:"status"`
+	CreatedAt time.Time  `json:"created_at"`
+	SentAt    *time.Time `json:"sent_at"`
+	FailedAt  *time.Time `json:"failed_at"`
+}
+
+// UserEmailLogFromModel 从投递记录创建用户查看的DTO
+func UserEmailLogFromModel(log *models.EmailLog) UserEmailLogDTO {
+	return UserEmailLogDTO{
+		ID:        log.ID,
+		Type:      log.Type,
+		ToAddress: log.ToAddress,
+		Subject:   log.Subject,
+		Status:    log.Status,
+		CreatedAt: log.CreatedAt,
+		SentAt:    log.SentAt,
+		FailedAt:  log.FailedAt,
+	}
+}
+
+// EmailPreferenceDTO 某类邮件的接收开关，必要邮件总是开启且不能修改
+type EmailPreferenceDTO struct {
+	Type      string `json:"type"`
+	Enabled   bool   `json:"enabled"`
+	Mandatory bool   `json:"mandatory"`
+}
+
+// UpdateEmailPreferenceRequest 开关某类邮件
+type UpdateEmailPreferenceRequest struct {
+	Enabled *bool `json:"enabled" binding:"required"`
+}
+
+// UnsubscribeRequest 退订请求，令牌可以放在查询参数（邮件客户端一键退订）或请求体中
+type UnsubscribeRequest struct {
+	Token string `form:"token" json:"token"`
+}
+
+// UnsubscribeDTO 退订链接对应的邮件类型及是否已退订
+type UnsubscribeDTO struct {
+	Type         string `json:"type"`
+	Unsubscribed bool   `json:"unsubscribed"`
+}
@@ -7,7 +7,7 @@ import (
 
 // CreateLocationRequest 创建地点请求
 type CreateLocationRequest struct {
-	CoupleID    int64   `json:"couple_id,string" binding:"required"`
+	CoupleID    int64   `json:"couple_id,string"` // 由服务端根据当前用户填充
 	Name        string  `json:"name" binding:"required,max=100"`
 	Longitude   float64 `json:"longitude" binding:"required"`
 	Latitude    float64 `json:"latitude" binding:"required"`
@@ -25,7 +25,7 @@ type UpdateLocationRequest struct {
 // LocationQueryParams 查询地点的参数
 type LocationQueryParams struct {
 	PaginationRequest
-	CoupleID int64  `form:"couple_id,string" binding:"required"`
+	CoupleID int64  `form:"couple_id,string"` // 由服务端根据当前用户填充
 	Name     string `form:"name,omitempty"`
 }
 
@@ -0,0 +1,146 @@
+package dto
+
+import (
+	"time"
+
+	"memoir-api/internal/models"
+)
+
+// 倒计时列表中的来源
+const (
+	UpcomingSourceReminder = "reminder" // 自定义提醒
+	UpcomingSourceWishlist = "wishlist" // 心愿单的提醒日期
+)
+
+// CreateReminderRequest 创建自定义提醒请求
+type CreateReminderRequest struct {
+	Title string `json:"title" binding:"required,max=100"`
+	Note  string `json:"note" binding:"omitempty,max=1000"`
+	Date  string `json:"date" binding:"required,datetime=2006-01-02"`
+	// RRule RFC 5545 重复规则，如 FREQ=YEARLY;BYMONTH=5;BYDAY=2SU，不填表示只有一次
+	RRule string `json:"rrule" binding:"omitempty,max=255"`
+	// LeadDays 提前几天提醒，不填时当天提醒
+	LeadDays []int  `json:"lead_days" binding:"omitempty,max=5,dive,min=0,max=365"`
+	Target   string `json:"target" binding:"omitempty,oneof=creator partner both"`
+	// Channels 提醒方式，不填时发送邮件，传空数组表示只用于倒计时
+	Channels []string `json:"channels" binding:"omitempty,dive,oneof=email"`
+}
+
+// ToModel 将创建请求转换为模型对象，未填写的字段使用默认值
+func (r *CreateReminderRequest) ToModel(coupleID, createdBy int64) (*models.Reminder, error) {
+	date, err := time.Parse("2006-01-02", r.Date)
+	if err != nil {
+		return nil, err
+	}
+
+	reminder := &models.Reminder{
+		CoupleID:  coupleID,
+		CreatedBy: createdBy,
+		Title:     r.Title,
+		Note:      r.Note,
+		Date:      date,
+		RRule:     r.RRule,
+		LeadDays:  uniqueLeadDays(r.LeadDays),
+		Target:    r.Target,
+		Channels:  r.Channels,
+	}
+	if len(reminder.LeadDays) == 0 {
+		reminder.LeadDays = []int{0}
+	}
+	if reminder.Target == "" {
+		reminder.Target = models.ReminderTargetBoth
+	}
+	if reminder.Channels == nil {
+		reminder.Channels = []string{models.ReminderChannelEmail}
+	}
+	return reminder, nil
+}
+
+// UpdateReminderRequest 更新自定义提醒请求，未填写的字段保持不变，rrule 传空字符串表示改为只有一次
+type UpdateReminderRequest struct {
+	Title    *string   `json:"title" binding:"omitempty,max=100"`
+	Note     *string   `json:"note" binding:"omitempty,max=1000"`
+	Date     *string   `json:"date" binding:"omitempty,datetime=2006-01-02"`
+	RRule    *string   `json:"rrule" binding:"omitempty,max=255"`
+	LeadDays *[]int    `json:"lead_days" binding:"omitempty,max=5,dive,min=0,max=365"`
+	Target   *string   `json:"target" binding:"omitempty,oneof=creator partner both"`
+	Channels *[]string `json:"channels" binding:"omitempty,dive,oneof=email"`
+}
+
+// ApplyToModel 将更新请求应用到模型对象
+func (r *UpdateReminderRequest) ApplyToModel(reminder *models.Reminder) error {
+	if r.Title != nil {
+		reminder.Title = *r.Title
+	}
+	if r.Note != nil {
+		reminder.Note = *r.Note
+	}
+	if r.Date != nil {
+		date, err := time.Parse("2006-01-02", *r.Date)
+		if err != nil {
+			return err
+		}
+		reminder.Date = date
+	}
+	if r.RRule != nil {
+		reminder.RRule = *r.RRule
+	}
+	if r.LeadDays != nil {
+		reminder.LeadDays = uniqueLeadDays(*r.LeadDays)
+		if len(reminder.LeadDays) == 0 {
+			reminder.LeadDays = []int{0}
+		}
+	}
+	if r.Target != nil {
+		reminder.Target = *r.Target
+	}
+	if r.Channels != nil {
+		reminder.Channels = *r.Channels
+	}
+	return nil
+}
+
+// uniqueLeadDays 去掉重复的提前天数
+func uniqueLeadDays(days []int) []int {
+	seen := make(map[int]bool, len(days))
+	result := make([]int, 0, len(days))
+	for _, day := range days {
+		if !seen[day] {
+			seen[day] = true
+			result = append(result, day)
+		}
+	}
+	return result
+}
+
+// ReminderDTO 自定义提醒响应，附带下一次的倒计时
+type ReminderDTO struct {
+	ID        int64    `json:"id,string"`
+	CreatedBy int64    `json:"created_by,string"`
+	Title     string   `json:"title"`
+	Note      string   `json:"note,omitempty"`
+	Date      string   `json:"date"`
+	RRule     string   `json:"rrule,omitempty"`
+	LeadDays  []int    `json:"lead_days"`
+	Target    string   `json:"target"`
+	Channels  []string `json:"channels"`
+	// NextDate 下一次的日期，已经过去且不再重复时为空
+	NextDate  string `json:"next_date,omitempty"`
+	DaysUntil *int   `json:"days_until,omitempty"`
+}
+
+// UpcomingRemindersRequest 倒计时列表请求
+type UpcomingRemindersRequest struct {
+	// Days 查看未来多少天，默认30天
+	Days int `form:"days" binding:"omitempty,min=1,max=366"`
+}
+
+// UpcomingReminderDTO 倒计时列表中的一项
+type UpcomingReminderDTO struct {
+	// Source 来源：reminder 或 wishlist
+	Source    string `json:"source"`
+	ID        int64  `json:"id,string"`
+	Title     string `json:"title"`
+	Date      string `json:"date"`
+	DaysUntil int    `json:"days_until"`
+}
@@ -7,7 +7,7 @@ import (
 
 // CreateTimelineEventRequest 创建时间线事件的请求
 type CreateTimelineEventRequest struct {
-	CoupleID      int64      `json:"couple_id,string" binding:"required"`
+	CoupleID      int64      `json:"couple_id,string"`              // 由服务端根据当前用户填充
 	StartDate     string     `json:"start_date" binding:"required"` // 格式：2006-01-02
 	EndDate       string     `json:"end_date" binding:"required"`
 	Title         string     `json:"title" binding:"required,max=100"`
@@ -32,7 +32,7 @@ type UpdateTimelineEventRequest struct {
 // TimelineEventQueryParams 查询时间线事件的参数
 type TimelineEventQueryParams struct {
 	PaginationRequest
-	CoupleID   int64  `form:"couple_id,string" binding:"required"`
+	CoupleID   int64  `form:"couple_id,string"`     // 由服务端根据当前用户填充
 	StartDate  string `form:"start_date,omitempty"` // 格式：2006-01-02
 	EndDate    string `form:"end_date,omitempty"`   // 格式：2006-01-02
 	Title      string `form:"title,omitempty"`
@@ -9,8 +9,23 @@ import (
 type UpdateUserRequest struct {
 	UserID   int64  `json:"user_id"`
 	Username string `json:"username" binding:"omitempty,min=3,max=50"`
+	// Email 不能在这里修改，只用于识别旧客户端的修改请求，修改邮箱请使用 ChangeEmailRequest
 	Email    string `json:"email" binding:"omitempty,email"`
 	DarkMode bool   `json:"dark_mode" binding:"omitempty"`
+	// Language 邮件语言，为空表示不修改
+	Language string `json:"language" binding:"omitempty,oneof=zh-CN en-US"`
+}
+
+// ChangeEmailRequest 申请修改邮箱，需要当前密码，验证码发送到新邮箱
+type ChangeEmailRequest struct {
+	NewEmail string `json:"new_email" binding:"required,email"`
+	Password string `json:"password" binding:"required"`
+}
+
+// ConfirmEmailChangeRequest 使用新邮箱收到的验证码确认修改
+type ConfirmEmailChangeRequest struct {
+	NewEmail string `json:"new_email" binding:"required,email"`
+	Code     string `json:"code" binding:"required"`
 }
 
 type UpdateUserPasswordDTO struct {
@@ -21,51 +36,59 @@ type UpdateUserPasswordDTO struct {
 
 // UserResponse 用户响应（不包含敏感信息）
 type UserResponse struct {
-	ID        int64     `json:"id,string"`
-	CoupleID  int64     `json:"couple_id,string"`
-	Username  string    `json:"username"`
-	Email     string    `json:"email"`
-	DarkMode  bool      `json:"dark_mode"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID              int64      `json:"id,string"`
+	CoupleID        int64      `json:"couple_id,string"`
+	Username        string     `json:"username"`
+	Email           string     `json:"email"`
+	DarkMode        bool       `json:"dark_mode"`
+	Language        string     `json:"language"`
+	EmailVerifiedAt *time.Time `json:"email_verified_at"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
 }
 
 // UserProfileResponse 用户个人资料响应（更详细的信息）
 type UserProfileResponse struct {
-	ID        int64     `json:"id,string"`
-	CoupleID  int64     `json:"couple_id,string"`
-	Username  string    `json:"username"`
-	Email     string    `json:"email"`
-	DarkMode  bool      `json:"dark_mode"`
-	HasCouple bool      `json:"has_couple"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID              int64      `json:"id,string"`
+	CoupleID        int64      `json:"couple_id,string"`
+	Username        string     `json:"username"`
+	Email           string     `json:"email"`
+	DarkMode        bool       `json:"dark_mode"`
+	Language        string     `json:"language"`
+	EmailVerifiedAt *time.Time `json:"email_verified_at"`
+	HasCouple       bool       `json:"has_couple"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
 }
 
 // FromModel 从用户模型创建响应DTO
 func UserFromModel(user *models.User) UserResponse {
 	return UserResponse{
-		ID:        user.ID,
-		CoupleID:  user.CoupleID,
-		Username:  user.Username,
-		Email:     user.Email,
-		DarkMode:  user.DarkMode,
-		CreatedAt: user.CreatedAt,
-		UpdatedAt: user.UpdatedAt,
+		ID:              user.ID,
+		CoupleID:        user.CoupleID,
+		Username:        user.Username,
+		Email:           user.Email,
+		DarkMode:        user.DarkMode,
+		Language:        user.Language,
+		EmailVerifiedAt: user.EmailVerifiedAt,
+		CreatedAt:       user.CreatedAt,
+		UpdatedAt:       user.UpdatedAt,
 	}
 }
 
 // UserProfileFromModel 从用户模型创建个人资料响应DTO
 func UserProfileFromModel(user *models.User) UserProfileResponse {
 	return UserProfileResponse{
-		ID:        user.ID,
-		CoupleID:  user.CoupleID,
-		Username:  user.Username,
-		Email:     user.Email,
-		DarkMode:  user.DarkMode,
-		HasCouple: user.CoupleID > 0,
-		CreatedAt: user.CreatedAt,
-		UpdatedAt: user.UpdatedAt,
+		ID:              user.ID,
+		CoupleID:        user.CoupleID,
+		Username:        user.Username,
+		Email:           user.Email,
+		DarkMode:        user.DarkMode,
+		Language:        user.Language,
+		EmailVerifiedAt: user.EmailVerifiedAt,
+		HasCouple:       user.CoupleID > 0,
+		CreatedAt:       user.CreatedAt,
+		UpdatedAt:       user.UpdatedAt,
 	}
 }
 
@@ -74,10 +97,28 @@ func (r *UpdateUserRequest) ApplyUpdates(user *models.User) {
 	if r.Username != "" {
 		user.Username = r.Username
 	}
-	if r.Email != "" {
-		user.Email = r.Email
-	}
 	if r.DarkMode {
 		user.DarkMode = true
 	}
+	if r.Language != "" {
+		user.Language = r.Language
+	}
+}
+
+// AdminUserResponse 管理员查看的用户信息
+type AdminUserResponse struct {
+	UserResponse
+	Role       string     `json:"role"`
+	MFAEnabled bool       `json:"mfa_enabled"`
+	DisabledAt *time.Time `json:"disabled_at"`
+}
+
+// AdminUserFromModel 从用户模型创建管理员查看的响应DTO
+func AdminUserFromModel(user *models.User) AdminUserResponse {
+	return AdminUserResponse{
+		UserResponse: UserFromModel(user),
+		Role:         user.Role,
+		MFAEnabled:   user.MFAEnabled,
+		DisabledAt:   user.DisabledAt,
+	}
 }
@@ -7,7 +7,7 @@ import (
 
 // CreateWishlistRequest 创建心愿清单请求
 type CreateWishlistRequest struct {
-	CoupleID      int64      `json:"couple_id,string" binding:"required"`
+	CoupleID      int64      `json:"couple_id,string"` // 由服务端根据当前用户填充
 	Title         string     `json:"title" binding:"required,max=100"`
 	Description   string     `json:"description,omitempty"`
 	Priority      int        `json:"priority" binding:"omitempty,min=1,max=3"` // 1-高，2-中，3-低
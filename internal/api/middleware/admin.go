@@ -0,0 +1,36 @@
+package middleware
+
+import (
+	"net/http"
+
+	"memoir-api/internal/api/dto"
+	"memoir-api/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequireAdmin 要求当前用户为管理员，且必须通过登录会话认证
+func RequireAdmin(services service.Factory) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetString(AuthMethodKey) != AuthMethodSession {
+			c.JSON(http.StatusForbidden, dto.NewErrorResponse(http.StatusForbidden, "该操作需要登录会话", "access tokens are not allowed"))
+			c.Abort()
+			return
+		}
+
+		user, err := services.User().GetUserByID(c.Request.Context(), c.GetInt64("user_id"))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, dto.NewErrorResponse(http.StatusInternalServerError, "获取用户信息失败", err.Error()))
+			c.Abort()
+			return
+		}
+
+		if !user.IsAdmin() || user.IsDisabled() {
+			c.JSON(http.StatusForbidden, dto.NewErrorResponse(http.StatusForbidden, "需要管理员权限", "admin role required"))
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -5,6 +5,7 @@ import (
 
 	"memoir-api/internal/config"
 	"memoir-api/internal/logger"
+	"memoir-api/internal/service"
 	"net/http"
 
 	"github.com/gin-contrib/cors"
@@ -109,6 +110,9 @@ func RequestIDMiddleware() gin.HandlerFunc {
 		c.Writer.Header().Set("X-Request-ID", requestID)
 		c.Set("requestID", requestID)
 
+		// 审计日志需要在服务层拿到请求ID和客户端IP
+		c.Request = c.Request.WithContext(service.WithAuditRequest(c.Request.Context(), requestID, c.ClientIP()))
+
 		c.Next()
 	}
 }
@@ -0,0 +1,54 @@
+package middleware
+
+import (
+	"net/http"
+
+	"memoir-api/internal/api/dto"
+	"memoir-api/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CoupleIDKey 上下文中记录当前用户情侣ID的键，处理器不能再信任请求中的 couple_id
+const CoupleIDKey = "couple_id"
+
+// ResolveCouple 根据 user_id 解析当前用户的情侣ID并写入上下文，没有情侣关系时为0
+func ResolveCouple(services service.Factory) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, ok := resolveCouple(c, services); !ok {
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireCouple 解析当前用户的情侣ID并写入上下文，没有情侣关系时拒绝访问
+func RequireCouple(services service.Factory) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		coupleID, ok := resolveCouple(c, services)
+		if !ok {
+			return
+		}
+
+		if coupleID == 0 {
+			c.JSON(http.StatusForbidden, dto.NewErrorResponse(http.StatusForbidden, "用户不属于任何情侣关系", service.ErrNotInCouple.Error()))
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// resolveCouple 查询情侣ID并写入上下文，失败时已写入错误响应
+func resolveCouple(c *gin.Context, services service.Factory) (int64, bool) {
+	coupleID, err := services.CoupleGuard().ResolveCoupleID(c.Request.Context(), c.GetInt64("user_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.NewErrorResponse(http.StatusInternalServerError, "获取情侣关系失败", err.Error()))
+		c.Abort()
+		return 0, false
+	}
+
+	c.Set(CoupleIDKey, coupleID)
+	return coupleID, true
+}
@@ -1,6 +1,7 @@
 package middleware
 
 import (
+	"errors"
 	"net/http"
 	"strings"
 
@@ -30,17 +31,42 @@ func JWTAuthMiddleware(services service.Factory) gin.HandlerFunc {
 
 		tokenString := parts[1]
 
-		// 使用JWT服务验证令牌并提取用户ID
+		// 个人访问令牌：只能访问令牌权限范围内的接口
+		if service.IsAccessToken(tokenString) {
+			token, err := services.AccessToken().Authenticate(c.Request.Context(), tokenString)
+			if errors.Is(err, service.ErrUserDisabled) {
+				c.JSON(http.StatusForbidden, gin.H{"error": "Account disabled"})
+				c.Abort()
+				return
+			}
+			if err != nil {
+				c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
+				c.Abort()
+				return
+			}
+
+			c.Set("user_id", token.UserID)
+			c.Request = c.Request.WithContext(service.WithAuditActor(c.Request.Context(), token.UserID))
+			c.Set(AuthMethodKey, AuthMethodAccessToken)
+			c.Set(TokenScopesKey, token.ScopeList())
+			c.Next()
+			return
+		}
+
+		// 使用JWT服务验证令牌，已注销会话的访问令牌会被拒绝
 		jwtService := services.JWT()
-		userID, err := jwtService.ExtractUserID(tokenString)
+		claims, err := jwtService.ValidateAccessToken(c.Request.Context(), tokenString)
 		if err != nil {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
 			c.Abort()
 			return
 		}
 
-		// Set user ID to context
-		c.Set("user_id", userID)
+		// Set user ID and session to context
+		c.Set("user_id", claims.UserID)
+		c.Set("session_id", claims.SessionID)
+		c.Request = c.Request.WithContext(service.WithAuditActor(c.Request.Context(), claims.UserID))
+		c.Set(AuthMethodKey, AuthMethodSession)
 		c.Next()
 	}
 }
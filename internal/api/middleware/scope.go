@@ -0,0 +1,64 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"memoir-api/internal/api/dto"
+	"memoir-api/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// AuthMethodKey 上下文中记录认证方式的键
+	AuthMethodKey = "auth_method"
+	// TokenScopesKey 上下文中记录个人访问令牌权限范围的键
+	TokenScopesKey = "token_scopes"
+
+	// AuthMethodSession 通过登录会话的JWT认证
+	AuthMethodSession = "session"
+	// AuthMethodAccessToken 通过个人访问令牌认证
+	AuthMethodAccessToken = "access_token"
+)
+
+// RequireScope 个人访问令牌访问资源时检查权限范围，登录会话不受限制。
+// 传入资源名时 GET/HEAD 请求需要 <resource>:read，其他请求需要 <resource>:write；
+// 传入完整的权限范围（如 media:write）时按原样检查
+func RequireScope(resource string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetString(AuthMethodKey) != AuthMethodAccessToken {
+			c.Next()
+			return
+		}
+
+		required := resource
+		if !strings.Contains(resource, ":") {
+			required = resource + ":write"
+			if c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead {
+				required = resource + ":read"
+			}
+		}
+
+		if !service.HasScope(c.GetStringSlice(TokenScopesKey), required) {
+			c.JSON(http.StatusForbidden, dto.NewErrorResponse(http.StatusForbidden, "访问令牌权限不足", "missing scope "+required))
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// RequireSession 要求通过登录会话认证，个人访问令牌不能管理账号安全设置
+func RequireSession() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetString(AuthMethodKey) != AuthMethodSession {
+			c.JSON(http.StatusForbidden, dto.NewErrorResponse(http.StatusForbidden, "该操作需要登录会话", "access tokens are not allowed"))
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,36 @@
+package middleware
+
+import (
+	"net/http"
+
+	"memoir-api/internal/api/dto"
+	"memoir-api/internal/config"
+	"memoir-api/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequireVerifiedEmail 要求当前用户已完成邮箱验证，策略关闭时直接放行
+func RequireVerifiedEmail(services service.Factory, cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !cfg.Auth.RequireEmailVerification {
+			c.Next()
+			return
+		}
+
+		user, err := services.User().GetUserByID(c.Request.Context(), c.GetInt64("user_id"))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, dto.NewErrorResponse(http.StatusInternalServerError, "获取用户信息失败", err.Error()))
+			c.Abort()
+			return
+		}
+
+		if !user.IsEmailVerified() {
+			c.JSON(http.StatusForbidden, dto.NewErrorResponse(http.StatusForbidden, "请先完成邮箱验证", "email not verified"))
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -21,6 +21,9 @@ func RegisterRoutes(router *gin.Engine, services service.Factory, db *gorm.DB, c
 	// Simple ping endpoint
 	router.GET("/ping", handlers.PingHandler())
 
+	// JWT verification keys
+	router.GET("/.well-known/jwks.json", handlers.JWKSHandler(services))
+
 	// API v1 group
 	v1 := router.Group("/api/v1")
 
@@ -36,6 +39,15 @@ func RegisterRoutes(router *gin.Engine, services service.Factory, db *gorm.DB, c
 		authRoutes.POST("/register", authHandler.Register)
 		authRoutes.POST("/login", authHandler.Login)
 		authRoutes.POST("/refresh", authHandler.RefreshToken)
+		authRoutes.POST("/mfa/verify", authHandler.VerifyMFA)
+	}
+
+	// Session routes (require a valid access token)
+	sessionRoutes := v1.Group("/auth")
+	sessionRoutes.Use(middleware.JWTAuthMiddleware(services), middleware.RequireSession())
+	{
+		sessionRoutes.POST("/logout", authHandler.Logout)
+		sessionRoutes.POST("/logout-all", authHandler.LogoutAll)
 	}
 
 	// Email verification routes (public)
@@ -44,6 +56,19 @@ func RegisterRoutes(router *gin.Engine, services service.Factory, db *gorm.DB, c
 		emailRoutes.POST("/verify", emailHandler.VerifyEmail)
 		emailRoutes.POST("/resend-code", emailHandler.ResendVerificationCode)
 		emailRoutes.POST("/forgot-password", emailHandler.ForgotPassword)
+		emailRoutes.POST("/reset-password", emailHandler.ResetPassword)
+		// 退订链接由签名校验身份，不需要登录
+		emailRoutes.GET("/unsubscribe", handlers.VerifyUnsubscribeHandler(services))
+		emailRoutes.POST("/unsubscribe", handlers.UnsubscribeHandler(services))
+	}
+
+	// 邮件模板预览，只在开发环境开放
+	if cfg.Server.Mode != "release" {
+		devEmailRoutes := v1.Group("/dev/emails")
+		{
+			devEmailRoutes.GET("", handlers.ListEmailTemplatesHandler(services))
+			devEmailRoutes.GET("/:type", handlers.PreviewEmailTemplateHandler(services))
+		}
 	}
 
 	// Protected routes
@@ -51,31 +76,75 @@ func RegisterRoutes(router *gin.Engine, services service.Factory, db *gorm.DB, c
 	protected := v1.Group("")
 	protected.Use(middleware.JWTAuthMiddleware(services))
 
+	// 未验证邮箱的账号不能创建情侣关系或上传媒体
+	requireVerified := middleware.RequireVerifiedEmail(services, cfg)
+
+	// 情侣数据路由统一从 user_id 解析情侣ID，处理器不再信任请求中的 couple_id
+	requireCouple := middleware.RequireCouple(services)
+
 	//Dashboard routes
-	dashboardRoutes := protected.Group("/dashboard")
+	dashboardRoutes := protected.Group("/dashboard", middleware.RequireScope("couple"))
 	{
 		dashboardRoutes.GET("", handlers.GetDashboardDataHandler(services))
 	}
 
 	// User routes
-	userRoutes := protected.Group("/users")
+	userRoutes := protected.Group("/users", middleware.RequireScope("profile"))
 	{
 		userRoutes.GET("/me", handlers.GetCurrentUserHandler(services))
 		userRoutes.GET("/exist-couple", handlers.ExistCoupleHandler(services))
 		userRoutes.PUT("/update", handlers.UpdateUserHandler(services))
-		userRoutes.PUT("/password", handlers.UpdatePassword(services))
+		userRoutes.GET("/me/emails", handlers.ListMyEmailsHandler(services))
+		userRoutes.GET("/me/email-preferences", handlers.ListEmailPreferencesHandler(services))
+		userRoutes.PUT("/me/email-preferences/:type", handlers.UpdateEmailPreferenceHandler(services))
+	}
+
+	// Account security routes (personal access tokens are not allowed)
+	accountRoutes := protected.Group("/users", middleware.RequireSession())
+	{
+		accountRoutes.PUT("/password", handlers.UpdatePassword(services))
+		accountRoutes.POST("/me/email", handlers.RequestEmailChangeHandler(services))
+		accountRoutes.POST("/me/email/confirm", handlers.ConfirmEmailChangeHandler(services))
+		accountRoutes.GET("/me/sessions", handlers.ListSessionsHandler(services))
+		accountRoutes.DELETE("/me/sessions/:id", handlers.RevokeSessionHandler(services))
+		accountRoutes.GET("/me/mfa", handlers.GetMFAStatusHandler(services))
+		accountRoutes.POST("/me/mfa/enroll", handlers.EnrollTOTPHandler(services))
+		accountRoutes.POST("/me/mfa/confirm", handlers.ConfirmTOTPHandler(services))
+		accountRoutes.POST("/me/mfa/disable", handlers.DisableTOTPHandler(services))
+		accountRoutes.GET("/me/tokens", handlers.ListAccessTokensHandler(services))
+		accountRoutes.POST("/me/tokens", handlers.CreateAccessTokenHandler(services))
+		accountRoutes.DELETE("/me/tokens/:id", handlers.RevokeAccessTokenHandler(services))
 	}
 
 	// Couple routes
-	coupleRoutes := protected.Group("/couple")
+	coupleRoutes := protected.Group("/couple", middleware.RequireScope("couple"))
 	{
-		coupleRoutes.POST("/create", handlers.CreateCoupleHandler(services))
-		coupleRoutes.GET("/sts", handlers.GenerateCoupleSTSToken(services))
+		coupleRoutes.POST("/create", requireVerified, handlers.CreateCoupleHandler(services))
+		// 配对决定了谁能看到账号的共享数据，邀请和加入只能在登录会话中操作
+		coupleRoutes.POST("/invite", middleware.RequireSession(), requireVerified, handlers.CreateCoupleInviteHandler(services))
+		coupleRoutes.POST("/join", middleware.RequireSession(), requireVerified, handlers.JoinCoupleHandler(services))
+		coupleRoutes.GET("/sts", requireVerified, middleware.RequireScope(service.ScopeMediaWrite), handlers.GenerateCoupleSTSToken(services))
 		coupleRoutes.GET("/info", handlers.GetCoupleInfoHandler(services))
+		coupleRoutes.GET("/audit", requireCouple, handlers.GetCoupleAuditHandler(services))
+		coupleRoutes.GET("/settings", requireCouple, handlers.GetCoupleSettingsHandler(services))
+		coupleRoutes.PUT("/settings", requireCouple, handlers.UpdateCoupleSettingsHandler(services))
+		coupleRoutes.GET("/anniversaries", requireCouple, handlers.ListCoupleAnniversariesHandler(services))
+		coupleRoutes.POST("/anniversaries", requireCouple, handlers.CreateCoupleAnniversaryHandler(services))
+		coupleRoutes.PUT("/anniversaries/:id", requireCouple, handlers.UpdateCoupleAnniversaryHandler(services))
+		coupleRoutes.DELETE("/anniversaries/:id", requireCouple, handlers.DeleteCoupleAnniversaryHandler(services))
+		coupleRoutes.GET("/festivals", requireCouple, handlers.ListCoupleFestivalsHandler(services))
+		coupleRoutes.PUT("/festivals/:key", requireCouple, handlers.UpdateCoupleFestivalHandler(services))
+		coupleRoutes.GET("/reminders/history", requireCouple, handlers.ListReminderDeliveriesHandler(services))
+		// 解除情侣关系只能在登录会话中操作，个人访问令牌不能发起或撤销
+		coupleRoutes.POST("/leave", middleware.RequireSession(), requireVerified, requireCouple, handlers.RequestCoupleDissolutionHandler(services))
+		coupleRoutes.DELETE("/leave", middleware.RequireSession(), requireCouple, handlers.CancelCoupleDissolutionHandler(services))
+		// 解除后用户已不在情侣关系中，导出不要求 requireCouple
+		coupleRoutes.GET("/export", handlers.ExportCoupleDataHandler(services))
+		coupleRoutes.GET("/past", handlers.ListPastCouplesHandler(services))
 	}
 
 	// Timeline event routes
-	eventRoutes := protected.Group("/events")
+	eventRoutes := protected.Group("/events", middleware.RequireScope("events"), requireCouple)
 	{
 		eventRoutes.POST("/create", handlers.CreateTimelineEventHandler(services))
 		eventRoutes.GET("/page", handlers.PageTimelineEventsHandler(services))
@@ -85,7 +154,7 @@ func RegisterRoutes(router *gin.Engine, services service.Factory, db *gorm.DB, c
 	}
 
 	// Location routes
-	locationRoutes := protected.Group("/locations")
+	locationRoutes := protected.Group("/locations", middleware.RequireScope("locations"), requireCouple)
 	{
 		locationRoutes.GET("/list", handlers.ListLocationsHandler(services))
 		locationRoutes.GET("/:id", handlers.GetLocationHandler(services))
@@ -94,23 +163,23 @@ func RegisterRoutes(router *gin.Engine, services service.Factory, db *gorm.DB, c
 	}
 
 	// Photos and videos routes
-	mediaRoutes := protected.Group("/media")
+	mediaRoutes := protected.Group("/media", middleware.RequireScope("media"), requireCouple)
 	{
-		mediaRoutes.POST("/create", handlers.CreatePhotoVideoHandler(services))
+		mediaRoutes.POST("/create", requireVerified, handlers.CreatePhotoVideoHandler(services))
 		mediaRoutes.GET("/page", handlers.ListPhotoVideoHandler(services))
 	}
 
 	// 个人媒体路由
 	// 注册个人媒体处
-	personalMediaRoutes := protected.Group("/personal-media")
+	personalMediaRoutes := protected.Group("/personal-media", middleware.RequireScope("media"))
 	{
-		personalMediaRoutes.POST("/create", handlers.CreatePersonalMediaWithURLHandler(services))
+		personalMediaRoutes.POST("/create", requireVerified, handlers.CreatePersonalMediaWithURLHandler(services))
 		personalMediaRoutes.GET("/page", handlers.PageQueryPersonalMediaHandler(services))
 		personalMediaRoutes.DELETE("/:id", handlers.DeletePersonalMediaHandler(services))
 	}
 
 	// Wishlist routes
-	wishlistRoutes := protected.Group("/wishlist")
+	wishlistRoutes := protected.Group("/wishlist", middleware.RequireScope("wishlist"), requireCouple)
 	{
 		wishlistRoutes.GET("/list", handlers.ListWishlistItemsHandler(services))
 		wishlistRoutes.POST("/create", handlers.CreateWishlistItemHandler(services))
@@ -120,8 +189,18 @@ func RegisterRoutes(router *gin.Engine, services service.Factory, db *gorm.DB, c
 		wishlistRoutes.POST("/associateAttachments", handlers.AssociateAttachments(services))
 	}
 
+	// 自定义提醒和倒计时
+	reminderRoutes := protected.Group("/reminders", middleware.RequireScope("couple"), requireCouple)
+	{
+		reminderRoutes.GET("", handlers.ListRemindersHandler(services))
+		reminderRoutes.POST("", handlers.CreateReminderHandler(services))
+		reminderRoutes.GET("/upcoming", handlers.ListUpcomingRemindersHandler(services))
+		reminderRoutes.PUT("/:id", handlers.UpdateReminderHandler(services))
+		reminderRoutes.DELETE("/:id", handlers.DeleteReminderHandler(services))
+	}
+
 	// 情侣相册路由
-	albumRoutes := protected.Group("/albums")
+	albumRoutes := protected.Group("/albums", middleware.RequireScope("media"), requireCouple)
 	{
 		albumRoutes.GET("/list", handlers.ListCoupleAlbumsHandler(services))
 		albumRoutes.POST("/create", handlers.CreateCoupleAlbumHandler(services))
@@ -134,26 +213,54 @@ func RegisterRoutes(router *gin.Engine, services service.Factory, db *gorm.DB, c
 	}
 
 	// 附件路由
-	attachmentRoutes := protected.Group("/attachments")
+	attachmentRoutes := protected.Group("/attachments", middleware.RequireScope("media"), middleware.ResolveCouple(services))
 	{
-		attachmentRoutes.POST("/create", handlers.CreateAttachmentHandler(services))
+		attachmentRoutes.POST("/create", requireVerified, handlers.CreateAttachmentHandler(services))
 		attachmentRoutes.GET("/:id", handlers.GetAttachmentHandler(services))
 		attachmentRoutes.GET("/list", handlers.ListAttachmentsHandler(services))
 		attachmentRoutes.DELETE("/:id", handlers.DeleteAttachmentHandler(services))
 	}
 
 	// OSS (Aliyun Object Storage Service) routes
-	ossRoutes := protected.Group("/oss")
+	// STS凭证用于直传文件，个人访问令牌需要 media:write
+	ossRoutes := protected.Group("/oss", middleware.RequireScope(service.ScopeMediaWrite))
 	{
-		ossRoutes.GET("/token", handlers.GenerateSTSToken)
+		ossRoutes.GET("/token", requireVerified, handlers.GenerateSTSToken)
 	}
 
-	// 管理员路由 - 用于手动触发提醒功能
-	adminRoutes := protected.Group("/reminders")
+	// 管理员路由
+	adminRoutes := protected.Group("/admin", middleware.RequireAdmin(services))
 	{
-		// 触发纪念日提醒
-		adminRoutes.POST("/anniversary", handlers.TriggerAnniversaryRemindersHandler(services))
-		// 触发节日提醒
-		adminRoutes.POST("/festival", handlers.TriggerFestivalRemindersHandler(services))
+		// 手动触发纪念日提醒
+		adminRoutes.POST("/reminders/anniversary", handlers.TriggerAnniversaryRemindersHandler(services))
+		// 手动触发节日提醒
+		adminRoutes.POST("/reminders/festival", handlers.TriggerFestivalRemindersHandler(services))
+
+		// 定时任务
+		adminRoutes.GET("/jobs", handlers.AdminListJobsHandler(services))
+		adminRoutes.GET("/jobs/:name/runs", handlers.AdminListJobRunsHandler(services))
+		adminRoutes.POST("/jobs/:name/run", handlers.AdminTriggerJobHandler(services))
+
+		// 邮件队列和死信
+		adminRoutes.GET("/email/queue", handlers.AdminEmailQueueStatsHandler(services))
+		adminRoutes.GET("/email/dead-letters", handlers.AdminListDeadLettersHandler(services))
+		adminRoutes.POST("/email/dead-letters/:id/requeue", handlers.AdminRequeueDeadLetterHandler(services))
+		adminRoutes.DELETE("/email/dead-letters/:id", handlers.AdminDeleteDeadLetterHandler(services))
+		adminRoutes.DELETE("/email/dead-letters", handlers.AdminPurgeDeadLettersHandler(services))
+		adminRoutes.GET("/email/logs", handlers.AdminQueryEmailLogsHandler(services))
+
+		adminRoutes.GET("/users", handlers.AdminListUsersHandler(services))
+		adminRoutes.POST("/users/:id/disable", handlers.AdminDisableUserHandler(services))
+		adminRoutes.POST("/users/:id/enable", handlers.AdminEnableUserHandler(services))
+		adminRoutes.DELETE("/users/:id/sessions", handlers.AdminRevokeUserSessionsHandler(services))
+		adminRoutes.GET("/couples", handlers.AdminListCouplesHandler(services))
+
+		// 登录锁定管理
+		adminRoutes.GET("/lockouts", handlers.AdminListLockoutsHandler(services))
+		adminRoutes.DELETE("/lockouts/:kind/:identifier", handlers.AdminClearLockoutHandler(services))
+
+		// 审计日志
+		adminRoutes.GET("/audit", handlers.AdminQueryAuditHandler(services))
+		adminRoutes.GET("/audit/verify", handlers.AdminVerifyAuditChainHandler(services))
 	}
 }
@@ -0,0 +1,871 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"memoir-api/internal/api/dto"
+	"memoir-api/internal/config"
+	"memoir-api/internal/email"
+	"memoir-api/internal/models"
+	"memoir-api/internal/repository"
+	"memoir-api/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 测试数据：ownedID 的资源属于 ownerUser/ownerCouple，请求由另一对情侣中的 caller 发起
+const (
+	ownerUserID    int64 = 1
+	ownerCoupleID  int64 = 10
+	callerUserID   int64 = 2
+	callerCoupleID int64 = 20
+	ownedID        int64 = 100
+
+	callerToken = "caller-session-token"
+)
+
+// coupleScopedPrefixes 这些路径下的接口都读写情侣或个人的数据，每个接口都必须出现在 TestCoupleScopedRoutes 的用例中
+var coupleScopedPrefixes = []string{
+	"/api/v1/events",
+	"/api/v1/locations",
+	"/api/v1/media",
+	"/api/v1/personal-media",
+	"/api/v1/wishlist",
+	"/api/v1/reminders",
+	"/api/v1/albums",
+	"/api/v1/attachments",
+	"/api/v1/couple/anniversaries",
+}
+
+// ownership 记录服务收到的情侣ID和用户ID，并模拟仓库按归属过滤的查询：只有资源的所有者能查到 ownedID
+type ownership struct {
+	coupleIDs []int64
+	userIDs   []int64
+}
+
+func (o *ownership) couple(coupleID int64) {
+	o.coupleIDs = append(o.coupleIDs, coupleID)
+}
+
+func (o *ownership) user(userID int64) {
+	o.userIDs = append(o.userIDs, userID)
+}
+
+// inCouple 模拟 repository.InCouple 查询
+func (o *ownership) inCouple(coupleID, id int64, notFound error) error {
+	o.couple(coupleID)
+	if coupleID == ownerCoupleID && id == ownedID {
+		return nil
+	}
+	return notFound
+}
+
+// forUser 模拟只能查到用户本人数据的查询
+func (o *ownership) forUser(userID, id int64, notFound error) error {
+	o.user(userID)
+	if userID == ownerUserID && id == ownedID {
+		return nil
+	}
+	return notFound
+}
+
+// accessibleAttachment 模拟附件的可访问范围：本人上传的，或同一情侣情侣空间中的
+func (o *ownership) accessibleAttachment(userID, coupleID, id int64) error {
+	o.user(userID)
+	o.couple(coupleID)
+	if id == ownedID && (userID == ownerUserID || coupleID == ownerCoupleID) {
+		return nil
+	}
+	return service.ErrAttachmentNotFound
+}
+
+func (o *ownership) inCoupleAll(coupleID int64, ids []int64, notFound error) error {
+	for _, id := range ids {
+		if err := o.inCouple(coupleID, id, notFound); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type fakeJWT struct {
+	service.JWTService
+	// revoked 记录被注销全部会话的用户
+	revoked *[]int64
+}
+
+func (f fakeJWT) RevokeAllSessions(ctx context.Context, userID int64) error {
+	*f.revoked = append(*f.revoked, userID)
+	return nil
+}
+
+func (fakeJWT) ValidateAccessToken(ctx context.Context, tokenString string) (*service.AccessClaims, error) {
+	if tokenString != callerToken {
+		return nil, errors.New("invalid token")
+	}
+	return &service.AccessClaims{UserID: callerUserID, SessionID: "caller-session"}, nil
+}
+
+// 个人访问令牌：callerAccessToken 属于 caller，带 profile:write 和 couple 读写权限，disabledAccessToken 的账号已停用
+var (
+	callerAccessToken   = service.AccessTokenPrefix + "caller"
+	disabledAccessToken = service.AccessTokenPrefix + "disabled"
+)
+
+type fakeAccessTokens struct {
+	service.AccessTokenService
+}
+
+func (fakeAccessTokens) Authenticate(ctx context.Context, plaintext string) (*models.PersonalAccessToken, error) {
+	switch plaintext {
+	case callerAccessToken:
+		return &models.PersonalAccessToken{UserID: callerUserID, Scopes: "couple:read couple:write profile:write"}, nil
+	case disabledAccessToken:
+		return nil, service.ErrUserDisabled
+	}
+	return nil, service.ErrInvalidAccessToken
+}
+
+type fakeGuard struct{}
+
+func (fakeGuard) ResolveCoupleID(ctx context.Context, userID int64) (int64, error) {
+	switch userID {
+	case ownerUserID:
+		return ownerCoupleID, nil
+	case callerUserID:
+		return callerCoupleID, nil
+	}
+	return 0, nil
+}
+
+type fakeTimelineEvents struct {
+	service.TimelineEventService
+	*ownership
+}
+
+func (f fakeTimelineEvents) CreateTimelineEvent(ctx context.Context, req *dto.CreateTimelineEventRequest) (bool, error) {
+	f.couple(req.CoupleID)
+	if err := f.inCoupleAll(req.CoupleID, req.LocationIDs, service.ErrLocationNotFound); err != nil {
+		return false, err
+	}
+	if err := f.inCoupleAll(req.CoupleID, req.PhotoVideoIDs, service.ErrPhotoVideoNotFound); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (f fakeTimelineEvents) GetTimelineEventByID(ctx context.Context, coupleID, id int64) (*models.TimelineEvent, error) {
+	if err := f.inCouple(coupleID, id, service.ErrTimelineEventNotFound); err != nil {
+		return nil, err
+	}
+	return &models.TimelineEvent{}, nil
+}
+
+func (f fakeTimelineEvents) ListTimelineEventsByCoupleID(ctx context.Context, coupleID int64, offset, limit int) ([]*models.TimelineEvent, int64, error) {
+	f.couple(coupleID)
+	return nil, 0, nil
+}
+
+func (f fakeTimelineEvents) UpdateTimelineEvent(ctx context.Context, coupleID int64, event *models.TimelineEvent, locationIDs, photoVideoIDs []int64) (*models.TimelineEvent, error) {
+	if err := f.inCouple(coupleID, event.ID, service.ErrTimelineEventNotFound); err != nil {
+		return nil, err
+	}
+	return event, nil
+}
+
+func (f fakeTimelineEvents) DeleteTimelineEvent(ctx context.Context, coupleID, id int64) error {
+	return f.inCouple(coupleID, id, service.ErrTimelineEventNotFound)
+}
+
+type fakeLocations struct {
+	service.LocationService
+	*ownership
+}
+
+func (f fakeLocations) CreateLocation(ctx context.Context, location *models.Location) (*models.Location, error) {
+	f.couple(location.CoupleID)
+	return location, nil
+}
+
+func (f fakeLocations) GetLocationByID(ctx context.Context, coupleID, id int64) (*models.Location, error) {
+	if err := f.inCouple(coupleID, id, service.ErrLocationNotFound); err != nil {
+		return nil, err
+	}
+	return &models.Location{}, nil
+}
+
+func (f fakeLocations) ListLocationsByCoupleID(ctx context.Context, coupleID int64, offset, limit int) ([]*models.Location, int64, error) {
+	f.couple(coupleID)
+	return nil, 0, nil
+}
+
+func (f fakeLocations) DeleteLocation(ctx context.Context, coupleID, id int64) error {
+	return f.inCouple(coupleID, id, service.ErrLocationNotFound)
+}
+
+type fakePhotoVideos struct {
+	service.PhotoVideoService
+	*ownership
+}
+
+func (f fakePhotoVideos) CreatePhotoVideo(ctx context.Context, req *dto.CreatePhotoVideoRequest) (*models.PhotoVideo, error) {
+	f.user(req.UserID)
+	if err := f.inCouple(req.CoupleID, req.AlbumID, service.ErrAlbumNotFound); err != nil {
+		return nil, err
+	}
+	if req.EventID != nil {
+		if err := f.inCouple(req.CoupleID, *req.EventID, service.ErrTimelineEventNotFound); err != nil {
+			return nil, err
+		}
+	}
+	if req.LocationID != nil {
+		if err := f.inCouple(req.CoupleID, *req.LocationID, service.ErrLocationNotFound); err != nil {
+			return nil, err
+		}
+	}
+	return req.ToModel(), nil
+}
+
+func (f fakePhotoVideos) Query(ctx context.Context, params *dto.PhotoVideoQueryParams) (*dto.PageResult, error) {
+	f.couple(params.CoupleID)
+	return &dto.PageResult{}, nil
+}
+
+type fakePersonalMedia struct {
+	service.PersonalMediaService
+	*ownership
+}
+
+func (f fakePersonalMedia) CreateWithURL(ctx context.Context, req dto.CreatePersonalMediaWithURLRequest) (*models.PersonalMedia, error) {
+	f.user(req.UserID)
+	return &models.PersonalMedia{}, nil
+}
+
+func (f fakePersonalMedia) PageQuery(ctx context.Context, req dto.QueryPersonalMediaRequest) (*dto.PageResult, error) {
+	f.user(req.UserID)
+	return &dto.PageResult{}, nil
+}
+
+func (f fakePersonalMedia) Delete(ctx context.Context, userID, id int64) error {
+	return f.forUser(userID, id, service.ErrPersonalMediaNotFound)
+}
+
+type fakeWishlists struct {
+	service.WishlistService
+	*ownership
+}
+
+func (f fakeWishlists) checkAttachments(userID, coupleID int64, ids []int64) error {
+	for _, id := range ids {
+		if err := f.accessibleAttachment(userID, coupleID, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (f fakeWishlists) CreateWishlist(ctx context.Context, userID int64, req *dto.CreateWishlistRequest) (*models.Wishlist, error) {
+	f.user(userID)
+	f.couple(req.CoupleID)
+	if err := f.checkAttachments(userID, req.CoupleID, req.AttachmentIDs); err != nil {
+		return nil, err
+	}
+	return &models.Wishlist{}, nil
+}
+
+func (f fakeWishlists) GetWishlistByID(ctx context.Context, coupleID, id int64) (*models.Wishlist, error) {
+	if err := f.inCouple(coupleID, id, service.ErrWishlistNotFound); err != nil {
+		return nil, err
+	}
+	return &models.Wishlist{}, nil
+}
+
+func (f fakeWishlists) ListWishlistsByCoupleID(ctx context.Context, coupleID int64) ([]dto.WishlistDTO, error) {
+	f.couple(coupleID)
+	return nil, nil
+}
+
+func (f fakeWishlists) UpdateWishlistStatus(ctx context.Context, coupleID, id int64, status string) error {
+	return f.inCouple(coupleID, id, service.ErrWishlistNotFound)
+}
+
+func (f fakeWishlists) DeleteWishlist(ctx context.Context, coupleID, id int64) error {
+	return f.inCouple(coupleID, id, service.ErrWishlistNotFound)
+}
+
+func (f fakeWishlists) UpdateWishlistByRequest(ctx context.Context, userID, coupleID int64, req *dto.UpdateWishlistRequest) (*models.Wishlist, error) {
+	f.user(userID)
+	if err := f.inCouple(coupleID, req.ID, service.ErrWishlistNotFound); err != nil {
+		return nil, err
+	}
+	if err := f.checkAttachments(userID, coupleID, req.AttachmentIDs); err != nil {
+		return nil, err
+	}
+	return &models.Wishlist{}, nil
+}
+
+func (f fakeWishlists) AssociateAttachments(ctx context.Context, userID, coupleID, wishlistID int64, attachmentIDs []int64) error {
+	f.user(userID)
+	if err := f.inCouple(coupleID, wishlistID, service.ErrWishlistNotFound); err != nil {
+		return err
+	}
+	return f.checkAttachments(userID, coupleID, attachmentIDs)
+}
+
+type fakeReminders struct {
+	service.ReminderService
+	*ownership
+}
+
+func (f fakeReminders) Create(ctx context.Context, coupleID, userID int64, req *dto.CreateReminderRequest) (*dto.ReminderDTO, error) {
+	f.couple(coupleID)
+	f.user(userID)
+	return &dto.ReminderDTO{}, nil
+}
+
+func (f fakeReminders) List(ctx context.Context, coupleID int64) ([]dto.ReminderDTO, error) {
+	f.couple(coupleID)
+	return nil, nil
+}
+
+func (f fakeReminders) Update(ctx context.Context, coupleID, id int64, req *dto.UpdateReminderRequest) (*dto.ReminderDTO, error) {
+	if err := f.inCouple(coupleID, id, service.ErrReminderNotFound); err != nil {
+		return nil, err
+	}
+	return &dto.ReminderDTO{}, nil
+}
+
+func (f fakeReminders) Delete(ctx context.Context, coupleID, id int64) error {
+	return f.inCouple(coupleID, id, service.ErrReminderNotFound)
+}
+
+func (f fakeReminders) Upcoming(ctx context.Context, coupleID int64, days int) ([]dto.UpcomingReminderDTO, error) {
+	f.couple(coupleID)
+	return nil, nil
+}
+
+type fakeAnniversaries struct {
+	service.CoupleAnniversaryService
+	*ownership
+}
+
+func (f fakeAnniversaries) Create(ctx context.Context, coupleID int64, req *dto.CreateCoupleAnniversaryRequest) (*dto.CoupleAnniversaryDTO, error) {
+	f.couple(coupleID)
+	return &dto.CoupleAnniversaryDTO{}, nil
+}
+
+func (f fakeAnniversaries) List(ctx context.Context, coupleID int64) ([]dto.CoupleAnniversaryDTO, error) {
+	f.couple(coupleID)
+	return nil, nil
+}
+
+func (f fakeAnniversaries) Update(ctx context.Context, coupleID, id int64, req *dto.UpdateCoupleAnniversaryRequest) (*dto.CoupleAnniversaryDTO, error) {
+	if err := f.inCouple(coupleID, id, service.ErrAnniversaryNotFound); err != nil {
+		return nil, err
+	}
+	return &dto.CoupleAnniversaryDTO{}, nil
+}
+
+func (f fakeAnniversaries) Delete(ctx context.Context, coupleID, id int64) error {
+	return f.inCouple(coupleID, id, service.ErrAnniversaryNotFound)
+}
+
+type fakeAlbums struct {
+	service.CoupleAlbumService
+	*ownership
+}
+
+func (f fakeAlbums) Create(ctx context.Context, req *dto.CreateCoupleAlbumRequest) (*models.CoupleAlbum, error) {
+	f.user(req.UserID)
+	return &models.CoupleAlbum{}, nil
+}
+
+func (f fakeAlbums) GetByID(ctx context.Context, coupleID, id int64) (*models.CoupleAlbum, error) {
+	if err := f.inCouple(coupleID, id, service.ErrAlbumNotFound); err != nil {
+		return nil, err
+	}
+	return &models.CoupleAlbum{}, nil
+}
+
+func (f fakeAlbums) GetByCoupleID(ctx context.Context, coupleID int64) ([]*models.CoupleAlbum, error) {
+	f.couple(coupleID)
+	return nil, nil
+}
+
+func (f fakeAlbums) Update(ctx context.Context, coupleID, id int64, req *dto.UpdateCoupleAlbumRequest) (*models.CoupleAlbum, error) {
+	return f.GetByID(ctx, coupleID, id)
+}
+
+func (f fakeAlbums) Delete(ctx context.Context, coupleID, id int64) error {
+	return f.inCouple(coupleID, id, service.ErrAlbumNotFound)
+}
+
+func (f fakeAlbums) GetWithPhotos(ctx context.Context, coupleID, id int64) (*models.CoupleAlbum, error) {
+	return f.GetByID(ctx, coupleID, id)
+}
+
+func (f fakeAlbums) BatchDeletePhotoVideo(ctx context.Context, coupleID int64, req *dto.DeleteCoupleAlbumPhotosRequest) error {
+	if err := f.inCouple(coupleID, req.AlbumID, service.ErrAlbumNotFound); err != nil {
+		return err
+	}
+	return f.inCoupleAll(coupleID, req.PhotoVideoIDs, service.ErrPhotoVideoNotFound)
+}
+
+func (f fakeAlbums) PageCoupleMedia(ctx context.Context, param *dto.CoupleAlbumQueryParams) ([]*models.PhotoVideo, int64, error) {
+	f.couple(param.CoupleID)
+	return nil, 0, nil
+}
+
+type fakeAttachments struct {
+	service.AttachmentService
+	*ownership
+}
+
+func (f fakeAttachments) CreateAttachment(ctx context.Context, req *dto.CreateAttachmentRequest) (*models.Attachment, error) {
+	f.user(req.UserID)
+	if req.CoupleID != 0 {
+		f.couple(req.CoupleID)
+	}
+	return req.ToModel(), nil
+}
+
+func (f fakeAttachments) GetAttachmentByID(ctx context.Context, userID, coupleID, id int64) (*models.Attachment, error) {
+	if err := f.accessibleAttachment(userID, coupleID, id); err != nil {
+		return nil, err
+	}
+	return &models.Attachment{}, nil
+}
+
+func (f fakeAttachments) QueryAttachments(ctx context.Context, params *dto.AttachmentQueryParams) (*dto.PageResult, error) {
+	f.user(params.UserID)
+	return &dto.PageResult{}, nil
+}
+
+func (f fakeAttachments) DeleteAttachment(ctx context.Context, userID, coupleID, id int64) error {
+	return f.accessibleAttachment(userID, coupleID, id)
+}
+
+// fakeServices 只实现路由用到的服务，其他服务保持为nil，被意外调用时请求会以500失败
+type fakeServices struct {
+	service.Factory
+	own     *ownership
+	prefs   service.EmailPreferenceService
+	users   service.UserService
+	audit   service.AuditService
+	revoked []int64
+}
+
+func (f *fakeServices) User() service.UserService               { return f.users }
+func (f *fakeServices) Email() service.EmailService             { return nil }
+func (f *fakeServices) LoginGuard() service.LoginGuardService   { return nil }
+func (f *fakeServices) Audit() service.AuditService             { return f.audit }
+func (f *fakeServices) JWT() service.JWTService                 { return fakeJWT{revoked: &f.revoked} }
+func (f *fakeServices) CoupleGuard() service.CoupleGuardService { return fakeGuard{} }
+
+func (f *fakeServices) EmailPreference() service.EmailPreferenceService {
+	return f.prefs
+}
+
+func (f *fakeServices) AccessToken() service.AccessTokenService {
+	return fakeAccessTokens{}
+}
+
+func (f *fakeServices) TimelineEvent() service.TimelineEventService {
+	return fakeTimelineEvents{ownership: f.own}
+}
+
+func (f *fakeServices) Location() service.LocationService {
+	return fakeLocations{ownership: f.own}
+}
+
+func (f *fakeServices) PhotoVideo() service.PhotoVideoService {
+	return fakePhotoVideos{ownership: f.own}
+}
+
+func (f *fakeServices) PersonalMedia() service.PersonalMediaService {
+	return fakePersonalMedia{ownership: f.own}
+}
+
+func (f *fakeServices) Wishlist() service.WishlistService {
+	return fakeWishlists{ownership: f.own}
+}
+
+func (f *fakeServices) Reminder() service.ReminderService {
+	return fakeReminders{ownership: f.own}
+}
+
+func (f *fakeServices) CoupleAnniversary() service.CoupleAnniversaryService {
+	return fakeAnniversaries{ownership: f.own}
+}
+
+func (f *fakeServices) CoupleAlbum() service.CoupleAlbumService {
+	return fakeAlbums{ownership: f.own}
+}
+
+func (f *fakeServices) Attachment() service.AttachmentService {
+	return fakeAttachments{ownership: f.own}
+}
+
+func newTestRouter(t *testing.T, services service.Factory) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	cfg := &config.Config{
+		Server: config.ServerConfig{
+			Mode:        "release",
+			MaxBodySize: 1 << 20,
+			CorsOrigins: []string{"http://localhost"},
+		},
+	}
+	router := gin.New()
+	RegisterRoutes(router, services, nil, cfg)
+	return router
+}
+
+// TestCoupleScopedRoutes 以另一对情侣的用户身份调用所有情侣和个人数据接口：
+// 按ID访问对方的数据一律返回404，创建和列表接口只能作用于调用者自己的情侣，请求中伪造的 couple_id 不起作用
+func TestCoupleScopedRoutes(t *testing.T) {
+	tests := []struct {
+		name   string
+		route  string // 路由模板，用于检查所有接口都有用例
+		method string
+		path   string
+		body   string
+		want   int
+	}{
+		{"create event", "/api/v1/events/create", http.MethodPost, "/api/v1/events/create",
+			`{"couple_id":"10","title":"t","content":"c","start_date":"2024-01-01","end_date":"2024-01-02"}`, http.StatusCreated},
+		{"create event with foreign location", "/api/v1/events/create", http.MethodPost, "/api/v1/events/create",
+			`{"title":"t","content":"c","start_date":"2024-01-01","end_date":"2024-01-02","location_ids":["100"]}`, http.StatusNotFound},
+		{"create event with foreign photo", "/api/v1/events/create", http.MethodPost, "/api/v1/events/create",
+			`{"title":"t","content":"c","start_date":"2024-01-01","end_date":"2024-01-02","photo_video_ids":["100"]}`, http.StatusNotFound},
+		{"page events", "/api/v1/events/page", http.MethodGet, "/api/v1/events/page?couple_id=10", "", http.StatusOK},
+		{"get foreign event", "/api/v1/events/:id", http.MethodGet, "/api/v1/events/100", "", http.StatusNotFound},
+		{"update foreign event", "/api/v1/events/:id", http.MethodPut, "/api/v1/events/100",
+			`{"event_id":"100","start_date":"2024-01-01","end_date":"2024-01-02"}`, http.StatusNotFound},
+		{"delete foreign event", "/api/v1/events/:id", http.MethodDelete, "/api/v1/events/100", "", http.StatusNotFound},
+
+		{"list locations", "/api/v1/locations/list", http.MethodGet, "/api/v1/locations/list?couple_id=10", "", http.StatusOK},
+		{"create location", "/api/v1/locations/create", http.MethodPost, "/api/v1/locations/create",
+			`{"couple_id":"10","name":"n","longitude":1,"latitude":1}`, http.StatusCreated},
+		{"get foreign location", "/api/v1/locations/:id", http.MethodGet, "/api/v1/locations/100", "", http.StatusNotFound},
+		{"delete foreign location", "/api/v1/locations/:id", http.MethodDelete, "/api/v1/locations/100", "", http.StatusNotFound},
+
+		{"create media in foreign album", "/api/v1/media/create", http.MethodPost, "/api/v1/media/create",
+			`{"couple_id":10,"user_id":1,"media_type":"photo","media_url":"u","thumbnail_url":"t","album_id":"100"}`, http.StatusNotFound},
+		{"page media", "/api/v1/media/page", http.MethodGet, "/api/v1/media/page?couple_id=10", "", http.StatusOK},
+
+		{"create personal media", "/api/v1/personal-media/create", http.MethodPost, "/api/v1/personal-media/create",
+			`{"user_id":1,"media_type":"photo","media_url":"u","thumbnail_url":"t"}`, http.StatusCreated},
+		{"page personal media", "/api/v1/personal-media/page", http.MethodGet, "/api/v1/personal-media/page", "", http.StatusOK},
+		{"delete foreign personal media", "/api/v1/personal-media/:id", http.MethodDelete, "/api/v1/personal-media/100", "", http.StatusNotFound},
+
+		{"list wishlist", "/api/v1/wishlist/list", http.MethodGet, "/api/v1/wishlist/list?couple_id=10", "", http.StatusOK},
+		{"create wishlist", "/api/v1/wishlist/create", http.MethodPost, "/api/v1/wishlist/create",
+			`{"couple_id":"10","title":"t"}`, http.StatusCreated},
+		{"create wishlist with foreign attachment", "/api/v1/wishlist/create", http.MethodPost, "/api/v1/wishlist/create",
+			`{"title":"t","attachment_ids":["100"]}`, http.StatusNotFound},
+		{"update foreign wishlist", "/api/v1/wishlist/update", http.MethodPut, "/api/v1/wishlist/update",
+			`{"id":"100","title":"t"}`, http.StatusNotFound},
+		{"update foreign wishlist status", "/api/v1/wishlist/:id/status", http.MethodPut, "/api/v1/wishlist/100/status",
+			`{"status":"completed"}`, http.StatusNotFound},
+		{"delete foreign wishlist", "/api/v1/wishlist/:id", http.MethodDelete, "/api/v1/wishlist/100", "", http.StatusNotFound},
+		{"associate foreign wishlist", "/api/v1/wishlist/associateAttachments", http.MethodPost, "/api/v1/wishlist/associateAttachments",
+			`{"wishlist_id":"100","attachment_ids":[]}`, http.StatusNotFound},
+
+		{"list reminders", "/api/v1/reminders", http.MethodGet, "/api/v1/reminders", "", http.StatusOK},
+		{"create reminder", "/api/v1/reminders", http.MethodPost, "/api/v1/reminders",
+			`{"title":"t","date":"2024-01-01"}`, http.StatusCreated},
+		{"upcoming reminders", "/api/v1/reminders/upcoming", http.MethodGet, "/api/v1/reminders/upcoming", "", http.StatusOK},
+		{"update foreign reminder", "/api/v1/reminders/:id", http.MethodPut, "/api/v1/reminders/100", `{"title":"t"}`, http.StatusNotFound},
+		{"delete foreign reminder", "/api/v1/reminders/:id", http.MethodDelete, "/api/v1/reminders/100", "", http.StatusNotFound},
+
+		{"list anniversaries", "/api/v1/couple/anniversaries", http.MethodGet, "/api/v1/couple/anniversaries", "", http.StatusOK},
+		{"create anniversary", "/api/v1/couple/anniversaries", http.MethodPost, "/api/v1/couple/anniversaries",
+			`{"title":"t","date":"2024-01-01"}`, http.StatusCreated},
+		{"update foreign anniversary", "/api/v1/couple/anniversaries/:id", http.MethodPut, "/api/v1/couple/anniversaries/100",
+			`{"title":"t"}`, http.StatusNotFound},
+		{"delete foreign anniversary", "/api/v1/couple/anniversaries/:id", http.MethodDelete, "/api/v1/couple/anniversaries/100", "", http.StatusNotFound},
+
+		{"list albums", "/api/v1/albums/list", http.MethodGet, "/api/v1/albums/list?couple_id=10", "", http.StatusOK},
+		{"create album", "/api/v1/albums/create", http.MethodPost, "/api/v1/albums/create",
+			`{"user_id":1,"title":"t"}`, http.StatusCreated},
+		{"get foreign album photos", "/api/v1/albums/photos", http.MethodGet, "/api/v1/albums/photos?id=100", "", http.StatusNotFound},
+		{"delete foreign album", "/api/v1/albums/:id", http.MethodDelete, "/api/v1/albums/100", "", http.StatusNotFound},
+		{"delete photos from foreign album", "/api/v1/albums/deletePhotos", http.MethodPost, "/api/v1/albums/deletePhotos",
+			`{"album_id":"100","photo_video_ids":["100"]}`, http.StatusNotFound},
+		{"page couple media", "/api/v1/albums/all-media/page", http.MethodGet, "/api/v1/albums/all-media/page?couple_id=10", "", http.StatusOK},
+		{"get foreign album", "/api/v1/albums/:id", http.MethodGet, "/api/v1/albums/100", "", http.StatusNotFound},
+		{"update foreign album", "/api/v1/albums/:id", http.MethodPut, "/api/v1/albums/100", `{"title":"t"}`, http.StatusNotFound},
+
+		{"create couple attachment", "/api/v1/attachments/create", http.MethodPost, "/api/v1/attachments/create",
+			`{"user_id":1,"couple_id":"10","file_name":"f","file_type":"image","file_size":1,"url":"u","space_type":"couple"}`, http.StatusCreated},
+		{"get foreign attachment", "/api/v1/attachments/:id", http.MethodGet, "/api/v1/attachments/100", "", http.StatusNotFound},
+		{"list attachments", "/api/v1/attachments/list", http.MethodGet, "/api/v1/attachments/list", "", http.StatusOK},
+		{"delete foreign attachment", "/api/v1/attachments/:id", http.MethodDelete, "/api/v1/attachments/100", "", http.StatusNotFound},
+	}
+
+	covered := make(map[string]bool)
+	for _, tt := range tests {
+		covered[tt.method+" "+tt.route] = true
+	}
+	for _, route := range newTestRouter(t, &fakeServices{own: &ownership{}}).Routes() {
+		if !isCoupleScoped(route.Path) {
+			continue
+		}
+		if !covered[route.Method+" "+route.Path] {
+			t.Errorf("route %s %s has no foreign-couple test case", route.Method, route.Path)
+		}
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			own := &ownership{}
+			router := newTestRouter(t, &fakeServices{own: own})
+
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			req.Header.Set("Authorization", "Bearer "+callerToken)
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.want {
+				t.Fatalf("status = %d, want %d, body: %s", w.Code, tt.want, w.Body.String())
+			}
+			if len(own.coupleIDs) == 0 && len(own.userIDs) == 0 {
+				t.Fatal("handler did not reach the service")
+			}
+			for _, id := range own.coupleIDs {
+				if id != callerCoupleID {
+					t.Errorf("service received couple %d, want caller's couple %d", id, callerCoupleID)
+				}
+			}
+			for _, id := range own.userIDs {
+				if id != callerUserID {
+					t.Errorf("service received user %d, want caller %d", id, callerUserID)
+				}
+			}
+		})
+	}
+}
+
+func isCoupleScoped(path string) bool {
+	for _, prefix := range coupleScopedPrefixes {
+		if path == prefix || strings.HasPrefix(path, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// sessionRequired RequireSession 拒绝个人访问令牌时的错误详情，用来区分权限不足
+const sessionRequired = "access tokens are not allowed"
+
+// TestAccessTokenRestrictions 个人访问令牌不能修改邮箱、配对或解除情侣关系，账号停用后令牌立即失效
+func TestAccessTokenRestrictions(t *testing.T) {
+	tests := []struct {
+		name   string
+		token  string
+		method string
+		path   string
+		body   string
+		want   int
+		detail string // 响应中应包含的错误详情，为空时不检查
+	}{
+		{"request email change", callerAccessToken, http.MethodPost, "/api/v1/users/me/email",
+			`{"new_email":"new@example.com","password":"secret"}`, http.StatusForbidden, sessionRequired},
+		{"confirm email change", callerAccessToken, http.MethodPost, "/api/v1/users/me/email/confirm",
+			`{"new_email":"new@example.com","code":"123456"}`, http.StatusForbidden, sessionRequired},
+		{"create couple invite", callerAccessToken, http.MethodPost, "/api/v1/couple/invite", `{}`, http.StatusForbidden, sessionRequired},
+		{"join couple", callerAccessToken, http.MethodPost, "/api/v1/couple/join", `{"code":"ABCDEF"}`, http.StatusForbidden, sessionRequired},
+		{"request couple dissolution", callerAccessToken, http.MethodPost, "/api/v1/couple/leave",
+			`{"handoff":"archive"}`, http.StatusForbidden, sessionRequired},
+		{"cancel couple dissolution", callerAccessToken, http.MethodDelete, "/api/v1/couple/leave", "", http.StatusForbidden, sessionRequired},
+		{"disabled account", disabledAccessToken, http.MethodGet, "/api/v1/events/page", "", http.StatusForbidden, ""},
+		{"unknown token", service.AccessTokenPrefix + "unknown", http.MethodGet, "/api/v1/events/page", "", http.StatusUnauthorized, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := newTestRouter(t, &fakeServices{own: &ownership{}})
+
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.want {
+				t.Fatalf("status = %d, want %d, body: %s", w.Code, tt.want, w.Body.String())
+			}
+			if tt.detail != "" && !strings.Contains(w.Body.String(), tt.detail) {
+				t.Fatalf("body = %s, want detail %q", w.Body.String(), tt.detail)
+			}
+		})
+	}
+}
+
+// fakePreferenceRepo 用 map 模拟邮件偏好表
+type fakePreferenceRepo struct {
+	repository.EmailPreferenceRepository
+	rows map[int64]map[string]bool
+}
+
+func (r *fakePreferenceRepo) ListByUserID(ctx context.Context, userID int64) ([]*models.EmailPreference, error) {
+	var preferences []*models.EmailPreference
+	for emailType, enabled := range r.rows[userID] {
+		preferences = append(preferences, &models.EmailPreference{UserID: userID, EmailType: emailType, Enabled: enabled})
+	}
+	return preferences, nil
+}
+
+func (r *fakePreferenceRepo) Upsert(ctx context.Context, userID int64, emailType string, enabled bool) error {
+	if r.rows[userID] == nil {
+		r.rows[userID] = make(map[string]bool)
+	}
+	r.rows[userID][emailType] = enabled
+	return nil
+}
+
+type discardAudit struct {
+	service.AuditService
+}
+
+func (discardAudit) Record(ctx context.Context, entry service.AuditEntry) {}
+
+// TestEmailPreferenceRoutes 登录用户只能开关可退订的邮件，退订链接不需要登录，签名无效时返回400
+func TestEmailPreferenceRoutes(t *testing.T) {
+	const secret = "test-unsubscribe-secret"
+	festivalToken := email.NewUnsubscribeToken(secret, callerUserID, email.EmailTypeFestival)
+
+	tests := []struct {
+		name    string
+		token   string // 登录令牌，为空表示匿名请求
+		method  string
+		path    string
+		body    string
+		want    int
+		enabled map[email.EmailType]bool // 请求后 caller 的邮件开关
+	}{
+		{"list preferences", callerToken, http.MethodGet, "/api/v1/users/me/email-preferences", "", http.StatusOK,
+			map[email.EmailType]bool{email.EmailTypeFestival: true}},
+		{"disable optional type", callerToken, http.MethodPut, "/api/v1/users/me/email-preferences/festival",
+			`{"enabled":false}`, http.StatusOK, map[email.EmailType]bool{email.EmailTypeFestival: false, email.EmailTypeAnniversary: true}},
+		{"disable mandatory type", callerToken, http.MethodPut, "/api/v1/users/me/email-preferences/reset_password",
+			`{"enabled":false}`, http.StatusBadRequest, map[email.EmailType]bool{email.EmailTypeResetPassword: true}},
+		{"disable unknown type", callerToken, http.MethodPut, "/api/v1/users/me/email-preferences/unknown",
+			`{"enabled":false}`, http.StatusBadRequest, nil},
+		{"missing enabled", callerToken, http.MethodPut, "/api/v1/users/me/email-preferences/festival",
+			`{}`, http.StatusBadRequest, map[email.EmailType]bool{email.EmailTypeFestival: true}},
+		{"anonymous preferences", "", http.MethodGet, "/api/v1/users/me/email-preferences", "", http.StatusUnauthorized, nil},
+
+		{"verify unsubscribe link", "", http.MethodGet, "/api/v1/email/unsubscribe?token=" + festivalToken, "", http.StatusOK,
+			map[email.EmailType]bool{email.EmailTypeFestival: true}},
+		{"one-click unsubscribe", "", http.MethodPost, "/api/v1/email/unsubscribe?token=" + festivalToken,
+			"List-Unsubscribe=One-Click", http.StatusOK, map[email.EmailType]bool{email.EmailTypeFestival: false, email.EmailTypeReminder: true}},
+		{"unsubscribe from confirm page", "", http.MethodPost, "/api/v1/email/unsubscribe",
+			`{"token":"` + festivalToken + `"}`, http.StatusOK, map[email.EmailType]bool{email.EmailTypeFestival: false}},
+		{"forged unsubscribe link", "", http.MethodPost, "/api/v1/email/unsubscribe?token=" +
+			email.NewUnsubscribeToken("other-secret", callerUserID, email.EmailTypeFestival), "", http.StatusBadRequest,
+			map[email.EmailType]bool{email.EmailTypeFestival: true}},
+		{"unsubscribe from mandatory type", "", http.MethodPost, "/api/v1/email/unsubscribe?token=" +
+			email.NewUnsubscribeToken(secret, callerUserID, email.EmailTypeVerification), "", http.StatusBadRequest,
+			map[email.EmailType]bool{email.EmailTypeVerification: true}},
+		{"missing unsubscribe token", "", http.MethodPost, "/api/v1/email/unsubscribe", "", http.StatusBadRequest, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prefs := service.NewEmailPreferenceService(&fakePreferenceRepo{rows: make(map[int64]map[string]bool)}, discardAudit{}, secret)
+			router := newTestRouter(t, &fakeServices{own: &ownership{}, prefs: prefs})
+
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			if tt.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+			if strings.HasPrefix(tt.body, "{") {
+				req.Header.Set("Content-Type", "application/json")
+			} else {
+				req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.want {
+				t.Fatalf("status = %d, want %d, body: %s", w.Code, tt.want, w.Body.String())
+			}
+			for emailType, want := range tt.enabled {
+				enabled, err := prefs.IsEnabled(context.Background(), callerUserID, emailType)
+				if err != nil || enabled != want {
+					t.Errorf("%s enabled = %v, %v, want %v", emailType, enabled, err, want)
+				}
+			}
+		})
+	}
+}
+
+// fakeUsers caller 是管理员，ownerUserID 是普通用户，其他ID不存在
+type fakeUsers struct {
+	service.UserService
+}
+
+func (fakeUsers) GetUserByID(ctx context.Context, id int64) (*models.User, error) {
+	switch id {
+	case callerUserID:
+		return &models.User{Base: models.Base{ID: id}, Role: models.RoleAdmin}, nil
+	case ownerUserID:
+		return &models.User{Base: models.Base{ID: id}, Role: models.RoleUser}, nil
+	}
+	return nil, repository.ErrUserNotFound
+}
+
+// recordingAudit 记录收到的审计事件
+type recordingAudit struct {
+	service.AuditService
+	entries []service.AuditEntry
+}
+
+func (a *recordingAudit) Record(ctx context.Context, entry service.AuditEntry) {
+	a.entries = append(a.entries, entry)
+}
+
+// TestAdminRevokeUserSessions 管理员只能注销存在的用户的会话，不存在的ID返回404且不记审计日志
+func TestAdminRevokeUserSessions(t *testing.T) {
+	tests := []struct {
+		name    string
+		path    string
+		want    int
+		revoked []int64
+	}{
+		{"existing user", "/api/v1/admin/users/1/sessions", http.StatusOK, []int64{ownerUserID}},
+		{"unknown user", "/api/v1/admin/users/999/sessions", http.StatusNotFound, nil},
+		{"invalid id", "/api/v1/admin/users/abc/sessions", http.StatusBadRequest, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			audit := &recordingAudit{}
+			services := &fakeServices{own: &ownership{}, users: fakeUsers{}, audit: audit}
+			router := newTestRouter(t, services)
+
+			req := httptest.NewRequest(http.MethodDelete, tt.path, nil)
+			req.Header.Set("Authorization", "Bearer "+callerToken)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.want {
+				t.Fatalf("status = %d, want %d, body: %s", w.Code, tt.want, w.Body.String())
+			}
+			if len(services.revoked) != len(tt.revoked) || (len(tt.revoked) > 0 && services.revoked[0] != tt.revoked[0]) {
+				t.Errorf("revoked = %v, want %v", services.revoked, tt.revoked)
+			}
+			if len(audit.entries) != len(tt.revoked) {
+				t.Errorf("audit entries = %d, want %d", len(audit.entries), len(tt.revoked))
+			}
+		})
+	}
+}
@@ -1,6 +1,9 @@
 package config
 
 import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"log"
 	"os"
@@ -13,10 +16,13 @@ import (
 
 // Config 存储应用程序配置
 type Config struct {
-	DB     DBConfig
-	Redis  RedisConfig
-	Server ServerConfig
-	Email  EmailConfig // 新增邮件配置
+	DB        DBConfig
+	Redis     RedisConfig
+	Server    ServerConfig
+	Email     EmailConfig // 新增邮件配置
+	Auth      AuthConfig  // 账号安全策略
+	JWT       JWTKeyConfig
+	Scheduler SchedulerConfig // 定时任务
 }
 
 // DBConfig 存储数据库配置
@@ -44,6 +50,7 @@ type RedisConfig struct {
 // EmailConfig 存储邮件服务配置
 type EmailConfig struct {
 	Enabled         bool   // 是否启用邮件功能
+	Transport       string // 发送方式：aliyun（默认）、smtp、file
 	AccessKeyID     string // 阿里云AccessKeyID
 	AccessKeySecret string // 阿里云AccessKeySecret
 	RegionID        string // 阿里云区域ID
@@ -51,8 +58,53 @@ type EmailConfig struct {
 	FromAlias       string // 发信人名称
 	ReplyToAddress  bool   // 是否使用回信地址
 	AddressType     int    // 地址类型，0为随机账号，1为发信地址
+	SMTPHost        string // SMTP服务器地址
+	SMTPPort        int    // SMTP端口，为0时按加密方式使用587或465
+	SMTPUsername    string // SMTP用户名，为空时不认证
+	SMTPPassword    string // SMTP密码
+	SMTPEncryption  string // SMTP加密方式：starttls（默认）、tls、none
+	SpoolDir        string // file 方式写入 .eml 文件的目录
+	TemplateDir     string // 邮件模板目录，其中的同名文件覆盖内置模板，为空时只使用内置模板
 	AppName         string // 应用名称，用于邮件模板
 	AppURL          string // 应用URL，用于生成链接
+	APIURL          string // API的外部访问地址，用于生成一键退订链接
+	// UnsubscribeSecret 退订链接的签名密钥，未配置时由 JWT_SECRET 派生，两者都为空时邮件服务无法启动
+	UnsubscribeSecret string
+	// QueueConcurrency 每个实例同时发送邮件的协程数
+	QueueConcurrency int
+	// MaxRetries 发送失败后最多重试的次数，超过后放入死信队列
+	MaxRetries int
+	// RetryBaseSeconds 第一次重试前等待的秒数，之后每次翻倍，最长1小时
+	RetryBaseSeconds int
+}
+
+// AuthConfig 账号安全策略配置
+type AuthConfig struct {
+	RequireEmailVerification  bool // 未验证邮箱的账号不能创建情侣关系、上传媒体或接收提醒邮件，开启前先执行 migrate -action=grandfather-verified
+	LoginMaxFailures          int  // 同一账号连续登录失败多少次后锁定
+	LoginIPMaxFailures        int  // 同一IP登录失败多少次后锁定
+	LoginFailureWindowMinutes int  // 登录失败次数的统计窗口(分钟)
+	LoginLockoutMinutes       int  // 锁定时长(分钟)
+}
+
+// JWTKeyConfig JWT签名密钥配置
+type JWTKeyConfig struct {
+	Algorithm        string // 签名算法：RS256 或 EdDSA，留空时根据私钥类型推断
+	PrivateKeyFile   string // 当前签名私钥PEM文件路径
+	KeyID            string // 当前签名密钥的kid，留空时使用公钥指纹
+	VerificationKeys string // 轮换期间仍然有效的旧公钥，格式 kid=path,kid=path
+	Secret           string // HS256密钥，仅在未配置私钥的开发环境使用
+}
+
+// SchedulerConfig 定时任务配置，调度表为标准5段cron表达式，留空表示该任务只能手动触发
+type SchedulerConfig struct {
+	Enabled             bool   // 是否在本实例启动调度，多副本时每个任务仍只会在一个实例上执行
+	Timezone            string // 调度表使用的时区
+	LockTTLSeconds      int    // 任务锁的过期时间(秒)，执行中会自动续期，实例崩溃后锁在这个时间后释放
+	AnniversarySchedule string // 纪念日提醒，每次执行只处理当地时间已到提醒时刻的情侣，需要每小时执行
+	FestivalSchedule    string // 节日提醒，同上
+	ReminderSchedule    string // 自定义提醒和心愿单提醒，同上
+	DissolutionSchedule string // 完成冷静期已结束的情侣关系解除
 }
 
 // ServerConfig 服务配置
@@ -149,6 +201,13 @@ func New() *Config {
 		emailEnabled = true
 	}
 
+	// 退订链接的签名密钥未单独配置时由JWT密钥派生，加上用途标签避免两处签名可以互换
+	jwtSecret := getEnv("JWT_SECRET", getEnv("SERVER_JWTSECRET", ""))
+	unsubscribeSecret := getEnv("EMAIL_UNSUBSCRIBE_SECRET", "")
+	if unsubscribeSecret == "" {
+		unsubscribeSecret = deriveSecret(jwtSecret, "email-unsubscribe")
+	}
+
 	return &Config{
 		DB: DBConfig{
 			Host:            getEnv("DB_HOST", "localhost"),
@@ -170,6 +229,7 @@ func New() *Config {
 		},
 		Email: EmailConfig{
 			Enabled:         emailEnabled,
+			Transport:       getEnv("EMAIL_TRANSPORT", "aliyun"),
 			AccessKeyID:     getEnv("EMAIL_ACCESS_KEY_ID", ""),
 			AccessKeySecret: getEnv("EMAIL_ACCESS_KEY_SECRET", ""),
 			RegionID:        getEnv("EMAIL_REGION_ID", "cn-hangzhou"),
@@ -177,8 +237,45 @@ func New() *Config {
 			FromAlias:       getEnv("EMAIL_FROM_ALIAS", "Memoir App"),
 			ReplyToAddress:  getEnvBool("EMAIL_REPLY_TO_ADDRESS", "false"),
 			AddressType:     getEnvInt("EMAIL_ADDRESS_TYPE", "1"),
+			SMTPHost:        getEnv("SMTP_HOST", ""),
+			SMTPPort:        getEnvInt("SMTP_PORT", "0"),
+			SMTPUsername:    getEnv("SMTP_USERNAME", ""),
+			SMTPPassword:    getEnv("SMTP_PASSWORD", ""),
+			SMTPEncryption:  getEnv("SMTP_ENCRYPTION", "starttls"),
+			SpoolDir:        getEnv("EMAIL_SPOOL_DIR", "./tmp/mail"),
+			TemplateDir:     getEnv("EMAIL_TEMPLATE_DIR", ""),
 			AppName:         getEnv("APP_NAME", "Memoir"),
 			AppURL:          getEnv("APP_URL", "http://localhost:3000"),
+			APIURL:          getEnv("API_URL", "http://localhost:5000"),
+
+			UnsubscribeSecret: unsubscribeSecret,
+
+			QueueConcurrency: getEnvInt("EMAIL_QUEUE_CONCURRENCY", "4"),
+			MaxRetries:       getEnvInt("EMAIL_MAX_RETRIES", "5"),
+			RetryBaseSeconds: getEnvInt("EMAIL_RETRY_BASE_SECONDS", "30"),
+		},
+		Auth: AuthConfig{
+			RequireEmailVerification:  getEnvBool("AUTH_REQUIRE_EMAIL_VERIFICATION", "false"),
+			LoginMaxFailures:          getEnvInt("AUTH_LOGIN_MAX_FAILURES", "10"),
+			LoginIPMaxFailures:        getEnvInt("AUTH_LOGIN_IP_MAX_FAILURES", "50"),
+			LoginFailureWindowMinutes: getEnvInt("AUTH_LOGIN_FAILURE_WINDOW", "15"),
+			LoginLockoutMinutes:       getEnvInt("AUTH_LOGIN_LOCKOUT_MINUTES", "15"),
+		},
+		JWT: JWTKeyConfig{
+			Algorithm:        getEnv("JWT_ALGORITHM", ""),
+			PrivateKeyFile:   getEnv("JWT_PRIVATE_KEY_FILE", ""),
+			KeyID:            getEnv("JWT_KEY_ID", ""),
+			VerificationKeys: getEnv("JWT_VERIFICATION_KEYS", ""),
+			Secret:           jwtSecret,
+		},
+		Scheduler: SchedulerConfig{
+			Enabled:             getEnvBool("SCHEDULER_ENABLED", "true"),
+			Timezone:            getEnv("SCHEDULER_TIMEZONE", "Asia/Shanghai"),
+			LockTTLSeconds:      getEnvInt("SCHEDULER_LOCK_TTL", "300"),
+			AnniversarySchedule: getEnv("SCHEDULER_ANNIVERSARY_CRON", "0 * * * *"),
+			FestivalSchedule:    getEnv("SCHEDULER_FESTIVAL_CRON", "0 * * * *"),
+			ReminderSchedule:    getEnv("SCHEDULER_REMINDER_CRON", "0 * * * *"),
+			DissolutionSchedule: getEnv("SCHEDULER_DISSOLUTION_CRON", "0 * * * *"),
 		},
 		Server: ServerConfig{
 			Port:         getEnvInt("SERVER_PORT", "5000"),
@@ -196,6 +293,16 @@ func New() *Config {
 	}
 }
 
+// deriveSecret 用 HMAC-SHA256 从主密钥派生指定用途的子密钥，主密钥为空时返回空
+func deriveSecret(secret, purpose string) string {
+	if secret == "" {
+		return ""
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(purpose))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
 // 从环境变量获取值，如果不存在则使用默认值
 func getEnv(key, defaultValue string) string {
 	if value, exists := os.LookupEnv(key); exists {
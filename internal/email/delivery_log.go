@@ -0,0 +1,69 @@
+package email
+
+import (
+	"context"
+	"time"
+
+	"memoir-api/internal/models"
+)
+
+// 投递记录只用于排查问题，写入失败只记录日志，不影响邮件发送
+
+// recordQueued 记录放入队列的邮件
+func (s *MailService) recordQueued(ctx context.Context, task EmailTask) {
+	if s.logs == nil {
+		return
+	}
+	err := s.logs.Create(ctx, &models.EmailLog{
+		TaskID:    task.ID,
+		UserID:    task.UserID,
+		Type:      string(task.Type),
+		ToAddress: task.ToAddress,
+		Locale:    task.Locale,
+		Subject:   task.Subject,
+		Status:    models.EmailStatusQueued,
+	})
+	if err != nil {
+		s.log.Error(err, "保存邮件投递记录失败", "id", task.ID)
+	}
+}
+
+// recordSent 记录发送成功
+func (s *MailService) recordSent(ctx context.Context, task EmailTask, providerID string) {
+	if s.logs == nil {
+		return
+	}
+	if err := s.logs.MarkSent(ctx, task.ID, s.transport.Name(), providerID, time.Now()); err != nil {
+		s.log.Error(err, "更新邮件投递记录失败", "id", task.ID)
+	}
+}
+
+// recordFailed 记录发送失败，status 为 failed 或 dead
+func (s *MailService) recordFailed(ctx context.Context, task EmailTask, status string, sendErr error) {
+	if s.logs == nil {
+		return
+	}
+	if err := s.logs.MarkFailed(ctx, task.ID, s.transport.Name(), status, sendErr.Error(), time.Now()); err != nil {
+		s.log.Error(err, "更新邮件投递记录失败", "id", task.ID)
+	}
+}
+
+// recordRequeued 记录死信或崩溃遗留的任务重新放回队列
+func (s *MailService) recordRequeued(ctx context.Context, id string) {
+	if s.logs == nil {
+		return
+	}
+	if err := s.logs.MarkQueued(ctx, id); err != nil {
+		s.log.Error(err, "更新邮件投递记录失败", "id", id)
+	}
+}
+
+// recordDiscarded 记录死信被删除
+func (s *MailService) recordDiscarded(ctx context.Context, ids ...string) {
+	if s.logs == nil {
+		return
+	}
+	if err := s.logs.MarkDiscarded(ctx, ids); err != nil {
+		s.log.Error(err, "更新邮件投递记录失败", "ids", ids)
+	}
+}
@@ -2,28 +2,36 @@ package email
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"memoir-api/internal/config"
 	"memoir-api/internal/logger"
+	"memoir-api/internal/repository"
+	"net/url"
+	"strconv"
 	"time"
 
-	openapi "github.com/alibabacloud-go/darabonba-openapi/v2/client"
-	dm20151123 "github.com/alibabacloud-go/dm-20151123/v2/client"
-	"github.com/alibabacloud-go/tea/tea"
 	"github.com/go-redis/redis/v8"
 )
 
 // 邮件任务结构
 type EmailTask struct {
+	// ID 任务ID，用于在死信队列中定位任务
+	ID         string            `json:"id"`
+	UserID     int64             `json:"user_id,omitempty"`
 	Type       EmailType         `json:"type"`
 	ToAddress  string            `json:"to_address"`
+	Locale     string            `json:"locale,omitempty"`
 	Subject    string            `json:"subject"`
 	HtmlBody   string            `json:"html_body"`
 	TextBody   string            `json:"text_body"`
 	Data       map[string]string `json:"data"`
+	Headers    map[string]string `json:"headers,omitempty"` // 额外的邮件头，如退订链接
 	RetryCount int               `json:"retry_count"`
 	CreatedAt  time.Time         `json:"created_at"`
+	// LastError 最近一次发送失败的原因
+	LastError string `json:"last_error,omitempty"`
+	// FailedAt 放入死信队列的时间
+	FailedAt *time.Time `json:"failed_at,omitempty"`
 }
 
 // EmailType 邮件类型
@@ -31,12 +39,15 @@ type EmailType string
 
 // 邮件类型常量
 const (
-	EmailTypeVerification  EmailType = "verification"   // 邮箱验证
-	EmailTypeResetPassword EmailType = "reset_password" // 密码重置
-	EmailTypeNotification  EmailType = "notification"   // 系统通知
-	EmailTypeWelcome       EmailType = "welcome"        // 欢迎邮件
-	EmailTypeAnniversary   EmailType = "anniversary"    // 纪念日邮件
-	EmailTypeFestival      EmailType = "festival"       // 节日邮件
+	EmailTypeVerification      EmailType = "verification"       // 邮箱验证
+	EmailTypeResetPassword     EmailType = "reset_password"     // 密码重置
+	EmailTypeNotification      EmailType = "notification"       // 系统通知
+	EmailTypeWelcome           EmailType = "welcome"            // 欢迎邮件
+	EmailTypeAnniversary       EmailType = "anniversary"        // 纪念日邮件
+	EmailTypeFestival          EmailType = "festival"           // 节日邮件
+	EmailTypeCoupleInvite      EmailType = "couple_invite"      // 情侣配对邀请
+	EmailTypeCustomAnniversary EmailType = "custom_anniversary" // 自定义纪念日提醒
+	EmailTypeReminder          EmailType = "reminder"           // 自定义提醒
 )
 
 // EmailQueue Redis队列名
@@ -48,29 +59,74 @@ const RateLimitPrefix = "email:rate_limit:"
 // 验证码有效期（分钟）
 const VerificationCodeExpiry = 15
 
+// 密码重置令牌有效期（分钟）
+const PasswordResetTokenExpiry = 30
+
+// 情侣邀请码有效期
+const CoupleInviteExpiry = 48 * time.Hour
+
+// Recipient 收件人，Locale 决定使用哪种语言的模板，为空时使用默认语言。
+// UserID 用于投递记录，收件人还不是用户时为0
+type Recipient struct {
+	UserID  int64
+	Address string
+	Name    string
+	Locale  string
+}
+
 // EmailService 邮件服务接口
 type EmailService interface {
 	// 发送验证邮件
-	SendVerificationEmail(ctx context.Context, toAddress, username, verificationCode string) error
+	SendVerificationEmail(ctx context.Context, to Recipient, verificationCode string) error
 
 	// 发送密码重置邮件
-	SendPasswordResetEmail(ctx context.Context, toAddress, resetToken string) error
+	SendPasswordResetEmail(ctx context.Context, to Recipient, resetToken string) error
 
 	// 发送通知邮件
-	SendNotificationEmail(ctx context.Context, toAddress, username, message string) error
+	SendNotificationEmail(ctx context.Context, to Recipient, message string) error
 
 	// 发送欢迎邮件
-	SendWelcomeEmail(ctx context.Context, toAddress, username string) error
+	SendWelcomeEmail(ctx context.Context, to Recipient) error
 
 	// 发送纪念日邮件
-	SendAnniversaryEmail(ctx context.Context, toAddress, username, partnerName string, days int, date string) error
+	SendAnniversaryEmail(ctx context.Context, to Recipient, partnerName string, days int, date string) error
 
 	// 发送节日邮件
-	SendFestivalEmail(ctx context.Context, toAddress, username, partnerName, festivalName string) error
+	SendFestivalEmail(ctx context.Context, to Recipient, partnerName, festivalName string) error
+
+	// 发送自定义纪念日提醒邮件，daysUntil 为0表示纪念日就是今天
+	SendCustomAnniversaryEmail(ctx context.Context, to Recipient, partnerName, title, date string, daysUntil int) error
+
+	// 发送自定义提醒邮件，daysUntil 为0表示就是今天
+	SendReminderEmail(ctx context.Context, to Recipient, title, note, date string, daysUntil int) error
 
-	// 处理邮件队列
+	// 发送情侣配对邀请邮件
+	SendCoupleInviteEmail(ctx context.Context, to Recipient, inviterName, inviteCode string, expireHours int) error
+
+	// 用示例数据渲染模板，用于开发环境预览
+	RenderPreview(emailType EmailType, locale string) (*RenderedEmail, error)
+
+	// 已加载模板的邮件类型
+	TemplateTypes() []EmailType
+
+	// 处理邮件队列，阻塞到 ctx 取消
 	ProcessEmailQueue(ctx context.Context)
 
+	// 获取邮件队列各部分的任务数
+	QueueStats(ctx context.Context) (*QueueStats, error)
+
+	// 分页获取死信
+	ListDeadLetters(ctx context.Context, offset, limit int) ([]EmailTask, int64, error)
+
+	// 把死信重新放回待发送队列
+	RequeueDeadLetter(ctx context.Context, id string) error
+
+	// 删除一条死信
+	DeleteDeadLetter(ctx context.Context, id string) error
+
+	// 清空死信队列
+	PurgeDeadLetters(ctx context.Context) (int64, error)
+
 	// 存储验证码到Redis
 	StoreVerificationCode(ctx context.Context, email, code string) error
 
@@ -84,342 +140,273 @@ type EmailService interface {
 	VerifyPasswordResetToken(ctx context.Context, email, token string) (bool, error)
 }
 
-// DirectMailService 阿里云邮件服务实现
-type DirectMailService struct {
-	client *dm20151123.Client
-	config *config.EmailConfig
-	redis  *redis.Client
-	log    logger.Logger
+// MailService 邮件服务实现，邮件先放入Redis队列，由队列处理协程通过 Transport 发送
+type MailService struct {
+	transport Transport
+	renderer  *TemplateRenderer
+	config    *config.EmailConfig
+	redis     *redis.Client
+	logs      repository.EmailLogRepository
+	log       logger.Logger
 }
 
-// NewEmailService 创建新的邮件服务实例
-func NewEmailService(cfg *config.Config, redisClient *redis.Client) (EmailService, error) {
+// NewEmailService 创建新的邮件服务实例，每封邮件的投递状态记录到 logs
+func NewEmailService(cfg *config.Config, redisClient *redis.Client, logs repository.EmailLogRepository) (EmailService, error) {
+	// 模板在邮件服务未启用时也加载，开发环境的预览接口需要用到
+	renderer, err := NewTemplateRenderer(cfg.Email.TemplateDir)
+	if err != nil {
+		return nil, err
+	}
+
 	// 如果邮件服务未启用，返回空实现
 	if !cfg.Email.Enabled {
-		return &noOpEmailService{}, nil
+		return &noOpEmailService{renderer: renderer, config: &cfg.Email}, nil
 	}
 
-	// 创建阿里云邮件客户端
-	client, err := createDMClient(cfg.Email)
-	if err != nil {
-		return nil, fmt.Errorf("创建阿里云邮件客户端失败: %w", err)
+	// 可退订的邮件必须带退订链接，没有签名密钥时拒绝启动
+	if cfg.Email.UnsubscribeSecret == "" {
+		return nil, ErrMissingUnsubscribeSecret
 	}
 
-	return &DirectMailService{
-		client: client,
-		config: &cfg.Email,
-		redis:  redisClient,
-		log:    logger.GetLogger("email-service"),
-	}, nil
-}
-
-// 创建阿里云邮件客户端
-func createDMClient(cfg config.EmailConfig) (*dm20151123.Client, error) {
-	config := &openapi.Config{
-		AccessKeyId:     tea.String(cfg.AccessKeyID),
-		AccessKeySecret: tea.String(cfg.AccessKeySecret),
-		RegionId:        tea.String(cfg.RegionID),
+	// 按配置创建发送方式
+	transport, err := NewTransport(cfg.Email)
+	if err != nil {
+		return nil, err
 	}
 
-	// 使用邮件服务的默认域名
-	config.Endpoint = tea.String("dm.aliyuncs.com")
-
-	return dm20151123.NewClient(config)
+	return &MailService{
+		transport: transport,
+		renderer:  renderer,
+		config:    &cfg.Email,
+		redis:     redisClient,
+		logs:      logs,
+		log:       logger.GetLogger("email-service"),
+	}, nil
 }
 
 // SendVerificationEmail 发送验证邮件
-func (s *DirectMailService) SendVerificationEmail(ctx context.Context, toAddress, username, verificationCode string) error {
+func (s *MailService) SendVerificationEmail(ctx context.Context, to Recipient, verificationCode string) error {
 	// 检查发送频率限制
-	if err := s.checkRateLimit(ctx, toAddress); err != nil {
+	if err := s.checkRateLimit(ctx, to.Address); err != nil {
 		return err
 	}
 
 	// 存储验证码到Redis
-	if err := s.StoreVerificationCode(ctx, toAddress, verificationCode); err != nil {
+	if err := s.StoreVerificationCode(ctx, to.Address, verificationCode); err != nil {
 		s.log.Error(err, "存储验证码失败")
 		return err
 	}
 
-	// 准备邮件内容
-	task := EmailTask{
-		Type:      EmailTypeVerification,
-		ToAddress: toAddress,
-		Subject:   fmt.Sprintf("%s - 请验证您的邮箱", s.config.AppName),
-		Data: map[string]string{
-			"AppName":          s.config.AppName,
-			"Username":         username,
-			"VerificationCode": verificationCode,
-			"ExpireMinutes":    fmt.Sprintf("%d", VerificationCodeExpiry),
-		},
-		CreatedAt: time.Now(),
-	}
-
-	// 渲染邮件内容
-	task.HtmlBody = renderVerificationEmailTemplate(task.Data)
-	task.TextBody = fmt.Sprintf("您好 %s，您的验证码是：%s，%d分钟内有效。",
-		username, verificationCode, VerificationCodeExpiry)
-
-	return s.addToQueue(ctx, task)
+	return s.enqueue(ctx, EmailTypeVerification, to, map[string]string{
+		"AppName":          s.config.AppName,
+		"Username":         to.Name,
+		"VerificationCode": verificationCode,
+		"ExpireMinutes":    strconv.Itoa(VerificationCodeExpiry),
+	})
 }
 
 // SendPasswordResetEmail 发送密码重置邮件
-func (s *DirectMailService) SendPasswordResetEmail(ctx context.Context, toAddress, resetToken string) error {
+func (s *MailService) SendPasswordResetEmail(ctx context.Context, to Recipient, resetToken string) error {
 	// 检查发送频率限制
-	if err := s.checkRateLimit(ctx, toAddress); err != nil {
+	if err := s.checkRateLimit(ctx, to.Address); err != nil {
 		return err
 	}
 
 	// 存储密码重置令牌到Redis
-	if err := s.StorePasswordResetToken(ctx, toAddress, resetToken); err != nil {
+	if err := s.StorePasswordResetToken(ctx, to.Address, resetToken); err != nil {
 		s.log.Error(err, "存储密码重置令牌失败")
 		return err
 	}
 
 	// 构建重置链接
 	resetLink := fmt.Sprintf("%s/reset-password?token=%s&email=%s",
-		s.config.AppURL, resetToken, toAddress)
-
-	// 准备邮件内容
-	task := EmailTask{
-		Type:      EmailTypeResetPassword,
-		ToAddress: toAddress,
-		Subject:   fmt.Sprintf("%s - 密码重置请求", s.config.AppName),
-		Data: map[string]string{
-			"AppName":   s.config.AppName,
-			"ResetLink": resetLink,
-		},
-		CreatedAt: time.Now(),
-	}
-
-	// 渲染邮件内容
-	task.HtmlBody = renderPasswordResetEmailTemplate(task.Data)
-	task.TextBody = fmt.Sprintf("您请求重置密码，请访问以下链接完成重置：%s", resetLink)
+		s.config.AppURL, url.QueryEscape(resetToken), url.QueryEscape(to.Address))
 
-	return s.addToQueue(ctx, task)
+	return s.enqueue(ctx, EmailTypeResetPassword, to, map[string]string{
+		"AppName":       s.config.AppName,
+		"ResetLink":     resetLink,
+		"ExpireMinutes": strconv.Itoa(PasswordResetTokenExpiry),
+	})
 }
 
 // SendNotificationEmail 发送通知邮件
-func (s *DirectMailService) SendNotificationEmail(ctx context.Context, toAddress, username, message string) error {
+func (s *MailService) SendNotificationEmail(ctx context.Context, to Recipient, message string) error {
 	// 检查发送频率限制
-	if err := s.checkRateLimit(ctx, toAddress); err != nil {
+	if err := s.checkRateLimit(ctx, to.Address); err != nil {
 		return err
 	}
 
-	// 准备邮件内容
-	task := EmailTask{
-		Type:      EmailTypeNotification,
-		ToAddress: toAddress,
-		Subject:   fmt.Sprintf("%s - 系统通知", s.config.AppName),
-		Data: map[string]string{
-			"AppName":  s.config.AppName,
-			"Username": username,
-			"Message":  message,
-		},
-		CreatedAt: time.Now(),
-	}
-
-	// 渲染邮件内容
-	task.HtmlBody = renderNotificationEmailTemplate(task.Data)
-	task.TextBody = fmt.Sprintf("您好 %s，%s", username, message)
-
-	return s.addToQueue(ctx, task)
+	return s.enqueue(ctx, EmailTypeNotification, to, map[string]string{
+		"AppName":  s.config.AppName,
+		"Username": to.Name,
+		"Message":  message,
+	})
 }
 
 // SendWelcomeEmail 发送欢迎邮件
-func (s *DirectMailService) SendWelcomeEmail(ctx context.Context, toAddress, username string) error {
-	// 准备邮件内容
-	task := EmailTask{
-		Type:      EmailTypeWelcome,
-		ToAddress: toAddress,
-		Subject:   fmt.Sprintf("欢迎加入 %s", s.config.AppName),
-		Data: map[string]string{
-			"AppName":  s.config.AppName,
-			"Username": username,
-			"AppURL":   s.config.AppURL,
-		},
-		CreatedAt: time.Now(),
-	}
-
-	// 渲染邮件内容
-	task.HtmlBody = renderWelcomeEmailTemplate(task.Data)
-	task.TextBody = fmt.Sprintf("欢迎 %s 加入 %s！", username, s.config.AppName)
-
-	return s.addToQueue(ctx, task)
+func (s *MailService) SendWelcomeEmail(ctx context.Context, to Recipient) error {
+	return s.enqueue(ctx, EmailTypeWelcome, to, map[string]string{
+		"AppName":  s.config.AppName,
+		"Username": to.Name,
+		"AppURL":   s.config.AppURL,
+	})
 }
 
 // SendAnniversaryEmail 发送纪念日邮件
-func (s *DirectMailService) SendAnniversaryEmail(ctx context.Context, toAddress, username, partnerName string, days int, date string) error {
-	// 准备邮件内容
-	task := EmailTask{
-		Type:      EmailTypeAnniversary,
-		ToAddress: toAddress,
-		Subject:   fmt.Sprintf("❤️ 您与%s的恋爱纪念日 - %s天快乐", partnerName, formatDays(days)),
-		Data: map[string]string{
-			"AppName":     s.config.AppName,
-			"Username":    username,
-			"PartnerName": partnerName,
-			"Days":        formatDays(days),
-			"Date":        date,
-			"AppURL":      s.config.AppURL,
-		},
-		CreatedAt: time.Now(),
+func (s *MailService) SendAnniversaryEmail(ctx context.Context, to Recipient, partnerName string, days int, date string) error {
+	years := ""
+	if n := anniversaryYears(days); n > 0 {
+		years = strconv.Itoa(n)
 	}
 
-	// 渲染邮件内容
-	task.HtmlBody = renderAnniversaryEmailTemplate(task.Data)
-	task.TextBody = fmt.Sprintf("亲爱的%s，今天是您和%s在一起的第%s天！祝福你们爱情长久，记得珍惜这美好的时光。",
-		username, partnerName, formatDays(days))
-
-	return s.addToQueue(ctx, task)
+	return s.enqueue(ctx, EmailTypeAnniversary, to, map[string]string{
+		"AppName":     s.config.AppName,
+		"Username":    to.Name,
+		"PartnerName": partnerName,
+		"Days":        strconv.Itoa(days),
+		"Years":       years,
+		"Date":        date,
+		"AppURL":      s.config.AppURL,
+	})
 }
 
 // SendFestivalEmail 发送节日邮件
-func (s *DirectMailService) SendFestivalEmail(ctx context.Context, toAddress, username, partnerName, festivalName string) error {
-	// 准备邮件内容
-	task := EmailTask{
-		Type:      EmailTypeFestival,
-		ToAddress: toAddress,
-		Subject:   fmt.Sprintf("❤️ %s快乐 - 给%s的祝福", festivalName, username),
-		Data: map[string]string{
-			"AppName":      s.config.AppName,
-			"Username":     username,
-			"PartnerName":  partnerName,
-			"FestivalName": festivalName,
-			"AppURL":       s.config.AppURL,
-		},
-		CreatedAt: time.Now(),
-	}
+func (s *MailService) SendFestivalEmail(ctx context.Context, to Recipient, partnerName, festivalName string) error {
+	return s.enqueue(ctx, EmailTypeFestival, to, map[string]string{
+		"AppName":      s.config.AppName,
+		"Username":     to.Name,
+		"PartnerName":  partnerName,
+		"FestivalName": festivalName,
+		"AppURL":       s.config.AppURL,
+	})
+}
 
-	// 渲染邮件内容
-	task.HtmlBody = renderFestivalEmailTemplate(task.Data)
-	task.TextBody = fmt.Sprintf("亲爱的%s，祝您和%s%s快乐！希望你们能一起度过一个浪漫美好的节日。",
-		username, partnerName, festivalName)
-
-	return s.addToQueue(ctx, task)
-}
-
-// 格式化天数，添加特殊处理
-func formatDays(days int) string {
-	if days == 100 {
-		return "100"
-	} else if days == 365 || days == 366 {
-		return "一周年"
-	} else if days == 730 || days == 731 {
-		return "两周年"
-	} else if days == 1095 || days == 1096 {
-		return "三周年"
-	} else if days == 1825 || days == 1826 {
-		return "五周年"
-	} else if days == 3650 || days == 3651 || days == 3652 {
-		return "十周年"
-	} else {
-		return fmt.Sprintf("%d", days)
-	}
+// SendCustomAnniversaryEmail 发送自定义纪念日提醒邮件
+func (s *MailService) SendCustomAnniversaryEmail(ctx context.Context, to Recipient, partnerName, title, date string, daysUntil int) error {
+	return s.enqueue(ctx, EmailTypeCustomAnniversary, to, map[string]string{
+		"AppName":     s.config.AppName,
+		"Username":    to.Name,
+		"PartnerName": partnerName,
+		"Title":       title,
+		"Date":        date,
+		"DaysUntil":   strconv.Itoa(daysUntil),
+		"AppURL":      s.config.AppURL,
+	})
 }
 
-// 添加任务到队列
-func (s *DirectMailService) addToQueue(ctx context.Context, task EmailTask) error {
-	taskJSON, err := json.Marshal(task)
-	if err != nil {
-		s.log.Error(err, "序列化邮件任务失败")
-		return fmt.Errorf("序列化邮件任务失败: %w", err)
-	}
+// SendReminderEmail 发送自定义提醒邮件
+func (s *MailService) SendReminderEmail(ctx context.Context, to Recipient, title, note, date string, daysUntil int) error {
+	return s.enqueue(ctx, EmailTypeReminder, to, map[string]string{
+		"AppName":   s.config.AppName,
+		"Username":  to.Name,
+		"Title":     title,
+		"Note":      note,
+		"Date":      date,
+		"DaysUntil": strconv.Itoa(daysUntil),
+		"AppURL":    s.config.AppURL,
+	})
+}
 
-	err = s.redis.LPush(ctx, EmailQueue, taskJSON).Err()
-	if err != nil {
-		s.log.Error(err, "添加邮件任务到队列失败")
-		return fmt.Errorf("添加邮件任务到队列失败: %w", err)
+// SendCoupleInviteEmail 发送情侣配对邀请邮件
+func (s *MailService) SendCoupleInviteEmail(ctx context.Context, to Recipient, inviterName, inviteCode string, expireHours int) error {
+	// 检查发送频率限制
+	if err := s.checkRateLimit(ctx, to.Address); err != nil {
+		return err
 	}
 
-	s.log.Info("邮件任务已添加到队列", "type", task.Type, "to", task.ToAddress)
-	return nil
+	// 构建加入链接
+	joinLink := fmt.Sprintf("%s/couple/join?code=%s", s.config.AppURL, url.QueryEscape(inviteCode))
+
+	return s.enqueue(ctx, EmailTypeCoupleInvite, to, map[string]string{
+		"AppName":     s.config.AppName,
+		"InviterName": inviterName,
+		"InviteCode":  inviteCode,
+		"JoinLink":    joinLink,
+		"ExpireHours": strconv.Itoa(expireHours),
+	})
 }
 
-// ProcessEmailQueue 处理邮件队列
-func (s *DirectMailService) ProcessEmailQueue(ctx context.Context) {
-	s.log.Info("开始处理邮件队列")
-
-	for {
-		select {
-		case <-ctx.Done():
-			s.log.Info("邮件队列处理已停止")
-			return
-		default:
-			// 从队列获取任务
-			result, err := s.redis.BRPop(ctx, 5*time.Second, EmailQueue).Result()
-			if err != nil {
-				if err != redis.Nil {
-					s.log.Error(err, "从队列获取任务失败")
-				}
-				continue
-			}
-
-			if len(result) < 2 {
-				continue
-			}
-
-			var task EmailTask
-			if err := json.Unmarshal([]byte(result[1]), &task); err != nil {
-				s.log.Error(err, "反序列化邮件任务失败")
-				continue
-			}
-
-			// 发送邮件
-			s.log.Info("处理邮件任务", "type", task.Type, "to", task.ToAddress)
-			if err := s.sendEmail(task); err != nil {
-				s.log.Error(err, "发送邮件失败", "type", task.Type, "to", task.ToAddress)
-
-				// 重试逻辑
-				if task.RetryCount < 3 {
-					task.RetryCount++
-					s.log.Info("邮件发送失败，稍后重试", "retry", task.RetryCount, "to", task.ToAddress)
-					time.Sleep(time.Duration(task.RetryCount) * time.Minute)
-					s.addToQueue(ctx, task)
-				} else {
-					s.log.Error(nil, "邮件发送失败，超过最大重试次数", "to", task.ToAddress)
-				}
-			}
-		}
-	}
+// RenderPreview 用示例数据渲染模板，用于开发环境预览
+func (s *MailService) RenderPreview(emailType EmailType, locale string) (*RenderedEmail, error) {
+	return s.renderer.Render(emailType, locale, SampleData(emailType, locale, s.config.AppName, s.config.AppURL))
 }
 
-// 实际发送邮件
-func (s *DirectMailService) sendEmail(task EmailTask) error {
-	s.log.Info("发送邮件", "to", task.ToAddress, "subject", task.Subject)
+// TemplateTypes 已加载模板的邮件类型
+func (s *MailService) TemplateTypes() []EmailType {
+	return s.renderer.Types()
+}
 
-	request := &dm20151123.SingleSendMailRequest{
-		AccountName:    tea.String(s.config.AccountName),
-		AddressType:    tea.Int32(int32(s.config.AddressType)),
-		ReplyToAddress: tea.Bool(s.config.ReplyToAddress),
-		ToAddress:      tea.String(task.ToAddress),
-		Subject:        tea.String(task.Subject),
-		HtmlBody:       tea.String(task.HtmlBody),
-		TextBody:       tea.String(task.TextBody),
-		FromAlias:      tea.String(s.config.FromAlias),
+// enqueue 按收件人的语言渲染模板并放入发送队列，可退订的邮件带上退订链接
+func (s *MailService) enqueue(ctx context.Context, emailType EmailType, to Recipient, data map[string]string) error {
+	var headers map[string]string
+	if emailType.IsOptional() && to.UserID != 0 && s.config.UnsubscribeSecret != "" {
+		page, oneClick := s.unsubscribeLinks(to.UserID, emailType)
+		data["UnsubscribeURL"] = page
+		headers = unsubscribeHeaders(oneClick)
 	}
 
-	response, err := s.client.SingleSendMail(request)
+	locale := NormalizeLocale(to.Locale)
+	rendered, err := s.renderer.Render(emailType, locale, data)
 	if err != nil {
-		return fmt.Errorf("调用阿里云邮件API失败: %w", err)
+		s.log.Error(err, "渲染邮件模板失败", "type", emailType, "locale", locale)
+		return err
 	}
 
-	if response.StatusCode == nil || *response.StatusCode != 200 {
-		// 提取错误信息
-		errMsg := "未知错误"
+	return s.addToQueue(ctx, EmailTask{
+		UserID:    to.UserID,
+		Type:      emailType,
+		ToAddress: to.Address,
+		Locale:    locale,
+		Subject:   rendered.Subject,
+		HtmlBody:  rendered.HTML,
+		TextBody:  rendered.Text,
+		Data:      data,
+		Headers:   headers,
+		CreatedAt: time.Now(),
+	})
+}
 
-		// 如果有响应体和RequestId，则加入错误信息
-		if response.Body != nil && response.Body.RequestId != nil {
-			errMsg = fmt.Sprintf("请求失败 (RequestId: %s)", *response.Body.RequestId)
-		}
-		return fmt.Errorf("邮件发送失败: %s", errMsg)
+// anniversaryYears 天数正好是整周年时返回周年数，否则返回0
+func anniversaryYears(days int) int {
+	switch days {
+	case 365, 366:
+		return 1
+	case 730, 731:
+		return 2
+	case 1095, 1096:
+		return 3
+	case 1825, 1826:
+		return 5
+	case 3650, 3651, 3652:
+		return 10
+	default:
+		return 0
 	}
+}
 
-	s.log.Info("邮件发送成功", "type", task.Type, "to", task.ToAddress, "requestId", *response.Body.RequestId)
-	return nil
+// 实际发送邮件，返回发送服务的ID
+func (s *MailService) sendEmail(ctx context.Context, task EmailTask) (string, error) {
+	s.log.Info("发送邮件", "to", task.ToAddress, "subject", task.Subject, "transport", s.transport.Name())
+
+	providerID, err := s.transport.Send(ctx, &Message{
+		From:     s.config.AccountName,
+		FromName: s.config.FromAlias,
+		To:       task.ToAddress,
+		Subject:  task.Subject,
+		HTMLBody: task.HtmlBody,
+		TextBody: task.TextBody,
+		Headers:  task.Headers,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	s.log.Info("邮件发送成功", "type", task.Type, "to", task.ToAddress, "provider_id", providerID)
+	return providerID, nil
 }
 
 // 检查发送频率限制
-func (s *DirectMailService) checkRateLimit(ctx context.Context, email string) error {
+func (s *MailService) checkRateLimit(ctx context.Context, email string) error {
 	key := fmt.Sprintf("%s%s", RateLimitPrefix, email)
 	count, err := s.redis.Incr(ctx, key).Result()
 	if err != nil {
@@ -439,13 +426,13 @@ func (s *DirectMailService) checkRateLimit(ctx context.Context, email string) er
 }
 
 // StoreVerificationCode 存储验证码到Redis
-func (s *DirectMailService) StoreVerificationCode(ctx context.Context, email, code string) error {
+func (s *MailService) StoreVerificationCode(ctx context.Context, email, code string) error {
 	key := fmt.Sprintf("email:verify:%s", email)
 	return s.redis.Set(ctx, key, code, VerificationCodeExpiry*time.Minute).Err()
 }
 
 // VerifyCode 验证验证码
-func (s *DirectMailService) VerifyCode(ctx context.Context, email, code string) (bool, error) {
+func (s *MailService) VerifyCode(ctx context.Context, email, code string) (bool, error) {
 	key := fmt.Sprintf("email:verify:%s", email)
 	storedCode, err := s.redis.Get(ctx, key).Result()
 	if err != nil {
@@ -465,13 +452,13 @@ func (s *DirectMailService) VerifyCode(ctx context.Context, email, code string)
 }
 
 // StorePasswordResetToken 存储密码重置令牌
-func (s *DirectMailService) StorePasswordResetToken(ctx context.Context, email, token string) error {
+func (s *MailService) StorePasswordResetToken(ctx context.Context, email, token string) error {
 	key := fmt.Sprintf("email:reset:%s", email)
-	return s.redis.Set(ctx, key, token, 30*time.Minute).Err()
+	return s.redis.Set(ctx, key, token, PasswordResetTokenExpiry*time.Minute).Err()
 }
 
 // VerifyPasswordResetToken 验证密码重置令牌
-func (s *DirectMailService) VerifyPasswordResetToken(ctx context.Context, email, token string) (bool, error) {
+func (s *MailService) VerifyPasswordResetToken(ctx context.Context, email, token string) (bool, error) {
 	key := fmt.Sprintf("email:reset:%s", email)
 	storedToken, err := s.redis.Get(ctx, key).Result()
 	if err != nil {
@@ -491,36 +478,79 @@ func (s *DirectMailService) VerifyPasswordResetToken(ctx context.Context, email,
 }
 
 // noOpEmailService 空实现（当邮件服务未启用时使用）
-type noOpEmailService struct{}
+type noOpEmailService struct {
+	renderer *TemplateRenderer
+	config   *config.EmailConfig
+}
+
+func (s *noOpEmailService) SendVerificationEmail(ctx context.Context, to Recipient, verificationCode string) error {
+	return nil
+}
+
+func (s *noOpEmailService) SendPasswordResetEmail(ctx context.Context, to Recipient, resetToken string) error {
+	return nil
+}
 
-func (s *noOpEmailService) SendVerificationEmail(ctx context.Context, toAddress, username, verificationCode string) error {
+func (s *noOpEmailService) SendNotificationEmail(ctx context.Context, to Recipient, message string) error {
 	return nil
 }
 
-func (s *noOpEmailService) SendPasswordResetEmail(ctx context.Context, toAddress, resetToken string) error {
+func (s *noOpEmailService) SendWelcomeEmail(ctx context.Context, to Recipient) error {
 	return nil
 }
 
-func (s *noOpEmailService) SendNotificationEmail(ctx context.Context, toAddress, username, message string) error {
+func (s *noOpEmailService) SendAnniversaryEmail(ctx context.Context, to Recipient, partnerName string, days int, date string) error {
 	return nil
 }
 
-func (s *noOpEmailService) SendWelcomeEmail(ctx context.Context, toAddress, username string) error {
+func (s *noOpEmailService) SendFestivalEmail(ctx context.Context, to Recipient, partnerName, festivalName string) error {
 	return nil
 }
 
-func (s *noOpEmailService) SendAnniversaryEmail(ctx context.Context, toAddress, username, partnerName string, days int, date string) error {
+func (s *noOpEmailService) SendCustomAnniversaryEmail(ctx context.Context, to Recipient, partnerName, title, date string, daysUntil int) error {
 	return nil
 }
 
-func (s *noOpEmailService) SendFestivalEmail(ctx context.Context, toAddress, username, partnerName, festivalName string) error {
+func (s *noOpEmailService) SendReminderEmail(ctx context.Context, to Recipient, title, note, date string, daysUntil int) error {
 	return nil
 }
 
+func (s *noOpEmailService) SendCoupleInviteEmail(ctx context.Context, to Recipient, inviterName, inviteCode string, expireHours int) error {
+	return nil
+}
+
+func (s *noOpEmailService) RenderPreview(emailType EmailType, locale string) (*RenderedEmail, error) {
+	return s.renderer.Render(emailType, locale, SampleData(emailType, locale, s.config.AppName, s.config.AppURL))
+}
+
+func (s *noOpEmailService) TemplateTypes() []EmailType {
+	return s.renderer.Types()
+}
+
 func (s *noOpEmailService) ProcessEmailQueue(ctx context.Context) {
 	// 空实现，不做任何处理
 }
 
+func (s *noOpEmailService) QueueStats(ctx context.Context) (*QueueStats, error) {
+	return &QueueStats{}, nil
+}
+
+func (s *noOpEmailService) ListDeadLetters(ctx context.Context, offset, limit int) ([]EmailTask, int64, error) {
+	return []EmailTask{}, 0, nil
+}
+
+func (s *noOpEmailService) RequeueDeadLetter(ctx context.Context, id string) error {
+	return ErrDeadLetterNotFound
+}
+
+func (s *noOpEmailService) DeleteDeadLetter(ctx context.Context, id string) error {
+	return ErrDeadLetterNotFound
+}
+
+func (s *noOpEmailService) PurgeDeadLetters(ctx context.Context) (int64, error) {
+	return 0, nil
+}
+
 func (s *noOpEmailService) StoreVerificationCode(ctx context.Context, email, code string) error {
 	return nil
 }
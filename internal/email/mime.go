@@ -0,0 +1,91 @@
+package email
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"sort"
+	"strings"
+	"time"
+)
+
+// buildMIME 生成 multipart/alternative 邮件，同时包含纯文本和HTML正文，用于SMTP和 .eml 文件。
+// 同时返回生成的 Message-ID
+func buildMIME(msg *Message, now time.Time) ([]byte, string, error) {
+	from := mail.Address{Name: msg.FromName, Address: msg.From}
+	to := mail.Address{Address: msg.To}
+
+	var buf bytes.Buffer
+	writer := multipart.NewWriter(&buf)
+	id := messageID(msg.From)
+
+	header := []struct{ key, value string }{
+		{"From", from.String()},
+		{"To", to.String()},
+		{"Subject", mime.QEncoding.Encode("utf-8", msg.Subject)},
+		{"Date", now.Format(time.RFC1123Z)},
+		{"Message-ID", id},
+		{"MIME-Version", "1.0"},
+		{"Content-Type", fmt.Sprintf("multipart/alternative; boundary=%q", writer.Boundary())},
+	}
+	// 额外的邮件头按名称排序，生成的内容保持稳定
+	names := make([]string, 0, len(msg.Headers))
+	for name := range msg.Headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		header = append(header, struct{ key, value string }{textproto.CanonicalMIMEHeaderKey(name), msg.Headers[name]})
+	}
+
+	var head bytes.Buffer
+	for _, h := range header {
+		fmt.Fprintf(&head, "%s: %s\r\n", h.key, h.value)
+	}
+	head.WriteString("\r\n")
+
+	// 纯文本在前，支持HTML的客户端会显示最后一个能展示的部分
+	if err := writeQuotedPrintablePart(writer, "text/plain", msg.TextBody); err != nil {
+		return nil, "", err
+	}
+	if err := writeQuotedPrintablePart(writer, "text/html", msg.HTMLBody); err != nil {
+		return nil, "", err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, "", err
+	}
+	return append(head.Bytes(), buf.Bytes()...), id, nil
+}
+
+// writeQuotedPrintablePart 写入一个 quoted-printable 编码的正文部分
+func writeQuotedPrintablePart(writer *multipart.Writer, contentType, body string) error {
+	part, err := writer.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {contentType + "; charset=utf-8"},
+		"Content-Transfer-Encoding": {"quoted-printable"},
+	})
+	if err != nil {
+		return err
+	}
+	qp := quotedprintable.NewWriter(part)
+	if _, err := qp.Write([]byte(body)); err != nil {
+		return err
+	}
+	return qp.Close()
+}
+
+// messageID 生成 Message-ID，域名取发信地址的域名
+func messageID(from string) string {
+	domain := "localhost"
+	if i := strings.LastIndex(from, "@"); i >= 0 && i < len(from)-1 {
+		domain = from[i+1:]
+	}
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return fmt.Sprintf("<%s@%s>", hex.EncodeToString(b), domain)
+}
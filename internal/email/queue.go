@@ -0,0 +1,421 @@
+package email
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"memoir-api/internal/models"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// 邮件队列使用的Redis键
+const (
+	// EmailProcessingQueue 正在发送的任务，消费者用 BRPOPLPUSH 从 EmailQueue 移入，发送完成后删除，
+	// 实例崩溃时任务留在这里，超过 processingTimeout 后重新放回 EmailQueue
+	EmailProcessingQueue = "email:processing"
+	// emailProcessingSince 记录 EmailProcessingQueue 中每个任务第一次被发现的时间
+	emailProcessingSince = "email:processing:since"
+	// EmailRetryQueue 等待重试的任务，有序集合，分数为下次发送的时间（毫秒时间戳）
+	EmailRetryQueue = "email:retry"
+	// EmailDeadLetterQueue 超过最大重试次数的任务，最新的在最前面
+	EmailDeadLetterQueue = "email:dead"
+)
+
+const (
+	// processingTimeout 任务在 EmailProcessingQueue 中超过这个时间视为消费者已崩溃
+	processingTimeout = 5 * time.Minute
+	// reapInterval 检查崩溃遗留任务的间隔
+	reapInterval = 30 * time.Second
+	// retryPollInterval 检查到期重试任务的间隔
+	retryPollInterval = time.Second
+	// retryBatchSize 每次最多移回队列的重试任务数
+	retryBatchSize = 100
+	// maxRetryDelay 重试间隔的上限
+	maxRetryDelay = time.Hour
+	// maxDeadLetters 死信队列最多保留的任务数
+	maxDeadLetters = 10000
+)
+
+var (
+	ErrDeadLetterNotFound = errors.New("死信不存在")
+)
+
+// QueueStats 邮件队列各部分的任务数
+type QueueStats struct {
+	Pending    int64 `json:"pending"`
+	Processing int64 `json:"processing"`
+	Retrying   int64 `json:"retrying"`
+	Dead       int64 `json:"dead"`
+}
+
+// moveDueRetriesScript 把到期的重试任务移回待发送队列
+var moveDueRetriesScript = redis.NewScript(`
+local items = redis.call('ZRANGEBYSCORE', KEYS[1], '-inf', ARGV[1], 'LIMIT', 0, tonumber(ARGV[2]))
+for _, raw in ipairs(items) do
+	redis.call('ZREM', KEYS[1], raw)
+	redis.call('LPUSH', KEYS[2], raw)
+end
+return #items
+`)
+
+// reapProcessingScript 第一次看到正在发送的任务时记下时间，超时的任务放回待发送队列，返回放回的任务
+var reapProcessingScript = redis.NewScript(`
+local items = redis.call('LRANGE', KEYS[1], 0, -1)
+local moved = {}
+for _, raw in ipairs(items) do
+	local since = redis.call('ZSCORE', KEYS[2], raw)
+	if not since then
+		redis.call('ZADD', KEYS[2], ARGV[1], raw)
+	elseif tonumber(since) < tonumber(ARGV[2]) then
+		redis.call('LREM', KEYS[1], 1, raw)
+		redis.call('ZREM', KEYS[2], raw)
+		redis.call('RPUSH', KEYS[3], raw)
+		table.insert(moved, raw)
+	end
+end
+return moved
+`)
+
+// requeueDeadLetterScript 死信还在时才放回待发送队列，避免并发重复放入
+var requeueDeadLetterScript = redis.NewScript(`
+if redis.call('LREM', KEYS[1], 1, ARGV[1]) == 0 then
+	return 0
+end
+redis.call('LPUSH', KEYS[2], ARGV[2])
+return 1
+`)
+
+// newTaskID 生成任务ID，用于在死信队列中定位任务
+func newTaskID() string {
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
+
+// 添加任务到队列
+func (s *MailService) addToQueue(ctx context.Context, task EmailTask) error {
+	if task.ID == "" {
+		task.ID = newTaskID()
+	}
+	taskJSON, err := json.Marshal(task)
+	if err != nil {
+		s.log.Error(err, "序列化邮件任务失败")
+		return fmt.Errorf("序列化邮件任务失败: %w", err)
+	}
+
+	// 先写投递记录，发送协程更新状态时记录已经存在
+	s.recordQueued(ctx, task)
+
+	err = s.redis.LPush(ctx, EmailQueue, taskJSON).Err()
+	if err != nil {
+		s.log.Error(err, "添加邮件任务到队列失败")
+		return fmt.Errorf("添加邮件任务到队列失败: %w", err)
+	}
+
+	s.log.Info("邮件任务已添加到队列", "type", task.Type, "to", task.ToAddress)
+	return nil
+}
+
+// ProcessEmailQueue 处理邮件队列：按配置的并发数启动发送协程，并定期把到期的重试任务和崩溃遗留的任务放回队列。
+// 阻塞到 ctx 取消且正在发送的邮件处理完为止
+func (s *MailService) ProcessEmailQueue(ctx context.Context) {
+	concurrency := s.config.QueueConcurrency
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	s.log.Info("开始处理邮件队列", "concurrency", concurrency)
+
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.consume(ctx)
+		}()
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		s.maintainQueue(ctx)
+	}()
+
+	wg.Wait()
+	s.log.Info("邮件队列处理已停止")
+}
+
+// consume 循环取出任务并发送
+func (s *MailService) consume(ctx context.Context) {
+	for ctx.Err() == nil {
+		raw, err := s.redis.BRPopLPush(ctx, EmailQueue, EmailProcessingQueue, 5*time.Second).Result()
+		if err != nil {
+			if err != redis.Nil && ctx.Err() == nil {
+				s.log.Error(err, "从队列获取任务失败")
+				time.Sleep(time.Second)
+			}
+			continue
+		}
+		// 已取出的任务不因停止而中断，保证发送结果能写回队列
+		s.handleTask(context.WithoutCancel(ctx), raw)
+	}
+}
+
+// handleTask 发送一个任务，失败时按指数退避放入重试队列，超过最大重试次数后放入死信队列
+func (s *MailService) handleTask(ctx context.Context, raw string) {
+	var task EmailTask
+	if err := json.Unmarshal([]byte(raw), &task); err != nil {
+		s.log.Error(err, "反序列化邮件任务失败，放入死信队列")
+		s.finishTask(ctx, raw, func(pipe redis.Pipeliner) {
+			pipe.LPush(ctx, EmailDeadLetterQueue, raw)
+			pipe.LTrim(ctx, EmailDeadLetterQueue, 0, maxDeadLetters-1)
+		})
+		return
+	}
+
+	s.log.Info("处理邮件任务", "type", task.Type, "to", task.ToAddress, "retry", task.RetryCount)
+	providerID, sendErr := s.sendEmail(ctx, task)
+	if sendErr == nil {
+		s.finishTask(ctx, raw, nil)
+		s.recordSent(ctx, task, providerID)
+		return
+	}
+	s.log.Error(sendErr, "发送邮件失败", "type", task.Type, "to", task.ToAddress)
+
+	task.LastError = sendErr.Error()
+	if task.RetryCount >= s.config.MaxRetries {
+		now := time.Now()
+		task.FailedAt = &now
+		s.log.Error(nil, "邮件发送失败，超过最大重试次数，放入死信队列", "id", task.ID, "to", task.ToAddress)
+		data, _ := json.Marshal(task)
+		s.finishTask(ctx, raw, func(pipe redis.Pipeliner) {
+			pipe.LPush(ctx, EmailDeadLetterQueue, data)
+			pipe.LTrim(ctx, EmailDeadLetterQueue, 0, maxDeadLetters-1)
+		})
+		s.recordFailed(ctx, task, models.EmailStatusDead, sendErr)
+		return
+	}
+
+	task.RetryCount++
+	delay := s.retryDelay(task.RetryCount)
+	s.log.Info("邮件发送失败，稍后重试", "retry", task.RetryCount, "delay", delay, "to", task.ToAddress)
+	data, _ := json.Marshal(task)
+	s.finishTask(ctx, raw, func(pipe redis.Pipeliner) {
+		pipe.ZAdd(ctx, EmailRetryQueue, &redis.Z{
+			Score:  float64(time.Now().Add(delay).UnixMilli()),
+			Member: data,
+		})
+	})
+	s.recordFailed(ctx, task, models.EmailStatusFailed, sendErr)
+}
+
+// finishTask 把任务移出正在发送的队列，then 中的操作在同一个事务里执行
+func (s *MailService) finishTask(ctx context.Context, raw string, then func(pipe redis.Pipeliner)) {
+	_, err := s.redis.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.LRem(ctx, EmailProcessingQueue, 1, raw)
+		pipe.ZRem(ctx, emailProcessingSince, raw)
+		if then != nil {
+			then(pipe)
+		}
+		return nil
+	})
+	if err != nil {
+		s.log.Error(err, "更新邮件队列失败")
+	}
+}
+
+// retryDelay 第 retry 次重试前的等待时间，从 RetryBaseSeconds 开始每次翻倍
+func (s *MailService) retryDelay(retry int) time.Duration {
+	base := time.Duration(s.config.RetryBaseSeconds) * time.Second
+	if base <= 0 {
+		base = 30 * time.Second
+	}
+	delay := base
+	for i := 1; i < retry && delay < maxRetryDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxRetryDelay {
+		delay = maxRetryDelay
+	}
+	return delay
+}
+
+// maintainQueue 定期移回到期的重试任务和崩溃遗留的任务
+func (s *MailService) maintainQueue(ctx context.Context) {
+	retryTicker := time.NewTicker(retryPollInterval)
+	defer retryTicker.Stop()
+	reapTicker := time.NewTicker(reapInterval)
+	defer reapTicker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-retryTicker.C:
+			now := time.Now().UnixMilli()
+			moved, err := moveDueRetriesScript.Run(ctx, s.redis,
+				[]string{EmailRetryQueue, EmailQueue}, now, retryBatchSize).Int()
+			if err != nil && ctx.Err() == nil {
+				s.log.Error(err, "移回重试任务失败")
+			} else if moved > 0 {
+				s.log.Info("重试任务已放回队列", "count", moved)
+			}
+		case <-reapTicker.C:
+			now := time.Now()
+			moved, err := reapProcessingScript.Run(ctx, s.redis,
+				[]string{EmailProcessingQueue, emailProcessingSince, EmailQueue},
+				now.UnixMilli(), now.Add(-processingTimeout).UnixMilli()).StringSlice()
+			if err != nil && ctx.Err() == nil {
+				s.log.Error(err, "检查未完成的邮件任务失败")
+			} else if len(moved) > 0 {
+				for _, id := range taskIDs(moved) {
+					s.recordRequeued(ctx, id)
+				}
+				s.log.Warn("未完成的邮件任务已放回队列", "count", len(moved))
+			}
+		}
+	}
+}
+
+// QueueStats 获取邮件队列各部分的任务数
+func (s *MailService) QueueStats(ctx context.Context) (*QueueStats, error) {
+	pipe := s.redis.Pipeline()
+	pending := pipe.LLen(ctx, EmailQueue)
+	processing := pipe.LLen(ctx, EmailProcessingQueue)
+	retrying := pipe.ZCard(ctx, EmailRetryQueue)
+	dead := pipe.LLen(ctx, EmailDeadLetterQueue)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, err
+	}
+	return &QueueStats{
+		Pending:    pending.Val(),
+		Processing: processing.Val(),
+		Retrying:   retrying.Val(),
+		Dead:       dead.Val(),
+	}, nil
+}
+
+// ListDeadLetters 分页获取死信，最新的在前，只返回收件人、主题和失败原因等摘要
+func (s *MailService) ListDeadLetters(ctx context.Context, offset, limit int) ([]EmailTask, int64, error) {
+	total, err := s.redis.LLen(ctx, EmailDeadLetterQueue).Result()
+	if err != nil {
+		return nil, 0, err
+	}
+	items, err := s.redis.LRange(ctx, EmailDeadLetterQueue, int64(offset), int64(offset+limit-1)).Result()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	tasks := make([]EmailTask, 0, len(items))
+	for _, raw := range items {
+		var task EmailTask
+		if err := json.Unmarshal([]byte(raw), &task); err != nil {
+			s.log.Warn("死信无法解析", "error", err.Error())
+			continue
+		}
+		// 不返回邮件正文和模板数据，避免验证码、重置链接等出现在管理后台
+		task.HtmlBody, task.TextBody, task.Data = "", "", nil
+		tasks = append(tasks, task)
+	}
+	return tasks, total, nil
+}
+
+// RequeueDeadLetter 把死信重新放回待发送队列，重试次数清零
+func (s *MailService) RequeueDeadLetter(ctx context.Context, id string) error {
+	raw, task, err := s.findDeadLetter(ctx, id)
+	if err != nil {
+		return err
+	}
+	task.RetryCount = 0
+	task.LastError = ""
+	task.FailedAt = nil
+	data, err := json.Marshal(task)
+	if err != nil {
+		return fmt.Errorf("序列化邮件任务失败: %w", err)
+	}
+
+	moved, err := requeueDeadLetterScript.Run(ctx, s.redis,
+		[]string{EmailDeadLetterQueue, EmailQueue}, raw, data).Int()
+	if err != nil {
+		return err
+	}
+	if moved == 0 {
+		// 已经被其他请求重新放入或删除
+		return ErrDeadLetterNotFound
+	}
+	s.recordRequeued(ctx, id)
+	s.log.Info("死信已重新放入队列", "id", id, "to", task.ToAddress)
+	return nil
+}
+
+// DeleteDeadLetter 删除一条死信
+func (s *MailService) DeleteDeadLetter(ctx context.Context, id string) error {
+	raw, _, err := s.findDeadLetter(ctx, id)
+	if err != nil {
+		return err
+	}
+	removed, err := s.redis.LRem(ctx, EmailDeadLetterQueue, 1, raw).Result()
+	if err != nil {
+		return err
+	}
+	if removed == 0 {
+		return ErrDeadLetterNotFound
+	}
+	s.recordDiscarded(ctx, id)
+	return nil
+}
+
+// PurgeDeadLetters 清空死信队列，返回删除的数量
+func (s *MailService) PurgeDeadLetters(ctx context.Context) (int64, error) {
+	var items *redis.StringSliceCmd
+	_, err := s.redis.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		items = pipe.LRange(ctx, EmailDeadLetterQueue, 0, -1)
+		pipe.Del(ctx, EmailDeadLetterQueue)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	count := int64(len(items.Val()))
+	s.recordDiscarded(ctx, taskIDs(items.Val())...)
+	s.log.Info("死信队列已清空", "count", count)
+	return count, nil
+}
+
+// taskIDs 从队列中的原始数据解析任务ID，无法解析或没有ID的任务跳过
+func taskIDs(items []string) []string {
+	ids := make([]string, 0, len(items))
+	for _, raw := range items {
+		var task EmailTask
+		if err := json.Unmarshal([]byte(raw), &task); err != nil || task.ID == "" {
+			continue
+		}
+		ids = append(ids, task.ID)
+	}
+	return ids
+}
+
+// findDeadLetter 按任务ID查找死信，返回原始数据用于删除
+func (s *MailService) findDeadLetter(ctx context.Context, id string) (string, *EmailTask, error) {
+	items, err := s.redis.LRange(ctx, EmailDeadLetterQueue, 0, -1).Result()
+	if err != nil {
+		return "", nil, err
+	}
+	for _, raw := range items {
+		var task EmailTask
+		if err := json.Unmarshal([]byte(raw), &task); err != nil {
+			continue
+		}
+		if task.ID == id {
+			return raw, &task, nil
+		}
+	}
+	return "", nil, ErrDeadLetterNotFound
+}
@@ -0,0 +1,115 @@
+package email
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestEmbeddedTemplatesRenderAllLocales(t *testing.T) {
+	renderer, err := NewTemplateRenderer("")
+	if err != nil {
+		t.Fatalf("NewTemplateRenderer() error: %v", err)
+	}
+	types := []EmailType{
+		EmailTypeVerification, EmailTypeResetPassword, EmailTypeNotification, EmailTypeWelcome,
+		EmailTypeAnniversary, EmailTypeFestival, EmailTypeCoupleInvite, EmailTypeCustomAnniversary,
+		EmailTypeReminder,
+	}
+	for _, locale := range SupportedLocales {
+		for _, emailType := range types {
+			data := SampleData(emailType, locale, "Memoir", "https://example.com")
+			rendered, err := renderer.Render(emailType, locale, data)
+			if err != nil {
+				t.Errorf("Render(%s, %s) error: %v", emailType, locale, err)
+				continue
+			}
+			if rendered.Subject == "" || rendered.HTML == "" || rendered.Text == "" {
+				t.Errorf("Render(%s, %s) has an empty part: %+v", emailType, locale, rendered)
+			}
+			if strings.Contains(rendered.Subject, "\n") {
+				t.Errorf("Render(%s, %s) subject contains a newline: %q", emailType, locale, rendered.Subject)
+			}
+		}
+	}
+}
+
+func TestRenderEscapesHTMLOnly(t *testing.T) {
+	renderer, err := NewTemplateRenderer("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rendered, err := renderer.Render(EmailTypeReminder, LocaleEnUS, map[string]string{
+		"AppName": "Memoir", "Username": "Alice", "Title": "<script>x</script>", "DaysUntil": "0",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(rendered.HTML, "<script>") {
+		t.Errorf("HTML body is not escaped: %s", rendered.HTML)
+	}
+	if !strings.Contains(rendered.Text, "<script>x</script>") || !strings.Contains(rendered.Subject, "<script>x</script>") {
+		t.Errorf("text parts should not be HTML-escaped: subject %q, text %q", rendered.Subject, rendered.Text)
+	}
+	if !strings.Contains(rendered.Subject, "Today:") {
+		t.Errorf("Subject = %q, want the English template", rendered.Subject)
+	}
+}
+
+func TestRenderFallsBackToDefaultLocale(t *testing.T) {
+	renderer, err := NewTemplateRenderer("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rendered, err := renderer.Render(EmailTypeWelcome, "fr-FR", map[string]string{"AppName": "Memoir", "Username": "Alice"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(rendered.Subject, "欢迎") {
+		t.Errorf("Subject = %q, want the default locale", rendered.Subject)
+	}
+	if NormalizeLocale("en") != LocaleEnUS || NormalizeLocale("EN-gb") != LocaleEnUS || NormalizeLocale("") != DefaultLocale {
+		t.Error("NormalizeLocale() did not map locales as expected")
+	}
+
+	if _, err := renderer.Render("missing", LocaleZhCN, nil); !errors.Is(err, ErrTemplateNotFound) {
+		t.Errorf("Render(missing) error = %v, want ErrTemplateNotFound", err)
+	}
+}
+
+func TestTemplateDirOverridesEmbedded(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, LocaleEnUS), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	override := `{{define "subject"}}Hey {{.Username}}{{end}}{{define "html"}}<p>custom</p>{{end}}{{define "text"}}custom{{end}}`
+	if err := os.WriteFile(filepath.Join(dir, LocaleEnUS, "welcome.tmpl"), []byte(override), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	renderer, err := NewTemplateRenderer(dir)
+	if err != nil {
+		t.Fatalf("NewTemplateRenderer() error: %v", err)
+	}
+	rendered, err := renderer.Render(EmailTypeWelcome, LocaleEnUS, map[string]string{"Username": "Alice"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rendered.Subject != "Hey Alice" || rendered.HTML != "<p>custom</p>" {
+		t.Errorf("override not applied: %+v", rendered)
+	}
+	// 没有覆盖的模板仍使用内置版本
+	if _, err := renderer.Render(EmailTypeVerification, LocaleEnUS, nil); err != nil {
+		t.Errorf("embedded template missing after override: %v", err)
+	}
+
+	incomplete := `{{define "subject"}}x{{end}}`
+	if err := os.WriteFile(filepath.Join(dir, LocaleEnUS, "welcome.tmpl"), []byte(incomplete), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewTemplateRenderer(dir); err == nil {
+		t.Error("NewTemplateRenderer() accepted a template without html and text parts")
+	}
+}
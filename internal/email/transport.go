@@ -0,0 +1,49 @@
+package email
+
+import (
+	"context"
+	"fmt"
+
+	"memoir-api/internal/config"
+)
+
+// 邮件发送方式
+const (
+	TransportAliyun = "aliyun" // 阿里云邮件推送
+	TransportSMTP   = "smtp"   // SMTP服务器，如自建邮件服务、MailHog
+	TransportFile   = "file"   // 写入 .eml 文件，用于测试
+)
+
+// Message 一封待发送的邮件
+type Message struct {
+	From     string // 发信地址
+	FromName string // 发信人名称
+	To       string
+	Subject  string
+	HTMLBody string
+	TextBody string
+	// Headers 额外的邮件头，如 List-Unsubscribe
+	Headers map[string]string
+}
+
+// Transport 邮件发送方式，只负责把已经渲染好的邮件发出去，队列和重试由邮件服务处理
+type Transport interface {
+	// Name 发送方式名称，用于日志
+	Name() string
+	// Send 发送邮件，返回发送服务用于追踪这封邮件的ID：阿里云为 RequestId，SMTP和文件方式为 Message-ID
+	Send(ctx context.Context, msg *Message) (string, error)
+}
+
+// NewTransport 按配置创建邮件发送方式，未配置时使用阿里云邮件推送
+func NewTransport(cfg config.EmailConfig) (Transport, error) {
+	switch cfg.Transport {
+	case "", TransportAliyun:
+		return newAliyunTransport(cfg)
+	case TransportSMTP:
+		return newSMTPTransport(cfg)
+	case TransportFile:
+		return newFileTransport(cfg.SpoolDir)
+	default:
+		return nil, fmt.Errorf("不支持的邮件发送方式: %s", cfg.Transport)
+	}
+}
@@ -0,0 +1,91 @@
+package email
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"memoir-api/internal/config"
+
+	openapi "github.com/alibabacloud-go/darabonba-openapi/v2/client"
+	dm20151123 "github.com/alibabacloud-go/dm-20151123/v2/client"
+	"github.com/alibabacloud-go/tea/tea"
+)
+
+// aliyunTransport 通过阿里云邮件推送 SingleSendMail 接口发送
+type aliyunTransport struct {
+	client         *dm20151123.Client
+	addressType    int
+	replyToAddress bool
+}
+
+// newAliyunTransport 创建阿里云邮件客户端
+func newAliyunTransport(cfg config.EmailConfig) (*aliyunTransport, error) {
+	config := &openapi.Config{
+		AccessKeyId:     tea.String(cfg.AccessKeyID),
+		AccessKeySecret: tea.String(cfg.AccessKeySecret),
+		RegionId:        tea.String(cfg.RegionID),
+	}
+
+	// 使用邮件服务的默认域名
+	config.Endpoint = tea.String("dm.aliyuncs.com")
+
+	client, err := dm20151123.NewClient(config)
+	if err != nil {
+		return nil, fmt.Errorf("创建阿里云邮件客户端失败: %w", err)
+	}
+	return &aliyunTransport{
+		client:         client,
+		addressType:    cfg.AddressType,
+		replyToAddress: cfg.ReplyToAddress,
+	}, nil
+}
+
+// Name 发送方式名称
+func (t *aliyunTransport) Name() string {
+	return TransportAliyun
+}
+
+// Send 调用阿里云邮件API发送
+func (t *aliyunTransport) Send(ctx context.Context, msg *Message) (string, error) {
+	request := &dm20151123.SingleSendMailRequest{
+		AccountName:    tea.String(msg.From),
+		AddressType:    tea.Int32(int32(t.addressType)),
+		ReplyToAddress: tea.Bool(t.replyToAddress),
+		ToAddress:      tea.String(msg.To),
+		Subject:        tea.String(msg.Subject),
+		HtmlBody:       tea.String(msg.HTMLBody),
+		TextBody:       tea.String(msg.TextBody),
+		FromAlias:      tea.String(msg.FromName),
+	}
+	if len(msg.Headers) > 0 {
+		// 阿里云的自定义邮件头是JSON对象
+		headers, err := json.Marshal(msg.Headers)
+		if err != nil {
+			return "", fmt.Errorf("序列化邮件头失败: %w", err)
+		}
+		request.Headers = tea.String(string(headers))
+	}
+
+	response, err := t.client.SingleSendMail(request)
+	if err != nil {
+		return "", fmt.Errorf("调用阿里云邮件API失败: %w", err)
+	}
+
+	if response.StatusCode == nil || *response.StatusCode != 200 {
+		// 提取错误信息
+		errMsg := "未知错误"
+
+		// 如果有响应体和RequestId，则加入错误信息
+		if response.Body != nil && response.Body.RequestId != nil {
+			errMsg = fmt.Sprintf("请求失败 (RequestId: %s)", *response.Body.RequestId)
+		}
+		return "", fmt.Errorf("邮件发送失败: %s", errMsg)
+	}
+
+	var requestID string
+	if response.Body != nil && response.Body.RequestId != nil {
+		requestID = *response.Body.RequestId
+	}
+	return requestID, nil
+}
@@ -0,0 +1,59 @@
+package email
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// fileTransport 把邮件写成 .eml 文件，不真正发送，用于测试和本地开发
+type fileTransport struct {
+	dir string
+}
+
+// newFileTransport 创建写文件的发送方式，目录不存在时自动创建
+func newFileTransport(dir string) (*fileTransport, error) {
+	if dir == "" {
+		return nil, fmt.Errorf("未配置邮件文件目录")
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("创建邮件文件目录失败: %w", err)
+	}
+	return &fileTransport{dir: dir}, nil
+}
+
+// Name 发送方式名称
+func (t *fileTransport) Name() string {
+	return TransportFile
+}
+
+// Send 写入一个 .eml 文件，先写临时文件再改名，读取方不会看到写了一半的文件
+func (t *fileTransport) Send(ctx context.Context, msg *Message) (string, error) {
+	now := time.Now()
+	data, id, err := buildMIME(msg, now)
+	if err != nil {
+		return "", fmt.Errorf("生成邮件内容失败: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(t.dir, ".tmp-*")
+	if err != nil {
+		return "", fmt.Errorf("写入邮件文件失败: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return "", fmt.Errorf("写入邮件文件失败: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return "", fmt.Errorf("写入邮件文件失败: %w", err)
+	}
+
+	name := fmt.Sprintf("%s-%s.eml", now.Format("20060102T150405.000000000"), newTaskID())
+	if err := os.Rename(tmp.Name(), filepath.Join(t.dir, name)); err != nil {
+		return "", fmt.Errorf("写入邮件文件失败: %w", err)
+	}
+	return id, nil
+}
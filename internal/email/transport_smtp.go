@@ -0,0 +1,146 @@
+package email
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strconv"
+	"time"
+
+	"memoir-api/internal/config"
+	"memoir-api/internal/logger"
+)
+
+// SMTP 连接加密方式
+const (
+	SMTPEncryptionSTARTTLS = "starttls" // 明文连接后升级为TLS，通常使用587端口
+	SMTPEncryptionTLS      = "tls"      // 直接建立TLS连接，通常使用465端口
+	SMTPEncryptionNone     = "none"     // 不加密，仅用于本地测试，如 MailHog
+)
+
+// smtpTimeout 一次发送（连接、认证、传输）的最长时间
+const smtpTimeout = 30 * time.Second
+
+// smtpTransport 通过SMTP服务器发送
+type smtpTransport struct {
+	host       string
+	port       int
+	username   string
+	password   string
+	encryption string
+	log        logger.Logger
+}
+
+// newSMTPTransport 创建SMTP发送方式
+func newSMTPTransport(cfg config.EmailConfig) (*smtpTransport, error) {
+	if cfg.SMTPHost == "" {
+		return nil, fmt.Errorf("未配置SMTP服务器地址")
+	}
+	encryption := cfg.SMTPEncryption
+	if encryption == "" {
+		encryption = SMTPEncryptionSTARTTLS
+	}
+	switch encryption {
+	case SMTPEncryptionSTARTTLS, SMTPEncryptionTLS, SMTPEncryptionNone:
+	default:
+		return nil, fmt.Errorf("不支持的SMTP加密方式: %s", encryption)
+	}
+
+	port := cfg.SMTPPort
+	if port == 0 {
+		port = 587
+		if encryption == SMTPEncryptionTLS {
+			port = 465
+		}
+	}
+	return &smtpTransport{
+		host:       cfg.SMTPHost,
+		port:       port,
+		username:   cfg.SMTPUsername,
+		password:   cfg.SMTPPassword,
+		encryption: encryption,
+		log:        logger.GetLogger("smtp-transport"),
+	}, nil
+}
+
+// Name 发送方式名称
+func (t *smtpTransport) Name() string {
+	return TransportSMTP
+}
+
+// Send 连接SMTP服务器发送一封邮件，每次发送使用新的连接
+func (t *smtpTransport) Send(ctx context.Context, msg *Message) (string, error) {
+	data, id, err := buildMIME(msg, time.Now())
+	if err != nil {
+		return "", fmt.Errorf("生成邮件内容失败: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, smtpTimeout)
+	defer cancel()
+
+	conn, err := t.dial(ctx)
+	if err != nil {
+		return "", fmt.Errorf("连接SMTP服务器失败: %w", err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	client, err := smtp.NewClient(conn, t.host)
+	if err != nil {
+		conn.Close()
+		return "", fmt.Errorf("连接SMTP服务器失败: %w", err)
+	}
+	defer client.Close()
+
+	if t.encryption == SMTPEncryptionSTARTTLS {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			return "", fmt.Errorf("SMTP服务器不支持STARTTLS")
+		}
+		if err := client.StartTLS(&tls.Config{ServerName: t.host}); err != nil {
+			return "", fmt.Errorf("SMTP STARTTLS失败: %w", err)
+		}
+	}
+
+	if t.username != "" {
+		if err := client.Auth(smtp.PlainAuth("", t.username, t.password, t.host)); err != nil {
+			return "", fmt.Errorf("SMTP认证失败: %w", err)
+		}
+	}
+
+	if err := client.Mail(msg.From); err != nil {
+		return "", fmt.Errorf("SMTP MAIL FROM失败: %w", err)
+	}
+	if err := client.Rcpt(msg.To); err != nil {
+		return "", fmt.Errorf("SMTP RCPT TO失败: %w", err)
+	}
+	writer, err := client.Data()
+	if err != nil {
+		return "", fmt.Errorf("SMTP DATA失败: %w", err)
+	}
+	if _, err := writer.Write(data); err != nil {
+		writer.Close()
+		return "", fmt.Errorf("写入邮件内容失败: %w", err)
+	}
+	if err := writer.Close(); err != nil {
+		return "", fmt.Errorf("SMTP服务器拒绝了邮件: %w", err)
+	}
+	// DATA 结束后服务器已经接收了邮件，QUIT 失败不能当作发送失败，否则重试会让收件人收到重复的邮件
+	if err := client.Quit(); err != nil {
+		t.log.Warn("SMTP QUIT失败，邮件已被服务器接收", "message_id", id, "error", err.Error())
+	}
+	return id, nil
+}
+
+// dial 按加密方式建立连接
+func (t *smtpTransport) dial(ctx context.Context) (net.Conn, error) {
+	addr := net.JoinHostPort(t.host, strconv.Itoa(t.port))
+	if t.encryption == SMTPEncryptionTLS {
+		dialer := &tls.Dialer{Config: &tls.Config{ServerName: t.host}}
+		return dialer.DialContext(ctx, "tcp", addr)
+	}
+	var dialer net.Dialer
+	return dialer.DialContext(ctx, "tcp", addr)
+}
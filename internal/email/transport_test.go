@@ -0,0 +1,197 @@
+package email
+
+import (
+	"context"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"memoir-api/internal/config"
+)
+
+func TestFileTransportWritesMultipartEML(t *testing.T) {
+	dir := t.TempDir()
+	transport, err := NewTransport(config.EmailConfig{Transport: TransportFile, SpoolDir: dir})
+	if err != nil {
+		t.Fatalf("NewTransport() error: %v", err)
+	}
+
+	msg := &Message{
+		From:     "no-reply@example.com",
+		FromName: "Memoir App",
+		To:       "alice@example.com",
+		Subject:  "纪念日提醒",
+		HTMLBody: "<p>在一起100天 🎉</p>",
+		TextBody: "在一起100天",
+		Headers:  unsubscribeHeaders("https://api.example.com/api/v1/email/unsubscribe?token=abc"),
+	}
+	messageID, err := transport.Send(context.Background(), msg)
+	if err != nil {
+		t.Fatalf("Send() error: %v", err)
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.eml"))
+	if err != nil || len(files) != 1 {
+		t.Fatalf("want 1 .eml file, got %v (err %v)", files, err)
+	}
+	raw, err := os.Open(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer raw.Close()
+
+	parsed, err := mail.ReadMessage(raw)
+	if err != nil {
+		t.Fatalf("ReadMessage() error: %v", err)
+	}
+	if got := parsed.Header.Get("Message-ID"); got == "" || got != messageID {
+		t.Errorf("Message-ID = %q, Send() returned %q", got, messageID)
+	}
+	if got := parsed.Header.Get("List-Unsubscribe"); got != "<https://api.example.com/api/v1/email/unsubscribe?token=abc>" {
+		t.Errorf("List-Unsubscribe = %q", got)
+	}
+	if got := parsed.Header.Get("List-Unsubscribe-Post"); got != "List-Unsubscribe=One-Click" {
+		t.Errorf("List-Unsubscribe-Post = %q", got)
+	}
+	subject, err := new(mime.WordDecoder).DecodeHeader(parsed.Header.Get("Subject"))
+	if err != nil || subject != msg.Subject {
+		t.Errorf("Subject = %q (err %v), want %q", subject, err, msg.Subject)
+	}
+	from, err := parsed.Header.AddressList("From")
+	if err != nil || len(from) != 1 || from[0].Name != msg.FromName || from[0].Address != msg.From {
+		t.Errorf("From = %v (err %v)", from, err)
+	}
+
+	mediaType, params, err := mime.ParseMediaType(parsed.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/alternative" {
+		t.Fatalf("Content-Type = %q (err %v)", mediaType, err)
+	}
+	reader := multipart.NewReader(parsed.Body, params["boundary"])
+	want := map[string]string{"text/plain": msg.TextBody, "text/html": msg.HTMLBody}
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("NextPart() error: %v", err)
+		}
+		contentType := strings.Split(part.Header.Get("Content-Type"), ";")[0]
+		// multipart.Reader 会自动解码 quoted-printable
+		body, _ := io.ReadAll(part)
+		if string(body) != want[contentType] {
+			t.Errorf("%s body = %q, want %q", contentType, body, want[contentType])
+		}
+		delete(want, contentType)
+	}
+	if len(want) != 0 {
+		t.Errorf("missing parts: %v", want)
+	}
+}
+
+func TestNewTransportRejectsUnknown(t *testing.T) {
+	if _, err := NewTransport(config.EmailConfig{Transport: "pigeon"}); err == nil {
+		t.Error("NewTransport() succeeded for unknown transport")
+	}
+	if _, err := NewTransport(config.EmailConfig{Transport: TransportSMTP}); err == nil {
+		t.Error("NewTransport() succeeded without SMTP host")
+	}
+	if _, err := NewTransport(config.EmailConfig{Transport: TransportSMTP, SMTPHost: "localhost", SMTPEncryption: "ssl3"}); err == nil {
+		t.Error("NewTransport() succeeded with unknown SMTP encryption")
+	}
+}
+
+// fakeSMTPServer 只支持最基本命令的SMTP服务器，返回收到的 DATA 内容。
+// dropOnQuit 为 true 时收到 QUIT 直接断开连接，模拟服务器接收邮件后连接异常
+func fakeSMTPServer(t *testing.T, dropOnQuit bool) (addr string, received <-chan string) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	ch := make(chan string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		text := textproto.NewConn(conn)
+		text.PrintfLine("220 fake ESMTP")
+		for {
+			line, err := text.ReadLine()
+			if err != nil {
+				return
+			}
+			switch cmd := strings.ToUpper(strings.Fields(line)[0]); cmd {
+			case "EHLO":
+				text.PrintfLine("250-fake\r\n250 AUTH PLAIN")
+			case "AUTH":
+				text.PrintfLine("235 ok")
+			case "MAIL", "RCPT":
+				text.PrintfLine("250 ok")
+			case "DATA":
+				text.PrintfLine("354 go ahead")
+				data, err := text.ReadDotBytes()
+				if err != nil {
+					return
+				}
+				ch <- string(data)
+				text.PrintfLine("250 queued")
+			case "QUIT":
+				if !dropOnQuit {
+					text.PrintfLine("221 bye")
+				}
+				return
+			default:
+				text.PrintfLine("502 unsupported")
+			}
+		}
+	}()
+	return ln.Addr().String(), ch
+}
+
+func TestSMTPTransportSends(t *testing.T) {
+	for _, dropOnQuit := range []bool{false, true} {
+		addr, received := fakeSMTPServer(t, dropOnQuit)
+		host, port, _ := net.SplitHostPort(addr)
+		portNum, _ := strconv.Atoi(port)
+
+		transport, err := NewTransport(config.EmailConfig{
+			Transport:      TransportSMTP,
+			SMTPHost:       host,
+			SMTPPort:       portNum,
+			SMTPUsername:   "user",
+			SMTPPassword:   "secret",
+			SMTPEncryption: SMTPEncryptionNone,
+		})
+		if err != nil {
+			t.Fatalf("NewTransport() error: %v", err)
+		}
+		// QUIT 失败时邮件已被接收，不能返回错误，否则队列会重发
+		messageID, err := transport.Send(context.Background(), &Message{
+			From: "no-reply@example.com", To: "bob@example.com", Subject: "hi", HTMLBody: "<b>hi</b>", TextBody: "hi",
+		})
+		if err != nil {
+			t.Fatalf("Send() error with dropOnQuit=%v: %v", dropOnQuit, err)
+		}
+		select {
+		case data := <-received:
+			if !strings.Contains(data, "multipart/alternative") || !strings.Contains(data, "<b>hi</b>") || !strings.Contains(data, messageID) {
+				t.Errorf("unexpected message data: %q", data)
+			}
+		default:
+			t.Error("server did not receive the message")
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package email
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// OptionalTypes 用户可以退订的邮件类型，其余类型是验证码、密码重置、账号安全通知等必要邮件，不能退订
+var OptionalTypes = []EmailType{
+	EmailTypeAnniversary,
+	EmailTypeFestival,
+	EmailTypeCustomAnniversary,
+	EmailTypeReminder,
+}
+
+// MandatoryTypes 必要邮件类型，总是发送
+var MandatoryTypes = []EmailType{
+	EmailTypeVerification,
+	EmailTypeResetPassword,
+	EmailTypeNotification,
+	EmailTypeWelcome,
+	EmailTypeCoupleInvite,
+}
+
+var (
+	ErrInvalidUnsubscribeToken = errors.New("退订链接无效")
+	// ErrMissingUnsubscribeSecret 启用邮件服务时 EMAIL_UNSUBSCRIBE_SECRET 和 JWT_SECRET 都未配置
+	ErrMissingUnsubscribeSecret = errors.New("未配置退订链接签名密钥，请设置 EMAIL_UNSUBSCRIBE_SECRET 或 JWT_SECRET")
+)
+
+// IsOptional 邮件类型是否可以退订
+func (t EmailType) IsOptional() bool {
+	for _, optional := range OptionalTypes {
+		if t == optional {
+			return true
+		}
+	}
+	return false
+}
+
+// NewUnsubscribeToken 生成退订令牌，格式为 <用户ID>.<邮件类型>.<签名>。
+// 令牌不过期，邮件可能在很久之后才被打开，退订链接仍需要有效
+func NewUnsubscribeToken(secret string, userID int64, emailType EmailType) string {
+	payload := strconv.FormatInt(userID, 10) + "." + string(emailType)
+	return payload + "." + unsubscribeSignature(secret, payload)
+}
+
+// ParseUnsubscribeToken 校验退订令牌的签名，返回用户ID和邮件类型
+func ParseUnsubscribeToken(secret, token string) (int64, EmailType, error) {
+	if secret == "" {
+		return 0, "", ErrInvalidUnsubscribeToken
+	}
+	payload, signature, ok := cutLast(token, ".")
+	if !ok || !hmac.Equal([]byte(signature), []byte(unsubscribeSignature(secret, payload))) {
+		return 0, "", ErrInvalidUnsubscribeToken
+	}
+	id, emailType, ok := strings.Cut(payload, ".")
+	if !ok {
+		return 0, "", ErrInvalidUnsubscribeToken
+	}
+	userID, err := strconv.ParseInt(id, 10, 64)
+	if err != nil || userID <= 0 || !EmailType(emailType).IsOptional() {
+		return 0, "", ErrInvalidUnsubscribeToken
+	}
+	return userID, EmailType(emailType), nil
+}
+
+// unsubscribeSignature 计算 HMAC-SHA256 签名，加上用途前缀，避免和其他使用同一密钥的签名混用
+func unsubscribeSignature(secret, payload string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("unsubscribe:" + payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// cutLast 在最后一个分隔符处切分
+func cutLast(s, sep string) (before, after string, found bool) {
+	if i := strings.LastIndex(s, sep); i >= 0 {
+		return s[:i], s[i+len(sep):], true
+	}
+	return s, "", false
+}
+
+// unsubscribeHeaders 一键退订（RFC 8058）需要的邮件头
+func unsubscribeHeaders(oneClickURL string) map[string]string {
+	return map[string]string{
+		"List-Unsubscribe":      "<" + oneClickURL + ">",
+		"List-Unsubscribe-Post": "List-Unsubscribe=One-Click",
+	}
+}
+
+// unsubscribeLinks 生成退订链接：page 是邮件正文中的链接，打开前端确认页面；
+// oneClick 是邮件头中的链接，邮件客户端直接 POST 到接口完成退订
+func (s *MailService) unsubscribeLinks(userID int64, emailType EmailType) (page, oneClick string) {
+	token := url.QueryEscape(NewUnsubscribeToken(s.config.UnsubscribeSecret, userID, emailType))
+	page = s.config.AppURL + "/email/unsubscribe?token=" + token
+	oneClick = s.config.APIURL + "/api/v1/email/unsubscribe?token=" + token
+	return page, oneClick
+}
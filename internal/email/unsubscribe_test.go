@@ -0,0 +1,57 @@
+package email
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestUnsubscribeTokenRoundTrip(t *testing.T) {
+	token := NewUnsubscribeToken("secret", 42, EmailTypeFestival)
+	userID, emailType, err := ParseUnsubscribeToken("secret", token)
+	if err != nil {
+		t.Fatalf("ParseUnsubscribeToken() error: %v", err)
+	}
+	if userID != 42 || emailType != EmailTypeFestival {
+		t.Errorf("got (%d, %q), want (42, %q)", userID, emailType, EmailTypeFestival)
+	}
+}
+
+func TestParseUnsubscribeTokenRejects(t *testing.T) {
+	valid := NewUnsubscribeToken("secret", 42, EmailTypeReminder)
+	payload, signature, _ := cutLast(valid, ".")
+
+	tests := map[string]struct {
+		secret string
+		token  string
+	}{
+		"wrong secret":    {"other", valid},
+		"empty secret":    {"", NewUnsubscribeToken("", 42, EmailTypeReminder)},
+		"other user":      {"secret", strings.Replace(payload, "42", "43", 1) + "." + signature},
+		"other type":      {"secret", "42." + string(EmailTypeFestival) + "." + signature},
+		"mandatory type":  {"secret", NewUnsubscribeToken("secret", 42, EmailTypeResetPassword)},
+		"missing user id": {"secret", NewUnsubscribeToken("secret", 0, EmailTypeReminder)},
+		"no signature":    {"secret", payload},
+		"empty":           {"secret", ""},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, _, err := ParseUnsubscribeToken(tt.secret, tt.token); !errors.Is(err, ErrInvalidUnsubscribeToken) {
+				t.Errorf("ParseUnsubscribeToken(%q) error = %v, want ErrInvalidUnsubscribeToken", tt.token, err)
+			}
+		})
+	}
+}
+
+func TestOptionalTypes(t *testing.T) {
+	for _, emailType := range OptionalTypes {
+		if !emailType.IsOptional() {
+			t.Errorf("%s.IsOptional() = false", emailType)
+		}
+	}
+	for _, emailType := range MandatoryTypes {
+		if emailType.IsOptional() {
+			t.Errorf("%s.IsOptional() = true", emailType)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+// Package festival 节日计算，支持公历固定日期、农历日期和二十四节气三类节日
+package festival
+
+import (
+	"time"
+)
+
+// Kind 节日的日期规则
+type Kind string
+
+const (
+	KindGregorian Kind = "gregorian"  // 公历固定日期
+	KindLunar     Kind = "lunar"      // 农历日期
+	KindSolarTerm Kind = "solar_term" // 节气
+)
+
+// Festival 节日定义
+type Festival struct {
+	// Key 节日标识，情侣按它开关单个节日的提醒
+	Key  string `json:"key"`
+	Name string `json:"name"`
+	// EnglishName 英文邮件中使用的名称
+	EnglishName string `json:"english_name"`
+	Kind        Kind   `json:"kind"`
+	// Month/Day 公历或农历的月日，农历节日不在闰月过
+	Month int `json:"month,omitempty"`
+	Day   int `json:"day,omitempty"`
+	// SolarTerm 节气序号，见 SolarTermNames
+	SolarTerm int `json:"solar_term,omitempty"`
+}
+
+// Festivals 支持提醒的节日
+var Festivals = []Festival{
+	{Key: "spring_festival", Name: "春节", EnglishName: "Spring Festival", Kind: KindLunar, Month: 1, Day: 1},
+	{Key: "lantern_festival", Name: "元宵节", EnglishName: "Lantern Festival", Kind: KindLunar, Month: 1, Day: 15},
+	{Key: "valentines_day", Name: "情人节", EnglishName: "Valentine's Day", Kind: KindGregorian, Month: 2, Day: 14},
+	{Key: "qingming", Name: "清明", EnglishName: "Qingming Festival", Kind: KindSolarTerm, SolarTerm: SolarTermQingming},
+	{Key: "520", Name: "520", EnglishName: "520 Day", Kind: KindGregorian, Month: 5, Day: 20},
+	{Key: "qixi", Name: "七夕", EnglishName: "Qixi Festival", Kind: KindLunar, Month: 7, Day: 7},
+	{Key: "mid_autumn", Name: "中秋节", EnglishName: "Mid-Autumn Festival", Kind: KindLunar, Month: 8, Day: 15},
+	{Key: "dongzhi", Name: "冬至", EnglishName: "Winter Solstice", Kind: KindSolarTerm, SolarTerm: SolarTermDongzhi},
+	{Key: "christmas", Name: "圣诞节", EnglishName: "Christmas", Kind: KindGregorian, Month: 12, Day: 25},
+}
+
+// Lookup 按标识查找节日
+func Lookup(key string) (Festival, bool) {
+	for _, f := range Festivals {
+		if f.Key == key {
+			return f, true
+		}
+	}
+	return Festival{}, false
+}
+
+// Date 节日在公历某一年的日期，返回 loc 时区的零点。
+// 农历节日按农历年计算，春节这类在公历年初的节日返回的也是该公历年内的那一次
+func (f Festival) Date(year int, loc *time.Location) (time.Time, error) {
+	switch f.Kind {
+	case KindLunar:
+		date, err := LunarToSolar(LunarDate{Year: year, Month: f.Month, Day: f.Day}, loc)
+		if err != nil {
+			return time.Time{}, err
+		}
+		// 农历年比公历年晚一到两个月，腊月的节日可能落在下一个公历年
+		if date.Year() != year {
+			return LunarToSolar(LunarDate{Year: year - 1, Month: f.Month, Day: f.Day}, loc)
+		}
+		return date, nil
+	case KindSolarTerm:
+		return SolarTermDate(year, f.SolarTerm, loc)
+	default:
+		return time.Date(year, time.Month(f.Month), f.Day, 0, 0, 0, 0, loc), nil
+	}
+}
+
+// Next 节日在 from 当天或之后最近的一次
+func (f Festival) Next(from time.Time) (time.Time, error) {
+	loc := from.Location()
+	today := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, loc)
+	date, err := f.Date(today.Year(), loc)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if date.Before(today) {
+		return f.Date(today.Year()+1, loc)
+	}
+	return date, nil
+}
+
+// On 获取某天的所有节日，只使用 t 所在时区的年月日；超出换算范围的规则会被忽略
+func On(t time.Time) []Festival {
+	loc := t.Location()
+	today := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+
+	var result []Festival
+	for _, f := range Festivals {
+		date, err := f.Date(today.Year(), loc)
+		if err == nil && date.Equal(today) {
+			result = append(result, f)
+		}
+	}
+	return result
+}
@@ -0,0 +1,159 @@
+package festival
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFestivalDates(t *testing.T) {
+	tests := []struct {
+		key  string
+		year int
+		want string
+	}{
+		{"spring_festival", 2000, "2000-02-05"},
+		{"spring_festival", 2008, "2008-02-07"},
+		{"spring_festival", 2012, "2012-01-23"},
+		{"spring_festival", 2019, "2019-02-05"},
+		{"spring_festival", 2020, "2020-01-25"},
+		{"spring_festival", 2021, "2021-02-12"},
+		{"spring_festival", 2022, "2022-02-01"},
+		{"spring_festival", 2023, "2023-01-22"},
+		{"spring_festival", 2024, "2024-02-10"},
+		{"spring_festival", 2025, "2025-01-29"},
+		{"spring_festival", 2026, "2026-02-17"},
+		{"spring_festival", 2030, "2030-02-03"},
+		{"lantern_festival", 2023, "2023-02-05"},
+		{"lantern_festival", 2024, "2024-02-24"},
+		{"lantern_festival", 2025, "2025-02-12"},
+		{"qixi", 2020, "2020-08-25"},
+		{"qixi", 2021, "2021-08-14"},
+		{"qixi", 2022, "2022-08-04"},
+		{"qixi", 2023, "2023-08-22"},
+		{"qixi", 2024, "2024-08-10"},
+		{"qixi", 2025, "2025-08-29"},
+		{"qixi", 2026, "2026-08-19"},
+		{"mid_autumn", 2015, "2015-09-27"},
+		{"mid_autumn", 2017, "2017-10-04"},
+		{"mid_autumn", 2019, "2019-09-13"},
+		{"mid_autumn", 2020, "2020-10-01"},
+		{"mid_autumn", 2021, "2021-09-21"},
+		{"mid_autumn", 2022, "2022-09-10"},
+		{"mid_autumn", 2023, "2023-09-29"},
+		{"mid_autumn", 2024, "2024-09-17"},
+		{"mid_autumn", 2025, "2025-10-06"},
+		{"mid_autumn", 2026, "2026-09-25"},
+		{"qingming", 2008, "2008-04-04"},
+		{"qingming", 2019, "2019-04-05"},
+		{"qingming", 2020, "2020-04-04"},
+		{"qingming", 2023, "2023-04-05"},
+		{"qingming", 2024, "2024-04-04"},
+		{"qingming", 2025, "2025-04-04"},
+		{"dongzhi", 2020, "2020-12-21"},
+		{"dongzhi", 2021, "2021-12-21"},
+		{"dongzhi", 2022, "2022-12-22"},
+		{"dongzhi", 2023, "2023-12-22"},
+		{"dongzhi", 2024, "2024-12-21"},
+		{"valentines_day", 2024, "2024-02-14"},
+		{"520", 2024, "2024-05-20"},
+		{"christmas", 2024, "2024-12-25"},
+	}
+
+	for _, tt := range tests {
+		f, ok := Lookup(tt.key)
+		if !ok {
+			t.Fatalf("festival %q not found", tt.key)
+		}
+		got, err := f.Date(tt.year, time.UTC)
+		if err != nil {
+			t.Errorf("%s %d: unexpected error: %v", tt.key, tt.year, err)
+			continue
+		}
+		if got.Format("2006-01-02") != tt.want {
+			t.Errorf("%s %d = %s, want %s", tt.key, tt.year, got.Format("2006-01-02"), tt.want)
+		}
+	}
+}
+
+func TestSolarTermDates(t *testing.T) {
+	tests := []struct {
+		year  int
+		index int
+		want  string
+	}{
+		{2000, 0, "2000-01-06"},
+		{2019, 0, "2019-01-05"},
+		{2024, 2, "2024-02-04"},
+		{2025, 2, "2025-02-03"},
+		{2026, 3, "2026-02-18"},
+		{2008, 9, "2008-05-21"},
+		{2016, 12, "2016-07-07"},
+		{2024, 5, "2024-03-20"},
+		{2024, 11, "2024-06-21"},
+		{2024, 17, "2024-09-22"},
+	}
+
+	for _, tt := range tests {
+		got, err := SolarTermDate(tt.year, tt.index, time.UTC)
+		if err != nil {
+			t.Errorf("%d %s: unexpected error: %v", tt.year, SolarTermNames[tt.index], err)
+			continue
+		}
+		if got.Format("2006-01-02") != tt.want {
+			t.Errorf("%d %s = %s, want %s", tt.year, SolarTermNames[tt.index], got.Format("2006-01-02"), tt.want)
+		}
+	}
+
+	if _, err := SolarTermDate(MaxSolarTermYear+1, 0, time.UTC); err != ErrOutOfRange {
+		t.Errorf("expected ErrOutOfRange after %d, got %v", MaxSolarTermYear, err)
+	}
+}
+
+func TestLunarRoundTrip(t *testing.T) {
+	tests := []struct {
+		solar string
+		lunar LunarDate
+	}{
+		{"1900-01-31", LunarDate{Year: 1900, Month: 1, Day: 1}},
+		{"2020-05-23", LunarDate{Year: 2020, Month: 4, Day: 1, IsLeap: true}},
+		{"2023-03-22", LunarDate{Year: 2023, Month: 2, Day: 1, IsLeap: true}},
+		{"2025-07-25", LunarDate{Year: 2025, Month: 6, Day: 1, IsLeap: true}},
+		{"2024-02-09", LunarDate{Year: 2023, Month: 12, Day: 30}},
+	}
+
+	for _, tt := range tests {
+		solar, _ := time.Parse("2006-01-02", tt.solar)
+		got, err := SolarToLunar(solar)
+		if err != nil || got != tt.lunar {
+			t.Errorf("SolarToLunar(%s) = %v, %v, want %v", tt.solar, got, err, tt.lunar)
+		}
+		back, err := LunarToSolar(tt.lunar, time.UTC)
+		if err != nil || !back.Equal(solar) {
+			t.Errorf("LunarToSolar(%v) = %s, %v, want %s", tt.lunar, back.Format("2006-01-02"), err, tt.solar)
+		}
+	}
+
+	// 逐日往返换算整个支持范围
+	end := time.Date(2100, time.December, 31, 0, 0, 0, 0, time.UTC)
+	for day := time.Date(1900, time.January, 31, 0, 0, 0, 0, time.UTC); !day.After(end); day = day.AddDate(0, 0, 1) {
+		lunar, err := SolarToLunar(day)
+		if err != nil {
+			t.Fatalf("SolarToLunar(%s): %v", day.Format("2006-01-02"), err)
+		}
+		back, err := LunarToSolar(lunar, time.UTC)
+		if err != nil || !back.Equal(day) {
+			t.Fatalf("round trip %s -> %v -> %s, %v", day.Format("2006-01-02"), lunar, back.Format("2006-01-02"), err)
+		}
+	}
+}
+
+func TestOn(t *testing.T) {
+	day := time.Date(2024, time.September, 17, 20, 0, 0, 0, time.FixedZone("CST", 8*60*60))
+	festivals := On(day)
+	if len(festivals) != 1 || festivals[0].Key != "mid_autumn" {
+		t.Errorf("On(2024-09-17) = %v, want mid_autumn", festivals)
+	}
+	if festivals := On(time.Date(2024, time.September, 18, 0, 0, 0, 0, time.UTC)); len(festivals) != 0 {
+		t.Errorf("On(2024-09-18) = %v, want none", festivals)
+	}
+}
@@ -0,0 +1,183 @@
+package festival
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// 支持换算的农历年份范围
+const (
+	MinLunarYear = 1900
+	MaxLunarYear = 2100
+)
+
+var (
+	ErrOutOfRange   = errors.New("日期超出农历换算范围")
+	ErrInvalidLunar = errors.New("无效的农历日期")
+)
+
+// lunarInfo 1900-2100 年的农历数据，每年一个值：
+//   - 第 0-3 位：闰月月份，0 表示没有闰月
+//   - 第 4-15 位：从高到低依次表示正月到十二月是否为大月（30天），否则为小月（29天）
+//   - 第 16 位：闰月是否为大月
+var lunarInfo = [...]int{
+	0x04bd8, 0x04ae0, 0x0a570, 0x054d5, 0x0d260, 0x0d950, 0x16554, 0x056a0, 0x09ad0, 0x055d2, // 1900-1909
+	0x04ae0, 0x0a5b6, 0x0a4d0, 0x0d250, 0x1d255, 0x0b540, 0x0d6a0, 0x0ada2, 0x095b0, 0x14977, // 1910-1919
+	0x04970, 0x0a4b0, 0x0b4b5, 0x06a50, 0x06d40, 0x1ab54, 0x02b60, 0x09570, 0x052f2, 0x04970, // 1920-1929
+	0x06566, 0x0d4a0, 0x0ea50, 0x16a95, 0x05ad0, 0x02b60, 0x186e3, 0x092e0, 0x1c8d7, 0x0c950, // 1930-1939
+	0x0d4a0, 0x1d8a6, 0x0b550, 0x056a0, 0x1a5b4, 0x025d0, 0x092d0, 0x0d2b2, 0x0a950, 0x0b557, // 1940-1949
+	0x06ca0, 0x0b550, 0x15355, 0x04da0, 0x0a5b0, 0x14573, 0x052b0, 0x0a9a8, 0x0e950, 0x06aa0, // 1950-1959
+	0x0aea6, 0x0ab50, 0x04b60, 0x0aae4, 0x0a570, 0x05260, 0x0f263, 0x0d950, 0x05b57, 0x056a0, // 1960-1969
+	0x096d0, 0x04dd5, 0x04ad0, 0x0a4d0, 0x0d4d4, 0x0d250, 0x0d558, 0x0b540, 0x0b6a0, 0x195a6, // 1970-1979
+	0x095b0, 0x049b0, 0x0a974, 0x0a4b0, 0x0b27a, 0x06a50, 0x06d40, 0x0af46, 0x0ab60, 0x09570, // 1980-1989
+	0x04af5, 0x04970, 0x064b0, 0x074a3, 0x0ea50, 0x06b58, 0x05ac0, 0x0ab60, 0x096d5, 0x092e0, // 1990-1999
+	0x0c960, 0x0d954, 0x0d4a0, 0x0da50, 0x07552, 0x056a0, 0x0abb7, 0x025d0, 0x092d0, 0x0cab5, // 2000-2009
+	0x0a950, 0x0b4a0, 0x0baa4, 0x0ad50, 0x055d9, 0x04ba0, 0x0a5b0, 0x15176, 0x052b0, 0x0a930, // 2010-2019
+	0x07954, 0x06aa0, 0x0ad50, 0x05b52, 0x04b60, 0x0a6e6, 0x0a4e0, 0x0d260, 0x0ea65, 0x0d530, // 2020-2029
+	0x05aa0, 0x076a3, 0x096d0, 0x04afb, 0x04ad0, 0x0a4d0, 0x1d0b6, 0x0d250, 0x0d520, 0x0dd45, // 2030-2039
+	0x0b5a0, 0x056d0, 0x055b2, 0x049b0, 0x0a577, 0x0a4b0, 0x0aa50, 0x1b255, 0x06d20, 0x0ada0, // 2040-2049
+	0x14b63, 0x09370, 0x049f8, 0x04970, 0x064b0, 0x168a6, 0x0ea50, 0x06b20, 0x1a6c4, 0x0aae0, // 2050-2059
+	0x092e0, 0x0d2e3, 0x0c960, 0x0d557, 0x0d4a0, 0x0da50, 0x05d55, 0x056a0, 0x0a6d0, 0x055d4, // 2060-2069
+	0x052d0, 0x0a9b8, 0x0a950, 0x0b4a0, 0x0b6a6, 0x0ad50, 0x055a0, 0x0aba4, 0x0a5b0, 0x052b0, // 2070-2079
+	0x0b273, 0x06930, 0x07337, 0x06aa0, 0x0ad50, 0x14b55, 0x04b60, 0x0a570, 0x054e4, 0x0d160, // 2080-2089
+	0x0e968, 0x0d520, 0x0daa0, 0x16aa6, 0x056d0, 0x04ae0, 0x0a9d4, 0x0a2d0, 0x0d150, 0x0f252, // 2090-2099
+	0x0d520, // 2100
+}
+
+// lunarEpoch 农历1900年正月初一对应的公历日期
+var lunarEpoch = time.Date(1900, time.January, 31, 0, 0, 0, 0, time.UTC)
+
+// LunarDate 农历日期
+type LunarDate struct {
+	Year  int
+	Month int
+	Day   int
+	// IsLeap 是否为闰月
+	IsLeap bool
+}
+
+// String 格式化为 2024-08-15，闰月写作 2023-闰02-10
+func (d LunarDate) String() string {
+	if d.IsLeap {
+		return fmt.Sprintf("%04d-闰%02d-%02d", d.Year, d.Month, d.Day)
+	}
+	return fmt.Sprintf("%04d-%02d-%02d", d.Year, d.Month, d.Day)
+}
+
+// LeapMonth 农历年的闰月月份，没有闰月时返回0
+func LeapMonth(year int) int {
+	return lunarInfo[year-MinLunarYear] & 0xf
+}
+
+// leapMonthDays 农历年闰月的天数，没有闰月时返回0
+func leapMonthDays(year int) int {
+	if LeapMonth(year) == 0 {
+		return 0
+	}
+	if lunarInfo[year-MinLunarYear]&0x10000 != 0 {
+		return 30
+	}
+	return 29
+}
+
+// MonthDays 农历年某个月（非闰月）的天数
+func MonthDays(year, month int) int {
+	if lunarInfo[year-MinLunarYear]&(0x10000>>month) != 0 {
+		return 30
+	}
+	return 29
+}
+
+// yearDays 农历年的总天数
+func yearDays(year int) int {
+	days := 0
+	for month := 1; month <= 12; month++ {
+		days += MonthDays(year, month)
+	}
+	return days + leapMonthDays(year)
+}
+
+// dateOnly 只保留日期，统一换算到UTC零点，避免夏令时等时区问题影响天数计算
+func dateOnly(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// SolarToLunar 公历转农历，只使用 t 所在时区的年月日
+func SolarToLunar(t time.Time) (LunarDate, error) {
+	offset := int(dateOnly(t).Sub(lunarEpoch).Hours() / 24)
+	if offset < 0 {
+		return LunarDate{}, ErrOutOfRange
+	}
+
+	year := MinLunarYear
+	for ; year <= MaxLunarYear; year++ {
+		days := yearDays(year)
+		if offset < days {
+			break
+		}
+		offset -= days
+	}
+	if year > MaxLunarYear {
+		return LunarDate{}, ErrOutOfRange
+	}
+
+	leap := LeapMonth(year)
+	for month := 1; month <= 12; month++ {
+		days := MonthDays(year, month)
+		if offset < days {
+			return LunarDate{Year: year, Month: month, Day: offset + 1}, nil
+		}
+		offset -= days
+
+		// 闰月紧跟在同名的月份之后
+		if month == leap {
+			days = leapMonthDays(year)
+			if offset < days {
+				return LunarDate{Year: year, Month: month, Day: offset + 1, IsLeap: true}, nil
+			}
+			offset -= days
+		}
+	}
+	// 按 yearDays 计算不会走到这里
+	return LunarDate{}, ErrOutOfRange
+}
+
+// LunarToSolar 农历转公历，返回 loc 时区的零点
+func LunarToSolar(date LunarDate, loc *time.Location) (time.Time, error) {
+	if date.Year < MinLunarYear || date.Year > MaxLunarYear {
+		return time.Time{}, ErrOutOfRange
+	}
+	if date.Month < 1 || date.Month > 12 || date.Day < 1 {
+		return time.Time{}, ErrInvalidLunar
+	}
+	leap := LeapMonth(date.Year)
+	if date.IsLeap && leap != date.Month {
+		return time.Time{}, ErrInvalidLunar
+	}
+	monthDays := MonthDays(date.Year, date.Month)
+	if date.IsLeap {
+		monthDays = leapMonthDays(date.Year)
+	}
+	if date.Day > monthDays {
+		return time.Time{}, ErrInvalidLunar
+	}
+
+	offset := 0
+	for year := MinLunarYear; year < date.Year; year++ {
+		offset += yearDays(year)
+	}
+	for month := 1; month < date.Month; month++ {
+		offset += MonthDays(date.Year, month)
+		if month == leap {
+			offset += leapMonthDays(date.Year)
+		}
+	}
+	if date.IsLeap {
+		offset += MonthDays(date.Year, date.Month)
+	}
+	offset += date.Day - 1
+
+	solar := lunarEpoch.AddDate(0, 0, offset)
+	return time.Date(solar.Year(), solar.Month(), solar.Day(), 0, 0, 0, 0, loc), nil
+}
@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"memoir-api/internal/api/dto"
+	"memoir-api/internal/models"
+	"memoir-api/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// defaultAccessTokenDays 未指定有效期时令牌的默认有效天数
+const defaultAccessTokenDays = 90
+
+func newAccessTokenResponse(token *models.PersonalAccessToken) dto.AccessTokenResponse {
+	return dto.AccessTokenResponse{
+		ID:          strconv.FormatInt(token.ID, 10),
+		Name:        token.Name,
+		TokenPrefix: token.TokenPrefix,
+		Scopes:      token.ScopeList(),
+		ExpiresAt:   token.ExpiresAt,
+		LastUsedAt:  token.LastUsedAt,
+		CreatedAt:   token.CreatedAt,
+	}
+}
+
+// CreateAccessTokenHandler 创建个人访问令牌
+func CreateAccessTokenHandler(services service.Factory) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req dto.CreateAccessTokenRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, dto.NewErrorResponse(http.StatusBadRequest, "请求格式错误", err.Error()))
+			return
+		}
+
+		days := req.ExpiresInDays
+		if days == 0 {
+			days = defaultAccessTokenDays
+		}
+
+		token, plaintext, err := services.AccessToken().Create(c.Request.Context(), c.GetInt64("user_id"), req.Name, req.Scopes, time.Duration(days)*24*time.Hour)
+		if err != nil {
+			switch {
+			case errors.Is(err, service.ErrInvalidAccessTokenScope):
+				c.JSON(http.StatusBadRequest, dto.NewErrorResponse(http.StatusBadRequest, "无效的权限范围", err.Error()))
+			case errors.Is(err, service.ErrTooManyAccessTokens):
+				c.JSON(http.StatusConflict, dto.NewErrorResponse(http.StatusConflict, "访问令牌数量已达上限", err.Error()))
+			default:
+				c.JSON(http.StatusInternalServerError, dto.NewErrorResponse(http.StatusInternalServerError, "创建访问令牌失败", err.Error()))
+			}
+			return
+		}
+
+		c.JSON(http.StatusCreated, dto.NewSuccessResponse(dto.CreatedAccessTokenResponse{
+			AccessTokenResponse: newAccessTokenResponse(token),
+			Token:               plaintext,
+		}))
+	}
+}
+
+// ListAccessTokensHandler 列出当前用户的个人访问令牌
+func ListAccessTokensHandler(services service.Factory) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tokens, err := services.AccessToken().List(c.Request.Context(), c.GetInt64("user_id"))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, dto.NewErrorResponse(http.StatusInternalServerError, "获取访问令牌失败", err.Error()))
+			return
+		}
+
+		resp := make([]dto.AccessTokenResponse, 0, len(tokens))
+		for _, token := range tokens {
+			resp = append(resp, newAccessTokenResponse(token))
+		}
+		c.JSON(http.StatusOK, dto.NewSuccessResponse(resp))
+	}
+}
+
+// RevokeAccessTokenHandler 撤销个人访问令牌
+func RevokeAccessTokenHandler(services service.Factory) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, dto.NewErrorResponse(http.StatusBadRequest, "无效的令牌ID", err.Error()))
+			return
+		}
+
+		if err := services.AccessToken().Revoke(c.Request.Context(), c.GetInt64("user_id"), id); err != nil {
+			if errors.Is(err, service.ErrAccessTokenNotFound) {
+				c.JSON(http.StatusNotFound, dto.NewErrorResponse(http.StatusNotFound, "访问令牌不存在", err.Error()))
+				return
+			}
+			c.JSON(http.StatusInternalServerError, dto.NewErrorResponse(http.StatusInternalServerError, "撤销访问令牌失败", err.Error()))
+			return
+		}
+
+		c.JSON(http.StatusOK, dto.EmptySuccessResponse("访问令牌已撤销"))
+	}
+}
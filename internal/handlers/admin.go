@@ -0,0 +1,211 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"memoir-api/internal/api/dto"
+	"memoir-api/internal/logger"
+	"memoir-api/internal/models"
+	"memoir-api/internal/repository"
+	"memoir-api/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminListUsersHandler 分页列出全部用户
+func AdminListUsersHandler(services service.Factory) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req dto.PaginationRequest
+		if err := c.ShouldBindQuery(&req); err != nil {
+			c.JSON(http.StatusBadRequest, dto.NewErrorResponse(http.StatusBadRequest, "请求参数无效", err.Error()))
+			return
+		}
+
+		users, total, err := services.User().ListUsers(c.Request.Context(), req.Offset(), req.Limit())
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, dto.NewErrorResponse(http.StatusInternalServerError, "获取用户列表失败", err.Error()))
+			return
+		}
+
+		resp := make([]dto.AdminUserResponse, 0, len(users))
+		for _, user := range users {
+			resp = append(resp, dto.AdminUserFromModel(user))
+		}
+		c.JSON(http.StatusOK, dto.NewSuccessResponse(dto.NewPageResult(resp, total, req.Page, req.PageSize)))
+	}
+}
+
+// AdminListCouplesHandler 分页列出全部情侣关系及其成员
+func AdminListCouplesHandler(services service.Factory) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req dto.PaginationRequest
+		if err := c.ShouldBindQuery(&req); err != nil {
+			c.JSON(http.StatusBadRequest, dto.NewErrorResponse(http.StatusBadRequest, "请求参数无效", err.Error()))
+			return
+		}
+
+		ctx := c.Request.Context()
+		couples, total, err := services.Couple().ListCouples(ctx, req.Offset(), req.Limit())
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, dto.NewErrorResponse(http.StatusInternalServerError, "获取情侣列表失败", err.Error()))
+			return
+		}
+
+		resp := make([]dto.AdminCoupleResponse, 0, len(couples))
+		for _, couple := range couples {
+			users, err := services.Couple().GetCoupleUsers(ctx, couple.ID)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, dto.NewErrorResponse(http.StatusInternalServerError, "获取情侣成员失败", err.Error()))
+				return
+			}
+
+			item := dto.AdminCoupleResponse{
+				ID:              couple.ID,
+				AnniversaryDate: couple.AnniversaryDate,
+				CreatedAt:       couple.CreatedAt,
+				Users:           make([]dto.AdminUserResponse, 0, len(users)),
+			}
+			for _, user := range users {
+				item.Users = append(item.Users, dto.AdminUserFromModel(user))
+			}
+			resp = append(resp, item)
+		}
+		c.JSON(http.StatusOK, dto.NewSuccessResponse(dto.NewPageResult(resp, total, req.Page, req.PageSize)))
+	}
+}
+
+// parseTargetUserID 解析路径中的用户ID，管理员不能对自己执行停用等操作
+func parseTargetUserID(c *gin.Context) (int64, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse(http.StatusBadRequest, "无效的用户ID", err.Error()))
+		return 0, false
+	}
+	if id == c.GetInt64("user_id") {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse(http.StatusBadRequest, "不能对自己的账号执行该操作", "target is current user"))
+		return 0, false
+	}
+	return id, true
+}
+
+// AdminDisableUserHandler 停用用户账号，并注销其全部会话和访问令牌
+func AdminDisableUserHandler(services service.Factory) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := parseTargetUserID(c)
+		if !ok {
+			return
+		}
+
+		if err := services.User().DisableUser(c.Request.Context(), id); err != nil {
+			if errors.Is(err, repository.ErrUserNotFound) {
+				c.JSON(http.StatusNotFound, dto.NewErrorResponse(http.StatusNotFound, "用户不存在", err.Error()))
+				return
+			}
+			c.JSON(http.StatusInternalServerError, dto.NewErrorResponse(http.StatusInternalServerError, "停用账号失败", err.Error()))
+			return
+		}
+
+		logger.FromContext(c.Request.Context()).WithComponent("admin").Info("管理员停用了账号", "admin_id", c.GetInt64("user_id"), "user_id", id)
+		c.JSON(http.StatusOK, dto.EmptySuccessResponse("账号已停用"))
+	}
+}
+
+// AdminEnableUserHandler 恢复启用用户账号
+func AdminEnableUserHandler(services service.Factory) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := parseTargetUserID(c)
+		if !ok {
+			return
+		}
+
+		if err := services.User().EnableUser(c.Request.Context(), id); err != nil {
+			if errors.Is(err, repository.ErrUserNotFound) {
+				c.JSON(http.StatusNotFound, dto.NewErrorResponse(http.StatusNotFound, "用户不存在", err.Error()))
+				return
+			}
+			c.JSON(http.StatusInternalServerError, dto.NewErrorResponse(http.StatusInternalServerError, "启用账号失败", err.Error()))
+			return
+		}
+
+		logger.FromContext(c.Request.Context()).WithComponent("admin").Info("管理员启用了账号", "admin_id", c.GetInt64("user_id"), "user_id", id)
+		c.JSON(http.StatusOK, dto.EmptySuccessResponse("账号已启用"))
+	}
+}
+
+// AdminRevokeUserSessionsHandler 强制注销用户的全部登录会话
+func AdminRevokeUserSessionsHandler(services service.Factory) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, dto.NewErrorResponse(http.StatusBadRequest, "无效的用户ID", err.Error()))
+			return
+		}
+
+		// 先确认用户存在，不存在的ID不做注销也不记审计日志
+		if _, err := services.User().GetUserByID(c.Request.Context(), id); err != nil {
+			if errors.Is(err, repository.ErrUserNotFound) {
+				c.JSON(http.StatusNotFound, dto.NewErrorResponse(http.StatusNotFound, "用户不存在", err.Error()))
+				return
+			}
+			c.JSON(http.StatusInternalServerError, dto.NewErrorResponse(http.StatusInternalServerError, "注销会话失败", err.Error()))
+			return
+		}
+
+		if err := services.JWT().RevokeAllSessions(c.Request.Context(), id); err != nil {
+			c.JSON(http.StatusInternalServerError, dto.NewErrorResponse(http.StatusInternalServerError, "注销会话失败", err.Error()))
+			return
+		}
+
+		services.Audit().Record(c.Request.Context(), service.AuditEntry{
+			Action:     models.AuditActionLogoutAll,
+			EntityType: models.AuditEntityUser,
+			EntityID:   service.AuditEntityID(id),
+		})
+
+		logger.FromContext(c.Request.Context()).WithComponent("admin").Info("管理员注销了用户全部会话", "admin_id", c.GetInt64("user_id"), "user_id", id)
+		c.JSON(http.StatusOK, dto.EmptySuccessResponse("已注销该用户的全部会话"))
+	}
+}
+
+// AdminListLockoutsHandler 列出当前的登录锁定记录
+func AdminListLockoutsHandler(services service.Factory) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		lockouts, err := services.LoginGuard().ListLockouts(c.Request.Context())
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, dto.NewErrorResponse(http.StatusInternalServerError, "获取锁定记录失败", err.Error()))
+			return
+		}
+		c.JSON(http.StatusOK, dto.NewSuccessResponse(lockouts))
+	}
+}
+
+// AdminClearLockoutHandler 解除登录锁定
+func AdminClearLockoutHandler(services service.Factory) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		kind := c.Param("kind")
+		identifier := c.Param("identifier")
+
+		if err := services.LoginGuard().ClearLockout(c.Request.Context(), kind, identifier); err != nil {
+			switch {
+			case errors.Is(err, service.ErrInvalidLockoutKind):
+				c.JSON(http.StatusBadRequest, dto.NewErrorResponse(http.StatusBadRequest, "无效的锁定类型", err.Error()))
+			case errors.Is(err, service.ErrLockoutNotFound):
+				c.JSON(http.StatusNotFound, dto.NewErrorResponse(http.StatusNotFound, "锁定记录不存在", err.Error()))
+			default:
+				c.JSON(http.StatusInternalServerError, dto.NewErrorResponse(http.StatusInternalServerError, "解除锁定失败", err.Error()))
+			}
+			return
+		}
+
+		services.Audit().Record(c.Request.Context(), service.AuditEntry{
+			Action:     models.AuditActionLockoutClear,
+			EntityType: models.AuditEntityLoginLockout,
+			EntityID:   identifier,
+			After:      map[string]string{"kind": kind},
+		})
+		logger.FromContext(c.Request.Context()).WithComponent("admin").Info("管理员解除了登录锁定", "admin_id", c.GetInt64("user_id"), "kind", kind, "identifier", identifier)
+		c.JSON(http.StatusOK, dto.EmptySuccessResponse("已解除锁定"))
+	}
+}
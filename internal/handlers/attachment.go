@@ -20,8 +20,16 @@ func CreateAttachmentHandler(services service.Factory) gin.HandlerFunc {
 			c.JSON(http.StatusBadRequest, dto.NewErrorResponse(http.StatusBadRequest, "请求参数无效", err.Error()))
 			return
 		}
-		// 设置用户ID
+		// 设置用户ID，情侣空间的附件只能上传到自己的情侣关系下
 		req.UserID = c.GetInt64("user_id")
+		req.CoupleID = 0
+		if req.SpaceType == "couple" {
+			req.CoupleID = c.GetInt64("couple_id")
+			if req.CoupleID == 0 {
+				c.JSON(http.StatusForbidden, dto.NewErrorResponse(http.StatusForbidden, "用户不属于任何情侣关系", service.ErrNotInCouple.Error()))
+				return
+			}
+		}
 		// 创建附件
 		attachment, err := services.Attachment().CreateAttachment(c.Request.Context(), &req)
 		if err != nil {
@@ -44,14 +52,10 @@ func GetAttachmentHandler(services service.Factory) gin.HandlerFunc {
 			return
 		}
 
-		// 获取附件
-		attachment, err := services.Attachment().GetAttachmentByID(c.Request.Context(), attachmentID)
+		// 获取附件，只能查看自己的附件或所属情侣空间的附件
+		attachment, err := services.Attachment().GetAttachmentByID(c.Request.Context(), c.GetInt64("user_id"), c.GetInt64("couple_id"), attachmentID)
 		if err != nil {
-			if err == service.ErrAttachmentNotFound {
-				c.JSON(http.StatusNotFound, dto.NewErrorResponse(http.StatusNotFound, "附件不存在", err.Error()))
-				return
-			}
-			c.JSON(http.StatusInternalServerError, dto.NewErrorResponse(http.StatusInternalServerError, "获取附件失败", err.Error()))
+			respondServiceError(c, "获取附件失败", err)
 			return
 		}
 
@@ -70,25 +74,28 @@ func ListAttachmentsHandler(services service.Factory) gin.HandlerFunc {
 			return
 		}
 
-		// 获取用户ID
+		// 获取用户ID和情侣ID
 		userID := c.GetInt64("user_id")
-
-		// 只能查看自己的附件或所属情侣关系的附件
-		user, err := services.User().GetUserByID(c.Request.Context(), userID)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, dto.NewErrorResponse(http.StatusInternalServerError, "获取用户信息失败", err.Error()))
-			return
-		}
+		coupleID := c.GetInt64("couple_id")
 
 		// 如果没有指定用户ID，则默认查询当前用户的附件
 		if params.UserID == 0 {
 			params.UserID = userID
 		}
 
-		// 如果查询其他用户的附件，必须是同一情侣关系下的用户
-		if params.UserID != userID && (user.CoupleID == 0 || params.CoupleID != user.CoupleID) {
-			c.JSON(http.StatusForbidden, dto.NewErrorResponse(http.StatusForbidden, "无权访问其他用户的附件", ""))
-			return
+		// 情侣ID总是使用当前用户的情侣关系
+		if params.CoupleID != 0 {
+			params.CoupleID = coupleID
+		}
+
+		// 查询其他用户的附件时，只能看到同一情侣空间下的附件
+		if params.UserID != userID {
+			if coupleID == 0 {
+				c.JSON(http.StatusForbidden, dto.NewErrorResponse(http.StatusForbidden, "无权访问其他用户的附件", ""))
+				return
+			}
+			params.CoupleID = coupleID
+			params.SpaceType = "couple"
 		}
 
 		// 查询附件
@@ -113,37 +120,9 @@ func DeleteAttachmentHandler(services service.Factory) gin.HandlerFunc {
 			return
 		}
 
-		// 获取附件信息，检查权限
-		attachment, err := services.Attachment().GetAttachmentByID(c.Request.Context(), attachmentID)
-		if err != nil {
-			if err == service.ErrAttachmentNotFound {
-				c.JSON(http.StatusNotFound, dto.NewErrorResponse(http.StatusNotFound, "附件不存在", err.Error()))
-				return
-			}
-			c.JSON(http.StatusInternalServerError, dto.NewErrorResponse(http.StatusInternalServerError, "获取附件信息失败", err.Error()))
-			return
-		}
-
-		// 只有附件所有者或同一情侣关系的用户可以删除
-		userID := c.GetInt64("user_id")
-		if attachment.UserID != userID {
-			// 如果不是自己的附件，检查是否在同一情侣关系
-			user, err := services.User().GetUserByID(c.Request.Context(), userID)
-			if err != nil {
-				c.JSON(http.StatusInternalServerError, dto.NewErrorResponse(http.StatusInternalServerError, "获取用户信息失败", err.Error()))
-				return
-			}
-
-			// 如果不是同一情侣关系，或者附件不属于情侣空间，则无权删除
-			if user.CoupleID == 0 || user.CoupleID != attachment.CoupleID || attachment.SpaceType != "couple" {
-				c.JSON(http.StatusForbidden, dto.NewErrorResponse(http.StatusForbidden, "无权删除该附件", ""))
-				return
-			}
-		}
-
-		// 删除附件
-		if err := services.Attachment().DeleteAttachment(c.Request.Context(), attachmentID); err != nil {
-			c.JSON(http.StatusInternalServerError, dto.NewErrorResponse(http.StatusInternalServerError, "删除附件失败", err.Error()))
+		// 删除附件，只有附件所有者或同一情侣关系的用户可以删除情侣空间的附件
+		if err := services.Attachment().DeleteAttachment(c.Request.Context(), c.GetInt64("user_id"), c.GetInt64("couple_id"), attachmentID); err != nil {
+			respondServiceError(c, "删除附件失败", err)
 			return
 		}
 
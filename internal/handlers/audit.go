@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"net/http"
+
+	"memoir-api/internal/api/dto"
+	"memoir-api/internal/repository"
+	"memoir-api/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetCoupleAuditHandler 分页查看情侣双方的操作记录
+func GetCoupleAuditHandler(services service.Factory) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req dto.PaginationRequest
+		if err := c.ShouldBindQuery(&req); err != nil {
+			c.JSON(http.StatusBadRequest, dto.NewErrorResponse(http.StatusBadRequest, "请求参数无效", err.Error()))
+			return
+		}
+
+		entries, total, err := services.Audit().ListByCouple(c.Request.Context(), c.GetInt64("couple_id"), req.Offset(), req.Limit())
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, dto.NewErrorResponse(http.StatusInternalServerError, "获取操作记录失败", err.Error()))
+			return
+		}
+
+		c.JSON(http.StatusOK, dto.NewSuccessResponse(dto.NewPageResult(entries, total, req.Page, req.PageSize)))
+	}
+}
+
+// AdminQueryAuditHandler 按条件查询审计日志
+func AdminQueryAuditHandler(services service.Factory) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req dto.AuditLogQueryRequest
+		if err := c.ShouldBindQuery(&req); err != nil {
+			c.JSON(http.StatusBadRequest, dto.NewErrorResponse(http.StatusBadRequest, "请求参数无效", err.Error()))
+			return
+		}
+
+		filter := repository.AuditLogFilter{
+			ActorID:    req.ActorID,
+			CoupleID:   req.CoupleID,
+			Action:     req.Action,
+			EntityType: req.EntityType,
+			EntityID:   req.EntityID,
+		}
+		entries, total, err := services.Audit().Query(c.Request.Context(), filter, req.Offset(), req.Limit())
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, dto.NewErrorResponse(http.StatusInternalServerError, "查询审计日志失败", err.Error()))
+			return
+		}
+
+		c.JSON(http.StatusOK, dto.NewSuccessResponse(dto.NewPageResult(entries, total, req.Page, req.PageSize)))
+	}
+}
+
+// AdminVerifyAuditChainHandler 校验审计日志哈希链是否完整
+func AdminVerifyAuditChainHandler(services service.Factory) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		status, err := services.Audit().VerifyChain(c.Request.Context())
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, dto.NewErrorResponse(http.StatusInternalServerError, "校验审计日志失败", err.Error()))
+			return
+		}
+		c.JSON(http.StatusOK, dto.NewSuccessResponse(status))
+	}
+}
@@ -1,12 +1,17 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
+	"math"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"memoir-api/internal/api/dto"
 	"memoir-api/internal/logger"
 	"memoir-api/internal/models"
+	"memoir-api/internal/repository"
 	"memoir-api/internal/service"
 )
 
@@ -15,6 +20,8 @@ type AuthHandler struct {
 	userService service.UserService
 	jwtService  service.JWTService
 	emailSvc    service.EmailService
+	loginGuard  service.LoginGuardService
+	audit       service.AuditService
 }
 
 // NewAuthHandler 创建认证处理程序
@@ -23,6 +30,8 @@ func NewAuthHandler(services service.Factory) *AuthHandler {
 		userService: services.User(),
 		jwtService:  services.JWT(),
 		emailSvc:    services.Email(),
+		loginGuard:  services.LoginGuard(),
+		audit:       services.Audit(),
 	}
 }
 
@@ -40,24 +49,21 @@ func (h *AuthHandler) Register(c *gin.Context) {
 			c.JSON(http.StatusConflict, dto.NewErrorResponse(http.StatusConflict, "用户已存在", err.Error()))
 			return
 		}
+		if errors.Is(err, service.ErrCoupleFull) || errors.Is(err, service.ErrCoupleDissolving) {
+			c.JSON(http.StatusConflict, dto.NewErrorResponse(http.StatusConflict, "无法加入该情侣关系", err.Error()))
+			return
+		}
 		c.JSON(http.StatusInternalServerError, dto.NewErrorResponse(http.StatusInternalServerError, "注册失败", err.Error()))
 		return
 	}
 
 	// 生成JWT令牌
-	tokenResp, err := h.generateTokens(user.ID)
+	tokenResp, err := h.generateTokens(c, user.ID, req.DeviceName)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, dto.NewErrorResponse(http.StatusInternalServerError, "生成令牌失败", err.Error()))
 		return
 	}
 
-	// 验证成功后发送欢迎邮件
-	err = h.emailSvc.SendWelcomeEmail(c, req.Email, user.Username)
-	if err != nil {
-		// 记录错误但不影响验证流程
-		logger.GetLogger("auth").Error(err, "发送欢迎邮件错误")
-	}
-
 	c.JSON(http.StatusCreated, dto.NewSuccessResponse(tokenResp))
 }
 
@@ -69,55 +75,222 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		return
 	}
 
+	if req.Email == "" && req.Username == "" {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse(http.StatusBadRequest, "请提供用户名或邮箱", "missing login credentials"))
+		return
+	}
+
+	// 先检查退避和锁定，被限制时不做密码比对
+	attempt := service.LoginAttempt{Email: req.Email, Username: req.Username, IP: c.ClientIP()}
+	block, err := h.loginGuard.Check(c.Request.Context(), attempt)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.NewErrorResponse(http.StatusInternalServerError, "登录失败", err.Error()))
+		return
+	}
+	if block != nil {
+		respondLoginBlock(c, block)
+		return
+	}
+
 	var user *models.User
-	var err error
 
 	// 根据提供的登录方式选择登录方法
-	switch {
-	case req.Email != "":
+	if req.Email != "" {
 		user, err = h.userService.Login(c, req.Email, req.Password)
-	case req.Username != "":
+	} else {
 		user, err = h.userService.LoginByUsername(c, req.Username, req.Password)
-	default:
-		c.JSON(http.StatusBadRequest, dto.NewErrorResponse(http.StatusBadRequest, "请提供用户名或邮箱", "missing login credentials"))
-		return
 	}
 
 	if err != nil {
+		// 密码错误和账号不存在都计入失败次数
+		if err == service.ErrInvalidPassword || errors.Is(err, repository.ErrUserNotFound) {
+			if guardErr := h.loginGuard.RecordFailure(c.Request.Context(), attempt); guardErr != nil {
+				logger.FromContext(c.Request.Context()).WithComponent("auth").Error(guardErr, "记录登录失败次数失败")
+			}
+		}
+
+		identifier := req.Email
+		if identifier == "" {
+			identifier = req.Username
+		}
+		// 输入的邮箱或用户名可能不是已注册的账号，只记录在变更内容中，不作为对象ID
+		h.audit.Record(c.Request.Context(), service.AuditEntry{
+			Action:     models.AuditActionLoginFailed,
+			EntityType: models.AuditEntityUser,
+			After:      map[string]string{"identifier": identifier, "reason": err.Error()},
+		})
+
 		status := http.StatusInternalServerError
 		message := "登录失败"
 		if err == service.ErrInvalidPassword {
 			status = http.StatusUnauthorized
 			message = "密码错误"
+		} else if errors.Is(err, service.ErrUserDisabled) {
+			status = http.StatusForbidden
+			message = "账号已被停用"
 		}
 		c.JSON(status, dto.NewErrorResponse(status, message, err.Error()))
 		return
 	}
 
+	// 开启了二次验证的账号先返回挑战令牌，验证码通过后再签发会话。
+	// 失败计数等二次验证通过后再清除，否则知道密码就能反复重置验证码的尝试次数
+	if user.MFAEnabled {
+		h.respondMFAChallenge(c, user.ID)
+		return
+	}
+
+	attempt.UserID = user.ID
+	if err := h.loginGuard.RecordSuccess(c.Request.Context(), attempt); err != nil {
+		logger.FromContext(c.Request.Context()).WithComponent("auth").Error(err, "清除登录失败记录失败")
+	}
+
 	// 生成JWT令牌
-	tokenResp, err := h.generateTokens(user.ID)
+	tokenResp, err := h.generateTokens(c, user.ID, req.DeviceName)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.NewErrorResponse(http.StatusInternalServerError, "生成令牌失败", err.Error()))
+		return
+	}
+
+	h.recordLogin(c, user.ID)
+	c.JSON(http.StatusOK, dto.NewSuccessResponse(tokenResp))
+}
+
+// respondLoginBlock 登录被退避或锁定时返回429和 Retry-After
+func respondLoginBlock(c *gin.Context, block *service.LoginBlock) {
+	retryAfter := int(math.Ceil(block.RetryAfter.Seconds()))
+	c.Header("Retry-After", strconv.Itoa(retryAfter))
+	message := "登录尝试过于频繁，请稍后再试"
+	if block.Locked {
+		message = "登录失败次数过多，账号已被临时锁定"
+	}
+	c.JSON(http.StatusTooManyRequests, dto.NewErrorResponse(http.StatusTooManyRequests, message, fmt.Sprintf("retry after %d seconds", retryAfter)))
+}
+
+// respondMFAChallenge 返回二次验证挑战
+func (h *AuthHandler) respondMFAChallenge(c *gin.Context, userID int64) {
+	mfaToken, expiresIn, err := h.jwtService.GenerateMFAChallenge(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.NewErrorResponse(http.StatusInternalServerError, "生成二次验证令牌失败", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NewSuccessResponse(dto.MFAChallengeResponse{
+		MFARequired: true,
+		MFAToken:    mfaToken,
+		ExpiresIn:   expiresIn,
+	}))
+}
+
+// VerifyMFA 校验二次验证码并完成登录
+func (h *AuthHandler) VerifyMFA(c *gin.Context) {
+	var req dto.MFAVerifyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse(http.StatusBadRequest, "请求格式错误", err.Error()))
+		return
+	}
+
+	ctx := c.Request.Context()
+	userID, challengeID, err := h.jwtService.ParseMFAChallenge(ctx, req.MFAToken)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, dto.NewErrorResponse(http.StatusUnauthorized, "二次验证已失效，请重新登录", err.Error()))
+		return
+	}
+
+	// 验证码错误和密码错误计入同一个账号的失败次数，换新的挑战令牌也不能绕过
+	attempt := service.LoginAttempt{UserID: userID, IP: c.ClientIP()}
+	block, err := h.loginGuard.Check(ctx, attempt)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.NewErrorResponse(http.StatusInternalServerError, "二次验证失败", err.Error()))
+		return
+	}
+	if block != nil {
+		respondLoginBlock(c, block)
+		return
+	}
+
+	if err := h.userService.VerifyMFACode(ctx, userID, req.Code); err != nil {
+		if errors.Is(err, service.ErrInvalidMFACode) {
+			if failErr := h.jwtService.FailMFAChallenge(ctx, challengeID); failErr != nil {
+				logger.FromContext(ctx).WithComponent("auth").Error(failErr, "记录二次验证失败次数失败")
+			}
+			if guardErr := h.loginGuard.RecordFailure(ctx, attempt); guardErr != nil {
+				logger.FromContext(ctx).WithComponent("auth").Error(guardErr, "记录登录失败次数失败")
+			}
+			h.audit.Record(ctx, service.AuditEntry{
+				ActorID:    userID,
+				Action:     models.AuditActionMFAFailed,
+				EntityType: models.AuditEntityUser,
+				EntityID:   service.AuditEntityID(userID),
+			})
+			c.JSON(http.StatusUnauthorized, dto.NewErrorResponse(http.StatusUnauthorized, "验证码错误", err.Error()))
+			return
+		}
+		if errors.Is(err, service.ErrUserDisabled) {
+			c.JSON(http.StatusForbidden, dto.NewErrorResponse(http.StatusForbidden, "账号已被停用", err.Error()))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, dto.NewErrorResponse(http.StatusInternalServerError, "二次验证失败", err.Error()))
+		return
+	}
+
+	if err := h.jwtService.ConsumeMFAChallenge(ctx, challengeID); err != nil {
+		c.JSON(http.StatusUnauthorized, dto.NewErrorResponse(http.StatusUnauthorized, "二次验证已失效，请重新登录", err.Error()))
+		return
+	}
+
+	if err := h.loginGuard.RecordSuccess(ctx, attempt); err != nil {
+		logger.FromContext(ctx).WithComponent("auth").Error(err, "清除登录失败记录失败")
+	}
+
+	tokenResp, err := h.generateTokens(c, userID, req.DeviceName)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, dto.NewErrorResponse(http.StatusInternalServerError, "生成令牌失败", err.Error()))
 		return
 	}
 
+	h.recordLogin(c, userID)
 	c.JSON(http.StatusOK, dto.NewSuccessResponse(tokenResp))
 }
 
-// 生成JWT令牌
-func (h *AuthHandler) generateTokens(userID int64) (*dto.TokenResponse, error) {
+// recordLogin 记录登录成功的审计事件
+func (h *AuthHandler) recordLogin(c *gin.Context, userID int64) {
+	h.audit.Record(c.Request.Context(), service.AuditEntry{
+		ActorID:    userID,
+		Action:     models.AuditActionLogin,
+		EntityType: models.AuditEntityUser,
+		EntityID:   service.AuditEntityID(userID),
+		After:      map[string]string{"user_agent": c.Request.UserAgent()},
+	})
+}
+
+// 生成JWT令牌，并把本次登录登记为一个设备会话
+func (h *AuthHandler) generateTokens(c *gin.Context, userID int64, deviceName string) (*dto.TokenResponse, error) {
+	userAgent := c.Request.UserAgent()
+	if deviceName == "" {
+		deviceName = describeDevice(userAgent)
+	}
+
 	// 使用JWT服务生成令牌
-	tokenDetails, err := h.jwtService.GenerateTokens(userID)
+	tokenDetails, err := h.jwtService.GenerateTokens(c.Request.Context(), userID, service.SessionMeta{
+		DeviceName: deviceName,
+		IP:         c.ClientIP(),
+		UserAgent:  userAgent,
+	})
 	if err != nil {
 		return nil, err
 	}
 
+	return newTokenResponse(tokenDetails), nil
+}
+
+func newTokenResponse(tokenDetails *service.TokenDetails) *dto.TokenResponse {
 	return &dto.TokenResponse{
 		AccessToken:  tokenDetails.AccessToken,
 		RefreshToken: tokenDetails.RefreshToken,
 		ExpiresIn:    tokenDetails.ExpiresIn,
 		TokenType:    "Bearer",
-	}, nil
+	}
 }
 
 // RefreshToken 刷新令牌
@@ -132,34 +305,59 @@ func (h *AuthHandler) RefreshToken(c *gin.Context) {
 		return
 	}
 
-	// 使用JWT服务验证刷新令牌
-	_, claims, err := h.jwtService.ValidateToken(req.RefreshToken)
+	// 轮换令牌，旧的刷新令牌随即失效
+	tokenDetails, err := h.jwtService.RefreshTokens(c.Request.Context(), req.RefreshToken)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, dto.NewErrorResponse(http.StatusUnauthorized, "无效的刷新令牌", err.Error()))
+		switch {
+		case errors.Is(err, service.ErrRefreshTokenReused):
+			logger.FromContext(c.Request.Context()).WithComponent("auth").Warn("检测到刷新令牌重用，已注销对应会话")
+			c.JSON(http.StatusUnauthorized, dto.NewErrorResponse(http.StatusUnauthorized, "刷新令牌已失效，请重新登录", err.Error()))
+		case errors.Is(err, service.ErrInvalidTokenType):
+			c.JSON(http.StatusUnauthorized, dto.NewErrorResponse(http.StatusUnauthorized, "无效的令牌类型", err.Error()))
+		case errors.Is(err, service.ErrSessionNotFound), errors.Is(err, service.ErrTokenRevoked):
+			c.JSON(http.StatusUnauthorized, dto.NewErrorResponse(http.StatusUnauthorized, "会话已失效，请重新登录", err.Error()))
+		default:
+			c.JSON(http.StatusUnauthorized, dto.NewErrorResponse(http.StatusUnauthorized, "无效的刷新令牌", err.Error()))
+		}
 		return
 	}
 
-	// 检查令牌类型
-	tokenType, ok := claims["typ"].(string)
-	if !ok || tokenType != "refresh" {
-		c.JSON(http.StatusUnauthorized, dto.NewErrorResponse(http.StatusUnauthorized, "无效的令牌类型", "invalid token type"))
-		return
-	}
+	c.JSON(http.StatusOK, dto.NewSuccessResponse(newTokenResponse(tokenDetails)))
+}
+
+// Logout 退出当前会话
+func (h *AuthHandler) Logout(c *gin.Context) {
+	userID := c.GetInt64("user_id")
+	sessionID := c.GetString("session_id")
 
-	// 获取用户ID
-	userIDFloat, ok := claims["sub"].(float64)
-	if !ok {
-		c.JSON(http.StatusInternalServerError, dto.NewErrorResponse(http.StatusInternalServerError, "无法获取用户ID", "invalid user id in token"))
+	if err := h.jwtService.RevokeSession(c.Request.Context(), userID, sessionID); err != nil && !errors.Is(err, service.ErrSessionNotFound) {
+		c.JSON(http.StatusInternalServerError, dto.NewErrorResponse(http.StatusInternalServerError, "退出登录失败", err.Error()))
 		return
 	}
-	userID := int64(userIDFloat)
 
-	// 生成新令牌
-	tokenResp, err := h.generateTokens(userID)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, dto.NewErrorResponse(http.StatusInternalServerError, "生成令牌失败", err.Error()))
+	h.audit.Record(c.Request.Context(), service.AuditEntry{
+		Action:     models.AuditActionLogout,
+		EntityType: models.AuditEntitySession,
+		EntityID:   sessionID,
+	})
+
+	c.JSON(http.StatusOK, dto.EmptySuccessResponse("已退出登录"))
+}
+
+// LogoutAll 退出当前用户的全部会话
+func (h *AuthHandler) LogoutAll(c *gin.Context) {
+	userID := c.GetInt64("user_id")
+
+	if err := h.jwtService.RevokeAllSessions(c.Request.Context(), userID); err != nil {
+		c.JSON(http.StatusInternalServerError, dto.NewErrorResponse(http.StatusInternalServerError, "退出全部设备失败", err.Error()))
 		return
 	}
 
-	c.JSON(http.StatusOK, dto.NewSuccessResponse(tokenResp))
+	h.audit.Record(c.Request.Context(), service.AuditEntry{
+		Action:     models.AuditActionLogoutAll,
+		EntityType: models.AuditEntityUser,
+		EntityID:   service.AuditEntityID(userID),
+	})
+
+	c.JSON(http.StatusOK, dto.EmptySuccessResponse("已退出全部设备"))
 }
@@ -1,11 +1,13 @@
 package handlers
 
 import (
+	"errors"
 	"fmt"
 	"memoir-api/internal/aliyun"
 	"memoir-api/internal/api/dto"
 	"memoir-api/internal/service"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 )
@@ -22,6 +24,10 @@ func CreateCoupleHandler(services service.Factory) gin.HandlerFunc {
 		req.UserID = userId
 		_, err := services.Couple().CreateCouple(c, &req)
 		if err != nil {
+			if errors.Is(err, service.ErrAlreadyInCouple) {
+				c.JSON(http.StatusConflict, dto.NewErrorResponse(http.StatusConflict, "您已经有情侣关系", err.Error()))
+				return
+			}
 			c.JSON(http.StatusInternalServerError, dto.NewErrorResponse(http.StatusInternalServerError, "创建情侣关系失败", err.Error()))
 			return
 		}
@@ -65,3 +71,228 @@ func GetCoupleInfoHandler(services service.Factory) gin.HandlerFunc {
 		c.JSON(http.StatusOK, dto.NewSuccessResponse(coupleInfo))
 	}
 }
+
+// CreateCoupleInviteHandler 生成一次性配对邀请码
+func CreateCoupleInviteHandler(services service.Factory) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req dto.CreateCoupleInviteRequest
+		// 请求体可以为空
+		if c.Request.ContentLength > 0 {
+			if err := c.ShouldBindJSON(&req); err != nil {
+				c.JSON(http.StatusBadRequest, dto.NewErrorResponse(http.StatusBadRequest, "请求参数错误", err.Error()))
+				return
+			}
+		}
+
+		invite, err := services.CoupleInvite().CreateInvite(c.Request.Context(), c.GetInt64("user_id"), req.Email)
+		if err != nil {
+			switch {
+			case errors.Is(err, service.ErrCoupleFull), errors.Is(err, service.ErrAlreadyInCouple):
+				c.JSON(http.StatusConflict, dto.NewErrorResponse(http.StatusConflict, "已经完成配对，无法再邀请", err.Error()))
+				return
+			case errors.Is(err, service.ErrCoupleDissolving):
+				c.JSON(http.StatusConflict, dto.NewErrorResponse(http.StatusConflict, "情侣关系正在解除中，无法邀请", err.Error()))
+				return
+			}
+			c.JSON(http.StatusInternalServerError, dto.NewErrorResponse(http.StatusInternalServerError, "生成邀请码失败", err.Error()))
+			return
+		}
+		c.JSON(http.StatusCreated, dto.NewSuccessResponse(invite))
+	}
+}
+
+// JoinCoupleHandler 使用邀请码加入情侣关系
+func JoinCoupleHandler(services service.Factory) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req dto.JoinCoupleRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, dto.NewErrorResponse(http.StatusBadRequest, "请求参数错误", err.Error()))
+			return
+		}
+
+		userID := c.GetInt64("user_id")
+		if _, err := services.CoupleInvite().JoinCouple(c.Request.Context(), userID, req.Code); err != nil {
+			switch {
+			case errors.Is(err, service.ErrInvalidInviteCode):
+				c.JSON(http.StatusNotFound, dto.NewErrorResponse(http.StatusNotFound, "邀请码无效或已过期", err.Error()))
+			case errors.Is(err, service.ErrCannotJoinOwnCode):
+				c.JSON(http.StatusBadRequest, dto.NewErrorResponse(http.StatusBadRequest, "不能使用自己生成的邀请码", err.Error()))
+			case errors.Is(err, service.ErrAlreadyInCouple), errors.Is(err, service.ErrCoupleFull):
+				c.JSON(http.StatusConflict, dto.NewErrorResponse(http.StatusConflict, "无法加入该情侣关系", err.Error()))
+			default:
+				c.JSON(http.StatusInternalServerError, dto.NewErrorResponse(http.StatusInternalServerError, "加入情侣关系失败", err.Error()))
+			}
+			return
+		}
+
+		coupleInfo, err := services.Couple().GetCoupleInfo(c, userID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, dto.NewErrorResponse(http.StatusInternalServerError, "获取情侣信息失败", err.Error()))
+			return
+		}
+		c.JSON(http.StatusOK, dto.NewSuccessResponse(coupleInfo))
+	}
+}
+
+// RequestCoupleDissolutionHandler 申请解除情侣关系，冷静期内可以撤销
+func RequestCoupleDissolutionHandler(services service.Factory) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req dto.LeaveCoupleRequest
+		// 请求体可以为空
+		if c.Request.ContentLength > 0 {
+			if err := c.ShouldBindJSON(&req); err != nil {
+				c.JSON(http.StatusBadRequest, dto.NewErrorResponse(http.StatusBadRequest, "请求参数错误", err.Error()))
+				return
+			}
+		}
+
+		couple, err := services.CoupleDissolution().RequestDissolution(c.Request.Context(), c.GetInt64("user_id"), req.Handoff)
+		if err != nil {
+			switch {
+			case errors.Is(err, service.ErrInvalidHandoff):
+				c.JSON(http.StatusBadRequest, dto.NewErrorResponse(http.StatusBadRequest, "请求参数错误", err.Error()))
+			case errors.Is(err, service.ErrCoupleDissolving):
+				c.JSON(http.StatusConflict, dto.NewErrorResponse(http.StatusConflict, "已经申请解除情侣关系", err.Error()))
+			case errors.Is(err, service.ErrNotInCouple):
+				c.JSON(http.StatusForbidden, dto.NewErrorResponse(http.StatusForbidden, "您还没有情侣关系", err.Error()))
+			default:
+				c.JSON(http.StatusInternalServerError, dto.NewErrorResponse(http.StatusInternalServerError, "申请解除情侣关系失败", err.Error()))
+			}
+			return
+		}
+		c.JSON(http.StatusOK, dto.NewSuccessResponse(couple))
+	}
+}
+
+// CancelCoupleDissolutionHandler 在冷静期内撤销解除申请
+func CancelCoupleDissolutionHandler(services service.Factory) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if err := services.CoupleDissolution().CancelDissolution(c.Request.Context(), c.GetInt64("user_id")); err != nil {
+			switch {
+			case errors.Is(err, service.ErrCoupleNotDissolving):
+				c.JSON(http.StatusConflict, dto.NewErrorResponse(http.StatusConflict, "没有待撤销的解除申请", err.Error()))
+			case errors.Is(err, service.ErrNotInCouple):
+				c.JSON(http.StatusForbidden, dto.NewErrorResponse(http.StatusForbidden, "您还没有情侣关系", err.Error()))
+			default:
+				c.JSON(http.StatusInternalServerError, dto.NewErrorResponse(http.StatusInternalServerError, "撤销解除申请失败", err.Error()))
+			}
+			return
+		}
+		c.JSON(http.StatusOK, dto.NewSuccessResponse(gin.H{"message": "已撤销解除申请"}))
+	}
+}
+
+// ExportCoupleDataHandler 导出情侣共享数据的完整副本，解除后仍可导出原情侣关系的数据。
+// 可以通过 couple_id 指定以前的情侣关系，不指定时导出当前或最近一次解除的
+func ExportCoupleDataHandler(services service.Factory) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var coupleID int64
+		if value := c.Query("couple_id"); value != "" {
+			id, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, dto.NewErrorResponse(http.StatusBadRequest, "无效的情侣ID", err.Error()))
+				return
+			}
+			coupleID = id
+		}
+
+		export, err := services.CoupleDissolution().Export(c.Request.Context(), c.GetInt64("user_id"), coupleID)
+		if err != nil {
+			if errors.Is(err, service.ErrNoCoupleDataToExport) {
+				c.JSON(http.StatusNotFound, dto.NewErrorResponse(http.StatusNotFound, "没有可以导出的情侣数据", err.Error()))
+				return
+			}
+			c.JSON(http.StatusInternalServerError, dto.NewErrorResponse(http.StatusInternalServerError, "导出情侣数据失败", err.Error()))
+			return
+		}
+
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=couple-%d-export.json", export.Couple.ID))
+		c.JSON(http.StatusOK, dto.NewSuccessResponse(export))
+	}
+}
+
+// ListPastCouplesHandler 列出用户曾经所在的已解除情侣关系，用于选择要导出的数据
+func ListPastCouplesHandler(services service.Factory) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		pastCouples, err := services.CoupleDissolution().ListPastCouples(c.Request.Context(), c.GetInt64("user_id"))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, dto.NewErrorResponse(http.StatusInternalServerError, "获取以前的情侣关系失败", err.Error()))
+			return
+		}
+		c.JSON(http.StatusOK, dto.NewSuccessResponse(pastCouples))
+	}
+}
+
+// GetCoupleSettingsHandler 获取情侣设置
+func GetCoupleSettingsHandler(services service.Factory) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		settings, err := services.Couple().GetSettings(c.Request.Context(), c.GetInt64("user_id"))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, dto.NewErrorResponse(http.StatusInternalServerError, "获取情侣设置失败", err.Error()))
+			return
+		}
+		c.JSON(http.StatusOK, dto.NewSuccessResponse(settings))
+	}
+}
+
+// UpdateCoupleSettingsHandler 更新情侣设置
+func UpdateCoupleSettingsHandler(services service.Factory) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req dto.UpdateCoupleSettingsRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, dto.NewErrorResponse(http.StatusBadRequest, "请求参数错误", err.Error()))
+			return
+		}
+
+		settings, err := services.Couple().UpdateSettings(c.Request.Context(), c.GetInt64("user_id"), &req)
+		if err != nil {
+			if errors.Is(err, service.ErrInvalidTimezone) || errors.Is(err, service.ErrInvalidAnniversaryDate) {
+				c.JSON(http.StatusBadRequest, dto.NewErrorResponse(http.StatusBadRequest, "请求参数错误", err.Error()))
+				return
+			}
+			c.JSON(http.StatusInternalServerError, dto.NewErrorResponse(http.StatusInternalServerError, "更新情侣设置失败", err.Error()))
+			return
+		}
+		c.JSON(http.StatusOK, dto.NewSuccessResponse(settings))
+	}
+}
+
+// ListCoupleFestivalsHandler 获取所有节日及情侣的提醒开关
+func ListCoupleFestivalsHandler(services service.Factory) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		festivals, err := services.CoupleReminder().ListFestivals(c.Request.Context(), c.GetInt64("couple_id"))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, dto.NewErrorResponse(http.StatusInternalServerError, "获取节日列表失败", err.Error()))
+			return
+		}
+		c.JSON(http.StatusOK, dto.NewSuccessResponse(festivals))
+	}
+}
+
+// UpdateCoupleFestivalHandler 开关某个节日的提醒
+func UpdateCoupleFestivalHandler(services service.Factory) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req dto.UpdateCoupleFestivalRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, dto.NewErrorResponse(http.StatusBadRequest, "请求参数错误", err.Error()))
+			return
+		}
+
+		coupleID := c.GetInt64("couple_id")
+		if err := services.CoupleReminder().SetFestivalEnabled(c.Request.Context(), coupleID, c.Param("key"), *req.Enabled); err != nil {
+			if errors.Is(err, service.ErrFestivalNotFound) {
+				c.JSON(http.StatusNotFound, dto.NewErrorResponse(http.StatusNotFound, "节日不存在", err.Error()))
+				return
+			}
+			c.JSON(http.StatusInternalServerError, dto.NewErrorResponse(http.StatusInternalServerError, "更新节日提醒失败", err.Error()))
+			return
+		}
+
+		festivals, err := services.CoupleReminder().ListFestivals(c.Request.Context(), coupleID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, dto.NewErrorResponse(http.StatusInternalServerError, "获取节日列表失败", err.Error()))
+			return
+		}
+		c.JSON(http.StatusOK, dto.NewSuccessResponse(festivals))
+	}
+}
@@ -19,21 +19,8 @@ func CreateCoupleAlbumHandler(services service.Factory) gin.HandlerFunc {
 			return
 		}
 
-		// 如果请求中没有指定用户ID，则使用当前登录用户的ID
-		if req.UserID == 0 {
-			userIDValue, exists := c.Get("user_id")
-			if !exists {
-				c.JSON(http.StatusUnauthorized, dto.NewErrorResponse(http.StatusUnauthorized, "用户未登录", "未找到用户ID"))
-				return
-			}
-
-			userID, ok := userIDValue.(int64)
-			if !ok {
-				c.JSON(http.StatusInternalServerError, dto.NewErrorResponse(http.StatusInternalServerError, "用户ID类型无效", "用户ID类型断言失败"))
-				return
-			}
-			req.UserID = userID
-		}
+		// 相册总是创建在当前登录用户的情侣关系下
+		req.UserID = c.GetInt64("user_id")
 
 		// 创建相册
 		album, err := services.CoupleAlbum().Create(c.Request.Context(), &req)
@@ -56,10 +43,10 @@ func GetCoupleAlbumHandler(services service.Factory) gin.HandlerFunc {
 			return
 		}
 
-		// 获取相册
-		album, err := services.CoupleAlbum().GetByID(c.Request.Context(), albumID)
+		// 获取相册，只在当前情侣的相册中查找
+		album, err := services.CoupleAlbum().GetByID(c.Request.Context(), c.GetInt64("couple_id"), albumID)
 		if err != nil {
-			c.JSON(http.StatusNotFound, dto.NewErrorResponse(http.StatusNotFound, "相册不存在", err.Error()))
+			respondServiceError(c, "获取相册失败", err)
 			return
 		}
 
@@ -79,9 +66,9 @@ func GetCoupleAlbumWithPhotosHandler(services service.Factory) gin.HandlerFunc {
 		}
 
 		// 获取相册及其照片
-		album, err := services.CoupleAlbum().GetWithPhotos(c.Request.Context(), albumID)
+		album, err := services.CoupleAlbum().GetWithPhotos(c.Request.Context(), c.GetInt64("couple_id"), albumID)
 		if err != nil {
-			c.JSON(http.StatusNotFound, dto.NewErrorResponse(http.StatusNotFound, "相册不存在", err.Error()))
+			respondServiceError(c, "获取相册失败", err)
 			return
 		}
 
@@ -92,23 +79,8 @@ func GetCoupleAlbumWithPhotosHandler(services service.Factory) gin.HandlerFunc {
 // ListCoupleAlbumsHandler 获取情侣的所有相册
 func ListCoupleAlbumsHandler(services service.Factory) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// 从上下文中获取用户ID（JWT中间件设置）
-		userID := c.GetInt64("user_id")
-		// 获取用户信息
-		user, err := services.User().GetUserByID(c.Request.Context(), userID)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, dto.NewErrorResponse(http.StatusInternalServerError, "获取用户信息失败", err.Error()))
-			return
-		}
-
-		// 确保用户属于一个情侣关系
-		if user.CoupleID == 0 {
-			c.JSON(http.StatusBadRequest, dto.NewErrorResponse(http.StatusBadRequest, "用户不属于任何情侣关系", ""))
-			return
-		}
-
-		// 获取情侣相册列表
-		albums, err := services.CoupleAlbum().GetByCoupleID(c.Request.Context(), user.CoupleID)
+		// 获取情侣相册列表（情侣ID由中间件根据当前用户解析）
+		albums, err := services.CoupleAlbum().GetByCoupleID(c.Request.Context(), c.GetInt64("couple_id"))
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, dto.NewErrorResponse(http.StatusInternalServerError, "获取相册列表失败", err.Error()))
 			return
@@ -135,9 +107,9 @@ func UpdateCoupleAlbumHandler(services service.Factory) gin.HandlerFunc {
 		}
 
 		// 更新相册
-		album, err := services.CoupleAlbum().Update(c.Request.Context(), albumID, &req)
+		album, err := services.CoupleAlbum().Update(c.Request.Context(), c.GetInt64("couple_id"), albumID, &req)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, dto.NewErrorResponse(http.StatusInternalServerError, "更新相册失败", err.Error()))
+			respondServiceError(c, "更新相册失败", err)
 			return
 		}
 
@@ -156,8 +128,8 @@ func DeleteCoupleAlbumHandler(services service.Factory) gin.HandlerFunc {
 		}
 
 		// 删除相册
-		if err := services.CoupleAlbum().Delete(c.Request.Context(), albumID); err != nil {
-			c.JSON(http.StatusInternalServerError, dto.NewErrorResponse(http.StatusInternalServerError, "删除相册失败", err.Error()))
+		if err := services.CoupleAlbum().Delete(c.Request.Context(), c.GetInt64("couple_id"), albumID); err != nil {
+			respondServiceError(c, "删除相册失败", err)
 			return
 		}
 
@@ -173,8 +145,8 @@ func DeleteCoupleAlbumPhotosHandler(services service.Factory) gin.HandlerFunc {
 			return
 		}
 		// 删除相册照片
-		if err := services.CoupleAlbum().BatchDeletePhotoVideo(c.Request.Context(), &delReq); err != nil {
-			c.JSON(http.StatusInternalServerError, dto.NewErrorResponse(http.StatusInternalServerError, "删除相册照片失败", err.Error()))
+		if err := services.CoupleAlbum().BatchDeletePhotoVideo(c.Request.Context(), c.GetInt64("couple_id"), &delReq); err != nil {
+			respondServiceError(c, "删除相册照片失败", err)
 			return
 		}
 		c.JSON(http.StatusOK, dto.NewSuccessResponse(nil))
@@ -189,6 +161,8 @@ func PageCoupleMedia(services service.Factory) gin.HandlerFunc {
 			c.JSON(http.StatusBadRequest, dto.NewErrorResponse(http.StatusBadRequest, "请求参数无效", err.Error()))
 			return
 		}
+		// 只能查询自己情侣的媒体
+		req.CoupleID = c.GetInt64("couple_id")
 		media, total, err := services.CoupleAlbum().PageCoupleMedia(c.Request.Context(), &req)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, dto.NewErrorResponse(http.StatusInternalServerError, "查询媒体失败", err.Error()))
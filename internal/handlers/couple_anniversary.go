@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"memoir-api/internal/api/dto"
+	"memoir-api/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListCoupleAnniversariesHandler 获取情侣的所有纪念日
+func ListCoupleAnniversariesHandler(services service.Factory) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		anniversaries, err := services.CoupleAnniversary().List(c.Request.Context(), c.GetInt64("couple_id"))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, dto.NewErrorResponse(http.StatusInternalServerError, "查询纪念日失败", err.Error()))
+			return
+		}
+		c.JSON(http.StatusOK, dto.NewSuccessResponse(anniversaries))
+	}
+}
+
+// CreateCoupleAnniversaryHandler 创建纪念日
+func CreateCoupleAnniversaryHandler(services service.Factory) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req dto.CreateCoupleAnniversaryRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, dto.NewErrorResponse(http.StatusBadRequest, "请求参数无效", err.Error()))
+			return
+		}
+
+		anniversary, err := services.CoupleAnniversary().Create(c.Request.Context(), c.GetInt64("couple_id"), &req)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, dto.NewErrorResponse(http.StatusInternalServerError, "创建纪念日失败", err.Error()))
+			return
+		}
+		c.JSON(http.StatusCreated, dto.NewSuccessResponse(anniversary))
+	}
+}
+
+// UpdateCoupleAnniversaryHandler 更新纪念日
+func UpdateCoupleAnniversaryHandler(services service.Factory) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, dto.NewErrorResponse(http.StatusBadRequest, "无效的纪念日ID", err.Error()))
+			return
+		}
+
+		var req dto.UpdateCoupleAnniversaryRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, dto.NewErrorResponse(http.StatusBadRequest, "请求参数无效", err.Error()))
+			return
+		}
+
+		anniversary, err := services.CoupleAnniversary().Update(c.Request.Context(), c.GetInt64("couple_id"), id, &req)
+		if err != nil {
+			if errors.Is(err, service.ErrAnniversaryNotFound) {
+				c.JSON(http.StatusNotFound, dto.NewErrorResponse(http.StatusNotFound, "纪念日不存在", err.Error()))
+				return
+			}
+			c.JSON(http.StatusInternalServerError, dto.NewErrorResponse(http.StatusInternalServerError, "更新纪念日失败", err.Error()))
+			return
+		}
+		c.JSON(http.StatusOK, dto.NewSuccessResponse(anniversary))
+	}
+}
+
+// DeleteCoupleAnniversaryHandler 删除纪念日
+func DeleteCoupleAnniversaryHandler(services service.Factory) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, dto.NewErrorResponse(http.StatusBadRequest, "无效的纪念日ID", err.Error()))
+			return
+		}
+
+		if err := services.CoupleAnniversary().Delete(c.Request.Context(), c.GetInt64("couple_id"), id); err != nil {
+			if errors.Is(err, service.ErrAnniversaryNotFound) {
+				c.JSON(http.StatusNotFound, dto.NewErrorResponse(http.StatusNotFound, "纪念日不存在", err.Error()))
+				return
+			}
+			c.JSON(http.StatusInternalServerError, dto.NewErrorResponse(http.StatusInternalServerError, "删除纪念日失败", err.Error()))
+			return
+		}
+		c.JSON(http.StatusOK, dto.NewSuccessResponse(gin.H{"message": "纪念日已删除"}))
+	}
+}
@@ -111,3 +111,33 @@ func (h *EmailHandler) ForgotPassword(c *gin.Context) {
 
 	c.JSON(http.StatusOK, dto.EmptySuccessResponse("密码重置邮件已发送"))
 }
+
+// ResetPassword 使用重置令牌设置新密码
+func (h *EmailHandler) ResetPassword(c *gin.Context) {
+	var req struct {
+		Email       string `json:"email" binding:"required,email"`
+		Token       string `json:"token" binding:"required"`
+		NewPassword string `json:"new_password" binding:"required,min=6"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse(http.StatusBadRequest, "请求格式错误", err.Error()))
+		return
+	}
+
+	err := h.userService.ResetPassword(c, req.Email, req.Token, req.NewPassword)
+	if err != nil {
+		status := http.StatusInternalServerError
+		message := "重置密码失败"
+
+		if err == service.ErrInvalidResetToken {
+			status = http.StatusBadRequest
+			message = "重置链接无效或已过期"
+		}
+
+		c.JSON(status, dto.NewErrorResponse(status, message, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.EmptySuccessResponse("密码已重置，请重新登录"))
+}
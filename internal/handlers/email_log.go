@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"net/http"
+
+	"memoir-api/internal/api/dto"
+	"memoir-api/internal/repository"
+	"memoir-api/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListMyEmailsHandler 分页查看发给当前用户的邮件及投递状态
+func ListMyEmailsHandler(services service.Factory) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req dto.PaginationRequest
+		if err := c.ShouldBindQuery(&req); err != nil {
+			c.JSON(http.StatusBadRequest, dto.NewErrorResponse(http.StatusBadRequest, "请求参数无效", err.Error()))
+			return
+		}
+
+		logs, total, err := services.EmailLog().ListByUser(c.Request.Context(), c.GetInt64("user_id"), req.Offset(), req.Limit())
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, dto.NewErrorResponse(http.StatusInternalServerError, "获取邮件记录失败", err.Error()))
+			return
+		}
+
+		items := make([]dto.UserEmailLogDTO, 0, len(logs))
+		for _, log := range logs {
+			items = append(items, dto.UserEmailLogFromModel(log))
+		}
+		c.JSON(http.StatusOK, dto.NewSuccessResponse(dto.NewPageResult(items, total, req.Page, req.PageSize)))
+	}
+}
+
+// AdminQueryEmailLogsHandler 按条件查询邮件投递记录
+func AdminQueryEmailLogsHandler(services service.Factory) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req dto.EmailLogQueryRequest
+		if err := c.ShouldBindQuery(&req); err != nil {
+			c.JSON(http.StatusBadRequest, dto.NewErrorResponse(http.StatusBadRequest, "请求参数无效", err.Error()))
+			return
+		}
+
+		filter := repository.EmailLogFilter{
+			UserID:    req.UserID,
+			ToAddress: req.ToAddress,
+			Type:      req.Type,
+			Status:    req.Status,
+			Since:     req.Since,
+			Until:     req.Until,
+		}
+		logs, total, err := services.EmailLog().Query(c.Request.Context(), filter, req.Offset(), req.Limit())
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, dto.NewErrorResponse(http.StatusInternalServerError, "查询邮件记录失败", err.Error()))
+			return
+		}
+
+		c.JSON(http.StatusOK, dto.NewSuccessResponse(dto.NewPageResult(logs, total, req.Page, req.PageSize)))
+	}
+}
@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"memoir-api/internal/api/dto"
+	"memoir-api/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListEmailPreferencesHandler 获取当前用户各类邮件的接收开关
+func ListEmailPreferencesHandler(services service.Factory) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		preferences, err := services.EmailPreference().List(c.Request.Context(), c.GetInt64("user_id"))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, dto.NewErrorResponse(http.StatusInternalServerError, "获取邮件偏好失败", err.Error()))
+			return
+		}
+		c.JSON(http.StatusOK, dto.NewSuccessResponse(preferences))
+	}
+}
+
+// UpdateEmailPreferenceHandler 开关当前用户某类邮件，必要邮件不能关闭
+func UpdateEmailPreferenceHandler(services service.Factory) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req dto.UpdateEmailPreferenceRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, dto.NewErrorResponse(http.StatusBadRequest, "请求参数错误", err.Error()))
+			return
+		}
+
+		userID := c.GetInt64("user_id")
+		if err := services.EmailPreference().SetEnabled(c.Request.Context(), userID, c.Param("type"), *req.Enabled); err != nil {
+			if errors.Is(err, service.ErrEmailTypeNotOptional) {
+				c.JSON(http.StatusBadRequest, dto.NewErrorResponse(http.StatusBadRequest, "该类型的邮件不能退订", err.Error()))
+				return
+			}
+			c.JSON(http.StatusInternalServerError, dto.NewErrorResponse(http.StatusInternalServerError, "更新邮件偏好失败", err.Error()))
+			return
+		}
+
+		preferences, err := services.EmailPreference().List(c.Request.Context(), userID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, dto.NewErrorResponse(http.StatusInternalServerError, "获取邮件偏好失败", err.Error()))
+			return
+		}
+		c.JSON(http.StatusOK, dto.NewSuccessResponse(preferences))
+	}
+}
+
+// VerifyUnsubscribeHandler 校验退订链接，前端确认页面用它展示要退订的邮件类型
+func VerifyUnsubscribeHandler(services service.Factory) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req dto.UnsubscribeRequest
+		if err := c.ShouldBindQuery(&req); err != nil || req.Token == "" {
+			c.JSON(http.StatusBadRequest, dto.NewErrorResponse(http.StatusBadRequest, "缺少退订令牌", "token is required"))
+			return
+		}
+
+		result, err := services.EmailPreference().VerifyUnsubscribeToken(c.Request.Context(), req.Token)
+		if err != nil {
+			respondUnsubscribeError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, dto.NewSuccessResponse(result))
+	}
+}
+
+// UnsubscribeHandler 通过签名的退订链接关闭某类邮件，不需要登录。
+// 邮件客户端一键退订（RFC 8058）时令牌在查询参数中，请求体是 List-Unsubscribe=One-Click；
+// 前端确认页面也可以把令牌放在JSON请求体中
+func UnsubscribeHandler(services service.Factory) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token := c.Query("token")
+		if token == "" {
+			var req dto.UnsubscribeRequest
+			if err := c.ShouldBind(&req); err == nil {
+				token = req.Token
+			}
+		}
+		if token == "" {
+			c.JSON(http.StatusBadRequest, dto.NewErrorResponse(http.StatusBadRequest, "缺少退订令牌", "token is required"))
+			return
+		}
+
+		result, err := services.EmailPreference().Unsubscribe(c.Request.Context(), token)
+		if err != nil {
+			respondUnsubscribeError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, dto.NewSuccessResponse(result))
+	}
+}
+
+// respondUnsubscribeError 退订链接无效时返回400，其余错误返回500
+func respondUnsubscribeError(c *gin.Context, err error) {
+	if errors.Is(err, service.ErrInvalidUnsubscribeToken) {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse(http.StatusBadRequest, "退订链接无效", err.Error()))
+		return
+	}
+	c.JSON(http.StatusInternalServerError, dto.NewErrorResponse(http.StatusInternalServerError, "退订失败", err.Error()))
+}
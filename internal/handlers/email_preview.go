@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"sort"
+
+	"memoir-api/internal/api/dto"
+	"memoir-api/internal/email"
+	"memoir-api/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListEmailTemplatesHandler 列出可以预览的邮件类型和语言，只在开发环境注册
+func ListEmailTemplatesHandler(services service.Factory) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		types := services.Email().TemplateTypes()
+		sort.Slice(types, func(i, j int) bool { return types[i] < types[j] })
+		c.JSON(http.StatusOK, dto.NewSuccessResponse(gin.H{
+			"types":   types,
+			"locales": email.SupportedLocales,
+		}))
+	}
+}
+
+// PreviewEmailTemplateHandler 用示例数据渲染邮件模板，只在开发环境注册
+func PreviewEmailTemplateHandler(services service.Factory) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req dto.EmailPreviewRequest
+		if err := c.ShouldBindQuery(&req); err != nil {
+			c.JSON(http.StatusBadRequest, dto.NewErrorResponse(http.StatusBadRequest, "请求参数无效", err.Error()))
+			return
+		}
+
+		rendered, err := services.Email().RenderPreview(email.EmailType(c.Param("type")), req.Locale)
+		if err != nil {
+			if errors.Is(err, service.ErrTemplateNotFound) {
+				c.JSON(http.StatusNotFound, dto.NewErrorResponse(http.StatusNotFound, "邮件模板不存在", err.Error()))
+				return
+			}
+			c.JSON(http.StatusInternalServerError, dto.NewErrorResponse(http.StatusInternalServerError, "渲染邮件模板失败", err.Error()))
+			return
+		}
+
+		switch req.Format {
+		case dto.EmailPreviewFormatText:
+			c.String(http.StatusOK, "Subject: %s\n\n%s\n", rendered.Subject, rendered.Text)
+		case dto.EmailPreviewFormatJSON:
+			c.JSON(http.StatusOK, dto.NewSuccessResponse(rendered))
+		default:
+			c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(rendered.HTML))
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"memoir-api/internal/api/dto"
+	"memoir-api/internal/logger"
+	"memoir-api/internal/models"
+	"memoir-api/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminEmailQueueStatsHandler 获取邮件队列各部分的任务数
+func AdminEmailQueueStatsHandler(services service.Factory) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		stats, err := services.Email().QueueStats(c.Request.Context())
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, dto.NewErrorResponse(http.StatusInternalServerError, "获取邮件队列状态失败", err.Error()))
+			return
+		}
+		c.JSON(http.StatusOK, dto.NewSuccessResponse(stats))
+	}
+}
+
+// AdminListDeadLettersHandler 分页获取发送失败的邮件
+func AdminListDeadLettersHandler(services service.Factory) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req dto.PaginationRequest
+		if err := c.ShouldBindQuery(&req); err != nil {
+			c.JSON(http.StatusBadRequest, dto.NewErrorResponse(http.StatusBadRequest, "请求参数无效", err.Error()))
+			return
+		}
+
+		tasks, total, err := services.Email().ListDeadLetters(c.Request.Context(), req.Offset(), req.Limit())
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, dto.NewErrorResponse(http.StatusInternalServerError, "获取死信失败", err.Error()))
+			return
+		}
+		c.JSON(http.StatusOK, dto.NewSuccessResponse(dto.NewPageResult(tasks, total, req.Page, req.PageSize)))
+	}
+}
+
+// AdminRequeueDeadLetterHandler 把发送失败的邮件重新放回队列
+func AdminRequeueDeadLetterHandler(services service.Factory) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.Param("id")
+		if err := services.Email().RequeueDeadLetter(c.Request.Context(), id); err != nil {
+			if errors.Is(err, service.ErrDeadLetterNotFound) {
+				c.JSON(http.StatusNotFound, dto.NewErrorResponse(http.StatusNotFound, "死信不存在", err.Error()))
+				return
+			}
+			c.JSON(http.StatusInternalServerError, dto.NewErrorResponse(http.StatusInternalServerError, "重新发送失败", err.Error()))
+			return
+		}
+
+		services.Audit().Record(c.Request.Context(), service.AuditEntry{
+			Action:     models.AuditActionDeadLetterRequeue,
+			EntityType: models.AuditEntityEmailTask,
+			EntityID:   id,
+		})
+		logger.FromContext(c.Request.Context()).WithComponent("admin").Info("管理员重新发送了死信", "admin_id", c.GetInt64("user_id"), "id", id)
+		c.JSON(http.StatusOK, dto.EmptySuccessResponse("已重新放入队列"))
+	}
+}
+
+// AdminDeleteDeadLetterHandler 删除一条发送失败的邮件
+func AdminDeleteDeadLetterHandler(services service.Factory) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.Param("id")
+		if err := services.Email().DeleteDeadLetter(c.Request.Context(), id); err != nil {
+			if errors.Is(err, service.ErrDeadLetterNotFound) {
+				c.JSON(http.StatusNotFound, dto.NewErrorResponse(http.StatusNotFound, "死信不存在", err.Error()))
+				return
+			}
+			c.JSON(http.StatusInternalServerError, dto.NewErrorResponse(http.StatusInternalServerError, "删除死信失败", err.Error()))
+			return
+		}
+
+		services.Audit().Record(c.Request.Context(), service.AuditEntry{
+			Action:     models.AuditActionDelete,
+			EntityType: models.AuditEntityEmailTask,
+			EntityID:   id,
+		})
+		c.JSON(http.StatusOK, dto.EmptySuccessResponse("死信已删除"))
+	}
+}
+
+// AdminPurgeDeadLettersHandler 清空死信队列
+func AdminPurgeDeadLettersHandler(services service.Factory) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		count, err := services.Email().PurgeDeadLetters(c.Request.Context())
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, dto.NewErrorResponse(http.StatusInternalServerError, "清空死信失败", err.Error()))
+			return
+		}
+
+		services.Audit().Record(c.Request.Context(), service.AuditEntry{
+			Action:     models.AuditActionDeadLetterPurge,
+			EntityType: models.AuditEntityEmailTask,
+			After:      gin.H{"count": count},
+		})
+		logger.FromContext(c.Request.Context()).WithComponent("admin").Info("管理员清空了死信队列", "admin_id", c.GetInt64("user_id"), "count", count)
+		c.JSON(http.StatusOK, dto.NewSuccessResponse(gin.H{"purged": count}))
+	}
+}
@@ -16,11 +16,15 @@ func PageTimelineEventsHandler(services service.Factory) gin.HandlerFunc {
 		var req dto.TimelineEventQueryParams
 		if err := c.ShouldBindQuery(&req); err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
 		}
+		// 只能查询自己情侣的时间线
+		req.CoupleID = c.GetInt64("couple_id")
 		models, total, err := services.TimelineEvent().ListTimelineEventsByCoupleID(
 			c.Request.Context(), req.CoupleID, req.Offset(), req.Limit())
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
 		}
 		c.JSON(http.StatusOK,
 			dto.NewSuccessResponse(dto.NewPageResult(models, total, req.Page, req.PageSize)))
@@ -36,9 +40,11 @@ func CreateTimelineEventHandler(services service.Factory) gin.HandlerFunc {
 			c.JSON(http.StatusBadRequest, dto.NewErrorResponse(http.StatusBadRequest, "请求参数无效", err.Error()))
 			return
 		}
+		req.CoupleID = c.GetInt64("couple_id")
 		result, err := services.TimelineEvent().CreateTimelineEvent(c.Request.Context(), &req)
 		if err != nil || result == false {
-			c.JSON(http.StatusInternalServerError, dto.NewErrorResponse(http.StatusInternalServerError, "创建时间线事件失败", err.Error()))
+			respondServiceError(c, "创建时间线事件失败", err)
+			return
 		}
 		c.JSON(http.StatusCreated, dto.EmptySuccessResponse("创建时间线事件成功"))
 	}
@@ -47,13 +53,15 @@ func CreateTimelineEventHandler(services service.Factory) gin.HandlerFunc {
 // GetTimelineEventHandler gets a specific timeline event
 func GetTimelineEventHandler(services service.Factory) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		coupleID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+		eventID, err := strconv.ParseInt(c.Param("id"), 10, 64)
 		if err != nil {
 			c.JSON(http.StatusBadRequest, dto.NewErrorResponse(http.StatusBadRequest, "获取故事ID失败", err.Error()))
+			return
 		}
-		event, err := services.TimelineEvent().GetTimelineEventByID(c.Request.Context(), coupleID)
+		event, err := services.TimelineEvent().GetTimelineEventByID(c.Request.Context(), c.GetInt64("couple_id"), eventID)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, dto.NewErrorResponse(http.StatusInternalServerError, "<UNK>", err.Error()))
+			respondServiceError(c, "获取时间线事件失败", err)
+			return
 		}
 		c.JSON(http.StatusOK, dto.NewSuccessResponse(event))
 	}
@@ -69,9 +77,10 @@ func UpdateTimelineEventHandler(services service.Factory) gin.HandlerFunc {
 		}
 
 		// 先获取现有事件
-		existingEvent, err := services.TimelineEvent().GetTimelineEventByID(c.Request.Context(), req.EventId)
+		coupleID := c.GetInt64("couple_id")
+		existingEvent, err := services.TimelineEvent().GetTimelineEventByID(c.Request.Context(), coupleID, req.EventId)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, dto.NewErrorResponse(http.StatusInternalServerError, "获取时间线事件失败", err.Error()))
+			respondServiceError(c, "获取时间线事件失败", err)
 			return
 		}
 
@@ -84,9 +93,9 @@ func UpdateTimelineEventHandler(services service.Factory) gin.HandlerFunc {
 			return
 		}
 
-		_, err = services.TimelineEvent().UpdateTimelineEvent(c.Request.Context(), existingEvent, req.LocationIDs, req.PhotoVideoIDs)
+		_, err = services.TimelineEvent().UpdateTimelineEvent(c.Request.Context(), coupleID, existingEvent, req.LocationIDs, req.PhotoVideoIDs)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, dto.NewErrorResponse(http.StatusInternalServerError, "更新回忆失败", err.Error()))
+			respondServiceError(c, "更新回忆失败", err)
 			return
 		}
 		c.JSON(http.StatusOK, dto.EmptySuccessResponse("更新回忆成功"))
@@ -99,10 +108,11 @@ func DeleteTimelineEventHandler(services service.Factory) gin.HandlerFunc {
 		evevtId, err := strconv.ParseInt(c.Param("id"), 10, 64)
 		if err != nil {
 			c.JSON(http.StatusBadRequest, dto.NewErrorResponse(http.StatusBadRequest, "获取时间线事件ID失败", err.Error()))
+			return
 		}
-		err = services.TimelineEvent().DeleteTimelineEvent(c.Request.Context(), evevtId)
+		err = services.TimelineEvent().DeleteTimelineEvent(c.Request.Context(), c.GetInt64("couple_id"), evevtId)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, dto.NewErrorResponse(http.StatusInternalServerError, "删除时间线事件失败", err.Error()))
+			respondServiceError(c, "删除时间线事件失败", err)
 			return
 		}
 		c.JSON(http.StatusOK, dto.EmptySuccessResponse("删除时间线事件成功"))
@@ -1 +1,21 @@
 package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"memoir-api/internal/api/dto"
+	"memoir-api/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// respondServiceError 输出服务调用失败的响应。资源不存在和不属于当前情侣都返回404，两种情况不做区分，
+// 其他错误按 message 返回500
+func respondServiceError(c *gin.Context, message string, err error) {
+	if errors.Is(err, service.ErrResourceNotFound) {
+		c.JSON(http.StatusNotFound, dto.NewErrorResponse(http.StatusNotFound, err.Error(), err.Error()))
+		return
+	}
+	c.JSON(http.StatusInternalServerError, dto.NewErrorResponse(http.StatusInternalServerError, message, err.Error()))
+}
@@ -0,0 +1,18 @@
+package handlers
+
+import (
+	"net/http"
+
+	"memoir-api/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// JWKSHandler 公开JWT验证公钥，供其他服务离线验证访问令牌
+func JWKSHandler(services service.Factory) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// 允许短时间缓存，密钥轮换时旧公钥仍会保留在集合中
+		c.Header("Cache-Control", "public, max-age=300")
+		c.JSON(http.StatusOK, services.JWT().JWKS())
+	}
+}
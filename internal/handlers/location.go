@@ -12,17 +12,18 @@ import (
 // ListLocationsHandler lists locations
 func ListLocationsHandler(services service.Factory) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		value, err := strconv.ParseInt(c.Query("couple_id"), 10, 64)
-		if err != nil {
-			c.JSON(http.StatusBadRequest, dto.NewErrorResponse(http.StatusBadRequest, "无效的情侣ID", err.Error()))
-			return
-		}
 		var req dto.LocationQueryParams
-		locations, _, err := services.Location().ListLocationsByCoupleID(c.Request.Context(), value, 0, 0)
 		if err := c.ShouldBindQuery(&req); err != nil {
 			c.JSON(http.StatusBadRequest, dto.NewErrorResponse(http.StatusBadRequest, "查询所有的地点出错", err.Error()))
 			return
 		}
+		// 只能查询自己情侣的地点
+		req.CoupleID = c.GetInt64("couple_id")
+		locations, _, err := services.Location().ListLocationsByCoupleID(c.Request.Context(), req.CoupleID, 0, 0)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, dto.NewErrorResponse(http.StatusInternalServerError, "查询所有的地点出错", err.Error()))
+			return
+		}
 		c.JSON(http.StatusOK, dto.NewSuccessResponse(locations))
 	}
 }
@@ -35,6 +36,7 @@ func CreateLocationHandler(services service.Factory) gin.HandlerFunc {
 			c.JSON(http.StatusBadRequest, dto.NewErrorResponse(http.StatusBadRequest, "请求参数无效", err.Error()))
 			return
 		}
+		req.CoupleID = c.GetInt64("couple_id")
 		location, err := services.Location().CreateLocation(c.Request.Context(), req.ToModel())
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, dto.NewErrorResponse(http.StatusInternalServerError, "创建位置失败", err.Error()))
@@ -47,8 +49,17 @@ func CreateLocationHandler(services service.Factory) gin.HandlerFunc {
 // GetLocationHandler gets a specific location
 func GetLocationHandler(services service.Factory) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// TODO: Implement get location logic
-		c.JSON(http.StatusOK, dto.EmptySuccessResponse("获取位置成功"))
+		locationID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, dto.NewErrorResponse(http.StatusBadRequest, "地点ID出错", err.Error()))
+			return
+		}
+		location, err := services.Location().GetLocationByID(c.Request.Context(), c.GetInt64("couple_id"), locationID)
+		if err != nil {
+			respondServiceError(c, "获取位置失败", err)
+			return
+		}
+		c.JSON(http.StatusOK, dto.NewSuccessResponse(dto.LocationFromModel(location)))
 	}
 }
 
@@ -66,10 +77,11 @@ func DeleteLocationHandler(services service.Factory) gin.HandlerFunc {
 		locationId, err := strconv.ParseInt(c.Param("id"), 10, 64)
 		if err != nil {
 			c.JSON(http.StatusBadRequest, dto.NewErrorResponse(http.StatusBadRequest, "地点ID出错", err.Error()))
+			return
 		}
-		err = services.Location().DeleteLocation(c.Request.Context(), locationId)
+		err = services.Location().DeleteLocation(c.Request.Context(), c.GetInt64("couple_id"), locationId)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, dto.NewErrorResponse(http.StatusInternalServerError, "删除地点出错", err.Error()))
+			respondServiceError(c, "删除地点出错", err)
 			return
 		}
 		c.JSON(http.StatusOK, dto.EmptySuccessResponse("删除地点成功"))
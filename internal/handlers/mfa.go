@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"memoir-api/internal/api/dto"
+	"memoir-api/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetMFAStatusHandler 获取当前用户的二次验证状态
+func GetMFAStatusHandler(services service.Factory) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		status, err := services.User().GetMFAStatus(c.Request.Context(), c.GetInt64("user_id"))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, dto.NewErrorResponse(http.StatusInternalServerError, "获取二次验证状态失败", err.Error()))
+			return
+		}
+		c.JSON(http.StatusOK, dto.NewSuccessResponse(status))
+	}
+}
+
+// EnrollTOTPHandler 生成TOTP密钥和otpauth URI
+func EnrollTOTPHandler(services service.Factory) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		enrollment, err := services.User().EnrollTOTP(c.Request.Context(), c.GetInt64("user_id"))
+		if err != nil {
+			if errors.Is(err, service.ErrMFAAlreadyEnabled) {
+				c.JSON(http.StatusConflict, dto.NewErrorResponse(http.StatusConflict, "二次验证已开启", err.Error()))
+				return
+			}
+			c.JSON(http.StatusInternalServerError, dto.NewErrorResponse(http.StatusInternalServerError, "生成二次验证密钥失败", err.Error()))
+			return
+		}
+		c.JSON(http.StatusOK, dto.NewSuccessResponse(enrollment))
+	}
+}
+
+// ConfirmTOTPHandler 使用第一个验证码确认绑定并返回恢复码
+func ConfirmTOTPHandler(services service.Factory) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req dto.MFACodeRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, dto.NewErrorResponse(http.StatusBadRequest, "请求格式错误", err.Error()))
+			return
+		}
+
+		recoveryCodes, err := services.User().ConfirmTOTP(c.Request.Context(), c.GetInt64("user_id"), req.Code)
+		if err != nil {
+			switch {
+			case errors.Is(err, service.ErrMFAAlreadyEnabled):
+				c.JSON(http.StatusConflict, dto.NewErrorResponse(http.StatusConflict, "二次验证已开启", err.Error()))
+			case errors.Is(err, service.ErrMFANotEnrolled):
+				c.JSON(http.StatusBadRequest, dto.NewErrorResponse(http.StatusBadRequest, "请先生成二次验证密钥", err.Error()))
+			case errors.Is(err, service.ErrInvalidMFACode):
+				c.JSON(http.StatusBadRequest, dto.NewErrorResponse(http.StatusBadRequest, "验证码错误", err.Error()))
+			default:
+				c.JSON(http.StatusInternalServerError, dto.NewErrorResponse(http.StatusInternalServerError, "开启二次验证失败", err.Error()))
+			}
+			return
+		}
+
+		c.JSON(http.StatusOK, dto.NewSuccessResponse(dto.MFARecoveryCodesResponse{RecoveryCodes: recoveryCodes}))
+	}
+}
+
+// DisableTOTPHandler 关闭二次验证
+func DisableTOTPHandler(services service.Factory) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req dto.MFACodeRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, dto.NewErrorResponse(http.StatusBadRequest, "请求格式错误", err.Error()))
+			return
+		}
+
+		if err := services.User().DisableTOTP(c.Request.Context(), c.GetInt64("user_id"), req.Code); err != nil {
+			switch {
+			case errors.Is(err, service.ErrMFANotEnabled):
+				c.JSON(http.StatusBadRequest, dto.NewErrorResponse(http.StatusBadRequest, "二次验证未开启", err.Error()))
+			case errors.Is(err, service.ErrInvalidMFACode):
+				c.JSON(http.StatusBadRequest, dto.NewErrorResponse(http.StatusBadRequest, "验证码错误", err.Error()))
+			default:
+				c.JSON(http.StatusInternalServerError, dto.NewErrorResponse(http.StatusInternalServerError, "关闭二次验证失败", err.Error()))
+			}
+			return
+		}
+
+		c.JSON(http.StatusOK, dto.EmptySuccessResponse("二次验证已关闭"))
+	}
+}
@@ -64,9 +64,9 @@ func DeletePersonalMediaHandler(services service.Factory) gin.HandlerFunc {
 			c.JSON(http.StatusBadRequest, dto.NewErrorResponse(http.StatusBadRequest, "无效的个人媒体ID", err.Error()))
 			return
 		}
-		err = services.PersonalMedia().Delete(c.Request.Context(), mediaID)
+		err = services.PersonalMedia().Delete(c.Request.Context(), c.GetInt64("user_id"), mediaID)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, dto.NewErrorResponse(http.StatusInternalServerError, "删除个人媒体失败", err.Error()))
+			respondServiceError(c, "删除个人媒体失败", err)
 			return
 		}
 		c.JSON(http.StatusOK, dto.EmptySuccessResponse("删除个人媒体成功"))
@@ -11,11 +11,14 @@ import (
 // ListMediaHandler lists media items
 func ListPhotoVideoHandler(services service.Factory) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		var parms *dto.PhotoVideoQueryParams
+		var parms dto.PhotoVideoQueryParams
 		if err := c.ShouldBindQuery(&parms); err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
 		}
-		pageResult, err := services.PhotoVideo().Query(c.Request.Context(), parms)
+		// 只能查询自己情侣的照片/视频
+		parms.CoupleID = c.GetInt64("couple_id")
+		pageResult, err := services.PhotoVideo().Query(c.Request.Context(), &parms)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, dto.NewErrorResponse(http.StatusInternalServerError, "查询失败", err.Error()))
 			return
@@ -34,9 +37,10 @@ func CreatePhotoVideoHandler(services service.Factory) gin.HandlerFunc {
 		}
 		userID := c.GetInt64("user_id")
 		request.UserID = userID
+		request.CoupleID = c.GetInt64("couple_id")
 		photoVideo, err := services.PhotoVideo().CreatePhotoVideo(c.Request.Context(), &request)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, dto.NewErrorResponse(http.StatusInternalServerError, "Failed to create photo video", err.Error()))
+			respondServiceError(c, "Failed to create photo video", err)
 			return
 		}
 		c.JSON(http.StatusCreated, dto.NewSuccessResponse(photoVideo))
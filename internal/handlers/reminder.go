@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"memoir-api/internal/api/dto"
+	"memoir-api/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListRemindersHandler 获取情侣的所有自定义提醒
+func ListRemindersHandler(services service.Factory) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		reminders, err := services.Reminder().List(c.Request.Context(), c.GetInt64("couple_id"))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, dto.NewErrorResponse(http.StatusInternalServerError, "查询提醒失败", err.Error()))
+			return
+		}
+		c.JSON(http.StatusOK, dto.NewSuccessResponse(reminders))
+	}
+}
+
+// CreateReminderHandler 创建自定义提醒
+func CreateReminderHandler(services service.Factory) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req dto.CreateReminderRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, dto.NewErrorResponse(http.StatusBadRequest, "请求参数无效", err.Error()))
+			return
+		}
+
+		reminder, err := services.Reminder().Create(c.Request.Context(), c.GetInt64("couple_id"), c.GetInt64("user_id"), &req)
+		if err != nil {
+			if errors.Is(err, service.ErrInvalidRRule) {
+				c.JSON(http.StatusBadRequest, dto.NewErrorResponse(http.StatusBadRequest, "请求参数无效", err.Error()))
+				return
+			}
+			c.JSON(http.StatusInternalServerError, dto.NewErrorResponse(http.StatusInternalServerError, "创建提醒失败", err.Error()))
+			return
+		}
+		c.JSON(http.StatusCreated, dto.NewSuccessResponse(reminder))
+	}
+}
+
+// UpdateReminderHandler 更新自定义提醒
+func UpdateReminderHandler(services service.Factory) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, dto.NewErrorResponse(http.StatusBadRequest, "无效的提醒ID", err.Error()))
+			return
+		}
+
+		var req dto.UpdateReminderRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, dto.NewErrorResponse(http.StatusBadRequest, "请求参数无效", err.Error()))
+			return
+		}
+
+		reminder, err := services.Reminder().Update(c.Request.Context(), c.GetInt64("couple_id"), id, &req)
+		if err != nil {
+			switch {
+			case errors.Is(err, service.ErrReminderNotFound):
+				c.JSON(http.StatusNotFound, dto.NewErrorResponse(http.StatusNotFound, "提醒不存在", err.Error()))
+			case errors.Is(err, service.ErrInvalidRRule):
+				c.JSON(http.StatusBadRequest, dto.NewErrorResponse(http.StatusBadRequest, "请求参数无效", err.Error()))
+			default:
+				c.JSON(http.StatusInternalServerError, dto.NewErrorResponse(http.StatusInternalServerError, "更新提醒失败", err.Error()))
+			}
+			return
+		}
+		c.JSON(http.StatusOK, dto.NewSuccessResponse(reminder))
+	}
+}
+
+// DeleteReminderHandler 删除自定义提醒
+func DeleteReminderHandler(services service.Factory) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, dto.NewErrorResponse(http.StatusBadRequest, "无效的提醒ID", err.Error()))
+			return
+		}
+
+		if err := services.Reminder().Delete(c.Request.Context(), c.GetInt64("couple_id"), id); err != nil {
+			if errors.Is(err, service.ErrReminderNotFound) {
+				c.JSON(http.StatusNotFound, dto.NewErrorResponse(http.StatusNotFound, "提醒不存在", err.Error()))
+				return
+			}
+			c.JSON(http.StatusInternalServerError, dto.NewErrorResponse(http.StatusInternalServerError, "删除提醒失败", err.Error()))
+			return
+		}
+		c.JSON(http.StatusOK, dto.NewSuccessResponse(gin.H{"message": "提醒已删除"}))
+	}
+}
+
+// ListUpcomingRemindersHandler 获取倒计时列表，包括自定义提醒和心愿单提醒
+func ListUpcomingRemindersHandler(services service.Factory) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req dto.UpcomingRemindersRequest
+		if err := c.ShouldBindQuery(&req); err != nil {
+			c.JSON(http.StatusBadRequest, dto.NewErrorResponse(http.StatusBadRequest, "请求参数无效", err.Error()))
+			return
+		}
+
+		upcoming, err := services.Reminder().Upcoming(c.Request.Context(), c.GetInt64("couple_id"), req.Days)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, dto.NewErrorResponse(http.StatusInternalServerError, "获取倒计时失败", err.Error()))
+			return
+		}
+		c.JSON(http.StatusOK, dto.NewSuccessResponse(upcoming))
+	}
+}
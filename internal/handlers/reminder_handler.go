@@ -8,34 +8,35 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-// TriggerAnniversaryRemindersHandler 触发纪念日提醒
+// TriggerAnniversaryRemindersHandler 触发纪念日提醒，与调度执行使用同一个任务锁和执行记录
 func TriggerAnniversaryRemindersHandler(services service.Factory) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// 执行纪念日提醒
-		err := services.CoupleReminder().CheckAndSendAnniversaryReminders(c.Request.Context())
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, dto.NewErrorResponse(http.StatusInternalServerError, "执行纪念日提醒失败", err.Error()))
-			return
-		}
-
-		c.JSON(http.StatusOK, dto.NewSuccessResponse(gin.H{
-			"message": "纪念日提醒已触发",
-		}))
+		triggerJob(c, services, service.JobAnniversaryReminders)
 	}
 }
 
-// TriggerFestivalRemindersHandler 触发节日提醒
+// TriggerFestivalRemindersHandler 触发节日提醒，与调度执行使用同一个任务锁和执行记录
 func TriggerFestivalRemindersHandler(services service.Factory) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// 执行节日提醒
-		err := services.CoupleReminder().CheckAndSendFestivalReminders(c.Request.Context())
+		triggerJob(c, services, service.JobFestivalReminders)
+	}
+}
+
+// ListReminderDeliveriesHandler 获取情侣的提醒发送记录
+func ListReminderDeliveriesHandler(services service.Factory) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req dto.PaginationRequest
+		if err := c.ShouldBindQuery(&req); err != nil {
+			c.JSON(http.StatusBadRequest, dto.NewErrorResponse(http.StatusBadRequest, "请求参数无效", err.Error()))
+			return
+		}
+
+		deliveries, total, err := services.CoupleReminder().ListDeliveries(c.Request.Context(), c.GetInt64("couple_id"), req.Offset(), req.Limit())
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, dto.NewErrorResponse(http.StatusInternalServerError, "执行节日提醒失败", err.Error()))
+			c.JSON(http.StatusInternalServerError, dto.NewErrorResponse(http.StatusInternalServerError, "获取提醒发送记录失败", err.Error()))
 			return
 		}
 
-		c.JSON(http.StatusOK, dto.NewSuccessResponse(gin.H{
-			"message": "节日提醒已触发",
-		}))
+		c.JSON(http.StatusOK, dto.NewSuccessResponse(dto.NewPageResult(deliveries, total, req.Page, req.PageSize)))
 	}
 }
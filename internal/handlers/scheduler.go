@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"memoir-api/internal/api/dto"
+	"memoir-api/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminListJobsHandler 获取所有定时任务及最近一次执行情况
+func AdminListJobsHandler(services service.Factory) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		jobs, err := services.Scheduler().ListJobs(c.Request.Context())
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, dto.NewErrorResponse(http.StatusInternalServerError, "获取定时任务失败", err.Error()))
+			return
+		}
+
+		c.JSON(http.StatusOK, dto.NewSuccessResponse(jobs))
+	}
+}
+
+// AdminListJobRunsHandler 分页获取定时任务的执行记录
+func AdminListJobRunsHandler(services service.Factory) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req dto.PaginationRequest
+		if err := c.ShouldBindQuery(&req); err != nil {
+			c.JSON(http.StatusBadRequest, dto.NewErrorResponse(http.StatusBadRequest, "请求参数无效", err.Error()))
+			return
+		}
+
+		runs, total, err := services.Scheduler().ListRuns(c.Request.Context(), c.Param("name"), req.Offset(), req.Limit())
+		if err != nil {
+			if errors.Is(err, service.ErrJobNotFound) {
+				c.JSON(http.StatusNotFound, dto.NewErrorResponse(http.StatusNotFound, "定时任务不存在", err.Error()))
+				return
+			}
+			c.JSON(http.StatusInternalServerError, dto.NewErrorResponse(http.StatusInternalServerError, "获取执行记录失败", err.Error()))
+			return
+		}
+
+		c.JSON(http.StatusOK, dto.NewSuccessResponse(dto.NewPageResult(runs, total, req.Page, req.PageSize)))
+	}
+}
+
+// AdminTriggerJobHandler 手动执行定时任务
+func AdminTriggerJobHandler(services service.Factory) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		triggerJob(c, services, c.Param("name"))
+	}
+}
+
+// triggerJob 手动执行任务并返回执行记录，任务正在执行时返回409
+func triggerJob(c *gin.Context, services service.Factory, name string) {
+	run, err := services.Scheduler().Trigger(c.Request.Context(), name, c.GetInt64("user_id"))
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrJobNotFound):
+			c.JSON(http.StatusNotFound, dto.NewErrorResponse(http.StatusNotFound, "定时任务不存在", err.Error()))
+		case errors.Is(err, service.ErrJobRunning):
+			c.JSON(http.StatusConflict, dto.NewErrorResponse(http.StatusConflict, "定时任务正在执行", err.Error()))
+		default:
+			c.JSON(http.StatusInternalServerError, dto.NewErrorResponse(http.StatusInternalServerError, "执行定时任务失败", err.Error()))
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NewSuccessResponse(run))
+}
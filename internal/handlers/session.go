@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"sort"
+	"strings"
+
+	"memoir-api/internal/api/dto"
+	"memoir-api/internal/models"
+	"memoir-api/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListSessionsHandler 列出当前用户已登录的设备
+func ListSessionsHandler(services service.Factory) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.GetInt64("user_id")
+		currentSessionID := c.GetString("session_id")
+
+		sessions, err := services.Session().ListByUser(c.Request.Context(), userID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, dto.NewErrorResponse(http.StatusInternalServerError, "获取登录设备失败", err.Error()))
+			return
+		}
+
+		// 最近活跃的设备排在前面
+		sort.Slice(sessions, func(i, j int) bool {
+			return sessions[i].LastSeenAt.After(sessions[j].LastSeenAt)
+		})
+
+		resp := make([]dto.SessionResponse, 0, len(sessions))
+		for _, session := range sessions {
+			resp = append(resp, dto.SessionResponse{
+				ID:         session.ID,
+				DeviceName: session.DeviceName,
+				IP:         session.IP,
+				UserAgent:  session.UserAgent,
+				CreatedAt:  session.CreatedAt,
+				LastSeenAt: session.LastSeenAt,
+				ExpiresAt:  session.ExpiresAt,
+				Current:    session.ID == currentSessionID,
+			})
+		}
+
+		c.JSON(http.StatusOK, dto.NewSuccessResponse(resp))
+	}
+}
+
+// RevokeSessionHandler 注销当前用户的某个登录设备
+func RevokeSessionHandler(services service.Factory) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.GetInt64("user_id")
+		sessionID := c.Param("id")
+
+		if err := services.Session().Revoke(c.Request.Context(), userID, sessionID); err != nil {
+			if errors.Is(err, service.ErrSessionNotFound) {
+				c.JSON(http.StatusNotFound, dto.NewErrorResponse(http.StatusNotFound, "会话不存在", err.Error()))
+				return
+			}
+			c.JSON(http.StatusInternalServerError, dto.NewErrorResponse(http.StatusInternalServerError, "注销设备失败", err.Error()))
+			return
+		}
+
+		services.Audit().Record(c.Request.Context(), service.AuditEntry{
+			Action:     models.AuditActionSessionRevoke,
+			EntityType: models.AuditEntitySession,
+			EntityID:   sessionID,
+		})
+
+		c.JSON(http.StatusOK, dto.EmptySuccessResponse("设备已注销"))
+	}
+}
+
+// describeDevice 根据User-Agent粗略推断设备名称，客户端未提供设备名时使用
+func describeDevice(userAgent string) string {
+	ua := strings.ToLower(userAgent)
+
+	var platform string
+	switch {
+	case strings.Contains(ua, "ipad"):
+		platform = "iPad"
+	case strings.Contains(ua, "iphone"):
+		platform = "iPhone"
+	case strings.Contains(ua, "android"):
+		platform = "Android"
+	case strings.Contains(ua, "windows"):
+		platform = "Windows"
+	case strings.Contains(ua, "mac os"), strings.Contains(ua, "macintosh"):
+		platform = "Mac"
+	case strings.Contains(ua, "linux"):
+		platform = "Linux"
+	}
+
+	var client string
+	switch {
+	case strings.Contains(ua, "micromessenger"):
+		client = "微信"
+	case strings.Contains(ua, "edg/"):
+		client = "Edge"
+	case strings.Contains(ua, "chrome/"), strings.Contains(ua, "crios/"):
+		client = "Chrome"
+	case strings.Contains(ua, "firefox/"), strings.Contains(ua, "fxios/"):
+		client = "Firefox"
+	case strings.Contains(ua, "safari/"):
+		client = "Safari"
+	}
+
+	switch {
+	case platform != "" && client != "":
+		return platform + " · " + client
+	case platform != "":
+		return platform
+	case client != "":
+		return client
+	default:
+		return "未知设备"
+	}
+}
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"memoir-api/internal/api/dto"
 	"memoir-api/internal/service"
 	"net/http"
@@ -64,6 +65,10 @@ func UpdateUserHandler(services service.Factory) gin.HandlerFunc {
 		userService := services.User()
 		err := userService.UpdateUser(c.Request.Context(), &updateUserRequest)
 		if err != nil {
+			if errors.Is(err, service.ErrEmailChangeNotAllowed) {
+				c.JSON(http.StatusBadRequest, dto.NewErrorResponse(http.StatusBadRequest, "修改邮箱需要验证新邮箱", err.Error()))
+				return
+			}
 			c.JSON(http.StatusInternalServerError, dto.NewErrorResponse(http.StatusInternalServerError, "更新用户信息失败", err.Error()))
 			return
 		}
@@ -89,3 +94,55 @@ func UpdatePassword(services service.Factory) gin.HandlerFunc {
 		c.JSON(http.StatusOK, dto.EmptySuccessResponse("更新成功"))
 	}
 }
+
+// RequestEmailChangeHandler 申请修改邮箱，校验当前密码后向新邮箱发送验证码
+func RequestEmailChangeHandler(services service.Factory) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req dto.ChangeEmailRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, dto.NewErrorResponse(http.StatusBadRequest, "请求格式错误", err.Error()))
+			return
+		}
+
+		err := services.User().RequestEmailChange(c.Request.Context(), c.GetInt64("user_id"), req.NewEmail, req.Password)
+		if err != nil {
+			respondEmailChangeError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, dto.EmptySuccessResponse("验证码已发送到新邮箱"))
+	}
+}
+
+// ConfirmEmailChangeHandler 使用新邮箱收到的验证码确认修改邮箱
+func ConfirmEmailChangeHandler(services service.Factory) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req dto.ConfirmEmailChangeRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, dto.NewErrorResponse(http.StatusBadRequest, "请求格式错误", err.Error()))
+			return
+		}
+
+		err := services.User().ConfirmEmailChange(c.Request.Context(), c.GetInt64("user_id"), req.NewEmail, req.Code)
+		if err != nil {
+			respondEmailChangeError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, dto.EmptySuccessResponse("邮箱已修改"))
+	}
+}
+
+// respondEmailChangeError 输出修改邮箱失败的响应
+func respondEmailChangeError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidPassword):
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse(http.StatusBadRequest, "密码错误", err.Error()))
+	case errors.Is(err, service.ErrInvalidVerificationCode):
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse(http.StatusBadRequest, "验证码错误", err.Error()))
+	case errors.Is(err, service.ErrEmailUnchanged):
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse(http.StatusBadRequest, "新邮箱与当前邮箱相同", err.Error()))
+	case errors.Is(err, service.ErrUserExists):
+		c.JSON(http.StatusConflict, dto.NewErrorResponse(http.StatusConflict, "邮箱已被使用", err.Error()))
+	default:
+		c.JSON(http.StatusInternalServerError, dto.NewErrorResponse(http.StatusInternalServerError, "修改邮箱失败", err.Error()))
+	}
+}
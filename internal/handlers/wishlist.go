@@ -12,14 +12,11 @@ import (
 // ListWishlistItemsHandler lists wishlist items
 func ListWishlistItemsHandler(services service.Factory) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		coupleId, err := strconv.ParseInt(c.Query("couple_id"), 10, 64)
-		if err != nil {
-			c.JSON(http.StatusBadRequest, dto.NewErrorResponse(http.StatusBadRequest, "无效的情侣ID", err.Error()))
-			return
-		}
-		wishListDTO, err := services.Wishlist().ListWishlistsByCoupleID(c.Request.Context(), coupleId)
+		// 只能查询自己情侣的心愿单
+		wishListDTO, err := services.Wishlist().ListWishlistsByCoupleID(c.Request.Context(), c.GetInt64("couple_id"))
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, dto.NewErrorResponse(http.StatusInternalServerError, "查询心愿单失败", err.Error()))
+			return
 		}
 		c.JSON(http.StatusOK, dto.NewSuccessResponse(wishListDTO))
 	}
@@ -33,9 +30,10 @@ func CreateWishlistItemHandler(services service.Factory) gin.HandlerFunc {
 			c.JSON(http.StatusBadRequest, dto.NewErrorResponse(http.StatusBadRequest, "请求参数无效", err.Error()))
 			return
 		}
-		wishlist, err := services.Wishlist().CreateWishlist(c.Request.Context(), &req)
+		req.CoupleID = c.GetInt64("couple_id")
+		wishlist, err := services.Wishlist().CreateWishlist(c.Request.Context(), c.GetInt64("user_id"), &req)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, dto.NewErrorResponse(http.StatusInternalServerError, "创建心愿单项目失败", err.Error()))
+			respondServiceError(c, "创建心愿单项目失败", err)
 			return
 		}
 		c.JSON(http.StatusCreated, dto.NewSuccessResponse(wishlist))
@@ -53,13 +51,9 @@ func UpdateWishlistItemHandler(services service.Factory) gin.HandlerFunc {
 		}
 
 		// 调用服务层方法处理更新逻辑
-		updatedWishlist, err := services.Wishlist().UpdateWishlistByRequest(c.Request.Context(), &req)
+		updatedWishlist, err := services.Wishlist().UpdateWishlistByRequest(c.Request.Context(), c.GetInt64("user_id"), c.GetInt64("couple_id"), &req)
 		if err != nil {
-			if err == service.ErrWishlistNotFound {
-				c.JSON(http.StatusNotFound, dto.NewErrorResponse(http.StatusNotFound, "心愿不存在", err.Error()))
-			} else {
-				c.JSON(http.StatusInternalServerError, dto.NewErrorResponse(http.StatusInternalServerError, "更新心愿失败", err.Error()))
-			}
+			respondServiceError(c, "更新心愿失败", err)
 			return
 		}
 
@@ -86,18 +80,15 @@ func UpdateWishlistItemStatusHandler(services service.Factory) gin.HandlerFunc {
 		}
 
 		// 调用服务更新状态
-		err = services.Wishlist().UpdateWishlistStatus(c.Request.Context(), id, req.Status)
+		coupleID := c.GetInt64("couple_id")
+		err = services.Wishlist().UpdateWishlistStatus(c.Request.Context(), coupleID, id, req.Status)
 		if err != nil {
-			if err == service.ErrWishlistNotFound {
-				c.JSON(http.StatusNotFound, dto.NewErrorResponse(http.StatusNotFound, "心愿不存在", err.Error()))
-			} else {
-				c.JSON(http.StatusInternalServerError, dto.NewErrorResponse(http.StatusInternalServerError, "更新心愿状态失败", err.Error()))
-			}
+			respondServiceError(c, "更新心愿状态失败", err)
 			return
 		}
 
 		// 获取更新后的心愿项目
-		wishlist, err := services.Wishlist().GetWishlistByID(c.Request.Context(), id)
+		wishlist, err := services.Wishlist().GetWishlistByID(c.Request.Context(), coupleID, id)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, dto.NewErrorResponse(http.StatusInternalServerError, "获取更新后的心愿失败", err.Error()))
 			return
@@ -119,13 +110,9 @@ func DeleteWishlistItemHandler(services service.Factory) gin.HandlerFunc {
 		}
 
 		// 调用服务删除心愿项
-		err = services.Wishlist().DeleteWishlist(c.Request.Context(), id)
+		err = services.Wishlist().DeleteWishlist(c.Request.Context(), c.GetInt64("couple_id"), id)
 		if err != nil {
-			if err == service.ErrWishlistNotFound {
-				c.JSON(http.StatusNotFound, dto.NewErrorResponse(http.StatusNotFound, "心愿不存在", err.Error()))
-			} else {
-				c.JSON(http.StatusInternalServerError, dto.NewErrorResponse(http.StatusInternalServerError, "删除心愿失败", err.Error()))
-			}
+			respondServiceError(c, "删除心愿失败", err)
 			return
 		}
 
@@ -142,13 +129,9 @@ func AssociateAttachments(services service.Factory) gin.HandlerFunc {
 			c.JSON(http.StatusBadRequest, dto.NewErrorResponse(http.StatusBadRequest, "请求参数无效", err.Error()))
 			return
 		}
-		err := services.Wishlist().AssociateAttachments(c.Request.Context(), req.WishlistID, req.AttachmentIDs)
+		err := services.Wishlist().AssociateAttachments(c.Request.Context(), c.GetInt64("user_id"), c.GetInt64("couple_id"), req.WishlistID, req.AttachmentIDs)
 		if err != nil {
-			if err == service.ErrWishlistNotFound {
-				c.JSON(http.StatusNotFound, dto.NewErrorResponse(http.StatusNotFound, "心愿不存在", err.Error()))
-			} else {
-				c.JSON(http.StatusInternalServerError, dto.NewErrorResponse(http.StatusInternalServerError, "心愿关联附件失败", err.Error()))
-			}
+			respondServiceError(c, "心愿关联附件失败", err)
 			return
 		}
 		c.JSON(http.StatusOK, dto.EmptySuccessResponse("附件关联成功"))
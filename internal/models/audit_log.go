@@ -0,0 +1,117 @@
+package models
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 审计动作
+const (
+	AuditActionCreate = "create"
+	AuditActionUpdate = "update"
+	AuditActionDelete = "delete"
+
+	AuditActionLogin          = "login"
+	AuditActionLoginFailed    = "login_failed"
+	AuditActionMFAFailed      = "mfa_failed"
+	AuditActionLogout         = "logout"
+	AuditActionLogoutAll      = "logout_all"
+	AuditActionSessionRevoke  = "session_revoke"
+	AuditActionPasswordChange = "password_change"
+	AuditActionPasswordReset  = "password_reset"
+	AuditActionEmailVerify    = "email_verify"
+	AuditActionEmailChange    = "email_change"
+	AuditActionMFAEnable      = "mfa_enable"
+	AuditActionMFADisable     = "mfa_disable"
+	AuditActionTokenCreate    = "token_create"
+	AuditActionTokenRevoke    = "token_revoke"
+	AuditActionUserDisable    = "user_disable"
+	AuditActionUserEnable     = "user_enable"
+	AuditActionLockoutClear   = "lockout_clear"
+	AuditActionCoupleInvite   = "couple_invite"
+	AuditActionCoupleJoin     = "couple_join"
+
+	AuditActionCoupleDissolveRequest = "couple_dissolve_request"
+	AuditActionCoupleDissolveCancel  = "couple_dissolve_cancel"
+	AuditActionCoupleDissolve        = "couple_dissolve"
+
+	AuditActionJobTrigger = "job_trigger"
+
+	AuditActionDeadLetterRequeue = "dead_letter_requeue"
+	AuditActionDeadLetterPurge   = "dead_letter_purge"
+)
+
+// 审计对象类型
+const (
+	AuditEntityUser          = "user"
+	AuditEntityCouple        = "couple"
+	AuditEntityAlbum         = "album"
+	AuditEntityPhotoVideo    = "photo_video"
+	AuditEntityTimelineEvent = "timeline_event"
+	AuditEntityLocation      = "location"
+	AuditEntityWishlist      = "wishlist"
+	AuditEntityAttachment    = "attachment"
+	AuditEntityPersonalMedia = "personal_media"
+	AuditEntityAccessToken   = "access_token"
+	AuditEntitySession       = "session"
+	AuditEntityAnniversary   = "anniversary"
+	AuditEntityJob           = "job"
+	AuditEntityReminder      = "reminder"
+	AuditEntityEmailTask     = "email_task"
+	AuditEntityLoginLockout  = "login_lockout"
+)
+
+// AuditLog 审计日志，只允许追加。每条记录的哈希包含上一条记录的哈希，
+// 任何修改或删除都会让之后的哈希链校验失败
+type AuditLog struct {
+	ID int64 `json:"id,string" gorm:"primaryKey"`
+	// Seq 哈希链中的序号，从1开始连续递增
+	Seq        int64  `json:"seq" gorm:"not null;uniqueIndex"`
+	ActorID    int64  `json:"actor_id,string" gorm:"not null;default:0;index"`
+	CoupleID   int64  `json:"couple_id,string" gorm:"not null;default:0;index"`
+	Action     string `json:"action" gorm:"type:varchar(50);not null"`
+	EntityType string `json:"entity_type" gorm:"type:varchar(50);not null;index:idx_audit_logs_entity"`
+	EntityID   string `json:"entity_id" gorm:"type:varchar(100);not null;default:'';index:idx_audit_logs_entity"`
+	// Before/After 只记录发生变化的字段（JSON），创建时 Before 为空，删除时 After 为空
+	Before    string    `json:"before,omitempty" gorm:"type:text"`
+	After     string    `json:"after,omitempty" gorm:"type:text"`
+	RequestID string    `json:"request_id" gorm:"type:varchar(64)"`
+	IP        string    `json:"ip" gorm:"type:varchar(64)"`
+	CreatedAt time.Time `json:"created_at" gorm:"not null;index"`
+	PrevHash  string    `json:"prev_hash" gorm:"type:varchar(64);not null"`
+	Hash      string    `json:"hash" gorm:"type:varchar(64);not null"`
+}
+
+// BeforeCreate 在创建记录前自动生成ID
+func (a *AuditLog) BeforeCreate(tx *gorm.DB) error {
+	if a.ID == 0 {
+		a.ID = GenerateID()
+	}
+	return nil
+}
+
+// ComputeHash 计算记录的哈希，覆盖除ID和Hash之外的全部字段
+func (a *AuditLog) ComputeHash() string {
+	fields := []string{
+		strconv.FormatInt(a.Seq, 10),
+		a.PrevHash,
+		strconv.FormatInt(a.ActorID, 10),
+		strconv.FormatInt(a.CoupleID, 10),
+		a.Action,
+		a.EntityType,
+		a.EntityID,
+		a.Before,
+		a.After,
+		a.RequestID,
+		a.IP,
+		strconv.FormatInt(a.CreatedAt.UnixMicro(), 10),
+	}
+
+	sum := sha256.Sum256([]byte(strings.Join(fields, "\x1f")))
+	return hex.EncodeToString(sum[:])
+}
@@ -4,13 +4,88 @@ import (
 	"time"
 )
 
+// 情侣关系状态
+const (
+	CoupleStatusActive     = "active"     // 正常
+	CoupleStatusDissolving = "dissolving" // 已申请解除，冷静期内可以撤销
+	CoupleStatusDissolved  = "dissolved"  // 已解除，数据只读归档
+)
+
+// 解除情侣关系时共享数据的处理方式
+const (
+	CoupleHandoffArchive = "archive" // 只读归档，双方仍可导出
+	CoupleHandoffCopy    = "copy"    // 归档的同时把照片视频复制到双方的个人空间
+)
+
+// 情侣设置的默认值和可选值
+const (
+	DefaultCoupleTimezone = "Asia/Shanghai"
+	DefaultReminderHour   = 9
+
+	LanguageZhCN          = "zh-CN"
+	LanguageEnUS          = "en-US"
+	DefaultCoupleLanguage = LanguageZhCN
+)
+
 // Couple 情侣关系，包含设置字段
 type Couple struct {
 	Base
 	AutoGenerateVideo     bool      `json:"auto_generate_video" gorm:"not null;default:true"`
 	ReminderNotifications bool      `json:"reminder_notifications" gorm:"not null;default:true"`
-	PairToken             string    `json:"pair_token" gorm:"type:varchar(50);uniqueIndex;not null"`
+	PairToken             string    `json:"-" gorm:"type:varchar(50);uniqueIndex;not null"`
 	AnniversaryDate       time.Time `json:"anniversary_date" gorm:"type:date"`
+	// Timezone IANA时区名，提醒时间和恋爱天数按这个时区计算
+	Timezone string `json:"timezone" gorm:"type:varchar(64);not null;default:'Asia/Shanghai'"`
+	// ReminderHour 每天发送提醒的时刻（0-23，按 Timezone）
+	ReminderHour int `json:"reminder_hour" gorm:"not null;default:9"`
+	// Language 邮件等通知使用的语言
+	Language string `json:"language" gorm:"type:varchar(10);not null;default:'zh-CN'"`
+	// Status 关系状态：active、dissolving、dissolved
+	Status string `json:"status" gorm:"type:varchar(20);not null;default:'active';index"`
+	// DissolveRequestedBy 发起解除的用户
+	DissolveRequestedBy int64 `json:"dissolve_requested_by,string,omitempty" gorm:"not null;default:0"`
+	// DissolveHandoff 解除后共享数据的处理方式：archive 或 copy
+	DissolveHandoff string `json:"dissolve_handoff,omitempty" gorm:"type:varchar(20)"`
+	// DissolveAt 冷静期结束、正式解除的时间
+	DissolveAt *time.Time `json:"dissolve_at,omitempty"`
+	// DissolvedAt 实际解除的时间
+	DissolvedAt *time.Time `json:"dissolved_at,omitempty"`
+	// MediaCopiedAt 选择 copy 方式解除时，照片视频复制到成员个人空间完成的时间。复制完成后才会正式解除
+	MediaCopiedAt *time.Time `json:"media_copied_at,omitempty"`
 	// 关联 - 没有外键约束
 	Users []User `json:"users,omitempty" gorm:"-"`
 }
+
+// IsActive 情侣关系是否正常（包括冷静期内）
+func (c *Couple) IsActive() bool {
+	return c.Status != CoupleStatusDissolved
+}
+
+// Location 情侣设置的时区，未设置或无法识别时使用默认时区
+func (c *Couple) Location() *time.Location {
+	return LoadCoupleLocation(c.Timezone)
+}
+
+// LoadCoupleLocation 加载情侣的时区，未设置或无法识别时使用默认时区
+func LoadCoupleLocation(name string) *time.Location {
+	if name == "" {
+		name = DefaultCoupleTimezone
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return time.FixedZone(DefaultCoupleTimezone, 8*60*60)
+	}
+	return loc
+}
+
+// IsDissolving 是否处于解除冷静期
+func (c *Couple) IsDissolving() bool {
+	return c.Status == CoupleStatusDissolving
+}
+
+// PastCoupleMember 用户曾经所在的已解除情侣关系。重新配对后仍可以导出以前的情侣数据
+type PastCoupleMember struct {
+	UserID      int64     `json:"user_id,string" gorm:"primaryKey"`
+	CoupleID    int64     `json:"couple_id,string" gorm:"primaryKey;index"`
+	DissolvedAt time.Time `json:"dissolved_at" gorm:"not null"`
+}
@@ -0,0 +1,89 @@
+package models
+
+import (
+	"time"
+)
+
+// 纪念日类型
+const (
+	AnniversaryTypeTogether   = "together"   // 在一起
+	AnniversaryTypeFirstMeet  = "first_meet" // 第一次见面
+	AnniversaryTypeEngagement = "engagement" // 订婚
+	AnniversaryTypeWedding    = "wedding"    // 结婚
+	AnniversaryTypeMoveIn     = "move_in"    // 同居
+	AnniversaryTypeCustom     = "custom"     // 自定义
+)
+
+// 纪念日重复方式
+const (
+	AnniversaryRecurrenceNone    = "none"    // 只有一次
+	AnniversaryRecurrenceYearly  = "yearly"  // 每年
+	AnniversaryRecurrenceMonthly = "monthly" // 每月
+)
+
+// CoupleAnniversary 情侣的自定义纪念日
+type CoupleAnniversary struct {
+	Base
+	CoupleID int64     `json:"couple_id,string" gorm:"not null;index"`
+	Title    string    `json:"title" gorm:"type:varchar(100);not null"`
+	Date     time.Time `json:"date" gorm:"type:date;not null"`
+	// Type 纪念日类型，情侣没有设置 AnniversaryDate 时，最早的 together 纪念日作为恋爱天数的起点
+	Type string `json:"type" gorm:"type:varchar(20);not null;default:'custom'"`
+	// Recurrence 重复方式：none、yearly、monthly
+	Recurrence string `json:"recurrence" gorm:"type:varchar(20);not null;default:'yearly'"`
+	// ReminderDaysBefore 提前几天发送提醒，0 表示当天提醒
+	ReminderDaysBefore int `json:"reminder_days_before" gorm:"not null;default:0"`
+}
+
+// NextOccurrence 获取 from 当天或之后最近的一次纪念日（只比较日期），不再重复时返回 false。
+// 纪念日是2月29日或31日这类在某些年份/月份不存在的日子时，取当月最后一天
+func (a *CoupleAnniversary) NextOccurrence(from time.Time) (time.Time, bool) {
+	loc := from.Location()
+	today := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, loc)
+	start := time.Date(a.Date.Year(), a.Date.Month(), a.Date.Day(), 0, 0, 0, 0, loc)
+	if !start.Before(today) {
+		return start, true
+	}
+
+	switch a.Recurrence {
+	case AnniversaryRecurrenceYearly:
+		next := dateInMonth(today.Year(), start.Month(), start.Day(), loc)
+		if next.Before(today) {
+			next = dateInMonth(today.Year()+1, start.Month(), start.Day(), loc)
+		}
+		return next, true
+	case AnniversaryRecurrenceMonthly:
+		next := dateInMonth(today.Year(), today.Month(), start.Day(), loc)
+		if next.Before(today) {
+			next = dateInMonth(today.Year(), today.Month()+1, start.Day(), loc)
+		}
+		return next, true
+	default:
+		return time.Time{}, false
+	}
+}
+
+// dateInMonth 返回指定月份的第 day 天，超过当月天数时取最后一天
+func dateInMonth(year int, month time.Month, day int, loc *time.Location) time.Time {
+	lastDay := time.Date(year, month+1, 0, 0, 0, 0, 0, loc)
+	if day > lastDay.Day() {
+		return lastDay
+	}
+	return time.Date(year, month, day, 0, 0, 0, 0, loc)
+}
+
+// DaysSince 从纪念日到 now 经过的天数（只比较日期），纪念日还没到时返回0
+func (a *CoupleAnniversary) DaysSince(now time.Time) int {
+	return DaysBetween(a.Date, now)
+}
+
+// DaysBetween 两个日期之间相差的天数，各自按所在时区的日期计算，from 晚于 to 时返回0
+func DaysBetween(from, to time.Time) int {
+	start := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.UTC)
+	end := time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, time.UTC)
+	days := int(end.Sub(start).Hours() / 24)
+	if days < 0 {
+		return 0
+	}
+	return days
+}
@@ -0,0 +1,9 @@
+package models
+
+// CoupleFestivalSetting 情侣对单个节日提醒的开关，没有记录时默认开启
+type CoupleFestivalSetting struct {
+	Base
+	CoupleID    int64  `json:"couple_id,string" gorm:"not null;uniqueIndex:idx_couple_festival"`
+	FestivalKey string `json:"festival_key" gorm:"type:varchar(50);not null;uniqueIndex:idx_couple_festival"`
+	Enabled     bool   `json:"enabled" gorm:"not null"`
+}
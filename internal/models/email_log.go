@@ -0,0 +1,39 @@
+package models
+
+import (
+	"time"
+)
+
+// 邮件投递状态
+const (
+	EmailStatusQueued = "queued" // 已放入队列，等待发送
+	EmailStatusSent   = "sent"   // 发送成功
+	EmailStatusFailed = "failed" // 最近一次发送失败，等待重试
+	EmailStatusDead   = "dead"   // 超过最大重试次数，已放入死信队列
+	// EmailStatusDiscarded 死信已被管理员删除，不会再发送
+	EmailStatusDiscarded = "discarded"
+)
+
+// EmailLog 一封邮件的投递记录，队列中的每个任务对应一条，发送过程中更新状态
+type EmailLog struct {
+	Base
+	// TaskID 队列任务ID，与死信队列中的ID一致
+	TaskID string `json:"task_id" gorm:"type:varchar(32);not null;uniqueIndex"`
+	// UserID 收件用户，收件人还不是用户时（如配对邀请）为0
+	UserID    int64  `json:"user_id,string" gorm:"not null;default:0;index"`
+	Type      string `json:"type" gorm:"type:varchar(50);not null;index"`
+	ToAddress string `json:"to_address" gorm:"type:varchar(100);not null;index"`
+	Locale    string `json:"locale" gorm:"type:varchar(10);not null;default:''"`
+	Subject   string `json:"subject" gorm:"type:varchar(500);not null;default:''"`
+	Status    string `json:"status" gorm:"type:varchar(20);not null;index"`
+	// Transport 最近一次发送使用的发送方式
+	Transport string `json:"transport" gorm:"type:varchar(20);not null;default:''"`
+	// ProviderRequestID 发送服务返回的ID：阿里云为 RequestId，SMTP和文件方式为 Message-ID
+	ProviderRequestID string `json:"provider_request_id" gorm:"type:varchar(255);not null;default:''"`
+	// Attempts 累计发送次数，死信重新放回队列后继续累加
+	Attempts      int        `json:"attempts" gorm:"not null;default:0"`
+	LastError     string     `json:"last_error" gorm:"type:text;not null;default:''"`
+	LastAttemptAt *time.Time `json:"last_attempt_at"`
+	SentAt        *time.Time `json:"sent_at"`
+	FailedAt      *time.Time `json:"failed_at"`
+}
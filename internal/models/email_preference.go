@@ -0,0 +1,9 @@
+package models
+
+// EmailPreference 用户对某类可退订邮件的开关，没有记录时默认开启
+type EmailPreference struct {
+	Base
+	UserID    int64  `json:"user_id,string" gorm:"not null;uniqueIndex:idx_user_email_type"`
+	EmailType string `json:"email_type" gorm:"type:varchar(50);not null;uniqueIndex:idx_user_email_type"`
+	Enabled   bool   `json:"enabled" gorm:"not null"`
+}
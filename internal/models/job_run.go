@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+)
+
+// 定时任务执行状态
+const (
+	JobRunRunning   = "running"   // 执行中
+	JobRunSucceeded = "succeeded" // 执行成功
+	JobRunFailed    = "failed"    // 执行失败
+)
+
+// 定时任务触发方式
+const (
+	JobTriggerSchedule = "schedule" // 按调度表触发
+	JobTriggerManual   = "manual"   // 管理员手动触发
+)
+
+// JobRun 定时任务的一次执行记录，只记录真正执行了的那个实例
+type JobRun struct {
+	Base
+	JobName string `json:"job_name" gorm:"type:varchar(100);not null;index:idx_job_run_name_started"`
+	Trigger string `json:"trigger" gorm:"type:varchar(20);not null"`
+	// TriggeredBy 手动触发的管理员ID，按调度表触发时为0
+	TriggeredBy int64      `json:"triggered_by,string,omitempty"`
+	Node        string     `json:"node" gorm:"type:varchar(255);not null"`
+	Status      string     `json:"status" gorm:"type:varchar(20);not null"`
+	StartedAt   time.Time  `json:"started_at" gorm:"not null;index:idx_job_run_name_started"`
+	FinishedAt  *time.Time `json:"finished_at,omitempty"`
+	DurationMs  int64      `json:"duration_ms"`
+	Error       string     `json:"error,omitempty" gorm:"type:text"`
+}
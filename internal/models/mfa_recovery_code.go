@@ -0,0 +1,13 @@
+package models
+
+import (
+	"time"
+)
+
+// MFARecoveryCode 二次验证恢复码，只保存哈希值，每个恢复码只能使用一次
+type MFARecoveryCode struct {
+	Base
+	UserID   int64      `json:"user_id,string" gorm:"not null;index"`
+	CodeHash string     `json:"-" gorm:"type:varchar(64);not null"`
+	UsedAt   *time.Time `json:"used_at"`
+}
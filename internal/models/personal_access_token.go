@@ -0,0 +1,31 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// PersonalAccessToken 个人访问令牌，供脚本和第三方集成调用API，只保存令牌哈希
+type PersonalAccessToken struct {
+	Base
+	UserID int64  `json:"user_id,string" gorm:"not null;index"`
+	Name   string `json:"name" gorm:"type:varchar(100);not null"`
+	// TokenPrefix 令牌开头的几个字符，便于用户在列表中辨认
+	TokenPrefix string `json:"token_prefix" gorm:"type:varchar(16);not null"`
+	TokenHash   string `json:"-" gorm:"type:varchar(64);not null;uniqueIndex"`
+	// Scopes 以空格分隔的权限范围，如 "media:write events:read"
+	Scopes     string     `json:"-" gorm:"type:varchar(500);not null"`
+	ExpiresAt  time.Time  `json:"expires_at" gorm:"not null"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+}
+
+// ScopeList 返回令牌的权限范围列表
+func (t *PersonalAccessToken) ScopeList() []string {
+	return strings.Fields(t.Scopes)
+}
+
+// IsActive 令牌是否未撤销且未过期
+func (t *PersonalAccessToken) IsActive(now time.Time) bool {
+	return t.RevokedAt == nil && now.Before(t.ExpiresAt)
+}
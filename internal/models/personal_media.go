@@ -10,6 +10,8 @@ type PersonalMedia struct {
 	ThumbnailURL *string `json:"thumbnail_url,omitempty" gorm:"type:text"`
 	Description  *string `json:"description,omitempty" gorm:"type:text"`
 	Title        *string `json:"title" gorm:"type:varchar(100)"`
+	// SourcePhotoVideoID 从情侣空间复制过来的媒体对应的原照片视频，重复复制时据此跳过
+	SourcePhotoVideoID int64 `json:"source_photo_video_id,string,omitempty" gorm:"not null;default:0;index"`
 
 	// 关联
 	User User `json:"-" gorm:"-"`
@@ -0,0 +1,74 @@
+package models
+
+import (
+	"time"
+
+	"memoir-api/internal/rrule"
+)
+
+// 提醒对象
+const (
+	ReminderTargetCreator = "creator" // 创建人自己
+	ReminderTargetPartner = "partner" // 另一半
+	ReminderTargetBoth    = "both"    // 两个人
+)
+
+// 提醒方式
+const (
+	ReminderChannelEmail = "email" // 通过邮件队列发送
+)
+
+// Reminder 用户自定义的提醒和倒计时，如续签护照、生日前7天订餐厅、旅行倒计时
+type Reminder struct {
+	Base
+	CoupleID  int64  `json:"couple_id,string" gorm:"not null;index"`
+	CreatedBy int64  `json:"created_by,string" gorm:"not null"`
+	Title     string `json:"title" gorm:"type:varchar(100);not null"`
+	Note      string `json:"note,omitempty" gorm:"type:text"`
+	// Date 第一次（或唯一一次）的日期
+	Date time.Time `json:"date" gorm:"type:date;not null"`
+	// RRule RFC 5545 重复规则，如 FREQ=YEARLY;BYMONTH=5;BYDAY=2SU，为空表示只有一次
+	RRule string `json:"rrule,omitempty" gorm:"type:varchar(255);not null;default:''"`
+	// LeadDays 提前几天提醒，可以有多个，0 表示当天提醒
+	LeadDays []int `json:"lead_days" gorm:"type:jsonb;serializer:json;not null"`
+	// Target 提醒谁：creator、partner、both
+	Target string `json:"target" gorm:"type:varchar(20);not null;default:'both'"`
+	// Channels 提醒方式，为空时只出现在倒计时列表中，不发送通知
+	Channels []string `json:"channels" gorm:"type:jsonb;serializer:json;not null"`
+}
+
+// NextOccurrence 获取 from 当天或之后最近的一次（只比较日期），不再重复时返回 false
+func (r *Reminder) NextOccurrence(from time.Time) (time.Time, bool) {
+	loc := from.Location()
+	today := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, loc)
+	start := time.Date(r.Date.Year(), r.Date.Month(), r.Date.Day(), 0, 0, 0, 0, loc)
+	if r.RRule == "" {
+		if start.Before(today) {
+			return time.Time{}, false
+		}
+		return start, true
+	}
+
+	// 规则在保存时已经校验过，这里解析失败只可能是数据被改坏，按不再重复处理
+	rule, err := rrule.Parse(r.RRule)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return rule.Next(start, today)
+}
+
+// OccursOn 在 day 这一天是否有一次
+func (r *Reminder) OccursOn(day time.Time) bool {
+	next, ok := r.NextOccurrence(day)
+	return ok && DaysBetween(day, next) == 0
+}
+
+// HasChannel 是否通过指定方式提醒
+func (r *Reminder) HasChannel(channel string) bool {
+	for _, c := range r.Channels {
+		if c == channel {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,40 @@
+package models
+
+import (
+	"time"
+)
+
+// 提醒类型
+const (
+	ReminderKindCoupleDays  = "couple_days" // 恋爱天数里程碑
+	ReminderKindAnniversary = "anniversary" // 自定义纪念日
+	ReminderKindFestival    = "festival"    // 节日
+	ReminderKindCustom      = "reminder"    // 用户自定义提醒
+	ReminderKindWishlist    = "wishlist"    // 心愿单提醒日期
+)
+
+// 提醒发送状态
+const (
+	ReminderDeliverySending = "sending" // 已占用，正在发送
+	ReminderDeliverySent    = "sent"    // 已发送
+	ReminderDeliveryFailed  = "failed"  // 发送失败，下次检查时重试
+)
+
+// ReminderDelivery 提醒发送记录。同一情侣、同一提醒、同一次纪念日、同一收件人只有一条记录，
+// 发送前先占用这条记录，保证手动触发、重试或多实例同时执行时每个提醒只发送一次
+type ReminderDelivery struct {
+	Base
+	CoupleID int64  `json:"couple_id,string" gorm:"not null;uniqueIndex:idx_reminder_delivery;index"`
+	Kind     string `json:"kind" gorm:"type:varchar(30);not null;uniqueIndex:idx_reminder_delivery"`
+	// ReminderKey 区分同一类型的不同提醒，如纪念日ID、恋爱天数
+	ReminderKey string `json:"reminder_key" gorm:"type:varchar(100);not null;default:'';uniqueIndex:idx_reminder_delivery"`
+	// OccurrenceDate 提醒对应的那一次纪念日/节日的日期
+	OccurrenceDate time.Time `json:"occurrence_date" gorm:"type:date;not null;uniqueIndex:idx_reminder_delivery"`
+	RecipientID    int64     `json:"recipient_id,string" gorm:"not null;uniqueIndex:idx_reminder_delivery"`
+	// Title 提醒内容摘要，用于展示发送历史
+	Title    string     `json:"title" gorm:"type:varchar(200);not null;default:''"`
+	Status   string     `json:"status" gorm:"type:varchar(20);not null"`
+	Attempts int        `json:"attempts" gorm:"not null;default:0"`
+	Error    string     `json:"error,omitempty" gorm:"type:text"`
+	SentAt   *time.Time `json:"sent_at,omitempty"`
+}
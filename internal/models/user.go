@@ -1,9 +1,17 @@
 package models
 
 import (
+	"time"
+
 	"gorm.io/gorm"
 )
 
+// 用户角色
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
 // User 用户信息，包含深色模式字段
 type User struct {
 	Base
@@ -12,10 +20,43 @@ type User struct {
 	Email        string `json:"email" gorm:"type:varchar(100);not null;index:users_username_key"`
 	PasswordHash string `json:"-" gorm:"type:varchar(255);not null"`
 	DarkMode     bool   `json:"dark_mode" gorm:"not null;default:false"`
+	// EmailVerifiedAt 邮箱验证通过的时间，为空表示尚未验证
+	EmailVerifiedAt *time.Time `json:"email_verified_at"`
+	// MFAEnabled 是否已开启TOTP二次验证
+	MFAEnabled bool `json:"mfa_enabled" gorm:"not null;default:false"`
+	// TOTPSecret TOTP密钥（Base32），绑定确认前也会暂存在这里
+	TOTPSecret string `json:"-" gorm:"type:varchar(64)"`
+	// TOTPLastStep 最近一次验证通过的时间步，防止同一个验证码被重放
+	TOTPLastStep int64 `json:"-" gorm:"not null;default:0"`
+	// Role 用户角色，admin 可以访问运维管理接口
+	Role string `json:"role" gorm:"type:varchar(20);not null;default:'user'"`
+	// DisabledAt 账号被管理员停用的时间，为空表示正常
+	DisabledAt *time.Time `json:"disabled_at"`
+	// PreviousCoupleID 最近一次已解除的情侣关系，用于导出归档数据
+	PreviousCoupleID int64 `json:"previous_couple_id,string,omitempty" gorm:"not null;default:0"`
+	// Language 用户自己的邮件语言，为空时使用情侣设置的语言
+	Language string `json:"language" gorm:"type:varchar(10);not null;default:''"`
+	// PendingPairToken 注册时提交的配对令牌，要求验证邮箱时在验证通过后才加入情侣关系
+	PendingPairToken string `json:"-" gorm:"type:varchar(50);not null;default:''"`
 
 	// 关联已移除
 }
 
+// IsEmailVerified 邮箱是否已验证
+func (u *User) IsEmailVerified() bool {
+	return u.EmailVerifiedAt != nil
+}
+
+// IsAdmin 是否为管理员
+func (u *User) IsAdmin() bool {
+	return u.Role == RoleAdmin
+}
+
+// IsDisabled 账号是否已被停用
+func (u *User) IsDisabled() bool {
+	return u.DisabledAt != nil
+}
+
 // BeforeUpdate GORM 更新用户前的钩子
 func (u *User) BeforeUpdate(tx *gorm.DB) error {
 	// 什么都不做，这样可以防止 GORM 尝试删除不存在的约束
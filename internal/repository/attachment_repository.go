@@ -18,6 +18,10 @@ type AttachmentRepository interface {
 	Repository
 	Create(ctx context.Context, attachment *models.Attachment) error
 	GetByID(ctx context.Context, id int64) (*models.Attachment, error)
+	// GetAccessibleByID 获取用户可以访问的附件，无权访问时返回 ErrAttachmentNotFound
+	GetAccessibleByID(ctx context.Context, userID, coupleID, id int64) (*models.Attachment, error)
+	// FindAccessibleByIDs 批量查询用户可以访问的附件，无权访问的不会返回
+	FindAccessibleByIDs(ctx context.Context, userID, coupleID int64, ids []int64) ([]models.Attachment, error)
 	Query(ctx context.Context, params *dto.AttachmentQueryParams) ([]models.Attachment, int64, error)
 	Update(ctx context.Context, attachment *models.Attachment) error
 	Delete(ctx context.Context, id int64) error
@@ -55,6 +59,39 @@ func (r *attachmentRepository) GetByID(ctx context.Context, id int64) (*models.A
 	return &attachment, nil
 }
 
+// accessibleBy 用户可以访问的附件：本人上传的，或同一情侣情侣空间中的
+func (r *attachmentRepository) accessibleBy(userID, coupleID int64) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if coupleID == 0 {
+			return db.Where("user_id = ?", userID)
+		}
+		return db.Where("user_id = ? OR (space_type = ? AND couple_id = ?)", userID, "couple", coupleID)
+	}
+}
+
+// GetAccessibleByID 获取用户可以访问的附件
+func (r *attachmentRepository) GetAccessibleByID(ctx context.Context, userID, coupleID, id int64) (*models.Attachment, error) {
+	var attachment models.Attachment
+	err := r.DB().WithContext(ctx).Scopes(r.accessibleBy(userID, coupleID)).First(&attachment, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrAttachmentNotFound
+		}
+		return nil, err
+	}
+	return &attachment, nil
+}
+
+// FindAccessibleByIDs 批量查询用户可以访问的附件
+func (r *attachmentRepository) FindAccessibleByIDs(ctx context.Context, userID, coupleID int64, ids []int64) ([]models.Attachment, error) {
+	var attachments []models.Attachment
+	err := r.DB().WithContext(ctx).Scopes(r.accessibleBy(userID, coupleID)).Where("id IN ?", ids).Find(&attachments).Error
+	if err != nil {
+		return nil, err
+	}
+	return attachments, nil
+}
+
 // Query 查询附件（支持分页和筛选）
 func (r *attachmentRepository) Query(ctx context.Context, params *dto.AttachmentQueryParams) ([]models.Attachment, int64, error) {
 	db := r.DB().WithContext(ctx).Model(&models.Attachment{})
@@ -0,0 +1,119 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"memoir-api/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// auditChainLockKey 追加审计日志时使用的事务级咨询锁，保证哈希链按顺序写入
+const auditChainLockKey = 0x617564697400
+
+// AuditLogFilter 审计日志查询条件，零值字段不参与过滤
+type AuditLogFilter struct {
+	ActorID    int64
+	CoupleID   int64
+	Action     string
+	EntityType string
+	EntityID   string
+}
+
+// AuditLogRepository 审计日志仓库接口，只提供追加和查询，不提供修改和删除
+type AuditLogRepository interface {
+	Repository
+	// Append 在哈希链末尾追加一条记录，自动填充 Seq、PrevHash 和 Hash
+	Append(ctx context.Context, entry *models.AuditLog) error
+	// Query 按条件分页查询，按序号倒序
+	Query(ctx context.Context, filter AuditLogFilter, offset, limit int) ([]*models.AuditLog, int64, error)
+	// ListAfterSeq 按序号正序获取指定序号之后的记录，用于校验哈希链
+	ListAfterSeq(ctx context.Context, afterSeq int64, limit int) ([]*models.AuditLog, error)
+}
+
+// auditLogRepository 审计日志仓库实现
+type auditLogRepository struct {
+	*BaseRepository
+}
+
+// NewAuditLogRepository 创建审计日志仓库
+func NewAuditLogRepository(db *gorm.DB) AuditLogRepository {
+	return &auditLogRepository{
+		BaseRepository: NewBaseRepository(db),
+	}
+}
+
+// Append 在哈希链末尾追加一条记录
+func (r *auditLogRepository) Append(ctx context.Context, entry *models.AuditLog) error {
+	return r.WithTx(ctx, func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", auditChainLockKey).Error; err != nil {
+			return err
+		}
+
+		var last models.AuditLog
+		err := tx.Order("seq DESC").Limit(1).Take(&last).Error
+		switch {
+		case err == nil:
+			entry.Seq = last.Seq + 1
+			entry.PrevHash = last.Hash
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			entry.Seq = 1
+			entry.PrevHash = ""
+		default:
+			return err
+		}
+
+		entry.Hash = entry.ComputeHash()
+		return tx.Create(entry).Error
+	})
+}
+
+// Query 按条件分页查询，按序号倒序
+func (r *auditLogRepository) Query(ctx context.Context, filter AuditLogFilter, offset, limit int) ([]*models.AuditLog, int64, error) {
+	db := r.DB().WithContext(ctx).Model(&models.AuditLog{})
+	if filter.ActorID != 0 {
+		db = db.Where("actor_id = ?", filter.ActorID)
+	}
+	if filter.CoupleID != 0 {
+		db = db.Where("couple_id = ?", filter.CoupleID)
+	}
+	if filter.Action != "" {
+		db = db.Where("action = ?", filter.Action)
+	}
+	if filter.EntityType != "" {
+		db = db.Where("entity_type = ?", filter.EntityType)
+	}
+	if filter.EntityID != "" {
+		db = db.Where("entity_id = ?", filter.EntityID)
+	}
+
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var entries []*models.AuditLog
+	query := db.Order("seq DESC")
+	if offset >= 0 && limit > 0 {
+		query = query.Offset(offset).Limit(limit)
+	}
+	if err := query.Find(&entries).Error; err != nil {
+		return nil, 0, err
+	}
+	return entries, total, nil
+}
+
+// ListAfterSeq 按序号正序获取指定序号之后的记录
+func (r *auditLogRepository) ListAfterSeq(ctx context.Context, afterSeq int64, limit int) ([]*models.AuditLog, error) {
+	var entries []*models.AuditLog
+	err := r.DB().WithContext(ctx).
+		Where("seq > ?", afterSeq).
+		Order("seq ASC").
+		Limit(limit).
+		Find(&entries).Error
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
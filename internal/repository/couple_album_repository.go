@@ -12,10 +12,13 @@ type CoupleAlbumRepository interface {
 	Repository
 	Create(ctx context.Context, album *models.CoupleAlbum) error
 	GetByID(ctx context.Context, id int64) (*models.CoupleAlbum, error)
+	// GetByIDInCouple 获取属于指定情侣的相册，不属于该情侣时返回 gorm.ErrRecordNotFound
+	GetByIDInCouple(ctx context.Context, coupleID, id int64) (*models.CoupleAlbum, error)
 	GetByCoupleID(ctx context.Context, coupleID int64) ([]*models.CoupleAlbum, error)
 	Update(ctx context.Context, album *models.CoupleAlbum) error
 	Delete(ctx context.Context, id int64) error
-	GetWithPhotos(ctx context.Context, id int64) (*models.CoupleAlbum, error)
+	// GetWithPhotos 获取属于指定情侣的相册及其照片/视频，不属于该情侣时返回 gorm.ErrRecordNotFound
+	GetWithPhotos(ctx context.Context, coupleID, id int64) (*models.CoupleAlbum, error)
 	CountByCoupleID(ctx context.Context, coupleID int64) (int64, error)
 	PageCoupleMedia(ctx context.Context, coupleID int64, limit, offset int, mediaType string) ([]*models.PhotoVideo, int64, error)
 }
@@ -48,6 +51,16 @@ func (r *coupleAlbumRepository) GetByID(ctx context.Context, id int64) (*models.
 	return &album, nil
 }
 
+// GetByIDInCouple 获取属于指定情侣的相册
+func (r *coupleAlbumRepository) GetByIDInCouple(ctx context.Context, coupleID, id int64) (*models.CoupleAlbum, error) {
+	var album models.CoupleAlbum
+	err := r.DB().WithContext(ctx).Scopes(InCouple(coupleID)).First(&album, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &album, nil
+}
+
 func (r *coupleAlbumRepository) GetByCoupleID(ctx context.Context, coupleID int64) ([]*models.CoupleAlbum, error) {
 	var albums []*models.CoupleAlbum
 	err := r.DB().WithContext(ctx).Where("couple_id = ?", coupleID).Find(&albums).Error
@@ -65,16 +78,16 @@ func (r *coupleAlbumRepository) Delete(ctx context.Context, id int64) error {
 	return r.DB().WithContext(ctx).Delete(&models.CoupleAlbum{}, id).Error
 }
 
-func (r *coupleAlbumRepository) GetWithPhotos(ctx context.Context, id int64) (*models.CoupleAlbum, error) {
+func (r *coupleAlbumRepository) GetWithPhotos(ctx context.Context, coupleID, id int64) (*models.CoupleAlbum, error) {
 	var album models.CoupleAlbum
-	err := r.DB().WithContext(ctx).First(&album, id).Error
+	err := r.DB().WithContext(ctx).Scopes(InCouple(coupleID)).First(&album, id).Error
 	if err != nil {
 		return nil, err
 	}
 
 	// 查询相关的照片和视频
 	var photos []models.PhotoVideo
-	err = r.DB().WithContext(ctx).Where("album_id = ?", id).Find(&photos).Error
+	err = r.DB().WithContext(ctx).Scopes(InCouple(coupleID)).Where("album_id = ?", id).Find(&photos).Error
 	if err != nil {
 		return nil, err
 	}
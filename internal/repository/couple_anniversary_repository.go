@@ -0,0 +1,107 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"memoir-api/internal/models"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrAnniversaryNotFound = errors.New("纪念日不存在")
+)
+
+// CoupleAnniversaryRepository 情侣纪念日仓库接口
+type CoupleAnniversaryRepository interface {
+	Repository
+	Create(ctx context.Context, anniversary *models.CoupleAnniversary) error
+	GetByID(ctx context.Context, id int64) (*models.CoupleAnniversary, error)
+	// GetByIDInCouple 获取属于指定情侣的纪念日，不属于该情侣时返回 ErrAnniversaryNotFound
+	GetByIDInCouple(ctx context.Context, coupleID, id int64) (*models.CoupleAnniversary, error)
+	// ListByCoupleID 获取情侣的所有纪念日，按日期排序
+	ListByCoupleID(ctx context.Context, coupleID int64) ([]*models.CoupleAnniversary, error)
+	Update(ctx context.Context, anniversary *models.CoupleAnniversary) error
+	Delete(ctx context.Context, id int64) error
+}
+
+// coupleAnniversaryRepository 情侣纪念日仓库实现
+type coupleAnniversaryRepository struct {
+	*BaseRepository
+}
+
+// NewCoupleAnniversaryRepository 创建情侣纪念日仓库
+func NewCoupleAnniversaryRepository(db *gorm.DB) CoupleAnniversaryRepository {
+	return &coupleAnniversaryRepository{
+		BaseRepository: NewBaseRepository(db),
+	}
+}
+
+// Create 创建纪念日
+func (r *coupleAnniversaryRepository) Create(ctx context.Context, anniversary *models.CoupleAnniversary) error {
+	return r.DB().WithContext(ctx).Create(anniversary).Error
+}
+
+// GetByID 通过ID获取纪念日
+func (r *coupleAnniversaryRepository) GetByID(ctx context.Context, id int64) (*models.CoupleAnniversary, error) {
+	var anniversary models.CoupleAnniversary
+	err := r.DB().WithContext(ctx).First(&anniversary, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrAnniversaryNotFound
+		}
+		return nil, err
+	}
+	return &anniversary, nil
+}
+
+// GetByIDInCouple 获取属于指定情侣的纪念日
+func (r *coupleAnniversaryRepository) GetByIDInCouple(ctx context.Context, coupleID, id int64) (*models.CoupleAnniversary, error) {
+	var anniversary models.CoupleAnniversary
+	err := r.DB().WithContext(ctx).Scopes(InCouple(coupleID)).First(&anniversary, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrAnniversaryNotFound
+		}
+		return nil, err
+	}
+	return &anniversary, nil
+}
+
+// ListByCoupleID 获取情侣的所有纪念日
+func (r *coupleAnniversaryRepository) ListByCoupleID(ctx context.Context, coupleID int64) ([]*models.CoupleAnniversary, error) {
+	var anniversaries []*models.CoupleAnniversary
+	err := r.DB().WithContext(ctx).
+		Where("couple_id = ?", coupleID).
+		Order("date ASC, created_at ASC").
+		Find(&anniversaries).Error
+	if err != nil {
+		return nil, err
+	}
+	return anniversaries, nil
+}
+
+// Update 更新纪念日
+func (r *coupleAnniversaryRepository) Update(ctx context.Context, anniversary *models.CoupleAnniversary) error {
+	result := r.DB().WithContext(ctx).Save(anniversary)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrAnniversaryNotFound
+	}
+	return nil
+}
+
+// Delete 删除纪念日
+func (r *coupleAnniversaryRepository) Delete(ctx context.Context, id int64) error {
+	result := r.DB().WithContext(ctx).Delete(&models.CoupleAnniversary{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrAnniversaryNotFound
+	}
+	return nil
+}
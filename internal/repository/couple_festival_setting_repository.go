@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"memoir-api/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// CoupleFestivalSettingRepository 情侣节日提醒开关仓库接口
+type CoupleFestivalSettingRepository interface {
+	Repository
+	// ListByCoupleID 获取情侣修改过的节日开关
+	ListByCoupleID(ctx context.Context, coupleID int64) ([]*models.CoupleFestivalSetting, error)
+	// Upsert 设置某个节日的开关
+	Upsert(ctx context.Context, coupleID int64, festivalKey string, enabled bool) error
+}
+
+// coupleFestivalSettingRepository 情侣节日提醒开关仓库实现
+type coupleFestivalSettingRepository struct {
+	*BaseRepository
+}
+
+// NewCoupleFestivalSettingRepository 创建情侣节日提醒开关仓库
+func NewCoupleFestivalSettingRepository(db *gorm.DB) CoupleFestivalSettingRepository {
+	return &coupleFestivalSettingRepository{
+		BaseRepository: NewBaseRepository(db),
+	}
+}
+
+// ListByCoupleID 获取情侣修改过的节日开关
+func (r *coupleFestivalSettingRepository) ListByCoupleID(ctx context.Context, coupleID int64) ([]*models.CoupleFestivalSetting, error) {
+	var settings []*models.CoupleFestivalSetting
+	if err := r.DB().WithContext(ctx).Where("couple_id = ?", coupleID).Find(&settings).Error; err != nil {
+		return nil, err
+	}
+	return settings, nil
+}
+
+// Upsert 设置某个节日的开关
+func (r *coupleFestivalSettingRepository) Upsert(ctx context.Context, coupleID int64, festivalKey string, enabled bool) error {
+	setting := &models.CoupleFestivalSetting{
+		CoupleID:    coupleID,
+		FestivalKey: festivalKey,
+		Enabled:     enabled,
+	}
+	return r.DB().WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "couple_id"}, {Name: "festival_key"}},
+			DoUpdates: clause.Assignments(map[string]interface{}{
+				"enabled":    enabled,
+				"updated_at": time.Now(),
+			}),
+		}).
+		Create(setting).Error
+}
@@ -3,15 +3,18 @@ package repository
 import (
 	"context"
 	"errors"
+	"time"
 
 	"memoir-api/internal/logger"
 	"memoir-api/internal/models"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 var (
-	ErrCoupleNotFound = errors.New("情侣关系不存在")
+	ErrCoupleNotFound       = errors.New("情侣关系不存在")
+	ErrCoupleStatusConflict = errors.New("情侣关系当前状态不允许该操作")
 )
 
 // CoupleRepository 情侣关系仓库接口
@@ -22,7 +25,27 @@ type CoupleRepository interface {
 	GetByPairToken(ctx context.Context, pairToken string) (*models.Couple, error)
 	List(ctx context.Context, offset, limit int) ([]*models.Couple, int64, error)
 	Update(ctx context.Context, couple *models.Couple) error
+	// UpdateSettings 只更新设置字段，不会覆盖状态等由其他流程维护的字段
+	UpdateSettings(ctx context.Context, couple *models.Couple) error
 	Delete(ctx context.Context, id int64) error
+	// MarkDissolving 把正常的情侣关系标记为解除冷静期
+	MarkDissolving(ctx context.Context, id, requestedBy int64, handoff string, dissolveAt time.Time) error
+	// CancelDissolving 撤销冷静期内的解除申请
+	CancelDissolving(ctx context.Context, id int64) error
+	// ListDueDissolutions 获取冷静期已结束的情侣关系
+	ListDueDissolutions(ctx context.Context, now time.Time) ([]*models.Couple, error)
+	// MarkMediaCopied 记录解除前复制媒体已完成
+	MarkMediaCopied(ctx context.Context, id int64, copiedAt time.Time) error
+	// Dissolve 正式解除情侣关系，成员的 couple_id 清零并记录到 previous_couple_id 和 past_couple_members
+	Dissolve(ctx context.Context, id int64, dissolvedAt time.Time) error
+	// ListPastCouples 获取用户曾经所在的已解除情侣关系，最近解除的在前
+	ListPastCouples(ctx context.Context, userID int64) ([]*models.PastCoupleMember, error)
+	// IsPastMember 用户是否曾是已解除情侣关系的成员
+	IsPastMember(ctx context.Context, userID, coupleID int64) (bool, error)
+	// ListReminderTimezones 获取开启了提醒的情侣使用的所有时区
+	ListReminderTimezones(ctx context.Context) ([]string, error)
+	// ListDueForReminder 获取某个时区中开启了提醒、且提醒时刻不晚于 hour 的情侣
+	ListDueForReminder(ctx context.Context, timezone string, hour int) ([]*models.Couple, error)
 }
 
 // coupleRepository 情侣关系仓库实现
@@ -171,3 +194,180 @@ func (r *coupleRepository) Delete(ctx context.Context, id int64) error {
 
 	return nil
 }
+
+// MarkDissolving 把正常的情侣关系标记为解除冷静期
+func (r *coupleRepository) MarkDissolving(ctx context.Context, id, requestedBy int64, handoff string, dissolveAt time.Time) error {
+	result := r.DB().WithContext(ctx).
+		Model(&models.Couple{}).
+		Where("id = ? AND status = ?", id, models.CoupleStatusActive).
+		Updates(map[string]interface{}{
+			"status":                models.CoupleStatusDissolving,
+			"dissolve_requested_by": requestedBy,
+			"dissolve_handoff":      handoff,
+			"dissolve_at":           dissolveAt,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrCoupleStatusConflict
+	}
+	return nil
+}
+
+// UpdateSettings 只更新设置字段
+func (r *coupleRepository) UpdateSettings(ctx context.Context, couple *models.Couple) error {
+	result := r.DB().WithContext(ctx).
+		Model(&models.Couple{}).
+		Where("id = ?", couple.ID).
+		Select("auto_generate_video", "reminder_notifications", "anniversary_date", "timezone", "reminder_hour", "language").
+		Updates(couple)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrCoupleNotFound
+	}
+	return nil
+}
+
+// CancelDissolving 撤销冷静期内的解除申请
+func (r *coupleRepository) CancelDissolving(ctx context.Context, id int64) error {
+	result := r.DB().WithContext(ctx).
+		Model(&models.Couple{}).
+		Where("id = ? AND status = ?", id, models.CoupleStatusDissolving).
+		Updates(map[string]interface{}{
+			"status":                models.CoupleStatusActive,
+			"dissolve_requested_by": 0,
+			"dissolve_handoff":      "",
+			"dissolve_at":           nil,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrCoupleStatusConflict
+	}
+	return nil
+}
+
+// ListDueDissolutions 获取冷静期已结束的情侣关系
+func (r *coupleRepository) ListDueDissolutions(ctx context.Context, now time.Time) ([]*models.Couple, error) {
+	var couples []*models.Couple
+	err := r.DB().WithContext(ctx).
+		Where("status = ? AND dissolve_at <= ?", models.CoupleStatusDissolving, now).
+		Find(&couples).Error
+	if err != nil {
+		return nil, err
+	}
+	return couples, nil
+}
+
+// ListReminderTimezones 获取开启了提醒的情侣使用的所有时区
+func (r *coupleRepository) ListReminderTimezones(ctx context.Context) ([]string, error) {
+	var timezones []string
+	err := r.DB().WithContext(ctx).
+		Model(&models.Couple{}).
+		Where("reminder_notifications = ? AND status <> ?", true, models.CoupleStatusDissolved).
+		Distinct().
+		Pluck("timezone", &timezones).Error
+	if err != nil {
+		return nil, err
+	}
+	return timezones, nil
+}
+
+// ListDueForReminder 获取某个时区中提醒时刻已到的情侣
+func (r *coupleRepository) ListDueForReminder(ctx context.Context, timezone string, hour int) ([]*models.Couple, error) {
+	var couples []*models.Couple
+	err := r.DB().WithContext(ctx).
+		Where("reminder_notifications = ? AND status <> ? AND timezone = ? AND reminder_hour <= ?",
+			true, models.CoupleStatusDissolved, timezone, hour).
+		Find(&couples).Error
+	if err != nil {
+		return nil, err
+	}
+	return couples, nil
+}
+
+// MarkMediaCopied 记录解除前复制媒体已完成
+func (r *coupleRepository) MarkMediaCopied(ctx context.Context, id int64, copiedAt time.Time) error {
+	result := r.DB().WithContext(ctx).
+		Model(&models.Couple{}).
+		Where("id = ?", id).
+		Update("media_copied_at", copiedAt)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrCoupleNotFound
+	}
+	return nil
+}
+
+// Dissolve 正式解除情侣关系
+func (r *coupleRepository) Dissolve(ctx context.Context, id int64, dissolvedAt time.Time) error {
+	return r.WithTx(ctx, func(tx *gorm.DB) error {
+		result := tx.Model(&models.Couple{}).
+			Where("id = ? AND status <> ?", id, models.CoupleStatusDissolved).
+			Updates(map[string]interface{}{
+				"status":       models.CoupleStatusDissolved,
+				"dissolved_at": dissolvedAt,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrCoupleStatusConflict
+		}
+
+		// 记录原成员，重新配对后仍然可以导出这段关系的数据
+		var memberIDs []int64
+		if err := tx.Model(&models.User{}).Where("couple_id = ?", id).Pluck("id", &memberIDs).Error; err != nil {
+			return err
+		}
+		if len(memberIDs) > 0 {
+			pastMembers := make([]models.PastCoupleMember, 0, len(memberIDs))
+			for _, userID := range memberIDs {
+				pastMembers = append(pastMembers, models.PastCoupleMember{UserID: userID, CoupleID: id, DissolvedAt: dissolvedAt})
+			}
+			if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&pastMembers).Error; err != nil {
+				return err
+			}
+		}
+
+		// 用户记录保留，只解除关联，之后可以重新配对
+		return tx.Model(&models.User{}).
+			Where("couple_id = ?", id).
+			Updates(map[string]interface{}{
+				"couple_id":          0,
+				"previous_couple_id": id,
+			}).Error
+	})
+}
+
+// ListPastCouples 获取用户曾经所在的已解除情侣关系，最近解除的在前
+func (r *coupleRepository) ListPastCouples(ctx context.Context, userID int64) ([]*models.PastCoupleMember, error) {
+	var pastCouples []*models.PastCoupleMember
+	err := r.DB().WithContext(ctx).
+		Where("user_id = ?", userID).
+		Order("dissolved_at DESC").
+		Find(&pastCouples).Error
+	if err != nil {
+		return nil, err
+	}
+	return pastCouples, nil
+}
+
+// IsPastMember 用户是否曾是已解除情侣关系的成员
+func (r *coupleRepository) IsPastMember(ctx context.Context, userID, coupleID int64) (bool, error) {
+	var count int64
+	err := r.DB().WithContext(ctx).
+		Model(&models.PastCoupleMember{}).
+		Where("user_id = ? AND couple_id = ?", userID, coupleID).
+		Count(&count).Error
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
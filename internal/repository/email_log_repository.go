@@ -0,0 +1,147 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"memoir-api/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// EmailLogFilter 邮件投递记录查询条件，零值字段不参与过滤
+type EmailLogFilter struct {
+	UserID    int64
+	ToAddress string
+	Type      string
+	Status    string
+	Since     *time.Time
+	Until     *time.Time
+}
+
+// EmailLogRepository 邮件投递记录仓库接口
+type EmailLogRepository interface {
+	Repository
+	// Create 记录一封放入队列的邮件
+	Create(ctx context.Context, log *models.EmailLog) error
+	// MarkSent 记录一次成功的发送
+	MarkSent(ctx context.Context, taskID, transport, providerRequestID string, at time.Time) error
+	// MarkFailed 记录一次失败的发送，status 为 failed（等待重试）或 dead（放入死信队列）
+	MarkFailed(ctx context.Context, taskID, transport, status, errText string, at time.Time) error
+	// MarkQueued 死信或崩溃遗留的任务重新放回队列后恢复为等待发送
+	MarkQueued(ctx context.Context, taskID string) error
+	// MarkDiscarded 死信被删除后标记为已丢弃
+	MarkDiscarded(ctx context.Context, taskIDs []string) error
+	// Query 按条件分页查询，按创建时间倒序
+	Query(ctx context.Context, filter EmailLogFilter, offset, limit int) ([]*models.EmailLog, int64, error)
+}
+
+// emailLogRepository 邮件投递记录仓库实现
+type emailLogRepository struct {
+	*BaseRepository
+}
+
+// NewEmailLogRepository 创建邮件投递记录仓库
+func NewEmailLogRepository(db *gorm.DB) EmailLogRepository {
+	return &emailLogRepository{
+		BaseRepository: NewBaseRepository(db),
+	}
+}
+
+// Create 记录一封放入队列的邮件
+func (r *emailLogRepository) Create(ctx context.Context, log *models.EmailLog) error {
+	return r.DB().WithContext(ctx).Create(log).Error
+}
+
+// MarkSent 记录一次成功的发送，发送次数在数据库中累加
+func (r *emailLogRepository) MarkSent(ctx context.Context, taskID, transport, providerRequestID string, at time.Time) error {
+	return r.DB().WithContext(ctx).
+		Model(&models.EmailLog{}).
+		Where("task_id = ?", taskID).
+		Updates(map[string]interface{}{
+			"status":              models.EmailStatusSent,
+			"transport":           transport,
+			"provider_request_id": providerRequestID,
+			"attempts":            gorm.Expr("attempts + 1"),
+			"last_attempt_at":     at,
+			"sent_at":             at,
+		}).Error
+}
+
+// MarkFailed 记录一次失败的发送
+func (r *emailLogRepository) MarkFailed(ctx context.Context, taskID, transport, status, errText string, at time.Time) error {
+	updates := map[string]interface{}{
+		"status":          status,
+		"transport":       transport,
+		"last_error":      errText,
+		"attempts":        gorm.Expr("attempts + 1"),
+		"last_attempt_at": at,
+	}
+	if status == models.EmailStatusDead {
+		updates["failed_at"] = at
+	}
+	return r.DB().WithContext(ctx).
+		Model(&models.EmailLog{}).
+		Where("task_id = ?", taskID).
+		Updates(updates).Error
+}
+
+// MarkQueued 任务重新放回队列后恢复为等待发送，保留发送次数和最近的错误
+func (r *emailLogRepository) MarkQueued(ctx context.Context, taskID string) error {
+	return r.DB().WithContext(ctx).
+		Model(&models.EmailLog{}).
+		Where("task_id = ?", taskID).
+		Updates(map[string]interface{}{
+			"status":    models.EmailStatusQueued,
+			"failed_at": nil,
+		}).Error
+}
+
+// MarkDiscarded 死信被删除后标记为已丢弃
+func (r *emailLogRepository) MarkDiscarded(ctx context.Context, taskIDs []string) error {
+	if len(taskIDs) == 0 {
+		return nil
+	}
+	return r.DB().WithContext(ctx).
+		Model(&models.EmailLog{}).
+		Where("task_id IN ?", taskIDs).
+		Update("status", models.EmailStatusDiscarded).Error
+}
+
+// Query 按条件分页查询，按创建时间倒序
+func (r *emailLogRepository) Query(ctx context.Context, filter EmailLogFilter, offset, limit int) ([]*models.EmailLog, int64, error) {
+	db := r.DB().WithContext(ctx).Model(&models.EmailLog{})
+	if filter.UserID != 0 {
+		db = db.Where("user_id = ?", filter.UserID)
+	}
+	if filter.ToAddress != "" {
+		db = db.Where("LOWER(to_address) = LOWER(?)", filter.ToAddress)
+	}
+	if filter.Type != "" {
+		db = db.Where("type = ?", filter.Type)
+	}
+	if filter.Status != "" {
+		db = db.Where("status = ?", filter.Status)
+	}
+	if filter.Since != nil {
+		db = db.Where("created_at >= ?", *filter.Since)
+	}
+	if filter.Until != nil {
+		db = db.Where("created_at < ?", *filter.Until)
+	}
+
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var logs []*models.EmailLog
+	query := db.Order("created_at DESC")
+	if offset >= 0 && limit > 0 {
+		query = query.Offset(offset).Limit(limit)
+	}
+	if err := query.Find(&logs).Error; err != nil {
+		return nil, 0, err
+	}
+	return logs, total, nil
+}
@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"memoir-api/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// EmailPreferenceRepository 邮件偏好仓库接口
+type EmailPreferenceRepository interface {
+	Repository
+	// ListByUserID 获取用户修改过的邮件开关
+	ListByUserID(ctx context.Context, userID int64) ([]*models.EmailPreference, error)
+	// Upsert 设置某类邮件的开关
+	Upsert(ctx context.Context, userID int64, emailType string, enabled bool) error
+}
+
+// emailPreferenceRepository 邮件偏好仓库实现
+type emailPreferenceRepository struct {
+	*BaseRepository
+}
+
+// NewEmailPreferenceRepository 创建邮件偏好仓库
+func NewEmailPreferenceRepository(db *gorm.DB) EmailPreferenceRepository {
+	return &emailPreferenceRepository{
+		BaseRepository: NewBaseRepository(db),
+	}
+}
+
+// ListByUserID 获取用户修改过的邮件开关
+func (r *emailPreferenceRepository) ListByUserID(ctx context.Context, userID int64) ([]*models.EmailPreference, error) {
+	var preferences []*models.EmailPreference
+	if err := r.DB().WithContext(ctx).Where("user_id = ?", userID).Find(&preferences).Error; err != nil {
+		return nil, err
+	}
+	return preferences, nil
+}
+
+// Upsert 设置某类邮件的开关
+func (r *emailPreferenceRepository) Upsert(ctx context.Context, userID int64, emailType string, enabled bool) error {
+	preference := &models.EmailPreference{
+		UserID:    userID,
+		EmailType: emailType,
+		Enabled:   enabled,
+	}
+	return r.DB().WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "user_id"}, {Name: "email_type"}},
+			DoUpdates: clause.Assignments(map[string]interface{}{
+				"enabled":    enabled,
+				"updated_at": time.Now(),
+			}),
+		}).
+		Create(preference).Error
+}
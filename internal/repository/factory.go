@@ -18,6 +18,16 @@ type Factory interface {
 	TimelineEventPhotoVideo() TimelineEventPhotoVideoRepository
 	Attachment() AttachmentRepository
 	WishlistAttachment() WishlistAttachmentRepository
+	MFARecoveryCode() MFARecoveryCodeRepository
+	PersonalAccessToken() PersonalAccessTokenRepository
+	AuditLog() AuditLogRepository
+	CoupleAnniversary() CoupleAnniversaryRepository
+	CoupleFestivalSetting() CoupleFestivalSettingRepository
+	ReminderDelivery() ReminderDeliveryRepository
+	JobRun() JobRunRepository
+	Reminder() ReminderRepository
+	EmailLog() EmailLogRepository
+	EmailPreference() EmailPreferenceRepository
 	GetDB() *gorm.DB
 }
 
@@ -36,6 +46,16 @@ type factory struct {
 	timelineEventPhotoVideoRepository TimelineEventPhotoVideoRepository
 	attachmentRepository              AttachmentRepository
 	wishlistAttachmentRepository      WishlistAttachmentRepository
+	mfaRecoveryCodeRepository         MFARecoveryCodeRepository
+	personalAccessTokenRepository     PersonalAccessTokenRepository
+	auditLogRepository                AuditLogRepository
+	coupleAnniversaryRepository       CoupleAnniversaryRepository
+	coupleFestivalSettingRepository   CoupleFestivalSettingRepository
+	reminderDeliveryRepository        ReminderDeliveryRepository
+	jobRunRepository                  JobRunRepository
+	reminderRepository                ReminderRepository
+	emailLogRepository                EmailLogRepository
+	emailPreferenceRepository         EmailPreferenceRepository
 }
 
 func (f *factory) TimelineEventLocation() TimelineEventLocationRepository {
@@ -62,6 +82,16 @@ func NewFactory(db *gorm.DB) Factory {
 		timelineEventPhotoVideoRepository: NewTimelineEventPhotoVideoRepository(db),
 		attachmentRepository:              NewAttachmentRepository(db),
 		wishlistAttachmentRepository:      NewWishlistAttachmentRepository(db),
+		mfaRecoveryCodeRepository:         NewMFARecoveryCodeRepository(db),
+		personalAccessTokenRepository:     NewPersonalAccessTokenRepository(db),
+		auditLogRepository:                NewAuditLogRepository(db),
+		coupleAnniversaryRepository:       NewCoupleAnniversaryRepository(db),
+		coupleFestivalSettingRepository:   NewCoupleFestivalSettingRepository(db),
+		reminderDeliveryRepository:        NewReminderDeliveryRepository(db),
+		jobRunRepository:                  NewJobRunRepository(db),
+		reminderRepository:                NewReminderRepository(db),
+		emailLogRepository:                NewEmailLogRepository(db),
+		emailPreferenceRepository:         NewEmailPreferenceRepository(db),
 	}
 }
 
@@ -115,6 +145,56 @@ func (f *factory) WishlistAttachment() WishlistAttachmentRepository {
 	return f.wishlistAttachmentRepository
 }
 
+// MFARecoveryCode 获取二次验证恢复码仓库
+func (f *factory) MFARecoveryCode() MFARecoveryCodeRepository {
+	return f.mfaRecoveryCodeRepository
+}
+
+// PersonalAccessToken 获取个人访问令牌仓库
+func (f *factory) PersonalAccessToken() PersonalAccessTokenRepository {
+	return f.personalAccessTokenRepository
+}
+
+// AuditLog 获取审计日志仓库
+func (f *factory) AuditLog() AuditLogRepository {
+	return f.auditLogRepository
+}
+
+// CoupleAnniversary 获取情侣纪念日仓库
+func (f *factory) CoupleAnniversary() CoupleAnniversaryRepository {
+	return f.coupleAnniversaryRepository
+}
+
+// CoupleFestivalSetting 获取情侣节日提醒开关仓库
+func (f *factory) CoupleFestivalSetting() CoupleFestivalSettingRepository {
+	return f.coupleFestivalSettingRepository
+}
+
+// ReminderDelivery 获取提醒发送记录仓库
+func (f *factory) ReminderDelivery() ReminderDeliveryRepository {
+	return f.reminderDeliveryRepository
+}
+
+// JobRun 获取定时任务执行记录仓库
+func (f *factory) JobRun() JobRunRepository {
+	return f.jobRunRepository
+}
+
+// Reminder 获取自定义提醒仓库
+func (f *factory) Reminder() ReminderRepository {
+	return f.reminderRepository
+}
+
+// EmailLog 获取邮件投递记录仓库
+func (f *factory) EmailLog() EmailLogRepository {
+	return f.emailLogRepository
+}
+
+// EmailPreference 获取邮件偏好仓库
+func (f *factory) EmailPreference() EmailPreferenceRepository {
+	return f.emailPreferenceRepository
+}
+
 // GetDB 获取数据库连接
 func (f *factory) GetDB() *gorm.DB {
 	return f.db
@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"context"
+
+	"memoir-api/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// JobRunRepository 定时任务执行记录仓库接口
+type JobRunRepository interface {
+	Repository
+	// Create 记录开始执行
+	Create(ctx context.Context, run *models.JobRun) error
+	// Finish 保存执行结果
+	Finish(ctx context.Context, run *models.JobRun) error
+	// ListByJobName 分页获取任务的执行记录，按开始时间倒序
+	ListByJobName(ctx context.Context, jobName string, offset, limit int) ([]*models.JobRun, int64, error)
+	// LatestByJobNames 获取每个任务最近一次执行记录，没有执行过的任务不在结果中
+	LatestByJobNames(ctx context.Context, jobNames []string) (map[string]*models.JobRun, error)
+}
+
+// jobRunRepository 定时任务执行记录仓库实现
+type jobRunRepository struct {
+	*BaseRepository
+}
+
+// NewJobRunRepository 创建定时任务执行记录仓库
+func NewJobRunRepository(db *gorm.DB) JobRunRepository {
+	return &jobRunRepository{
+		BaseRepository: NewBaseRepository(db),
+	}
+}
+
+// Create 记录开始执行
+func (r *jobRunRepository) Create(ctx context.Context, run *models.JobRun) error {
+	return r.DB().WithContext(ctx).Create(run).Error
+}
+
+// Finish 保存执行结果
+func (r *jobRunRepository) Finish(ctx context.Context, run *models.JobRun) error {
+	return r.DB().WithContext(ctx).
+		Model(run).
+		Select("status", "finished_at", "duration_ms", "error").
+		Updates(run).Error
+}
+
+// ListByJobName 分页获取任务的执行记录
+func (r *jobRunRepository) ListByJobName(ctx context.Context, jobName string, offset, limit int) ([]*models.JobRun, int64, error) {
+	db := r.DB().WithContext(ctx).Model(&models.JobRun{}).Where("job_name = ?", jobName)
+
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var runs []*models.JobRun
+	query := db.Order("started_at DESC")
+	if offset >= 0 && limit > 0 {
+		query = query.Offset(offset).Limit(limit)
+	}
+	if err := query.Find(&runs).Error; err != nil {
+		return nil, 0, err
+	}
+	return runs, total, nil
+}
+
+// LatestByJobNames 获取每个任务最近一次执行记录
+func (r *jobRunRepository) LatestByJobNames(ctx context.Context, jobNames []string) (map[string]*models.JobRun, error) {
+	result := make(map[string]*models.JobRun, len(jobNames))
+	if len(jobNames) == 0 {
+		return result, nil
+	}
+
+	var runs []*models.JobRun
+	if err := r.DB().WithContext(ctx).
+		Raw(`SELECT DISTINCT ON (job_name) * FROM job_runs
+			WHERE job_name IN ? AND deleted_at IS NULL
+			ORDER BY job_name, started_at DESC`, jobNames).
+		Scan(&runs).Error; err != nil {
+		return nil, err
+	}
+	for _, run := range runs {
+		result[run.JobName] = run
+	}
+	return result, nil
+}
@@ -23,6 +23,10 @@ type LocationRepository interface {
 	Delete(ctx context.Context, id int64) error
 	FindByID(ctx context.Context, id int64) (*models.Location, error)
 	FindByIDs(ctx context.Context, ids []int64) ([]models.Location, error)
+	// GetByIDInCouple 获取属于指定情侣的地点，不属于该情侣时返回 ErrLocationNotFound
+	GetByIDInCouple(ctx context.Context, coupleID, id int64) (*models.Location, error)
+	// FindByIDsInCouple 批量查询属于指定情侣的地点，其他情侣的地点不会返回
+	FindByIDsInCouple(ctx context.Context, coupleID int64, ids []int64) ([]models.Location, error)
 	FindByCoupleID(ctx context.Context, coupleID int64, offset, limit int) ([]models.Location, int64, error)
 }
 
@@ -75,6 +79,29 @@ func (r *locationRepository) GetByID(ctx context.Context, id int64) (*models.Loc
 	return &location, nil
 }
 
+// GetByIDInCouple 获取属于指定情侣的地点
+func (r *locationRepository) GetByIDInCouple(ctx context.Context, coupleID, id int64) (*models.Location, error) {
+	var location models.Location
+	err := r.DB().WithContext(ctx).Scopes(InCouple(coupleID)).First(&location, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrLocationNotFound
+		}
+		return nil, err
+	}
+	return &location, nil
+}
+
+// FindByIDsInCouple 批量查询属于指定情侣的地点
+func (r *locationRepository) FindByIDsInCouple(ctx context.Context, coupleID int64, ids []int64) ([]models.Location, error) {
+	var locations []models.Location
+	err := r.DB().WithContext(ctx).Scopes(InCouple(coupleID)).Where("id IN ?", ids).Find(&locations).Error
+	if err != nil {
+		return nil, err
+	}
+	return locations, nil
+}
+
 // ListByCoupleID 获取情侣关系下的所有地点
 func (r *locationRepository) ListByCoupleID(ctx context.Context, coupleID int64, offset, limit int) ([]*models.Location, int64, error) {
 	var locations []*models.Location
@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"memoir-api/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// MFARecoveryCodeRepository 二次验证恢复码仓库接口
+type MFARecoveryCodeRepository interface {
+	Repository
+	// ReplaceForUser 用一组新的恢复码哈希替换用户现有的恢复码
+	ReplaceForUser(ctx context.Context, userID int64, codeHashes []string) error
+	// Consume 使用一个未使用过的恢复码，返回是否匹配成功
+	Consume(ctx context.Context, userID int64, codeHash string) (bool, error)
+	// CountUnused 统计用户剩余可用的恢复码数量
+	CountUnused(ctx context.Context, userID int64) (int64, error)
+	// DeleteByUserID 删除用户的全部恢复码
+	DeleteByUserID(ctx context.Context, userID int64) error
+}
+
+// mfaRecoveryCodeRepository 二次验证恢复码仓库实现
+type mfaRecoveryCodeRepository struct {
+	*BaseRepository
+}
+
+// NewMFARecoveryCodeRepository 创建二次验证恢复码仓库
+func NewMFARecoveryCodeRepository(db *gorm.DB) MFARecoveryCodeRepository {
+	return &mfaRecoveryCodeRepository{
+		BaseRepository: NewBaseRepository(db),
+	}
+}
+
+// ReplaceForUser 用一组新的恢复码哈希替换用户现有的恢复码
+func (r *mfaRecoveryCodeRepository) ReplaceForUser(ctx context.Context, userID int64, codeHashes []string) error {
+	return r.DB().WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("user_id = ?", userID).Delete(&models.MFARecoveryCode{}).Error; err != nil {
+			return err
+		}
+
+		codes := make([]models.MFARecoveryCode, 0, len(codeHashes))
+		for _, hash := range codeHashes {
+			codes = append(codes, models.MFARecoveryCode{
+				UserID:   userID,
+				CodeHash: hash,
+			})
+		}
+		if len(codes) == 0 {
+			return nil
+		}
+		return tx.Create(&codes).Error
+	})
+}
+
+// Consume 使用一个未使用过的恢复码，返回是否匹配成功
+func (r *mfaRecoveryCodeRepository) Consume(ctx context.Context, userID int64, codeHash string) (bool, error) {
+	result := r.DB().WithContext(ctx).
+		Model(&models.MFARecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, codeHash).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// CountUnused 统计用户剩余可用的恢复码数量
+func (r *mfaRecoveryCodeRepository) CountUnused(ctx context.Context, userID int64) (int64, error) {
+	var count int64
+	err := r.DB().WithContext(ctx).
+		Model(&models.MFARecoveryCode{}).
+		Where("user_id = ? AND used_at IS NULL", userID).
+		Count(&count).Error
+	return count, err
+}
+
+// DeleteByUserID 删除用户的全部恢复码
+func (r *mfaRecoveryCodeRepository) DeleteByUserID(ctx context.Context, userID int64) error {
+	return r.DB().WithContext(ctx).Unscoped().Where("user_id = ?", userID).Delete(&models.MFARecoveryCode{}).Error
+}
@@ -0,0 +1,114 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"memoir-api/internal/models"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrPersonalAccessTokenNotFound = errors.New("访问令牌不存在")
+)
+
+// PersonalAccessTokenRepository 个人访问令牌仓库接口
+type PersonalAccessTokenRepository interface {
+	Repository
+	Create(ctx context.Context, token *models.PersonalAccessToken) error
+	// FindByHash 根据令牌哈希查询
+	FindByHash(ctx context.Context, tokenHash string) (*models.PersonalAccessToken, error)
+	// ListActiveByUserID 列出用户未撤销的令牌（包括已过期的）
+	ListActiveByUserID(ctx context.Context, userID int64) ([]*models.PersonalAccessToken, error)
+	// CountActiveByUserID 统计用户未撤销且未过期的令牌数量
+	CountActiveByUserID(ctx context.Context, userID int64) (int64, error)
+	// Revoke 撤销用户的指定令牌
+	Revoke(ctx context.Context, userID, id int64) error
+	// RevokeAllByUserID 撤销用户的全部令牌
+	RevokeAllByUserID(ctx context.Context, userID int64) error
+	// TouchLastUsed 更新最近使用时间
+	TouchLastUsed(ctx context.Context, id int64, usedAt time.Time) error
+}
+
+// personalAccessTokenRepository 个人访问令牌仓库实现
+type personalAccessTokenRepository struct {
+	*BaseRepository
+}
+
+// NewPersonalAccessTokenRepository 创建个人访问令牌仓库
+func NewPersonalAccessTokenRepository(db *gorm.DB) PersonalAccessTokenRepository {
+	return &personalAccessTokenRepository{
+		BaseRepository: NewBaseRepository(db),
+	}
+}
+
+// Create 创建访问令牌
+func (r *personalAccessTokenRepository) Create(ctx context.Context, token *models.PersonalAccessToken) error {
+	return r.DB().WithContext(ctx).Create(token).Error
+}
+
+// FindByHash 根据令牌哈希查询
+func (r *personalAccessTokenRepository) FindByHash(ctx context.Context, tokenHash string) (*models.PersonalAccessToken, error) {
+	var token models.PersonalAccessToken
+	err := r.DB().WithContext(ctx).Where("token_hash = ?", tokenHash).First(&token).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrPersonalAccessTokenNotFound
+		}
+		return nil, err
+	}
+	return &token, nil
+}
+
+// ListActiveByUserID 列出用户未撤销的令牌（包括已过期的）
+func (r *personalAccessTokenRepository) ListActiveByUserID(ctx context.Context, userID int64) ([]*models.PersonalAccessToken, error) {
+	var tokens []*models.PersonalAccessToken
+	err := r.DB().WithContext(ctx).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Order("created_at DESC").
+		Find(&tokens).Error
+	return tokens, err
+}
+
+// CountActiveByUserID 统计用户未撤销且未过期的令牌数量
+func (r *personalAccessTokenRepository) CountActiveByUserID(ctx context.Context, userID int64) (int64, error) {
+	var count int64
+	err := r.DB().WithContext(ctx).
+		Model(&models.PersonalAccessToken{}).
+		Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, time.Now()).
+		Count(&count).Error
+	return count, err
+}
+
+// Revoke 撤销用户的指定令牌
+func (r *personalAccessTokenRepository) Revoke(ctx context.Context, userID, id int64) error {
+	result := r.DB().WithContext(ctx).
+		Model(&models.PersonalAccessToken{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", id, userID).
+		Update("revoked_at", time.Now())
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrPersonalAccessTokenNotFound
+	}
+	return nil
+}
+
+// RevokeAllByUserID 撤销用户的全部令牌
+func (r *personalAccessTokenRepository) RevokeAllByUserID(ctx context.Context, userID int64) error {
+	return r.DB().WithContext(ctx).
+		Model(&models.PersonalAccessToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", time.Now()).Error
+}
+
+// TouchLastUsed 更新最近使用时间
+func (r *personalAccessTokenRepository) TouchLastUsed(ctx context.Context, id int64, usedAt time.Time) error {
+	return r.DB().WithContext(ctx).
+		Model(&models.PersonalAccessToken{}).
+		Where("id = ?", id).
+		UpdateColumn("last_used_at", usedAt).Error
+}
@@ -17,6 +17,10 @@ type PersonalMediaRepository interface {
 	FindByUserID(ctx context.Context, userID int64, category string) ([]models.PersonalMedia, error)
 	// 获取单个个人媒体
 	FindByID(ctx context.Context, id int64) (*models.PersonalMedia, error)
+	// FindByIDForUser 获取属于指定用户的个人媒体，不属于该用户时返回 gorm.ErrRecordNotFound
+	FindByIDForUser(ctx context.Context, userID, id int64) (*models.PersonalMedia, error)
+	// ListSourcePhotoVideoIDs 获取用户已从情侣空间复制过的原照片视频ID
+	ListSourcePhotoVideoIDs(ctx context.Context, userID int64) ([]int64, error)
 	// 更新个人媒体
 	Update(ctx context.Context, media *models.PersonalMedia) error
 	// 删除个人媒体
@@ -65,6 +69,29 @@ func (r *GormPersonalMediaRepository) FindByID(ctx context.Context, id int64) (*
 	return &media, nil
 }
 
+// FindByIDForUser 获取属于指定用户的个人媒体
+func (r *GormPersonalMediaRepository) FindByIDForUser(ctx context.Context, userID, id int64) (*models.PersonalMedia, error) {
+	var media models.PersonalMedia
+	err := r.db.WithContext(ctx).Where("id = ? AND user_id = ?", id, userID).First(&media).Error
+	if err != nil {
+		return nil, err
+	}
+	return &media, nil
+}
+
+// ListSourcePhotoVideoIDs 获取用户已从情侣空间复制过的原照片视频ID
+func (r *GormPersonalMediaRepository) ListSourcePhotoVideoIDs(ctx context.Context, userID int64) ([]int64, error) {
+	var ids []int64
+	err := r.db.WithContext(ctx).
+		Model(&models.PersonalMedia{}).
+		Where("user_id = ? AND source_photo_video_id <> 0", userID).
+		Pluck("source_photo_video_id", &ids).Error
+	if err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
 // Update 更新个人媒体
 func (r *GormPersonalMediaRepository) Update(ctx context.Context, media *models.PersonalMedia) error {
 	return r.db.WithContext(ctx).Save(media).Error
@@ -24,7 +24,10 @@ type PhotoVideoRepository interface {
 	Delete(ctx context.Context, id int64) error
 	BatchDelete(ctx context.Context, ids []int64) error
 	FindByIDs(ctx context.Context, ids []int64) ([]models.PhotoVideo, error)
+	// FindByIDsInCouple 批量查询属于指定情侣的照片/视频，其他情侣的不会返回
+	FindByIDsInCouple(ctx context.Context, coupleID int64, ids []int64) ([]models.PhotoVideo, error)
 	CountByCoupleID(ctx context.Context, id int64) (int64, error)
+	ListByCoupleID(ctx context.Context, coupleID int64, offset, limit int) ([]*models.PhotoVideo, int64, error)
 }
 
 // photoVideoRepository 照片和视频仓库实现
@@ -59,6 +62,16 @@ func (r *photoVideoRepository) FindByIDs(ctx context.Context, ids []int64) ([]mo
 	return photoVideos, nil
 }
 
+// FindByIDsInCouple 批量查询属于指定情侣的照片/视频
+func (r *photoVideoRepository) FindByIDsInCouple(ctx context.Context, coupleID int64, ids []int64) ([]models.PhotoVideo, error) {
+	var photoVideos []models.PhotoVideo
+	err := r.DB().WithContext(ctx).Scopes(InCouple(coupleID)).Where("id IN ?", ids).Find(&photoVideos).Error
+	if err != nil {
+		return nil, err
+	}
+	return photoVideos, nil
+}
+
 func (r *photoVideoRepository) Query(ctx context.Context, params *dto.PhotoVideoQueryParams) ([]*models.PhotoVideo, int64, error) {
 	db := r.DB().WithContext(ctx).Model(&models.PhotoVideo{}).Order("created_at DESC")
 	// 构建查询条件
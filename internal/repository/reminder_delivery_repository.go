@@ -0,0 +1,128 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"memoir-api/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ReminderDeliveryRepository 提醒发送记录仓库接口
+type ReminderDeliveryRepository interface {
+	Repository
+	// Claim 占用一条提醒发送记录，返回 false 表示已经发送过或正在由其他实例发送。
+	// 发送失败的记录，以及占用超过 staleAfter 仍未完成的记录可以被重新占用
+	Claim(ctx context.Context, delivery *models.ReminderDelivery, staleAfter time.Duration) (bool, error)
+	// MarkSent 标记为已发送
+	MarkSent(ctx context.Context, id int64, sentAt time.Time) error
+	// MarkFailed 标记为发送失败
+	MarkFailed(ctx context.Context, id int64, reason string) error
+	// ListByCoupleID 分页获取情侣的提醒发送记录，按创建时间倒序
+	ListByCoupleID(ctx context.Context, coupleID int64, offset, limit int) ([]*models.ReminderDelivery, int64, error)
+}
+
+// reminderDeliveryRepository 提醒发送记录仓库实现
+type reminderDeliveryRepository struct {
+	*BaseRepository
+}
+
+// NewReminderDeliveryRepository 创建提醒发送记录仓库
+func NewReminderDeliveryRepository(db *gorm.DB) ReminderDeliveryRepository {
+	return &reminderDeliveryRepository{
+		BaseRepository: NewBaseRepository(db),
+	}
+}
+
+// Claim 占用一条提醒发送记录
+func (r *reminderDeliveryRepository) Claim(ctx context.Context, delivery *models.ReminderDelivery, staleAfter time.Duration) (bool, error) {
+	delivery.Status = models.ReminderDeliverySending
+	delivery.Attempts = 1
+
+	// 唯一索引保证并发插入时只有一个成功
+	result := r.DB().WithContext(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(delivery)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	if result.RowsAffected > 0 {
+		return true, nil
+	}
+
+	// 已有记录时，只有失败或占用超时的记录可以重新占用
+	result = r.byKey(ctx, delivery).
+		Model(&models.ReminderDelivery{}).
+		Where("status = ? OR (status = ? AND updated_at < ?)",
+			models.ReminderDeliveryFailed, models.ReminderDeliverySending, time.Now().Add(-staleAfter)).
+		Updates(map[string]interface{}{
+			"status":     models.ReminderDeliverySending,
+			"attempts":   gorm.Expr("attempts + 1"),
+			"updated_at": time.Now(),
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return false, nil
+	}
+
+	var existing models.ReminderDelivery
+	if err := r.byKey(ctx, delivery).Take(&existing).Error; err != nil {
+		return false, err
+	}
+	*delivery = existing
+	return true, nil
+}
+
+// byKey 按唯一键定位发送记录
+func (r *reminderDeliveryRepository) byKey(ctx context.Context, delivery *models.ReminderDelivery) *gorm.DB {
+	return r.DB().WithContext(ctx).
+		Where("couple_id = ? AND kind = ? AND reminder_key = ? AND occurrence_date = ? AND recipient_id = ?",
+			delivery.CoupleID, delivery.Kind, delivery.ReminderKey, delivery.OccurrenceDate, delivery.RecipientID)
+}
+
+// MarkSent 标记为已发送
+func (r *reminderDeliveryRepository) MarkSent(ctx context.Context, id int64, sentAt time.Time) error {
+	return r.DB().WithContext(ctx).
+		Model(&models.ReminderDelivery{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"status":  models.ReminderDeliverySent,
+			"sent_at": sentAt,
+			"error":   "",
+		}).Error
+}
+
+// MarkFailed 标记为发送失败
+func (r *reminderDeliveryRepository) MarkFailed(ctx context.Context, id int64, reason string) error {
+	return r.DB().WithContext(ctx).
+		Model(&models.ReminderDelivery{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"status": models.ReminderDeliveryFailed,
+			"error":  reason,
+		}).Error
+}
+
+// ListByCoupleID 分页获取情侣的提醒发送记录
+func (r *reminderDeliveryRepository) ListByCoupleID(ctx context.Context, coupleID int64, offset, limit int) ([]*models.ReminderDelivery, int64, error) {
+	db := r.DB().WithContext(ctx).Model(&models.ReminderDelivery{}).Where("couple_id = ?", coupleID)
+
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var deliveries []*models.ReminderDelivery
+	query := db.Order("created_at DESC")
+	if offset >= 0 && limit > 0 {
+		query = query.Offset(offset).Limit(limit)
+	}
+	if err := query.Find(&deliveries).Error; err != nil {
+		return nil, 0, err
+	}
+	return deliveries, total, nil
+}
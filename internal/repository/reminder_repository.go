@@ -0,0 +1,107 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"memoir-api/internal/models"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrReminderNotFound = errors.New("提醒不存在")
+)
+
+// ReminderRepository 自定义提醒仓库接口
+type ReminderRepository interface {
+	Repository
+	Create(ctx context.Context, reminder *models.Reminder) error
+	GetByID(ctx context.Context, id int64) (*models.Reminder, error)
+	// GetByIDInCouple 获取属于指定情侣的提醒，不属于该情侣时返回 ErrReminderNotFound
+	GetByIDInCouple(ctx context.Context, coupleID, id int64) (*models.Reminder, error)
+	// ListByCoupleID 获取情侣的所有提醒，按日期排序
+	ListByCoupleID(ctx context.Context, coupleID int64) ([]*models.Reminder, error)
+	Update(ctx context.Context, reminder *models.Reminder) error
+	Delete(ctx context.Context, id int64) error
+}
+
+// reminderRepository 自定义提醒仓库实现
+type reminderRepository struct {
+	*BaseRepository
+}
+
+// NewReminderRepository 创建自定义提醒仓库
+func NewReminderRepository(db *gorm.DB) ReminderRepository {
+	return &reminderRepository{
+		BaseRepository: NewBaseRepository(db),
+	}
+}
+
+// Create 创建提醒
+func (r *reminderRepository) Create(ctx context.Context, reminder *models.Reminder) error {
+	return r.DB().WithContext(ctx).Create(reminder).Error
+}
+
+// GetByID 通过ID获取提醒
+func (r *reminderRepository) GetByID(ctx context.Context, id int64) (*models.Reminder, error) {
+	var reminder models.Reminder
+	err := r.DB().WithContext(ctx).First(&reminder, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrReminderNotFound
+		}
+		return nil, err
+	}
+	return &reminder, nil
+}
+
+// GetByIDInCouple 获取属于指定情侣的提醒
+func (r *reminderRepository) GetByIDInCouple(ctx context.Context, coupleID, id int64) (*models.Reminder, error) {
+	var reminder models.Reminder
+	err := r.DB().WithContext(ctx).Scopes(InCouple(coupleID)).First(&reminder, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrReminderNotFound
+		}
+		return nil, err
+	}
+	return &reminder, nil
+}
+
+// ListByCoupleID 获取情侣的所有提醒
+func (r *reminderRepository) ListByCoupleID(ctx context.Context, coupleID int64) ([]*models.Reminder, error) {
+	var reminders []*models.Reminder
+	err := r.DB().WithContext(ctx).
+		Where("couple_id = ?", coupleID).
+		Order("date ASC, created_at ASC").
+		Find(&reminders).Error
+	if err != nil {
+		return nil, err
+	}
+	return reminders, nil
+}
+
+// Update 更新提醒
+func (r *reminderRepository) Update(ctx context.Context, reminder *models.Reminder) error {
+	result := r.DB().WithContext(ctx).Save(reminder)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrReminderNotFound
+	}
+	return nil
+}
+
+// Delete 删除提醒
+func (r *reminderRepository) Delete(ctx context.Context, id int64) error {
+	result := r.DB().WithContext(ctx).Delete(&models.Reminder{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrReminderNotFound
+	}
+	return nil
+}
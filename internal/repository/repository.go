@@ -26,6 +26,17 @@ func (r *BaseRepository) DB() *gorm.DB {
 	return r.db
 }
 
+// InCouple 只匹配属于指定情侣的记录。情侣ID为0表示用户没有情侣关系，此时不匹配任何记录。
+// 按ID读取情侣数据的方法都要带上它，归属校验在查询条件中完成，不依赖调用方另外检查
+func InCouple(coupleID int64) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if coupleID == 0 {
+			return db.Where("1 = 0")
+		}
+		return db.Where("couple_id = ?", coupleID)
+	}
+}
+
 // WithTx 使用事务执行操作
 func (r *BaseRepository) WithTx(ctx context.Context, fn func(tx *gorm.DB) error) error {
 	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
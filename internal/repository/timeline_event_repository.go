@@ -18,6 +18,8 @@ type TimelineEventRepository interface {
 	Repository
 	Create(ctx context.Context, event *models.TimelineEvent) error
 	FindByID(ctx context.Context, id int64) (*models.TimelineEvent, error)
+	// FindByIDInCouple 查询属于指定情侣的时间轴事件，不属于该情侣时返回 ErrTimelineEventNotFound
+	FindByIDInCouple(ctx context.Context, coupleID, id int64) (*models.TimelineEvent, error)
 	FindWithPagination(ctx context.Context, conditions map[string]interface{}, offset, limit int) ([]models.TimelineEvent, int64, error)
 	Update(ctx context.Context, event *models.TimelineEvent) error
 	Delete(ctx context.Context, id int64) error
@@ -67,6 +69,19 @@ func (r *timelineEventRepository) FindByID(ctx context.Context, id int64) (*mode
 	return &event, nil
 }
 
+// FindByIDInCouple 查询属于指定情侣的时间轴事件
+func (r *timelineEventRepository) FindByIDInCouple(ctx context.Context, coupleID, id int64) (*models.TimelineEvent, error) {
+	var event models.TimelineEvent
+	err := r.DB().WithContext(ctx).Scopes(InCouple(coupleID)).First(&event, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTimelineEventNotFound
+		}
+		return nil, err
+	}
+	return &event, nil
+}
+
 // FindWithPagination 分页查询时间轴事件
 func (r *timelineEventRepository) FindWithPagination(ctx context.Context, conditions map[string]interface{}, offset, limit int) ([]models.TimelineEvent, int64, error) {
 	var events []models.TimelineEvent
@@ -3,16 +3,24 @@ package repository
 import (
 	"context"
 	"errors"
+	"time"
 
 	"memoir-api/internal/models"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 var (
-	ErrUserNotFound = errors.New("用户不存在")
+	ErrUserNotFound      = errors.New("用户不存在")
+	ErrUserAlreadyPaired = errors.New("用户已有情侣关系")
+	ErrCoupleFull        = errors.New("情侣关系成员已满")
+	ErrNoUpdateColumns   = errors.New("未指定要更新的字段")
 )
 
+// MaxCoupleMembers 一个情侣关系最多的成员数
+const MaxCoupleMembers = 2
+
 // UserRepository 用户仓库接口
 type UserRepository interface {
 	Repository
@@ -21,7 +29,18 @@ type UserRepository interface {
 	GetByEmail(ctx context.Context, email string) (*models.User, error)
 	GetByUsername(ctx context.Context, username string) (*models.User, error)
 	ListByCoupleID(ctx context.Context, coupleID int64) ([]*models.User, error)
-	Update(ctx context.Context, user *models.User) error
+	// ListPastMembers 获取已解除情侣关系的原成员
+	ListPastMembers(ctx context.Context, coupleID int64) ([]*models.User, error)
+	List(ctx context.Context, offset, limit int) ([]*models.User, int64, error)
+	// Update 只保存 columns 中列出的字段，其余字段可能被其他请求并发修改，不能用读到的旧值覆盖
+	Update(ctx context.Context, user *models.User, columns ...string) error
+	AdvanceTOTPStep(ctx context.Context, userID, step int64) (bool, error)
+	SetDisabledAt(ctx context.Context, userID int64, disabledAt *time.Time) error
+	SetRole(ctx context.Context, userID int64, role string) error
+	// JoinCouple 把未配对的用户加入情侣关系，成员已满或用户已配对时返回错误
+	JoinCouple(ctx context.Context, userID, coupleID int64) error
+	// CreateCoupleAndJoin 创建情侣关系并把未配对的用户加入，用户已配对时返回 ErrUserAlreadyPaired 且不会留下空的情侣关系
+	CreateCoupleAndJoin(ctx context.Context, userID int64, couple *models.Couple) error
 	Delete(ctx context.Context, id int64) error
 }
 
@@ -91,9 +110,88 @@ func (r *userRepository) ListByCoupleID(ctx context.Context, coupleID int64) ([]
 	return users, nil
 }
 
+// ListPastMembers 获取已解除情侣关系的原成员
+func (r *userRepository) ListPastMembers(ctx context.Context, coupleID int64) ([]*models.User, error) {
+	var users []*models.User
+	err := r.DB().WithContext(ctx).
+		Where("id IN (?)", r.DB().Model(&models.PastCoupleMember{}).Select("user_id").Where("couple_id = ?", coupleID)).
+		Find(&users).Error
+	if err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+// List 分页获取用户列表，按注册时间倒序
+func (r *userRepository) List(ctx context.Context, offset, limit int) ([]*models.User, int64, error) {
+	var users []*models.User
+	var total int64
+
+	if err := r.DB().WithContext(ctx).Model(&models.User{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	query := r.DB().WithContext(ctx).Order("created_at DESC")
+	if offset >= 0 && limit > 0 {
+		query = query.Offset(offset).Limit(limit)
+	}
+	if err := query.Find(&users).Error; err != nil {
+		return nil, 0, err
+	}
+	return users, total, nil
+}
+
 // Update 更新用户
-func (r *userRepository) Update(ctx context.Context, user *models.User) error {
-	result := r.DB().WithContext(ctx).Save(user)
+func (r *userRepository) Update(ctx context.Context, user *models.User, columns ...string) error {
+	if len(columns) == 0 {
+		return ErrNoUpdateColumns
+	}
+	result := r.DB().WithContext(ctx).
+		Model(user).
+		Select(append(columns, "updated_at")).
+		Updates(user)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+// AdvanceTOTPStep 记录已使用的TOTP时间步，时间步没有前进时返回false（验证码被重放）
+func (r *userRepository) AdvanceTOTPStep(ctx context.Context, userID, step int64) (bool, error) {
+	result := r.DB().WithContext(ctx).
+		Model(&models.User{}).
+		Where("id = ? AND totp_last_step < ?", userID, step).
+		Update("totp_last_step", step)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// SetDisabledAt 设置账号停用时间，传入nil表示恢复启用
+func (r *userRepository) SetDisabledAt(ctx context.Context, userID int64, disabledAt *time.Time) error {
+	result := r.DB().WithContext(ctx).
+		Model(&models.User{}).
+		Where("id = ?", userID).
+		Update("disabled_at", disabledAt)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+// SetRole 设置用户角色
+func (r *userRepository) SetRole(ctx context.Context, userID int64, role string) error {
+	result := r.DB().WithContext(ctx).
+		Model(&models.User{}).
+		Where("id = ?", userID).
+		Update("role", role)
 	if result.Error != nil {
 		return result.Error
 	}
@@ -103,6 +201,64 @@ func (r *userRepository) Update(ctx context.Context, user *models.User) error {
 	return nil
 }
 
+// JoinCouple 把未配对的用户加入情侣关系
+func (r *userRepository) JoinCouple(ctx context.Context, userID, coupleID int64) error {
+	return r.WithTx(ctx, func(tx *gorm.DB) error {
+		// 锁住情侣记录，避免两个人同时加入导致成员超过上限
+		var couple models.Couple
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&couple, coupleID).Error
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrCoupleNotFound
+			}
+			return err
+		}
+
+		if couple.Status != models.CoupleStatusActive {
+			return ErrCoupleStatusConflict
+		}
+
+		var members int64
+		if err := tx.Model(&models.User{}).Where("couple_id = ?", coupleID).Count(&members).Error; err != nil {
+			return err
+		}
+		if members >= MaxCoupleMembers {
+			return ErrCoupleFull
+		}
+
+		result := tx.Model(&models.User{}).
+			Where("id = ? AND (couple_id = 0 OR couple_id IS NULL)", userID).
+			Update("couple_id", coupleID)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrUserAlreadyPaired
+		}
+		return nil
+	})
+}
+
+// CreateCoupleAndJoin 在同一个事务中创建情侣关系并加入，加入失败时情侣记录随事务回滚
+func (r *userRepository) CreateCoupleAndJoin(ctx context.Context, userID int64, couple *models.Couple) error {
+	return r.WithTx(ctx, func(tx *gorm.DB) error {
+		if err := tx.Create(couple).Error; err != nil {
+			return err
+		}
+
+		result := tx.Model(&models.User{}).
+			Where("id = ? AND (couple_id = 0 OR couple_id IS NULL)", userID).
+			Update("couple_id", couple.ID)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrUserAlreadyPaired
+		}
+		return nil
+	})
+}
+
 // Delete 删除用户
 func (r *userRepository) Delete(ctx context.Context, id int64) error {
 	result := r.DB().WithContext(ctx).Delete(&models.User{}, id)
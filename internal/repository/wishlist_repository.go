@@ -19,10 +19,13 @@ type WishlistRepository interface {
 	Repository
 	Create(ctx context.Context, wishlist *models.Wishlist) error
 	GetByID(ctx context.Context, id int64) (*models.Wishlist, error)
+	// GetByIDInCouple 获取属于指定情侣的心愿，不属于该情侣时返回 ErrWishlistNotFound
+	GetByIDInCouple(ctx context.Context, coupleID, id int64) (*models.Wishlist, error)
 	ListByCoupleID(ctx context.Context, coupleID int64) ([]*models.Wishlist, error)
 	ListByStatus(ctx context.Context, coupleID int64, status string) ([]*models.Wishlist, error)
 	ListByPriority(ctx context.Context, coupleID int64, priority int) ([]*models.Wishlist, error)
-	ListUpcomingReminders(ctx context.Context, daysAhead int) ([]*models.Wishlist, error)
+	// ListUpcomingReminders 获取情侣未完成、且提醒日期在 from 当天到之后 daysAhead 天之间的心愿，from 只使用日期
+	ListUpcomingReminders(ctx context.Context, coupleID int64, from time.Time, daysAhead int) ([]*models.Wishlist, error)
 	Update(ctx context.Context, wishlist *models.Wishlist) error
 	UpdateStatus(ctx context.Context, id int64, status string) error
 	Delete(ctx context.Context, id int64) error
@@ -59,6 +62,19 @@ func (r *wishlistRepository) GetByID(ctx context.Context, id int64) (*models.Wis
 	return &wishlist, nil
 }
 
+// GetByIDInCouple 获取属于指定情侣的心愿
+func (r *wishlistRepository) GetByIDInCouple(ctx context.Context, coupleID, id int64) (*models.Wishlist, error) {
+	var wishlist models.Wishlist
+	err := r.DB().WithContext(ctx).Scopes(InCouple(coupleID)).First(&wishlist, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrWishlistNotFound
+		}
+		return nil, err
+	}
+	return &wishlist, nil
+}
+
 // ListByCoupleID 获取情侣关系下的所有心愿，按优先级和创建时间排序
 func (r *wishlistRepository) ListByCoupleID(ctx context.Context, coupleID int64) ([]*models.Wishlist, error) {
 	var wishlists []*models.Wishlist
@@ -108,13 +124,14 @@ func (r *wishlistRepository) ListByPriority(ctx context.Context, coupleID int64,
 }
 
 // ListUpcomingReminders 获取即将到期的提醒
-func (r *wishlistRepository) ListUpcomingReminders(ctx context.Context, daysAhead int) ([]*models.Wishlist, error) {
+func (r *wishlistRepository) ListUpcomingReminders(ctx context.Context, coupleID int64, from time.Time, daysAhead int) ([]*models.Wishlist, error) {
 	var wishlists []*models.Wishlist
-	today := time.Now().Truncate(24 * time.Hour)
-	future := today.AddDate(0, 0, daysAhead)
+	// 按日期字符串比较，不受数据库会话时区影响
+	today := from.Format("2006-01-02")
+	future := from.AddDate(0, 0, daysAhead).Format("2006-01-02")
 
 	err := r.DB().WithContext(ctx).
-		Where("reminder_date IS NOT NULL AND reminder_date BETWEEN ? AND ? AND status != ?", today, future, "completed").
+		Where("couple_id = ? AND reminder_date IS NOT NULL AND reminder_date BETWEEN ? AND ? AND status != ?", coupleID, today, future, "completed").
 		Order("reminder_date ASC").
 		Find(&wishlists).Error
 
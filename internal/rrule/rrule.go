@@ -0,0 +1,381 @@
+// Package rrule RFC 5545 重复规则（RRULE）的子集，只按日期计算，不处理时分秒。
+// 支持 FREQ（DAILY/WEEKLY/MONTHLY/YEARLY）、INTERVAL、COUNT、UNTIL、BYMONTH、BYMONTHDAY 和 BYDAY，
+// BYDAY 在 MONTHLY 和带 BYMONTH 的 YEARLY 规则中可以带序号，如 2SU 表示第二个周日、-1FR 表示最后一个周五
+package rrule
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Frequency 重复频率
+type Frequency string
+
+const (
+	Daily   Frequency = "DAILY"
+	Weekly  Frequency = "WEEKLY"
+	Monthly Frequency = "MONTHLY"
+	Yearly  Frequency = "YEARLY"
+)
+
+// maxPeriods 查找下一次时最多检查的周期数，避免规则永远匹配不到时死循环
+const maxPeriods = 10000
+
+var (
+	ErrInvalidRule = errors.New("无效的重复规则")
+)
+
+var weekdayCodes = map[string]time.Weekday{
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+	"SU": time.Sunday,
+}
+
+// WeekdayNum BYDAY 中的一项，Ordinal 为0表示每个该星期几
+type WeekdayNum struct {
+	Ordinal int
+	Weekday time.Weekday
+}
+
+// Rule 解析后的重复规则
+type Rule struct {
+	Freq     Frequency
+	Interval int
+	// Count 总共重复几次，0 表示不限
+	Count int
+	// Until 最后一次不晚于这一天，零值表示不限
+	Until      time.Time
+	ByMonth    []time.Month
+	ByMonthDay []int
+	ByDay      []WeekdayNum
+}
+
+// Parse 解析重复规则，可以带 "RRULE:" 前缀
+func Parse(s string) (*Rule, error) {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "RRULE:")
+	if s == "" {
+		return nil, fmt.Errorf("%w: 规则为空", ErrInvalidRule)
+	}
+
+	rule := &Rule{Interval: 1}
+	for _, part := range strings.Split(s, ";") {
+		key, value, ok := strings.Cut(part, "=")
+		if !ok || value == "" {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidRule, part)
+		}
+		var err error
+		switch strings.ToUpper(key) {
+		case "FREQ":
+			rule.Freq = Frequency(strings.ToUpper(value))
+			switch rule.Freq {
+			case Daily, Weekly, Monthly, Yearly:
+			default:
+				err = fmt.Errorf("不支持的频率 %s", value)
+			}
+		case "INTERVAL":
+			rule.Interval, err = parsePositive(value)
+		case "COUNT":
+			rule.Count, err = parsePositive(value)
+		case "UNTIL":
+			rule.Until, err = parseUntil(value)
+		case "BYMONTH":
+			rule.ByMonth, err = parseByMonth(value)
+		case "BYMONTHDAY":
+			rule.ByMonthDay, err = parseByMonthDay(value)
+		case "BYDAY":
+			rule.ByDay, err = parseByDay(value)
+		case "WKST":
+			// 只支持默认的周一开始
+			if strings.ToUpper(value) != "MO" {
+				err = fmt.Errorf("只支持 WKST=MO")
+			}
+		default:
+			err = fmt.Errorf("不支持的规则 %s", key)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidRule, err)
+		}
+	}
+
+	if rule.Freq == "" {
+		return nil, fmt.Errorf("%w: 缺少 FREQ", ErrInvalidRule)
+	}
+	if rule.Count > 0 && !rule.Until.IsZero() {
+		return nil, fmt.Errorf("%w: COUNT 和 UNTIL 不能同时使用", ErrInvalidRule)
+	}
+	if rule.Freq == Yearly && len(rule.ByDay) > 0 && len(rule.ByMonth) == 0 {
+		return nil, fmt.Errorf("%w: YEARLY 规则使用 BYDAY 时需要指定 BYMONTH", ErrInvalidRule)
+	}
+	for _, day := range rule.ByDay {
+		if day.Ordinal != 0 && rule.Freq != Monthly && rule.Freq != Yearly {
+			return nil, fmt.Errorf("%w: 带序号的 BYDAY 只能用于 MONTHLY 或 YEARLY", ErrInvalidRule)
+		}
+	}
+	return rule, nil
+}
+
+func parsePositive(value string) (int, error) {
+	n, err := strconv.Atoi(value)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("%s 不是正整数", value)
+	}
+	return n, nil
+}
+
+// parseUntil 支持 20250101 和 20250101T000000Z 两种格式，只使用日期
+func parseUntil(value string) (time.Time, error) {
+	if len(value) < 8 {
+		return time.Time{}, fmt.Errorf("无效的 UNTIL %s", value)
+	}
+	date, err := time.Parse("20060102", value[:8])
+	if err != nil {
+		return time.Time{}, fmt.Errorf("无效的 UNTIL %s", value)
+	}
+	return date, nil
+}
+
+func parseByMonth(value string) ([]time.Month, error) {
+	var months []time.Month
+	for _, item := range strings.Split(value, ",") {
+		n, err := strconv.Atoi(item)
+		if err != nil || n < 1 || n > 12 {
+			return nil, fmt.Errorf("无效的 BYMONTH %s", item)
+		}
+		months = append(months, time.Month(n))
+	}
+	return months, nil
+}
+
+func parseByMonthDay(value string) ([]int, error) {
+	var days []int
+	for _, item := range strings.Split(value, ",") {
+		n, err := strconv.Atoi(item)
+		if err != nil || n == 0 || n < -31 || n > 31 {
+			return nil, fmt.Errorf("无效的 BYMONTHDAY %s", item)
+		}
+		days = append(days, n)
+	}
+	return days, nil
+}
+
+func parseByDay(value string) ([]WeekdayNum, error) {
+	var days []WeekdayNum
+	for _, item := range strings.Split(strings.ToUpper(value), ",") {
+		if len(item) < 2 {
+			return nil, fmt.Errorf("无效的 BYDAY %s", item)
+		}
+		weekday, ok := weekdayCodes[item[len(item)-2:]]
+		if !ok {
+			return nil, fmt.Errorf("无效的 BYDAY %s", item)
+		}
+		day := WeekdayNum{Weekday: weekday}
+		if prefix := item[:len(item)-2]; prefix != "" {
+			n, err := strconv.Atoi(prefix)
+			if err != nil || n == 0 || n < -5 || n > 5 {
+				return nil, fmt.Errorf("无效的 BYDAY %s", item)
+			}
+			day.Ordinal = n
+		}
+		days = append(days, day)
+	}
+	return days, nil
+}
+
+// Next 以 start 为第一次的重复序列中，from 当天或之后最近的一次（只比较日期），
+// 返回 from 所在时区的零点；序列已经结束时返回 false。start 本身不符合规则时不算一次
+func (r *Rule) Next(start, from time.Time) (time.Time, bool) {
+	loc := from.Location()
+	first := dateOf(start)
+	target := dateOf(from)
+
+	// 没有 COUNT 时不需要数之前的次数，直接从 from 附近的周期开始找
+	period := 0
+	if r.Count == 0 {
+		period = r.periodsBefore(first, target)
+	}
+
+	count := 0
+	for end := period + maxPeriods; period < end; period++ {
+		for _, date := range r.candidates(first, period) {
+			if date.Before(first) {
+				continue
+			}
+			if !r.Until.IsZero() && date.After(r.Until) {
+				return time.Time{}, false
+			}
+			count++
+			if r.Count > 0 && count > r.Count {
+				return time.Time{}, false
+			}
+			if !date.Before(target) {
+				return time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, loc), true
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+// periodsBefore target 之前至少已经完整经过的周期数
+func (r *Rule) periodsBefore(first, target time.Time) int {
+	if !target.After(first) {
+		return 0
+	}
+	var elapsed int
+	switch r.Freq {
+	case Daily:
+		elapsed = int(target.Sub(first).Hours() / 24)
+	case Weekly:
+		elapsed = int(target.Sub(first).Hours()/24) / 7
+	case Monthly:
+		elapsed = (target.Year()-first.Year())*12 + int(target.Month()-first.Month())
+	case Yearly:
+		elapsed = target.Year() - first.Year()
+	}
+	// 退一个周期，避免漏掉跨周期边界的日期
+	periods := elapsed/r.Interval - 1
+	if periods < 0 {
+		return 0
+	}
+	return periods
+}
+
+// candidates 第 period 个周期内符合规则的日期，按日期排序
+func (r *Rule) candidates(first time.Time, period int) []time.Time {
+	step := period * r.Interval
+	var dates []time.Time
+
+	switch r.Freq {
+	case Daily:
+		date := first.AddDate(0, 0, step)
+		if r.matchMonth(date) && r.matchMonthDay(date) && r.matchWeekday(date) {
+			dates = append(dates, date)
+		}
+	case Weekly:
+		// 每周从周一开始
+		monday := first.AddDate(0, 0, -((int(first.Weekday())+6)%7)+7*step)
+		for i := 0; i < 7; i++ {
+			date := monday.AddDate(0, 0, i)
+			if r.matchMonth(date) && r.matchMonthDay(date) && r.matchWeekdayDefault(date, first) {
+				dates = append(dates, date)
+			}
+		}
+	case Monthly:
+		month := time.Date(first.Year(), first.Month()+time.Month(step), 1, 0, 0, 0, 0, time.UTC)
+		if r.matchMonth(month) {
+			dates = r.daysInMonth(month, first)
+		}
+	case Yearly:
+		year := first.Year() + step
+		months := r.ByMonth
+		if len(months) == 0 {
+			months = []time.Month{first.Month()}
+		}
+		for _, m := range months {
+			dates = append(dates, r.daysInMonth(time.Date(year, m, 1, 0, 0, 0, 0, time.UTC), first)...)
+		}
+	}
+
+	sort.Slice(dates, func(i, j int) bool { return dates[i].Before(dates[j]) })
+	return dates
+}
+
+// daysInMonth 某个月中符合 BYMONTHDAY/BYDAY 的日期，两者都没有时使用 first 的日；
+// 当月没有这一天（如2月30日）时跳过，与 RFC 5545 一致
+func (r *Rule) daysInMonth(month, first time.Time) []time.Time {
+	lastDay := month.AddDate(0, 1, -1).Day()
+	var dates []time.Time
+
+	if len(r.ByMonthDay) == 0 && len(r.ByDay) == 0 {
+		if first.Day() <= lastDay {
+			dates = append(dates, month.AddDate(0, 0, first.Day()-1))
+		}
+		return dates
+	}
+
+	for day := 1; day <= lastDay; day++ {
+		date := month.AddDate(0, 0, day-1)
+		if r.matchMonthDay(date) && r.matchOrdinalWeekday(date, lastDay) {
+			dates = append(dates, date)
+		}
+	}
+	return dates
+}
+
+func (r *Rule) matchMonth(date time.Time) bool {
+	if len(r.ByMonth) == 0 {
+		return true
+	}
+	for _, m := range r.ByMonth {
+		if date.Month() == m {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Rule) matchMonthDay(date time.Time) bool {
+	if len(r.ByMonthDay) == 0 {
+		return true
+	}
+	lastDay := time.Date(date.Year(), date.Month()+1, 0, 0, 0, 0, 0, time.UTC).Day()
+	for _, day := range r.ByMonthDay {
+		if day == date.Day() || (day < 0 && lastDay+day+1 == date.Day()) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Rule) matchWeekday(date time.Time) bool {
+	if len(r.ByDay) == 0 {
+		return true
+	}
+	for _, day := range r.ByDay {
+		if day.Weekday == date.Weekday() {
+			return true
+		}
+	}
+	return false
+}
+
+// matchWeekdayDefault WEEKLY 规则没有 BYDAY 时，按第一次的星期几重复
+func (r *Rule) matchWeekdayDefault(date, first time.Time) bool {
+	if len(r.ByDay) == 0 {
+		return date.Weekday() == first.Weekday()
+	}
+	return r.matchWeekday(date)
+}
+
+// matchOrdinalWeekday 匹配月内的星期几，带序号时 1 表示当月第一个、-1 表示当月最后一个
+func (r *Rule) matchOrdinalWeekday(date time.Time, lastDay int) bool {
+	if len(r.ByDay) == 0 {
+		return true
+	}
+	for _, day := range r.ByDay {
+		if day.Weekday != date.Weekday() {
+			continue
+		}
+		switch {
+		case day.Ordinal == 0:
+			return true
+		case day.Ordinal > 0 && (date.Day()-1)/7+1 == day.Ordinal:
+			return true
+		case day.Ordinal < 0 && (lastDay-date.Day())/7+1 == -day.Ordinal:
+			return true
+		}
+	}
+	return false
+}
+
+// dateOf 只保留日期，统一到UTC便于比较
+func dateOf(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
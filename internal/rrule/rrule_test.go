@@ -0,0 +1,97 @@
+package rrule
+
+import (
+	"testing"
+	"time"
+)
+
+func date(s string) time.Time {
+	t, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestNext(t *testing.T) {
+	tests := []struct {
+		name  string
+		rule  string
+		start string
+		from  string
+		want  string // 为空表示序列已结束
+	}{
+		{"daily", "FREQ=DAILY", "2024-01-01", "2024-03-05", "2024-03-05"},
+		{"daily before start", "FREQ=DAILY", "2024-01-01", "2023-06-01", "2024-01-01"},
+		{"daily interval", "FREQ=DAILY;INTERVAL=3", "2024-01-01", "2024-01-05", "2024-01-07"},
+		{"daily long ago", "FREQ=DAILY;INTERVAL=2", "1990-01-01", "2024-06-14", "2024-06-15"},
+		{"weekly default weekday", "FREQ=WEEKLY", "2024-01-03", "2024-01-04", "2024-01-10"},
+		{"weekly byday", "FREQ=WEEKLY;BYDAY=MO,FR", "2024-01-01", "2024-01-02", "2024-01-05"},
+		{"biweekly", "FREQ=WEEKLY;INTERVAL=2;BYDAY=SA", "2024-01-06", "2024-01-07", "2024-01-20"},
+		{"monthly", "FREQ=MONTHLY", "2024-01-15", "2024-02-16", "2024-03-15"},
+		{"monthly skips short months", "FREQ=MONTHLY", "2024-01-31", "2024-02-01", "2024-03-31"},
+		{"monthly last day", "FREQ=MONTHLY;BYMONTHDAY=-1", "2024-01-31", "2024-02-01", "2024-02-29"},
+		{"monthly last friday", "FREQ=MONTHLY;BYDAY=-1FR", "2024-01-01", "2024-02-01", "2024-02-23"},
+		{"yearly", "FREQ=YEARLY", "2020-05-20", "2024-05-21", "2025-05-20"},
+		{"yearly leap day", "FREQ=YEARLY", "2020-02-29", "2021-01-01", "2024-02-29"},
+		{"mothers day", "FREQ=YEARLY;BYMONTH=5;BYDAY=2SU", "2020-01-01", "2024-01-01", "2024-05-12"},
+		{"count", "FREQ=YEARLY;COUNT=3", "2020-06-01", "2022-06-02", ""},
+		{"count last", "FREQ=YEARLY;COUNT=3", "2020-06-01", "2022-01-01", "2022-06-01"},
+		{"until", "FREQ=MONTHLY;UNTIL=20240315", "2024-01-10", "2024-03-11", ""},
+		{"until inclusive", "FREQ=DAILY;UNTIL=20240315T000000Z", "2024-03-01", "2024-03-15", "2024-03-15"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, err := Parse(tt.rule)
+			if err != nil {
+				t.Fatalf("Parse(%q) error: %v", tt.rule, err)
+			}
+			got, ok := rule.Next(date(tt.start), date(tt.from))
+			if tt.want == "" {
+				if ok {
+					t.Fatalf("Next() = %s, want end of series", got.Format("2006-01-02"))
+				}
+				return
+			}
+			if !ok {
+				t.Fatalf("Next() ended, want %s", tt.want)
+			}
+			if got.Format("2006-01-02") != tt.want {
+				t.Errorf("Next() = %s, want %s", got.Format("2006-01-02"), tt.want)
+			}
+		})
+	}
+}
+
+func TestNextUsesFromLocation(t *testing.T) {
+	loc, err := time.LoadLocation("Asia/Shanghai")
+	if err != nil {
+		t.Skip("时区数据不可用")
+	}
+	rule, _ := Parse("RRULE:FREQ=DAILY")
+	from := time.Date(2024, 3, 1, 23, 30, 0, 0, loc)
+	got, ok := rule.Next(date("2024-01-01"), from)
+	if !ok || got.Location() != loc || got.Format("2006-01-02 15:04") != "2024-03-01 00:00" {
+		t.Errorf("Next() = %v, want 2024-03-01 00:00 in %s", got, loc)
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	for _, s := range []string{
+		"",
+		"INTERVAL=2",
+		"FREQ=HOURLY",
+		"FREQ=DAILY;INTERVAL=0",
+		"FREQ=DAILY;COUNT=2;UNTIL=20250101",
+		"FREQ=WEEKLY;BYDAY=2MO",
+		"FREQ=YEARLY;BYDAY=SU",
+		"FREQ=MONTHLY;BYMONTHDAY=32",
+		"FREQ=MONTHLY;BYDAY=XX",
+		"FREQ=DAILY;BYSETPOS=1",
+	} {
+		if _, err := Parse(s); err == nil {
+			t.Errorf("Parse(%q) succeeded, want error", s)
+		}
+	}
+}
@@ -0,0 +1,250 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"memoir-api/internal/logger"
+	"memoir-api/internal/models"
+	"memoir-api/internal/repository"
+)
+
+const (
+	// AccessTokenPrefix 个人访问令牌的固定前缀，用于和JWT区分
+	AccessTokenPrefix = "mpat_"
+
+	// maxAccessTokensPerUser 每个用户同时有效的令牌上限
+	maxAccessTokensPerUser = 20
+	// MaxAccessTokenLifetime 令牌最长有效期
+	MaxAccessTokenLifetime = 365 * 24 * time.Hour
+	// accessTokenTouchInterval 最近使用时间的更新间隔，避免每个请求都写库
+	accessTokenTouchInterval = time.Minute
+)
+
+// 个人访问令牌权限范围，按资源划分读写
+const (
+	ScopeProfileRead    = "profile:read"
+	ScopeProfileWrite   = "profile:write"
+	ScopeCoupleRead     = "couple:read"
+	ScopeCoupleWrite    = "couple:write"
+	ScopeEventsRead     = "events:read"
+	ScopeEventsWrite    = "events:write"
+	ScopeLocationsRead  = "locations:read"
+	ScopeLocationsWrite = "locations:write"
+	ScopeMediaRead      = "media:read"
+	ScopeMediaWrite     = "media:write"
+	ScopeWishlistRead   = "wishlist:read"
+	ScopeWishlistWrite  = "wishlist:write"
+)
+
+// AccessTokenScopes 所有可授予的权限范围
+var AccessTokenScopes = []string{
+	ScopeProfileRead, ScopeProfileWrite,
+	ScopeCoupleRead, ScopeCoupleWrite,
+	ScopeEventsRead, ScopeEventsWrite,
+	ScopeLocationsRead, ScopeLocationsWrite,
+	ScopeMediaRead, ScopeMediaWrite,
+	ScopeWishlistRead, ScopeWishlistWrite,
+}
+
+var (
+	ErrInvalidAccessTokenScope = errors.New("无效的令牌权限范围")
+	ErrInvalidAccessToken      = errors.New("访问令牌无效或已过期")
+	ErrAccessTokenNotFound     = errors.New("访问令牌不存在")
+	ErrTooManyAccessTokens     = errors.New("有效的访问令牌数量已达上限")
+)
+
+// IsAccessToken 判断Bearer凭证是否为个人访问令牌
+func IsAccessToken(token string) bool {
+	return strings.HasPrefix(token, AccessTokenPrefix)
+}
+
+// HasScope 判断已授予的权限范围是否包含所需权限
+func HasScope(granted []string, required string) bool {
+	for _, scope := range granted {
+		if scope == required {
+			return true
+		}
+	}
+	return false
+}
+
+// AccessTokenService 个人访问令牌服务接口
+type AccessTokenService interface {
+	Service
+	// Create 创建令牌，明文令牌只在创建时返回一次
+	Create(ctx context.Context, userID int64, name string, scopes []string, lifetime time.Duration) (*models.PersonalAccessToken, string, error)
+	// List 列出用户未撤销的令牌
+	List(ctx context.Context, userID int64) ([]*models.PersonalAccessToken, error)
+	// Revoke 撤销用户的指定令牌
+	Revoke(ctx context.Context, userID, id int64) error
+	// Authenticate 验证明文令牌，返回有效的令牌记录
+	Authenticate(ctx context.Context, token string) (*models.PersonalAccessToken, error)
+}
+
+// accessTokenService 个人访问令牌服务实现
+type accessTokenService struct {
+	*BaseService
+	tokenRepo repository.PersonalAccessTokenRepository
+	userRepo  repository.UserRepository // 认证时检查令牌所属账号是否已停用
+	auditSvc  AuditService
+	log       logger.Logger
+}
+
+// NewAccessTokenService 创建个人访问令牌服务
+func NewAccessTokenService(tokenRepo repository.PersonalAccessTokenRepository, userRepo repository.UserRepository, auditSvc AuditService) AccessTokenService {
+	return &accessTokenService{
+		BaseService: NewBaseService(tokenRepo),
+		tokenRepo:   tokenRepo,
+		userRepo:    userRepo,
+		auditSvc:    auditSvc,
+		log:         logger.GetLogger("access-token"),
+	}
+}
+
+// hashAccessToken 计算令牌的SHA-256哈希，令牌本身有足够的熵，无需加盐
+func hashAccessToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// normalizeScopes 校验权限范围并去重排序
+func normalizeScopes(scopes []string) ([]string, error) {
+	seen := make(map[string]bool, len(scopes))
+	result := make([]string, 0, len(scopes))
+	for _, scope := range scopes {
+		scope = strings.ToLower(strings.TrimSpace(scope))
+		if !HasScope(AccessTokenScopes, scope) {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidAccessTokenScope, scope)
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			result = append(result, scope)
+		}
+	}
+	if len(result) == 0 {
+		return nil, fmt.Errorf("%w: 至少需要一个权限范围", ErrInvalidAccessTokenScope)
+	}
+	sort.Strings(result)
+	return result, nil
+}
+
+// Create 创建令牌，明文令牌只在创建时返回一次
+func (s *accessTokenService) Create(ctx context.Context, userID int64, name string, scopes []string, lifetime time.Duration) (*models.PersonalAccessToken, string, error) {
+	scopes, err := normalizeScopes(scopes)
+	if err != nil {
+		return nil, "", err
+	}
+	if lifetime <= 0 || lifetime > MaxAccessTokenLifetime {
+		lifetime = MaxAccessTokenLifetime
+	}
+
+	count, err := s.tokenRepo.CountActiveByUserID(ctx, userID)
+	if err != nil {
+		return nil, "", fmt.Errorf("统计访问令牌失败: %w", err)
+	}
+	if count >= maxAccessTokensPerUser {
+		return nil, "", ErrTooManyAccessTokens
+	}
+
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return nil, "", fmt.Errorf("生成访问令牌失败: %w", err)
+	}
+	plaintext := AccessTokenPrefix + base64.RawURLEncoding.EncodeToString(b)
+
+	token := &models.PersonalAccessToken{
+		UserID:      userID,
+		Name:        strings.TrimSpace(name),
+		TokenPrefix: plaintext[:len(AccessTokenPrefix)+6],
+		TokenHash:   hashAccessToken(plaintext),
+		Scopes:      strings.Join(scopes, " "),
+		ExpiresAt:   time.Now().Add(lifetime),
+	}
+	if err := s.tokenRepo.Create(ctx, token); err != nil {
+		return nil, "", fmt.Errorf("保存访问令牌失败: %w", err)
+	}
+
+	s.auditSvc.Record(ctx, AuditEntry{
+		ActorID:    userID,
+		Action:     models.AuditActionTokenCreate,
+		EntityType: models.AuditEntityAccessToken,
+		EntityID:   AuditEntityID(token.ID),
+		After:      map[string]interface{}{"name": token.Name, "scopes": scopes, "expires_at": token.ExpiresAt},
+	})
+	return token, plaintext, nil
+}
+
+// List 列出用户未撤销的令牌
+func (s *accessTokenService) List(ctx context.Context, userID int64) ([]*models.PersonalAccessToken, error) {
+	tokens, err := s.tokenRepo.ListActiveByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("获取访问令牌失败: %w", err)
+	}
+	return tokens, nil
+}
+
+// Revoke 撤销用户的指定令牌
+func (s *accessTokenService) Revoke(ctx context.Context, userID, id int64) error {
+	if err := s.tokenRepo.Revoke(ctx, userID, id); err != nil {
+		if errors.Is(err, repository.ErrPersonalAccessTokenNotFound) {
+			return ErrAccessTokenNotFound
+		}
+		return fmt.Errorf("撤销访问令牌失败: %w", err)
+	}
+
+	s.auditSvc.Record(ctx, AuditEntry{
+		ActorID:    userID,
+		Action:     models.AuditActionTokenRevoke,
+		EntityType: models.AuditEntityAccessToken,
+		EntityID:   AuditEntityID(id),
+	})
+	return nil
+}
+
+// Authenticate 验证明文令牌，返回有效的令牌记录
+func (s *accessTokenService) Authenticate(ctx context.Context, plaintext string) (*models.PersonalAccessToken, error) {
+	if !IsAccessToken(plaintext) {
+		return nil, ErrInvalidAccessToken
+	}
+
+	token, err := s.tokenRepo.FindByHash(ctx, hashAccessToken(plaintext))
+	if err != nil {
+		if errors.Is(err, repository.ErrPersonalAccessTokenNotFound) {
+			return nil, ErrInvalidAccessToken
+		}
+		return nil, fmt.Errorf("查询访问令牌失败: %w", err)
+	}
+
+	now := time.Now()
+	if !token.IsActive(now) {
+		return nil, ErrInvalidAccessToken
+	}
+
+	// 停用账号时会撤销令牌，这里再检查一次，撤销失败或停用后新建的令牌同样不能使用
+	user, err := s.userRepo.GetByID(ctx, token.UserID)
+	if err != nil {
+		if errors.Is(err, repository.ErrUserNotFound) {
+			return nil, ErrInvalidAccessToken
+		}
+		return nil, fmt.Errorf("查询令牌所属用户失败: %w", err)
+	}
+	if user.IsDisabled() {
+		return nil, ErrUserDisabled
+	}
+
+	if token.LastUsedAt == nil || now.Sub(*token.LastUsedAt) >= accessTokenTouchInterval {
+		if err := s.tokenRepo.TouchLastUsed(ctx, token.ID, now); err != nil {
+			s.log.Error(err, "更新访问令牌使用时间失败", "token_id", token.ID)
+		}
+	}
+	return token, nil
+}
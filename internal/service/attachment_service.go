@@ -11,16 +11,17 @@ import (
 )
 
 var (
-	ErrAttachmentNotFound = errors.New("附件不存在")
+	ErrAttachmentNotFound = resourceNotFound("附件不存在")
 )
 
 // AttachmentService 附件服务接口
 type AttachmentService interface {
 	Service
 	CreateAttachment(ctx context.Context, request *dto.CreateAttachmentRequest) (*models.Attachment, error)
-	GetAttachmentByID(ctx context.Context, id int64) (*models.Attachment, error)
+	// GetAttachmentByID 获取用户本人或所属情侣空间的附件，无权访问时返回 ErrAttachmentNotFound
+	GetAttachmentByID(ctx context.Context, userID, coupleID, id int64) (*models.Attachment, error)
 	QueryAttachments(ctx context.Context, params *dto.AttachmentQueryParams) (*dto.PageResult, error)
-	DeleteAttachment(ctx context.Context, id int64) error
+	DeleteAttachment(ctx context.Context, userID, coupleID, id int64) error
 	ListByUserID(ctx context.Context, userID int64, spaceType string) ([]models.Attachment, error)
 	ListByCoupleID(ctx context.Context, coupleID int64) ([]models.Attachment, error)
 }
@@ -31,6 +32,7 @@ type attachmentService struct {
 	repo       repository.AttachmentRepository
 	userRepo   repository.UserRepository
 	coupleRepo repository.CoupleRepository
+	auditSvc   AuditService
 }
 
 // NewAttachmentService 创建附件服务
@@ -38,12 +40,14 @@ func NewAttachmentService(
 	repo repository.AttachmentRepository,
 	userRepo repository.UserRepository,
 	coupleRepo repository.CoupleRepository,
+	auditSvc AuditService,
 ) AttachmentService {
 	return &attachmentService{
 		BaseService: NewBaseService(repo),
 		repo:        repo,
 		userRepo:    userRepo,
 		coupleRepo:  coupleRepo,
+		auditSvc:    auditSvc,
 	}
 }
 
@@ -71,12 +75,19 @@ func (s *attachmentService) CreateAttachment(ctx context.Context, request *dto.C
 		return nil, fmt.Errorf("创建附件失败: %w", err)
 	}
 
+	s.auditSvc.Record(ctx, AuditEntry{
+		CoupleID:   attachment.CoupleID,
+		Action:     models.AuditActionCreate,
+		EntityType: models.AuditEntityAttachment,
+		EntityID:   AuditEntityID(attachment.ID),
+		After:      attachment,
+	})
 	return attachment, nil
 }
 
-// GetAttachmentByID 通过ID获取附件
-func (s *attachmentService) GetAttachmentByID(ctx context.Context, id int64) (*models.Attachment, error) {
-	attachment, err := s.repo.GetByID(ctx, id)
+// GetAttachmentByID 通过ID获取用户可以访问的附件
+func (s *attachmentService) GetAttachmentByID(ctx context.Context, userID, coupleID, id int64) (*models.Attachment, error) {
+	attachment, err := s.repo.GetAccessibleByID(ctx, userID, coupleID, id)
 	if err != nil {
 		if errors.Is(err, repository.ErrAttachmentNotFound) {
 			return nil, ErrAttachmentNotFound
@@ -102,9 +113,9 @@ func (s *attachmentService) QueryAttachments(ctx context.Context, params *dto.At
 }
 
 // DeleteAttachment 删除附件
-func (s *attachmentService) DeleteAttachment(ctx context.Context, id int64) error {
-	// 检查附件是否存在
-	_, err := s.repo.GetByID(ctx, id)
+func (s *attachmentService) DeleteAttachment(ctx context.Context, userID, coupleID, id int64) error {
+	// 只有附件所有者或同一情侣关系的用户可以删除情侣空间的附件
+	attachment, err := s.repo.GetAccessibleByID(ctx, userID, coupleID, id)
 	if err != nil {
 		if errors.Is(err, repository.ErrAttachmentNotFound) {
 			return ErrAttachmentNotFound
@@ -117,6 +128,13 @@ func (s *attachmentService) DeleteAttachment(ctx context.Context, id int64) erro
 		return fmt.Errorf("删除附件失败: %w", err)
 	}
 
+	s.auditSvc.Record(ctx, AuditEntry{
+		CoupleID:   attachment.CoupleID,
+		Action:     models.AuditActionDelete,
+		EntityType: models.AuditEntityAttachment,
+		EntityID:   AuditEntityID(id),
+		Before:     attachment,
+	})
 	return nil
 }
 
@@ -0,0 +1,262 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"time"
+
+	"memoir-api/internal/logger"
+	"memoir-api/internal/models"
+	"memoir-api/internal/repository"
+)
+
+// auditVerifyBatchSize 校验哈希链时每批读取的记录数
+const auditVerifyBatchSize = 500
+
+// auditIgnoredFields 计算变更时忽略的字段，这些字段每次保存都会变化
+var auditIgnoredFields = map[string]bool{
+	"updated_at": true,
+}
+
+type auditRequestKey struct{}
+type auditActorKey struct{}
+
+// auditRequest 请求级的审计信息
+type auditRequest struct {
+	RequestID string
+	IP        string
+}
+
+// WithAuditRequest 把请求ID和客户端IP写入上下文，供审计日志使用
+func WithAuditRequest(ctx context.Context, requestID, ip string) context.Context {
+	return context.WithValue(ctx, auditRequestKey{}, auditRequest{RequestID: requestID, IP: ip})
+}
+
+// WithAuditActor 把当前操作人写入上下文，供审计日志使用
+func WithAuditActor(ctx context.Context, userID int64) context.Context {
+	return context.WithValue(ctx, auditActorKey{}, userID)
+}
+
+// AuditActorFromContext 获取上下文中的操作人，未登录时返回0
+func AuditActorFromContext(ctx context.Context) int64 {
+	actorID, _ := ctx.Value(auditActorKey{}).(int64)
+	return actorID
+}
+
+// AuditEntry 一条待记录的审计事件
+type AuditEntry struct {
+	// ActorID 操作人，为0时从上下文获取（如登录前的认证事件需要显式指定）
+	ActorID    int64
+	CoupleID   int64
+	Action     string
+	EntityType string
+	EntityID   string
+	// Before/After 变更前后的对象，记录时只保留发生变化的字段
+	Before interface{}
+	After  interface{}
+}
+
+// AuditChainStatus 哈希链校验结果
+type AuditChainStatus struct {
+	Valid   bool  `json:"valid"`
+	Entries int64 `json:"entries"`
+	// BrokenAtSeq 第一条校验失败的记录序号，校验通过时为0
+	BrokenAtSeq int64  `json:"broken_at_seq,omitempty"`
+	Reason      string `json:"reason,omitempty"`
+}
+
+// AuditService 审计日志服务接口
+type AuditService interface {
+	// Record 记录审计事件，失败只记录日志，不影响业务操作。
+	// 审计日志在业务事务之外单独写入，不会随业务回滚，调用方应在业务写入成功后再记录
+	Record(ctx context.Context, entry AuditEntry)
+	// ListByCouple 查询情侣双方的操作记录
+	ListByCouple(ctx context.Context, coupleID int64, offset, limit int) ([]*models.AuditLog, int64, error)
+	// Query 按条件查询审计日志（管理员）
+	Query(ctx context.Context, filter repository.AuditLogFilter, offset, limit int) ([]*models.AuditLog, int64, error)
+	// VerifyChain 从头校验哈希链（管理员）
+	VerifyChain(ctx context.Context) (*AuditChainStatus, error)
+}
+
+// auditService 审计日志服务实现
+type auditService struct {
+	*BaseService
+	auditRepo repository.AuditLogRepository
+}
+
+// NewAuditService 创建审计日志服务
+func NewAuditService(auditRepo repository.AuditLogRepository) AuditService {
+	return &auditService{
+		BaseService: NewBaseService(auditRepo),
+		auditRepo:   auditRepo,
+	}
+}
+
+// AuditEntityID 把数值ID格式化为审计日志中的对象ID
+func AuditEntityID(id int64) string {
+	return strconv.FormatInt(id, 10)
+}
+
+// Record 记录审计事件。审计日志要维护全局的哈希链，单独提交，不加入调用方的事务
+func (s *auditService) Record(ctx context.Context, entry AuditEntry) {
+	log := logger.FromContext(ctx).WithComponent("audit")
+
+	before, after, err := auditDiff(entry.Before, entry.After)
+	if err != nil {
+		log.Error(err, "计算审计变更失败", "action", entry.Action, "entity_type", entry.EntityType)
+		return
+	}
+
+	actorID := entry.ActorID
+	if actorID == 0 {
+		actorID = AuditActorFromContext(ctx)
+	}
+	request, _ := ctx.Value(auditRequestKey{}).(auditRequest)
+
+	record := &models.AuditLog{
+		ActorID:    actorID,
+		CoupleID:   entry.CoupleID,
+		Action:     entry.Action,
+		EntityType: entry.EntityType,
+		EntityID:   entry.EntityID,
+		Before:     before,
+		After:      after,
+		RequestID:  request.RequestID,
+		IP:         request.IP,
+		// 数据库只保存到微秒，截断后哈希才能复现
+		CreatedAt: time.Now().UTC().Truncate(time.Microsecond),
+	}
+
+	// 业务请求结束后仍然要写入审计日志
+	if err := s.auditRepo.Append(context.WithoutCancel(ctx), record); err != nil {
+		log.Error(err, "写入审计日志失败", "action", entry.Action, "entity_type", entry.EntityType, "entity_id", entry.EntityID)
+	}
+}
+
+// ListByCouple 查询情侣双方的操作记录
+func (s *auditService) ListByCouple(ctx context.Context, coupleID int64, offset, limit int) ([]*models.AuditLog, int64, error) {
+	if coupleID == 0 {
+		return nil, 0, ErrNotInCouple
+	}
+	return s.auditRepo.Query(ctx, repository.AuditLogFilter{CoupleID: coupleID}, offset, limit)
+}
+
+// Query 按条件查询审计日志
+func (s *auditService) Query(ctx context.Context, filter repository.AuditLogFilter, offset, limit int) ([]*models.AuditLog, int64, error) {
+	return s.auditRepo.Query(ctx, filter, offset, limit)
+}
+
+// VerifyChain 从头校验哈希链
+func (s *auditService) VerifyChain(ctx context.Context) (*AuditChainStatus, error) {
+	status := &AuditChainStatus{Valid: true}
+	var lastSeq int64
+	var lastHash string
+
+	for {
+		entries, err := s.auditRepo.ListAfterSeq(ctx, lastSeq, auditVerifyBatchSize)
+		if err != nil {
+			return nil, fmt.Errorf("读取审计日志失败: %w", err)
+		}
+
+		for _, entry := range entries {
+			var reason string
+			switch {
+			case entry.Seq != lastSeq+1:
+				reason = fmt.Sprintf("序号不连续，期望 %d", lastSeq+1)
+			case entry.PrevHash != lastHash:
+				reason = "上一条记录的哈希不匹配"
+			case entry.Hash != entry.ComputeHash():
+				reason = "记录内容与哈希不匹配"
+			}
+			if reason != "" {
+				status.Valid = false
+				status.BrokenAtSeq = entry.Seq
+				status.Reason = reason
+				return status, nil
+			}
+
+			lastSeq = entry.Seq
+			lastHash = entry.Hash
+			status.Entries++
+		}
+
+		if len(entries) < auditVerifyBatchSize {
+			return status, nil
+		}
+	}
+}
+
+// auditDiff 计算变更前后发生变化的字段，返回两份JSON；对象为nil时对应结果为空字符串
+func auditDiff(before, after interface{}) (string, string, error) {
+	beforeFields, err := auditFields(before)
+	if err != nil {
+		return "", "", err
+	}
+	afterFields, err := auditFields(after)
+	if err != nil {
+		return "", "", err
+	}
+
+	// 更新时只保留变化的字段
+	if beforeFields != nil && afterFields != nil {
+		for key, value := range beforeFields {
+			if other, ok := afterFields[key]; ok && reflect.DeepEqual(value, other) {
+				delete(beforeFields, key)
+				delete(afterFields, key)
+			}
+		}
+	}
+
+	beforeJSON, err := marshalAuditFields(beforeFields)
+	if err != nil {
+		return "", "", err
+	}
+	afterJSON, err := marshalAuditFields(afterFields)
+	if err != nil {
+		return "", "", err
+	}
+	return beforeJSON, afterJSON, nil
+}
+
+// auditFields 按JSON字段展开对象，json:"-" 的敏感字段不会出现在审计日志中
+func auditFields(v interface{}) (map[string]interface{}, error) {
+	if v == nil {
+		return nil, nil
+	}
+	if rv := reflect.ValueOf(v); rv.Kind() == reflect.Ptr && rv.IsNil() {
+		return nil, nil
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	fields := make(map[string]interface{})
+	if err := json.Unmarshal(data, &fields); err != nil {
+		// 非对象类型（如ID列表）按单个值记录
+		var value interface{}
+		if err := json.Unmarshal(data, &value); err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"value": value}, nil
+	}
+	for key := range auditIgnoredFields {
+		delete(fields, key)
+	}
+	return fields, nil
+}
+
+// marshalAuditFields 序列化字段，空集合返回空字符串
+func marshalAuditFields(fields map[string]interface{}) (string, error) {
+	if len(fields) == 0 {
+		return "", nil
+	}
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
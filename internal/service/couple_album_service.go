@@ -7,19 +7,26 @@ import (
 	"memoir-api/internal/logger"
 	"memoir-api/internal/models"
 	"memoir-api/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrAlbumNotFound = resourceNotFound("相册不存在")
 )
 
 // CoupleAlbumService 情侣相册服务接口
 type CoupleAlbumService interface {
 	Service
 	Create(ctx context.Context, req *dto.CreateCoupleAlbumRequest) (*models.CoupleAlbum, error)
-	GetByID(ctx context.Context, id int64) (*models.CoupleAlbum, error)
+	// GetByID 获取情侣的相册，不属于该情侣时返回 ErrAlbumNotFound
+	GetByID(ctx context.Context, coupleID, id int64) (*models.CoupleAlbum, error)
 	GetByCoupleID(ctx context.Context, coupleID int64) ([]*models.CoupleAlbum, error)
-	Update(ctx context.Context, id int64, req *dto.UpdateCoupleAlbumRequest) (*models.CoupleAlbum, error)
-	Delete(ctx context.Context, id int64) error
-	GetWithPhotos(ctx context.Context, id int64) (*models.CoupleAlbum, error)
+	Update(ctx context.Context, coupleID, id int64, req *dto.UpdateCoupleAlbumRequest) (*models.CoupleAlbum, error)
+	Delete(ctx context.Context, coupleID, id int64) error
+	GetWithPhotos(ctx context.Context, coupleID, id int64) (*models.CoupleAlbum, error)
 	CountByCoupleID(ctx context.Context, coupleID int64) (int64, error)
-	BatchDeletePhotoVideo(ctx context.Context, deleteReq *dto.DeleteCoupleAlbumPhotosRequest) error
+	BatchDeletePhotoVideo(ctx context.Context, coupleID int64, deleteReq *dto.DeleteCoupleAlbumPhotosRequest) error
 	PageCoupleMedia(ctx context.Context, param *dto.CoupleAlbumQueryParams) ([]*models.PhotoVideo, int64, error)
 }
 
@@ -29,16 +36,17 @@ type coupleAlbumService struct {
 	coupleAlbumRepo   repository.CoupleAlbumRepository
 	userService       UserService
 	photoVideoService PhotoVideoService
+	auditSvc          AuditService
 }
 
-func (s *coupleAlbumService) BatchDeletePhotoVideo(ctx context.Context, deleteReq *dto.DeleteCoupleAlbumPhotosRequest) error {
-	err := s.photoVideoService.BatchDeletePhotoVideo(ctx, deleteReq.PhotoVideoIDs)
+func (s *coupleAlbumService) BatchDeletePhotoVideo(ctx context.Context, coupleID int64, deleteReq *dto.DeleteCoupleAlbumPhotosRequest) error {
+	album, err := s.GetByID(ctx, coupleID, deleteReq.AlbumID)
 	if err != nil {
-		logger.Error(err, "Failed to delete photos/videos")
 		return err
 	}
-	album, err := s.GetByID(ctx, deleteReq.AlbumID)
+	err = s.photoVideoService.BatchDeletePhotoVideo(ctx, coupleID, deleteReq.PhotoVideoIDs)
 	if err != nil {
+		logger.Error(err, "Failed to delete photos/videos")
 		return err
 	}
 	album.Count -= len(deleteReq.PhotoVideoIDs)
@@ -58,12 +66,14 @@ func NewCoupleAlbumService(
 	coupleAlbumRepo repository.CoupleAlbumRepository,
 	userService UserService,
 	photoVideoService PhotoVideoService,
+	auditSvc AuditService,
 ) CoupleAlbumService {
 	return &coupleAlbumService{
 		BaseService:       NewBaseService(coupleAlbumRepo),
 		coupleAlbumRepo:   coupleAlbumRepo,
 		userService:       userService,
 		photoVideoService: photoVideoService,
+		auditSvc:          auditSvc,
 	}
 }
 
@@ -102,12 +112,20 @@ func (s *coupleAlbumService) Create(ctx context.Context, req *dto.CreateCoupleAl
 		return nil, err
 	}
 
+	s.auditSvc.Record(ctx, AuditEntry{
+		CoupleID:   album.CoupleID,
+		Action:     models.AuditActionCreate,
+		EntityType: models.AuditEntityAlbum,
+		EntityID:   AuditEntityID(album.ID),
+		After:      album,
+	})
 	return album, nil
 }
 
 // GetByID 通过ID获取情侣相册
-func (s *coupleAlbumService) GetByID(ctx context.Context, id int64) (*models.CoupleAlbum, error) {
-	return s.coupleAlbumRepo.GetByID(ctx, id)
+func (s *coupleAlbumService) GetByID(ctx context.Context, coupleID, id int64) (*models.CoupleAlbum, error) {
+	album, err := s.coupleAlbumRepo.GetByIDInCouple(ctx, coupleID, id)
+	return album, albumLookupError(err)
 }
 
 // GetByCoupleID 通过情侣ID获取所有相册
@@ -116,11 +134,12 @@ func (s *coupleAlbumService) GetByCoupleID(ctx context.Context, coupleID int64)
 }
 
 // Update 更新情侣相册
-func (s *coupleAlbumService) Update(ctx context.Context, id int64, req *dto.UpdateCoupleAlbumRequest) (*models.CoupleAlbum, error) {
-	album, err := s.coupleAlbumRepo.GetByID(ctx, id)
+func (s *coupleAlbumService) Update(ctx context.Context, coupleID, id int64, req *dto.UpdateCoupleAlbumRequest) (*models.CoupleAlbum, error) {
+	album, err := s.GetByID(ctx, coupleID, id)
 	if err != nil {
 		return nil, err
 	}
+	before := *album
 
 	// 更新相册信息
 	if req.Title != "" {
@@ -139,17 +158,50 @@ func (s *coupleAlbumService) Update(ctx context.Context, id int64, req *dto.Upda
 		return nil, err
 	}
 
+	s.auditSvc.Record(ctx, AuditEntry{
+		CoupleID:   album.CoupleID,
+		Action:     models.AuditActionUpdate,
+		EntityType: models.AuditEntityAlbum,
+		EntityID:   AuditEntityID(album.ID),
+		Before:     &before,
+		After:      album,
+	})
 	return album, nil
 }
 
 // Delete 删除情侣相册
-func (s *coupleAlbumService) Delete(ctx context.Context, id int64) error {
-	return s.coupleAlbumRepo.Delete(ctx, id)
+func (s *coupleAlbumService) Delete(ctx context.Context, coupleID, id int64) error {
+	album, err := s.GetByID(ctx, coupleID, id)
+	if err != nil {
+		return err
+	}
+
+	if err := s.coupleAlbumRepo.Delete(ctx, id); err != nil {
+		return err
+	}
+
+	s.auditSvc.Record(ctx, AuditEntry{
+		CoupleID:   album.CoupleID,
+		Action:     models.AuditActionDelete,
+		EntityType: models.AuditEntityAlbum,
+		EntityID:   AuditEntityID(id),
+		Before:     album,
+	})
+	return nil
 }
 
 // GetWithPhotos 获取相册及其包含的照片和视频
-func (s *coupleAlbumService) GetWithPhotos(ctx context.Context, id int64) (*models.CoupleAlbum, error) {
-	return s.coupleAlbumRepo.GetWithPhotos(ctx, id)
+func (s *coupleAlbumService) GetWithPhotos(ctx context.Context, coupleID, id int64) (*models.CoupleAlbum, error) {
+	album, err := s.coupleAlbumRepo.GetWithPhotos(ctx, coupleID, id)
+	return album, albumLookupError(err)
+}
+
+// albumLookupError 相册仓库直接返回 gorm.ErrRecordNotFound，这里转换成 ErrAlbumNotFound
+func albumLookupError(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrAlbumNotFound
+	}
+	return err
 }
 
 // 分页查询couple的媒体列表
@@ -0,0 +1,299 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"memoir-api/internal/api/dto"
+	"memoir-api/internal/models"
+	"memoir-api/internal/repository"
+)
+
+var (
+	ErrAnniversaryNotFound = resourceNotFound("纪念日不存在")
+)
+
+// 恋爱天数里程碑的标题
+const coupleDaysMilestoneTitle = "在一起%d天"
+
+// CoupleAnniversaryService 情侣纪念日服务接口
+type CoupleAnniversaryService interface {
+	Service
+	Create(ctx context.Context, coupleID int64, req *dto.CreateCoupleAnniversaryRequest) (*dto.CoupleAnniversaryDTO, error)
+	List(ctx context.Context, coupleID int64) ([]dto.CoupleAnniversaryDTO, error)
+	// Update 更新情侣的纪念日，不属于该情侣时返回 ErrAnniversaryNotFound
+	Update(ctx context.Context, coupleID, id int64, req *dto.UpdateCoupleAnniversaryRequest) (*dto.CoupleAnniversaryDTO, error)
+	Delete(ctx context.Context, coupleID, id int64) error
+	// UpcomingMilestones 获取即将到来的纪念日和恋爱天数里程碑，按倒计时排序
+	UpcomingMilestones(ctx context.Context, coupleID int64, limit int) ([]dto.MilestoneDTO, error)
+	// CoupleDays 按情侣时区计算在一起的天数，没有设置起点时返回0
+	CoupleDays(ctx context.Context, coupleID int64) (int, error)
+}
+
+// coupleAnniversaryService 情侣纪念日服务实现
+type coupleAnniversaryService struct {
+	*BaseService
+	anniversaryRepo repository.CoupleAnniversaryRepository
+	coupleRepo      repository.CoupleRepository
+	auditSvc        AuditService
+}
+
+// NewCoupleAnniversaryService 创建情侣纪念日服务
+func NewCoupleAnniversaryService(
+	anniversaryRepo repository.CoupleAnniversaryRepository,
+	coupleRepo repository.CoupleRepository,
+	auditSvc AuditService,
+) CoupleAnniversaryService {
+	return &coupleAnniversaryService{
+		BaseService:     NewBaseService(anniversaryRepo),
+		anniversaryRepo: anniversaryRepo,
+		coupleRepo:      coupleRepo,
+		auditSvc:        auditSvc,
+	}
+}
+
+// Create 创建纪念日
+func (s *coupleAnniversaryService) Create(ctx context.Context, coupleID int64, req *dto.CreateCoupleAnniversaryRequest) (*dto.CoupleAnniversaryDTO, error) {
+	anniversary, err := req.ToModel(coupleID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.anniversaryRepo.Create(ctx, anniversary); err != nil {
+		return nil, fmt.Errorf("创建纪念日失败: %w", err)
+	}
+
+	s.auditSvc.Record(ctx, AuditEntry{
+		CoupleID:   coupleID,
+		Action:     models.AuditActionCreate,
+		EntityType: models.AuditEntityAnniversary,
+		EntityID:   AuditEntityID(anniversary.ID),
+		After:      anniversary,
+	})
+	return s.toDTO(ctx, anniversary)
+}
+
+// List 获取情侣的所有纪念日
+func (s *coupleAnniversaryService) List(ctx context.Context, coupleID int64) ([]dto.CoupleAnniversaryDTO, error) {
+	anniversaries, err := s.anniversaryRepo.ListByCoupleID(ctx, coupleID)
+	if err != nil {
+		return nil, fmt.Errorf("查询纪念日失败: %w", err)
+	}
+	now, err := s.coupleNow(ctx, coupleID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]dto.CoupleAnniversaryDTO, len(anniversaries))
+	for i, anniversary := range anniversaries {
+		result[i] = anniversaryToDTO(anniversary, now)
+	}
+	return result, nil
+}
+
+// Update 更新纪念日
+func (s *coupleAnniversaryService) Update(ctx context.Context, coupleID, id int64, req *dto.UpdateCoupleAnniversaryRequest) (*dto.CoupleAnniversaryDTO, error) {
+	anniversary, err := s.anniversaryRepo.GetByIDInCouple(ctx, coupleID, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrAnniversaryNotFound) {
+			return nil, ErrAnniversaryNotFound
+		}
+		return nil, fmt.Errorf("查询纪念日失败: %w", err)
+	}
+	before := *anniversary
+
+	if err := req.ApplyToModel(anniversary); err != nil {
+		return nil, fmt.Errorf("更新纪念日参数无效: %w", err)
+	}
+	if err := s.anniversaryRepo.Update(ctx, anniversary); err != nil {
+		return nil, fmt.Errorf("更新纪念日失败: %w", err)
+	}
+
+	s.auditSvc.Record(ctx, AuditEntry{
+		CoupleID:   anniversary.CoupleID,
+		Action:     models.AuditActionUpdate,
+		EntityType: models.AuditEntityAnniversary,
+		EntityID:   AuditEntityID(anniversary.ID),
+		Before:     &before,
+		After:      anniversary,
+	})
+	return s.toDTO(ctx, anniversary)
+}
+
+// Delete 删除纪念日
+func (s *coupleAnniversaryService) Delete(ctx context.Context, coupleID, id int64) error {
+	anniversary, err := s.anniversaryRepo.GetByIDInCouple(ctx, coupleID, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrAnniversaryNotFound) {
+			return ErrAnniversaryNotFound
+		}
+		return fmt.Errorf("查询纪念日失败: %w", err)
+	}
+	if err := s.anniversaryRepo.Delete(ctx, id); err != nil {
+		return fmt.Errorf("删除纪念日失败: %w", err)
+	}
+
+	s.auditSvc.Record(ctx, AuditEntry{
+		CoupleID:   anniversary.CoupleID,
+		Action:     models.AuditActionDelete,
+		EntityType: models.AuditEntityAnniversary,
+		EntityID:   AuditEntityID(anniversary.ID),
+		Before:     anniversary,
+	})
+	return nil
+}
+
+// UpcomingMilestones 获取即将到来的纪念日和恋爱天数里程碑
+func (s *coupleAnniversaryService) UpcomingMilestones(ctx context.Context, coupleID int64, limit int) ([]dto.MilestoneDTO, error) {
+	couple, err := s.coupleRepo.GetByID(ctx, coupleID)
+	if err != nil {
+		return nil, err
+	}
+	anniversaries, err := s.anniversaryRepo.ListByCoupleID(ctx, coupleID)
+	if err != nil {
+		return nil, fmt.Errorf("查询纪念日失败: %w", err)
+	}
+	now := time.Now().In(couple.Location())
+
+	milestones := make([]dto.MilestoneDTO, 0, len(anniversaries)+1)
+	for _, anniversary := range anniversaries {
+		next, ok := anniversary.NextOccurrence(now)
+		if !ok {
+			continue
+		}
+		milestones = append(milestones, dto.MilestoneDTO{
+			AnniversaryID: anniversary.ID,
+			Title:         anniversary.Title,
+			Type:          anniversary.Type,
+			Date:          next.Format("2006-01-02"),
+			DaysUntil:     models.DaysBetween(now, next),
+			Count:         anniversaryCount(anniversary, next),
+		})
+	}
+
+	// 恋爱天数里程碑（100天、一周年等）
+	if days, ok := coupleDaysAt(couple, anniversaries, now); ok {
+		start := coupleStartDate(couple, anniversaries)
+		if target, ok := nextCoupleDaysMilestone(days); ok {
+			date := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, now.Location()).AddDate(0, 0, target)
+			milestones = append(milestones, dto.MilestoneDTO{
+				Title:     fmt.Sprintf(coupleDaysMilestoneTitle, target),
+				Type:      "days",
+				Date:      date.Format("2006-01-02"),
+				DaysUntil: target - days,
+				Count:     target,
+			})
+		}
+	}
+
+	sort.SliceStable(milestones, func(i, j int) bool {
+		return milestones[i].DaysUntil < milestones[j].DaysUntil
+	})
+	if limit > 0 && len(milestones) > limit {
+		milestones = milestones[:limit]
+	}
+	return milestones, nil
+}
+
+// CoupleDays 按情侣时区计算在一起的天数
+func (s *coupleAnniversaryService) CoupleDays(ctx context.Context, coupleID int64) (int, error) {
+	couple, err := s.coupleRepo.GetByID(ctx, coupleID)
+	if err != nil {
+		return 0, err
+	}
+	anniversaries, err := s.anniversaryRepo.ListByCoupleID(ctx, coupleID)
+	if err != nil {
+		return 0, fmt.Errorf("查询纪念日失败: %w", err)
+	}
+	days, _ := coupleDaysAt(couple, anniversaries, time.Now())
+	return days, nil
+}
+
+// coupleNow 按情侣设置的时区获取当前时间
+func (s *coupleAnniversaryService) coupleNow(ctx context.Context, coupleID int64) (time.Time, error) {
+	couple, err := s.coupleRepo.GetByID(ctx, coupleID)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Now().In(couple.Location()), nil
+}
+
+// toDTO 转换为响应，按情侣时区计算天数
+func (s *coupleAnniversaryService) toDTO(ctx context.Context, anniversary *models.CoupleAnniversary) (*dto.CoupleAnniversaryDTO, error) {
+	now, err := s.coupleNow(ctx, anniversary.CoupleID)
+	if err != nil {
+		return nil, err
+	}
+	result := anniversaryToDTO(anniversary, now)
+	return &result, nil
+}
+
+// anniversaryToDTO 转换为响应，附带已经过的天数和下一次的倒计时
+func anniversaryToDTO(anniversary *models.CoupleAnniversary, now time.Time) dto.CoupleAnniversaryDTO {
+	result := dto.CoupleAnniversaryDTO{
+		ID:                 anniversary.ID,
+		Title:              anniversary.Title,
+		Date:               anniversary.Date.Format("2006-01-02"),
+		Type:               anniversary.Type,
+		Recurrence:         anniversary.Recurrence,
+		ReminderDaysBefore: anniversary.ReminderDaysBefore,
+		DaysSince:          anniversary.DaysSince(now),
+	}
+	if next, ok := anniversary.NextOccurrence(now); ok {
+		daysUntil := models.DaysBetween(now, next)
+		result.NextDate = next.Format("2006-01-02")
+		result.DaysUntil = &daysUntil
+	}
+	return result
+}
+
+// anniversaryCount 下一次纪念日是第几周年/第几个月，只有一次的纪念日返回0
+func anniversaryCount(anniversary *models.CoupleAnniversary, next time.Time) int {
+	switch anniversary.Recurrence {
+	case models.AnniversaryRecurrenceYearly:
+		return next.Year() - anniversary.Date.Year()
+	case models.AnniversaryRecurrenceMonthly:
+		return (next.Year()-anniversary.Date.Year())*12 + int(next.Month()-anniversary.Date.Month())
+	default:
+		return 0
+	}
+}
+
+// coupleStartDate 恋爱天数的起点：优先使用情侣的 AnniversaryDate，没有设置时使用最早的 together 纪念日
+func coupleStartDate(couple *models.Couple, anniversaries []*models.CoupleAnniversary) time.Time {
+	if !couple.AnniversaryDate.IsZero() {
+		return couple.AnniversaryDate
+	}
+	var start time.Time
+	for _, anniversary := range anniversaries {
+		if anniversary.Type != models.AnniversaryTypeTogether {
+			continue
+		}
+		if start.IsZero() || anniversary.Date.Before(start) {
+			start = anniversary.Date
+		}
+	}
+	return start
+}
+
+// coupleDaysAt 到 now 为止在一起的天数。按情侣时区的日历日期相减，不受服务器时区、
+// 午夜前后和夏令时切换的影响，起点当天为第0天；没有起点时返回 false
+func coupleDaysAt(couple *models.Couple, anniversaries []*models.CoupleAnniversary, now time.Time) (int, bool) {
+	start := coupleStartDate(couple, anniversaries)
+	if start.IsZero() {
+		return 0, false
+	}
+	return models.DaysBetween(start, now.In(couple.Location())), true
+}
+
+// nextCoupleDaysMilestone 获取今天或之后的下一个恋爱天数里程碑
+func nextCoupleDaysMilestone(days int) (int, bool) {
+	for _, milestone := range anniversaryDays {
+		if milestone >= days {
+			return milestone, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,418 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"memoir-api/internal/logger"
+	"memoir-api/internal/models"
+	"memoir-api/internal/repository"
+)
+
+const (
+	// CoupleDissolveCoolingOff 申请解除后的冷静期，期间任何一方都可以撤销
+	CoupleDissolveCoolingOff = 7 * 24 * time.Hour
+
+	// coupleArchiveCategory 复制到个人空间的情侣媒体使用的分类
+	coupleArchiveCategory = "couple_archive"
+)
+
+var (
+	ErrInvalidHandoff       = errors.New("无效的数据处理方式")
+	ErrCoupleDissolving     = errors.New("情侣关系正在解除中")
+	ErrCoupleNotDissolving  = errors.New("情侣关系没有待处理的解除申请")
+	ErrNoCoupleDataToExport = errors.New("没有可以导出的情侣数据")
+)
+
+// CoupleExportMember 导出数据中的成员信息
+type CoupleExportMember struct {
+	ID       int64  `json:"id,string"`
+	Username string `json:"username"`
+	Email    string `json:"email"`
+}
+
+// CoupleExportEvent 导出数据中的时间轴事件，附带关联的地点和照片视频ID（字符串形式，与其他ID一致）
+type CoupleExportEvent struct {
+	*models.TimelineEvent
+	LocationIDs   []string `json:"location_ids"`
+	PhotoVideoIDs []string `json:"photo_video_ids"`
+}
+
+// CoupleExport 情侣共享数据的完整副本
+type CoupleExport struct {
+	ExportedAt     time.Time                   `json:"exported_at"`
+	Couple         *models.Couple              `json:"couple"`
+	Members        []CoupleExportMember        `json:"members"`
+	Albums         []*models.CoupleAlbum       `json:"albums"`
+	PhotoVideos    []*models.PhotoVideo        `json:"photo_videos"`
+	TimelineEvents []CoupleExportEvent         `json:"timeline_events"`
+	Locations      []*models.Location          `json:"locations"`
+	Wishlists      []*models.Wishlist          `json:"wishlists"`
+	Attachments    []models.Attachment         `json:"attachments"`
+	Anniversaries  []*models.CoupleAnniversary `json:"anniversaries"`
+	Reminders      []*models.Reminder          `json:"reminders"`
+}
+
+// CoupleDissolutionService 情侣关系解除服务接口
+type CoupleDissolutionService interface {
+	// RequestDissolution 申请解除情侣关系，进入冷静期
+	RequestDissolution(ctx context.Context, userID int64, handoff string) (*models.Couple, error)
+	// CancelDissolution 冷静期内撤销解除申请，任何一方都可以撤销
+	CancelDissolution(ctx context.Context, userID int64) error
+	// FinalizeDueDissolutions 正式解除冷静期已结束的情侣关系，返回处理的数量
+	FinalizeDueDissolutions(ctx context.Context) (int, error)
+	// Export 导出情侣关系的全部共享数据。coupleID 为0时导出当前或最近一次已解除的情侣关系，
+	// 否则必须是用户当前或曾经所在的情侣关系
+	Export(ctx context.Context, userID, coupleID int64) (*CoupleExport, error)
+	// ListPastCouples 获取用户曾经所在的已解除情侣关系，最近解除的在前
+	ListPastCouples(ctx context.Context, userID int64) ([]*models.PastCoupleMember, error)
+}
+
+// coupleDissolutionService 情侣关系解除服务实现
+type coupleDissolutionService struct {
+	userRepo            repository.UserRepository
+	coupleRepo          repository.CoupleRepository
+	albumRepo           repository.CoupleAlbumRepository
+	photoVideoRepo      repository.PhotoVideoRepository
+	timelineEventRepo   repository.TimelineEventRepository
+	eventLocationRepo   repository.TimelineEventLocationRepository
+	eventPhotoVideoRepo repository.TimelineEventPhotoVideoRepository
+	locationRepo        repository.LocationRepository
+	wishlistRepo        repository.WishlistRepository
+	attachmentRepo      repository.AttachmentRepository
+	personalMediaRepo   repository.PersonalMediaRepository
+	anniversaryRepo     repository.CoupleAnniversaryRepository
+	reminderRepo        repository.ReminderRepository
+	emailSvc            EmailService
+	auditSvc            AuditService
+	log                 logger.Logger
+}
+
+// NewCoupleDissolutionService 创建情侣关系解除服务
+func NewCoupleDissolutionService(repoFactory repository.Factory, emailSvc EmailService, auditSvc AuditService) CoupleDissolutionService {
+	return &coupleDissolutionService{
+		userRepo:            repoFactory.User(),
+		coupleRepo:          repoFactory.Couple(),
+		albumRepo:           repoFactory.CoupleAlbum(),
+		photoVideoRepo:      repoFactory.PhotoVideo(),
+		timelineEventRepo:   repoFactory.TimelineEvent(),
+		eventLocationRepo:   repoFactory.TimelineEventLocation(),
+		eventPhotoVideoRepo: repoFactory.TimelineEventPhotoVideo(),
+		locationRepo:        repoFactory.Location(),
+		wishlistRepo:        repoFactory.Wishlist(),
+		attachmentRepo:      repoFactory.Attachment(),
+		personalMediaRepo:   repoFactory.PersonalMedia(),
+		anniversaryRepo:     repoFactory.CoupleAnniversary(),
+		reminderRepo:        repoFactory.Reminder(),
+		emailSvc:            emailSvc,
+		auditSvc:            auditSvc,
+		log:                 logger.GetLogger("couple-dissolution"),
+	}
+}
+
+// RequestDissolution 申请解除情侣关系
+func (s *coupleDissolutionService) RequestDissolution(ctx context.Context, userID int64, handoff string) (*models.Couple, error) {
+	if handoff == "" {
+		handoff = models.CoupleHandoffArchive
+	}
+	if handoff != models.CoupleHandoffArchive && handoff != models.CoupleHandoffCopy {
+		return nil, ErrInvalidHandoff
+	}
+
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.CoupleID == 0 {
+		return nil, ErrNotInCouple
+	}
+
+	dissolveAt := time.Now().Add(CoupleDissolveCoolingOff)
+	if err := s.coupleRepo.MarkDissolving(ctx, user.CoupleID, userID, handoff, dissolveAt); err != nil {
+		if errors.Is(err, repository.ErrCoupleStatusConflict) {
+			return nil, ErrCoupleDissolving
+		}
+		return nil, fmt.Errorf("申请解除情侣关系失败: %w", err)
+	}
+
+	s.auditSvc.Record(ctx, AuditEntry{
+		ActorID:    userID,
+		CoupleID:   user.CoupleID,
+		Action:     models.AuditActionCoupleDissolveRequest,
+		EntityType: models.AuditEntityCouple,
+		EntityID:   AuditEntityID(user.CoupleID),
+		After:      map[string]interface{}{"handoff": handoff, "dissolve_at": dissolveAt},
+	})
+
+	message := fmt.Sprintf("%s 申请解除你们的情侣关系，将在 %s 正式生效。冷静期内任何一方都可以撤销申请。",
+		user.Username, dissolveAt.Format("2006-01-02 15:04"))
+	s.notifyMembers(ctx, user.CoupleID, userID, message)
+
+	return s.coupleRepo.GetByID(ctx, user.CoupleID)
+}
+
+// CancelDissolution 撤销解除申请
+func (s *coupleDissolutionService) CancelDissolution(ctx context.Context, userID int64) error {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user.CoupleID == 0 {
+		return ErrNotInCouple
+	}
+
+	if err := s.coupleRepo.CancelDissolving(ctx, user.CoupleID); err != nil {
+		if errors.Is(err, repository.ErrCoupleStatusConflict) {
+			return ErrCoupleNotDissolving
+		}
+		return fmt.Errorf("撤销解除申请失败: %w", err)
+	}
+
+	s.auditSvc.Record(ctx, AuditEntry{
+		ActorID:    userID,
+		CoupleID:   user.CoupleID,
+		Action:     models.AuditActionCoupleDissolveCancel,
+		EntityType: models.AuditEntityCouple,
+		EntityID:   AuditEntityID(user.CoupleID),
+	})
+
+	s.notifyMembers(ctx, user.CoupleID, userID, fmt.Sprintf("%s 撤销了解除情侣关系的申请。", user.Username))
+	return nil
+}
+
+// FinalizeDueDissolutions 正式解除冷静期已结束的情侣关系
+func (s *coupleDissolutionService) FinalizeDueDissolutions(ctx context.Context) (int, error) {
+	couples, err := s.coupleRepo.ListDueDissolutions(ctx, time.Now())
+	if err != nil {
+		return 0, fmt.Errorf("查询待解除的情侣关系失败: %w", err)
+	}
+
+	finalized := 0
+	for _, couple := range couples {
+		if err := s.finalize(ctx, couple); err != nil {
+			// 单个失败不影响其他情侣，下次执行时会重试
+			s.log.Error(err, "解除情侣关系失败", "couple_id", couple.ID)
+			continue
+		}
+		finalized++
+	}
+	return finalized, nil
+}
+
+// finalize 解除单个情侣关系并按约定处理共享数据
+func (s *coupleDissolutionService) finalize(ctx context.Context, couple *models.Couple) error {
+	// 解除前先记下成员，解除后成员的 couple_id 会被清零
+	members, err := s.userRepo.ListByCoupleID(ctx, couple.ID)
+	if err != nil {
+		return fmt.Errorf("查询情侣成员失败: %w", err)
+	}
+
+	// 先复制再解除：复制失败时关系保持冷静期状态，下次执行时重试，直到 media_copied_at 记录完成
+	if couple.DissolveHandoff == models.CoupleHandoffCopy && couple.MediaCopiedAt == nil {
+		if err := s.copyMediaToPersonalSpace(ctx, couple.ID, members); err != nil {
+			return fmt.Errorf("复制情侣媒体到个人空间失败: %w", err)
+		}
+		if err := s.coupleRepo.MarkMediaCopied(ctx, couple.ID, time.Now()); err != nil {
+			return fmt.Errorf("记录媒体复制完成失败: %w", err)
+		}
+	}
+
+	if err := s.coupleRepo.Dissolve(ctx, couple.ID, time.Now()); err != nil {
+		return err
+	}
+
+	s.auditSvc.Record(ctx, AuditEntry{
+		ActorID:    couple.DissolveRequestedBy,
+		CoupleID:   couple.ID,
+		Action:     models.AuditActionCoupleDissolve,
+		EntityType: models.AuditEntityCouple,
+		EntityID:   AuditEntityID(couple.ID),
+		After:      map[string]string{"handoff": couple.DissolveHandoff},
+	})
+
+	message := "你们的情侣关系已正式解除，共享的相册和时间轴已归档为只读，可以随时导出完整副本。"
+	if couple.DissolveHandoff == models.CoupleHandoffCopy {
+		message = "你们的情侣关系已正式解除，共享的照片和视频已复制到你的个人空间，其余数据已归档，可以随时导出完整副本。"
+	}
+
+	for _, member := range members {
+		if err := s.emailSvc.SendNotificationEmail(ctx, coupleEmailRecipient(member, couple), message); err != nil {
+			s.log.Error(err, "发送解除通知失败", "couple_id", couple.ID, "user_id", member.ID)
+		}
+	}
+	return nil
+}
+
+// copyMediaToPersonalSpace 把情侣的照片和视频复制到每个成员的个人空间。
+// 已复制过的照片视频按 source_photo_video_id 跳过，中途失败后重试不会产生重复
+func (s *coupleDissolutionService) copyMediaToPersonalSpace(ctx context.Context, coupleID int64, members []*models.User) error {
+	photoVideos, _, err := s.photoVideoRepo.ListByCoupleID(ctx, coupleID, -1, 0)
+	if err != nil {
+		return fmt.Errorf("查询情侣媒体失败: %w", err)
+	}
+
+	for _, member := range members {
+		copiedIDs, err := s.personalMediaRepo.ListSourcePhotoVideoIDs(ctx, member.ID)
+		if err != nil {
+			return fmt.Errorf("查询已复制的媒体失败: %w", err)
+		}
+		copied := make(map[int64]bool, len(copiedIDs))
+		for _, id := range copiedIDs {
+			copied[id] = true
+		}
+
+		for _, pv := range photoVideos {
+			if copied[pv.ID] {
+				continue
+			}
+			category := coupleArchiveCategory
+			thumbnailURL := pv.ThumbnailURL
+			description := pv.Description
+			title := pv.Title
+			media := &models.PersonalMedia{
+				UserID:             member.ID,
+				MediaURL:           pv.MediaURL,
+				MediaType:          pv.MediaType,
+				Category:           &category,
+				ThumbnailURL:       &thumbnailURL,
+				Description:        &description,
+				Title:              &title,
+				SourcePhotoVideoID: pv.ID,
+			}
+			if err := s.personalMediaRepo.Create(ctx, media); err != nil {
+				return fmt.Errorf("复制媒体 %d 失败: %w", pv.ID, err)
+			}
+		}
+	}
+	return nil
+}
+
+// Export 导出情侣共享数据，已解除的情侣关系仍可由原成员导出
+func (s *coupleDissolutionService) Export(ctx context.Context, userID, coupleID int64) (*CoupleExport, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if coupleID == 0 {
+		coupleID = user.CoupleID
+		if coupleID == 0 {
+			coupleID = user.PreviousCoupleID
+		}
+		if coupleID == 0 {
+			return nil, ErrNoCoupleDataToExport
+		}
+	}
+
+	// 当前的情侣关系导出现有成员，已解除的只能由原成员导出
+	listMembers := s.userRepo.ListByCoupleID
+	if coupleID != user.CoupleID {
+		isPastMember, err := s.coupleRepo.IsPastMember(ctx, userID, coupleID)
+		if err != nil {
+			return nil, fmt.Errorf("查询情侣关系记录失败: %w", err)
+		}
+		if !isPastMember {
+			return nil, ErrNoCoupleDataToExport
+		}
+		listMembers = s.userRepo.ListPastMembers
+	}
+
+	couple, err := s.coupleRepo.GetByID(ctx, coupleID)
+	if err != nil {
+		return nil, fmt.Errorf("查询情侣关系失败: %w", err)
+	}
+	// 配对口令不属于共享数据
+	couple.PairToken = ""
+
+	export := &CoupleExport{ExportedAt: time.Now(), Couple: couple}
+
+	members, err := listMembers(ctx, coupleID)
+	if err != nil {
+		return nil, fmt.Errorf("查询情侣成员失败: %w", err)
+	}
+	for _, member := range members {
+		export.Members = append(export.Members, CoupleExportMember{ID: member.ID, Username: member.Username, Email: member.Email})
+	}
+
+	if export.Albums, err = s.albumRepo.GetByCoupleID(ctx, coupleID); err != nil {
+		return nil, fmt.Errorf("导出相册失败: %w", err)
+	}
+	if export.PhotoVideos, _, err = s.photoVideoRepo.ListByCoupleID(ctx, coupleID, -1, 0); err != nil {
+		return nil, fmt.Errorf("导出照片视频失败: %w", err)
+	}
+	if export.Locations, _, err = s.locationRepo.ListByCoupleID(ctx, coupleID, -1, 0); err != nil {
+		return nil, fmt.Errorf("导出地点失败: %w", err)
+	}
+	if export.Attachments, err = s.attachmentRepo.ListByCoupleID(ctx, coupleID); err != nil {
+		return nil, fmt.Errorf("导出附件失败: %w", err)
+	}
+
+	if export.Anniversaries, err = s.anniversaryRepo.ListByCoupleID(ctx, coupleID); err != nil {
+		return nil, fmt.Errorf("导出纪念日失败: %w", err)
+	}
+	if export.Reminders, err = s.reminderRepo.ListByCoupleID(ctx, coupleID); err != nil {
+		return nil, fmt.Errorf("导出提醒失败: %w", err)
+	}
+
+	if export.Wishlists, err = s.wishlistRepo.ListByCoupleID(ctx, coupleID); err != nil {
+		return nil, fmt.Errorf("导出心愿清单失败: %w", err)
+	}
+	for _, wishlist := range export.Wishlists {
+		if wishlist.Attachments, err = s.wishlistRepo.GetAttachments(ctx, wishlist.ID); err != nil {
+			return nil, fmt.Errorf("导出心愿附件失败: %w", err)
+		}
+	}
+
+	events, _, err := s.timelineEventRepo.FindByCoupleID(ctx, coupleID, -1, -1)
+	if err != nil {
+		return nil, fmt.Errorf("导出时间轴失败: %w", err)
+	}
+	for _, event := range events {
+		item := CoupleExportEvent{TimelineEvent: event, LocationIDs: []string{}, PhotoVideoIDs: []string{}}
+
+		eventLocations, err := s.eventLocationRepo.FindByEventID(ctx, event.ID)
+		if err != nil {
+			return nil, fmt.Errorf("导出时间轴地点失败: %w", err)
+		}
+		for _, el := range eventLocations {
+			item.LocationIDs = append(item.LocationIDs, strconv.FormatInt(el.LocationID, 10))
+		}
+
+		eventPhotoVideos, err := s.eventPhotoVideoRepo.FindByEventID(ctx, event.ID)
+		if err != nil {
+			return nil, fmt.Errorf("导出时间轴照片失败: %w", err)
+		}
+		for _, ep := range eventPhotoVideos {
+			item.PhotoVideoIDs = append(item.PhotoVideoIDs, strconv.FormatInt(ep.PhotoVideoID, 10))
+		}
+
+		export.TimelineEvents = append(export.TimelineEvents, item)
+	}
+
+	return export, nil
+}
+
+// ListPastCouples 获取用户曾经所在的已解除情侣关系
+func (s *coupleDissolutionService) ListPastCouples(ctx context.Context, userID int64) ([]*models.PastCoupleMember, error) {
+	return s.coupleRepo.ListPastCouples(ctx, userID)
+}
+
+// notifyMembers 通知情侣中除操作人以外的成员，发送失败只记录日志
+func (s *coupleDissolutionService) notifyMembers(ctx context.Context, coupleID, actorID int64, message string) {
+	members, err := s.userRepo.ListByCoupleID(ctx, coupleID)
+	if err != nil {
+		s.log.Error(err, "查询情侣成员失败", "couple_id", coupleID)
+		return
+	}
+	for _, member := range members {
+		if member.ID == actorID {
+			continue
+		}
+		if err := s.emailSvc.SendNotificationEmail(ctx, emailRecipient(member), message); err != nil {
+			s.log.Error(err, "发送情侣关系通知失败", "couple_id", coupleID, "user_id", member.ID)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"memoir-api/internal/repository"
+)
+
+var (
+	// ErrNotInCouple 当前用户还没有情侣关系
+	ErrNotInCouple = errors.New("用户不属于任何情侣关系")
+	// ErrResourceNotFound 资源不存在或不属于当前用户，两种情况不做区分，避免泄露其他情侣的数据
+	ErrResourceNotFound = errors.New("资源不存在")
+)
+
+// resourceNotFound 具体资源的"不存在"错误，可以用 errors.Is 匹配 ErrResourceNotFound，
+// 处理器据此统一返回404
+type resourceNotFound string
+
+func (e resourceNotFound) Error() string {
+	return string(e)
+}
+
+func (e resourceNotFound) Is(target error) bool {
+	return target == ErrResourceNotFound
+}
+
+// CoupleGuardService 情侣资源授权服务，负责解析当前用户所属的情侣。
+// 按ID访问情侣数据时，归属校验由各服务通过仓库的 InCouple 查询完成，不属于当前情侣的记录查不到，
+// 统一返回可以用 errors.Is 匹配 ErrResourceNotFound 的错误
+type CoupleGuardService interface {
+	// ResolveCoupleID 获取用户所属的情侣ID，没有情侣关系时返回0
+	ResolveCoupleID(ctx context.Context, userID int64) (int64, error)
+}
+
+// coupleGuardService 情侣资源授权服务实现
+type coupleGuardService struct {
+	userRepo repository.UserRepository
+}
+
+// NewCoupleGuardService 创建情侣资源授权服务
+func NewCoupleGuardService(repoFactory repository.Factory) CoupleGuardService {
+	return &coupleGuardService{
+		userRepo: repoFactory.User(),
+	}
+}
+
+// ResolveCoupleID 获取用户所属的情侣ID，没有情侣关系时返回0
+func (s *coupleGuardService) ResolveCoupleID(ctx context.Context, userID int64) (int64, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return 0, fmt.Errorf("查询用户失败: %w", err)
+	}
+	return user.CoupleID, nil
+}
+
+// uniqueIDs 去除重复和无效的ID
+func uniqueIDs(ids []int64) []int64 {
+	seen := make(map[int64]struct{}, len(ids))
+	result := make([]int64, 0, len(ids))
+	for _, id := range ids {
+		if id <= 0 {
+			continue
+		}
+		if _, ok := seen[id]; ok {
+			continue
+		}
+		seen[id] = struct{}{}
+		result = append(result, id)
+	}
+	return result
+}
@@ -0,0 +1,274 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"memoir-api/internal/email"
+	"memoir-api/internal/logger"
+	"memoir-api/internal/models"
+	"memoir-api/internal/repository"
+
+	"github.com/go-redis/redis/v8"
+)
+
+const (
+	// CoupleInvitePrefix 邀请码键前缀，值为邀请信息
+	CoupleInvitePrefix = "couple:invite:"
+	// CoupleInviteByCouplePrefix 情侣当前有效邀请码的键前缀，生成新邀请码时作废旧的
+	CoupleInviteByCouplePrefix = "couple:invite_by_couple:"
+
+	// CoupleInviteExpiry 邀请码有效期，邀请邮件和模板预览使用同一个值
+	CoupleInviteExpiry = email.CoupleInviteExpiry
+
+	// coupleInviteCodeLength 邀请码长度
+	coupleInviteCodeLength = 8
+	// coupleInviteCharset 邀请码字符集，去掉了容易混淆的 0/O、1/I/L
+	coupleInviteCharset = "ABCDEFGHJKMNPQRSTUVWXYZ23456789"
+)
+
+var (
+	ErrInvalidInviteCode = errors.New("邀请码无效或已过期")
+	ErrAlreadyInCouple   = errors.New("用户已有情侣关系")
+	ErrCoupleFull        = errors.New("情侣关系成员已满")
+	ErrCannotJoinOwnCode = errors.New("不能使用自己生成的邀请码")
+)
+
+// CoupleInvite 配对邀请
+type CoupleInvite struct {
+	Code      string    `json:"code"`
+	Link      string    `json:"link"`
+	CoupleID  int64     `json:"couple_id,string"`
+	InviterID int64     `json:"inviter_id,string"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// CoupleInviteService 情侣配对邀请服务接口
+type CoupleInviteService interface {
+	Service
+	// CreateInvite 生成一次性邀请码，邀请人还没有情侣关系时先创建；inviteeEmail 不为空时发送邀请邮件
+	CreateInvite(ctx context.Context, inviterID int64, inviteeEmail string) (*CoupleInvite, error)
+	// JoinCouple 使用邀请码加入情侣关系
+	JoinCouple(ctx context.Context, userID int64, code string) (*models.Couple, error)
+}
+
+// coupleInviteService 基于Redis的邀请码实现
+type coupleInviteService struct {
+	*BaseService
+	redis      *redis.Client
+	userRepo   repository.UserRepository
+	coupleRepo repository.CoupleRepository
+	emailSvc   EmailService
+	auditSvc   AuditService
+	appURL     string // 用于生成邀请链接
+	log        logger.Logger
+}
+
+// NewCoupleInviteService 创建情侣配对邀请服务
+func NewCoupleInviteService(
+	redisClient *redis.Client,
+	userRepo repository.UserRepository,
+	coupleRepo repository.CoupleRepository,
+	emailSvc EmailService,
+	auditSvc AuditService,
+	appURL string,
+) CoupleInviteService {
+	return &coupleInviteService{
+		BaseService: NewBaseService(coupleRepo),
+		redis:       redisClient,
+		userRepo:    userRepo,
+		coupleRepo:  coupleRepo,
+		emailSvc:    emailSvc,
+		auditSvc:    auditSvc,
+		appURL:      appURL,
+		log:         logger.GetLogger("couple-invite"),
+	}
+}
+
+// generateInviteCode 生成随机邀请码
+func generateInviteCode() (string, error) {
+	b := make([]byte, coupleInviteCodeLength)
+	for i := range b {
+		n, err := rand.Int(rand.Reader, big.NewInt(int64(len(coupleInviteCharset))))
+		if err != nil {
+			return "", fmt.Errorf("生成邀请码失败: %w", err)
+		}
+		b[i] = coupleInviteCharset[n.Int64()]
+	}
+	return string(b), nil
+}
+
+// normalizeInviteCode 邀请码不区分大小写，忽略首尾空白
+func normalizeInviteCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// CreateInvite 生成一次性邀请码
+func (s *coupleInviteService) CreateInvite(ctx context.Context, inviterID int64, inviteeEmail string) (*CoupleInvite, error) {
+	inviter, err := s.userRepo.GetByID(ctx, inviterID)
+	if err != nil {
+		return nil, err
+	}
+
+	coupleID := inviter.CoupleID
+	if coupleID == 0 {
+		// 还没有情侣关系时先创建，邀请码加入的是这个新的情侣关系
+		couple := &models.Couple{
+			PairToken:             GeneratePairToken(),
+			AutoGenerateVideo:     true,
+			ReminderNotifications: true,
+		}
+		// 创建和加入在同一个事务中完成，并发的邀请请求不会留下空的情侣关系
+		if err := s.userRepo.CreateCoupleAndJoin(ctx, inviterID, couple); err != nil {
+			if errors.Is(err, repository.ErrUserAlreadyPaired) {
+				return nil, ErrAlreadyInCouple
+			}
+			return nil, fmt.Errorf("创建情侣关系失败: %w", err)
+		}
+		coupleID = couple.ID
+	} else {
+		couple, err := s.coupleRepo.GetByID(ctx, coupleID)
+		if err != nil {
+			return nil, fmt.Errorf("查询情侣关系失败: %w", err)
+		}
+		if couple.IsDissolving() {
+			return nil, ErrCoupleDissolving
+		}
+
+		members, err := s.userRepo.ListByCoupleID(ctx, coupleID)
+		if err != nil {
+			return nil, fmt.Errorf("查询情侣成员失败: %w", err)
+		}
+		if len(members) >= repository.MaxCoupleMembers {
+			return nil, ErrCoupleFull
+		}
+	}
+
+	code, err := generateInviteCode()
+	if err != nil {
+		return nil, err
+	}
+	invite := &CoupleInvite{
+		Code:      code,
+		Link:      fmt.Sprintf("%s/couple/join?code=%s", s.appURL, code),
+		CoupleID:  coupleID,
+		InviterID: inviterID,
+		ExpiresAt: time.Now().Add(CoupleInviteExpiry),
+	}
+	payload, err := json.Marshal(invite)
+	if err != nil {
+		return nil, fmt.Errorf("序列化邀请信息失败: %w", err)
+	}
+
+	// 同一个情侣关系只保留最新的邀请码
+	coupleKey := fmt.Sprintf("%s%d", CoupleInviteByCouplePrefix, coupleID)
+	if previous, err := s.redis.Get(ctx, coupleKey).Result(); err == nil {
+		s.redis.Del(ctx, CoupleInvitePrefix+previous)
+	} else if !errors.Is(err, redis.Nil) {
+		return nil, fmt.Errorf("查询邀请码失败: %w", err)
+	}
+
+	_, err = s.redis.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, CoupleInvitePrefix+code, payload, CoupleInviteExpiry)
+		pipe.Set(ctx, coupleKey, code, CoupleInviteExpiry)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("保存邀请码失败: %w", err)
+	}
+
+	s.auditSvc.Record(ctx, AuditEntry{
+		ActorID:    inviterID,
+		CoupleID:   coupleID,
+		Action:     models.AuditActionCoupleInvite,
+		EntityType: models.AuditEntityCouple,
+		EntityID:   AuditEntityID(coupleID),
+		After:      map[string]interface{}{"invitee_email": inviteeEmail, "expires_at": invite.ExpiresAt},
+	})
+
+	// 邀请邮件发送失败不影响邀请码，用户仍可以手动分享
+	if inviteeEmail != "" {
+		// 还不知道被邀请人的语言，使用邀请人的语言
+		to := email.Recipient{Address: inviteeEmail, Locale: inviter.Language}
+		if err := s.emailSvc.SendCoupleInviteEmail(ctx, to, inviter.Username, code, int(CoupleInviteExpiry/time.Hour)); err != nil {
+			s.log.Error(err, "发送配对邀请邮件失败", "couple_id", coupleID)
+		}
+	}
+
+	return invite, nil
+}
+
+// JoinCouple 使用邀请码加入情侣关系
+func (s *coupleInviteService) JoinCouple(ctx context.Context, userID int64, code string) (*models.Couple, error) {
+	code = normalizeInviteCode(code)
+	if code == "" {
+		return nil, ErrInvalidInviteCode
+	}
+
+	payload, err := s.redis.Get(ctx, CoupleInvitePrefix+code).Result()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, ErrInvalidInviteCode
+		}
+		return nil, fmt.Errorf("查询邀请码失败: %w", err)
+	}
+	var invite CoupleInvite
+	if err := json.Unmarshal([]byte(payload), &invite); err != nil {
+		return nil, fmt.Errorf("解析邀请信息失败: %w", err)
+	}
+
+	if invite.InviterID == userID {
+		return nil, ErrCannotJoinOwnCode
+	}
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.CoupleID != 0 {
+		return nil, ErrAlreadyInCouple
+	}
+
+	// JoinCouple 在锁内检查成员数，同一个邀请码被并发使用时只有一个人能加入，
+	// 所以邀请码在加入成功后才删除，加入失败（例如数据库错误）时邀请码仍然可用
+	if err := s.userRepo.JoinCouple(ctx, userID, invite.CoupleID); err != nil {
+		switch {
+		case errors.Is(err, repository.ErrCoupleFull):
+			return nil, ErrCoupleFull
+		case errors.Is(err, repository.ErrUserAlreadyPaired):
+			return nil, ErrAlreadyInCouple
+		case errors.Is(err, repository.ErrCoupleNotFound), errors.Is(err, repository.ErrCoupleStatusConflict):
+			return nil, ErrInvalidInviteCode
+		}
+		return nil, fmt.Errorf("加入情侣关系失败: %w", err)
+	}
+
+	// 删除失败不影响结果：情侣关系已满，剩下的邀请码无法再被使用，到期后自动清除
+	if err := s.redis.Del(ctx, CoupleInvitePrefix+code, fmt.Sprintf("%s%d", CoupleInviteByCouplePrefix, invite.CoupleID)).Err(); err != nil {
+		s.log.Error(err, "删除已使用的邀请码失败", "couple_id", invite.CoupleID)
+	}
+
+	s.auditSvc.Record(ctx, AuditEntry{
+		ActorID:    userID,
+		CoupleID:   invite.CoupleID,
+		Action:     models.AuditActionCoupleJoin,
+		EntityType: models.AuditEntityCouple,
+		EntityID:   AuditEntityID(invite.CoupleID),
+		After:      map[string]int64{"inviter_id": invite.InviterID},
+	})
+
+	// 通知邀请人，发送失败只记录日志
+	if inviter, err := s.userRepo.GetByID(ctx, invite.InviterID); err == nil {
+		message := fmt.Sprintf("%s 已接受您的邀请，你们现在是情侣啦！", user.Username)
+		if err := s.emailSvc.SendNotificationEmail(ctx, emailRecipient(inviter), message); err != nil {
+			s.log.Error(err, "发送配对成功通知失败", "couple_id", invite.CoupleID)
+		}
+	}
+
+	return s.coupleRepo.GetByID(ctx, invite.CoupleID)
+}
@@ -2,18 +2,25 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"memoir-api/internal/api/dto"
+	"memoir-api/internal/email"
+	"memoir-api/internal/festival"
 	"memoir-api/internal/logger"
+	"memoir-api/internal/models"
 	"memoir-api/internal/repository"
+	"strconv"
+	"strings"
 	"time"
 )
 
-// 中国传统节日和西方节日
-var festivals = map[string]string{
-	"01-14": "情人节", // 2月14日，但月份从0开始
-	"05-20": "520", // 5月20日，中国网络情人节
-	"06-07": "七夕",  // 农历七月初七，这里使用公历近似值
-}
+// reminderDeliveryStaleAfter 发送记录占用超过这个时间仍未完成时，认为发送的实例已经中断，可以重新发送
+const reminderDeliveryStaleAfter = 15 * time.Minute
+
+var (
+	ErrFestivalNotFound = errors.New("节日不存在")
+)
 
 // 重要纪念日天数
 var anniversaryDays = []int{
@@ -34,61 +41,155 @@ type CoupleReminderService interface {
 	CheckAndSendAnniversaryReminders(ctx context.Context) error
 	// 检查并发送节日邮件
 	CheckAndSendFestivalReminders(ctx context.Context) error
-	// 计算恋爱天数
-	CalculateCoupleDays(anniversaryDate time.Time) int
+	// CalculateCoupleDays 按情侣时区计算在一起的天数，没有设置起点时返回0
+	CalculateCoupleDays(ctx context.Context, coupleID int64) (int, error)
+	// ListFestivals 获取所有节日及情侣的提醒开关
+	ListFestivals(ctx context.Context, coupleID int64) ([]dto.CoupleFestivalDTO, error)
+	// SetFestivalEnabled 开关情侣某个节日的提醒
+	SetFestivalEnabled(ctx context.Context, coupleID int64, festivalKey string, enabled bool) error
+	// ListDeliveries 分页获取情侣的提醒发送记录
+	ListDeliveries(ctx context.Context, coupleID int64, offset, limit int) ([]*models.ReminderDelivery, int64, error)
 }
 
 // coupleReminderService 情侣纪念日服务实现
 type coupleReminderService struct {
 	*BaseService
-	coupleRepo repository.CoupleRepository
-	userRepo   repository.UserRepository
-	emailSvc   EmailService
-	log        logger.Logger
+	coupleRepo      repository.CoupleRepository
+	userRepo        repository.UserRepository
+	anniversaryRepo repository.CoupleAnniversaryRepository
+	festivalRepo    repository.CoupleFestivalSettingRepository
+	deliveryRepo    repository.ReminderDeliveryRepository
+	emailSvc        EmailService
+	preferenceSvc   EmailPreferenceService
+	auditSvc        AuditService
+	log             logger.Logger
+	// requireVerifiedEmail 为true时，未验证邮箱的用户不接收提醒邮件
+	requireVerifiedEmail bool
 }
 
 // NewCoupleReminderService 创建情侣纪念日服务实例
 func NewCoupleReminderService(
 	coupleRepo repository.CoupleRepository,
 	userRepo repository.UserRepository,
+	anniversaryRepo repository.CoupleAnniversaryRepository,
+	festivalRepo repository.CoupleFestivalSettingRepository,
+	deliveryRepo repository.ReminderDeliveryRepository,
 	emailSvc EmailService,
+	preferenceSvc EmailPreferenceService,
+	auditSvc AuditService,
+	requireVerifiedEmail bool,
 ) CoupleReminderService {
 	return &coupleReminderService{
-		BaseService: NewBaseService(coupleRepo),
-		coupleRepo:  coupleRepo,
-		userRepo:    userRepo,
-		emailSvc:    emailSvc,
-		log:         logger.GetLogger("couple-reminder-service"),
+		BaseService:          NewBaseService(coupleRepo),
+		coupleRepo:           coupleRepo,
+		userRepo:             userRepo,
+		anniversaryRepo:      anniversaryRepo,
+		festivalRepo:         festivalRepo,
+		deliveryRepo:         deliveryRepo,
+		emailSvc:             emailSvc,
+		preferenceSvc:        preferenceSvc,
+		auditSvc:             auditSvc,
+		log:                  logger.GetLogger("couple-reminder-service"),
+		requireVerifiedEmail: requireVerifiedEmail,
+	}
+}
+
+// canReceiveReminder 判断用户是否可以接收提醒邮件
+func canReceiveReminder(log logger.Logger, user *models.User, requireVerifiedEmail bool) bool {
+	if requireVerifiedEmail && !user.IsEmailVerified() {
+		log.Info("用户邮箱未验证，跳过提醒邮件", "userID", user.ID)
+		return false
+	}
+	return true
+}
+
+// wantsEmail 判断用户是否接收这类邮件，查询偏好失败时跳过，下一次任务执行时会重新发送
+func wantsEmail(ctx context.Context, preferenceSvc EmailPreferenceService, log logger.Logger, user *models.User, emailType email.EmailType) bool {
+	enabled, err := preferenceSvc.IsEnabled(ctx, user.ID, emailType)
+	if err != nil {
+		log.Error(err, "获取邮件偏好失败，跳过提醒邮件", "userID", user.ID, "type", emailType)
+		return false
+	}
+	if !enabled {
+		log.Info("用户已退订该类邮件，跳过", "userID", user.ID, "type", emailType)
+		return false
+	}
+	return true
+}
+
+// coupleWantsReminders 判断情侣是否开启了提醒，已解除的情侣关系不再提醒
+func (s *coupleReminderService) coupleWantsReminders(couple *models.Couple) bool {
+	return couple.ReminderNotifications && couple.IsActive()
+}
+
+// dueCouples 按时区分组获取当地时间已到提醒时刻的情侣。任务每小时执行，提醒时刻之后的每次执行
+// 都会再检查一遍，配合发送记录去重，错过的整点（如实例重启、夏令时跳过的时刻）会在当天之后补发
+func dueCouples(ctx context.Context, coupleRepo repository.CoupleRepository, log logger.Logger, now time.Time) ([]*models.Couple, error) {
+	timezones, err := coupleRepo.ListReminderTimezones(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var couples []*models.Couple
+	for _, timezone := range timezones {
+		local := now.In(models.LoadCoupleLocation(timezone))
+		due, err := coupleRepo.ListDueForReminder(ctx, timezone, local.Hour())
+		if err != nil {
+			log.Error(err, "获取待提醒情侣失败", "timezone", timezone)
+			continue
+		}
+		couples = append(couples, due...)
 	}
+	return couples, nil
 }
 
-// CheckAndSendAnniversaryReminders 检查并发送纪念日邮件
+// CheckAndSendAnniversaryReminders 检查并发送纪念日邮件，包括恋爱天数里程碑和自定义纪念日
 func (s *coupleReminderService) CheckAndSendAnniversaryReminders(ctx context.Context) error {
 	s.log.Info("开始检查情侣纪念日")
 
-	// 获取所有情侣关系
-	couples, _, err := s.coupleRepo.List(ctx, 0, -1)
+	// 获取当地时间已到提醒时刻的情侣
+	now := time.Now()
+	couples, err := dueCouples(ctx, s.coupleRepo, s.log, now)
 	if err != nil {
 		s.log.Error(err, "获取情侣列表失败")
 		return err
 	}
 
-	today := time.Now()
-
 	for _, couple := range couples {
-		// 计算恋爱天数
-		days := s.CalculateCoupleDays(couple.AnniversaryDate)
+		if !s.coupleWantsReminders(couple) {
+			continue
+		}
+
+		anniversaries, err := s.anniversaryRepo.ListByCoupleID(ctx, couple.ID)
+		if err != nil {
+			s.log.Error(err, "获取纪念日失败", "coupleID", couple.ID)
+			continue
+		}
 
-		// 检查是否是重要纪念日
+		// 按情侣设置的时区判断今天
+		today := now.In(couple.Location())
+		dateStr := today.Format("2006-01-02")
+
+		// 检查恋爱天数是否是重要纪念日
+		days, hasStart := coupleDaysAt(couple, anniversaries, now)
 		isSpecialDay := false
 		for _, specialDay := range anniversaryDays {
-			if days == specialDay {
+			if hasStart && days == specialDay {
 				isSpecialDay = true
 				break
 			}
 		}
 
-		if !isSpecialDay {
+		// 检查自定义纪念日是否到了提醒的日子
+		var dueAnniversaries []*models.CoupleAnniversary
+		for _, anniversary := range anniversaries {
+			next, ok := anniversary.NextOccurrence(today)
+			if ok && models.DaysBetween(today, next) == anniversary.ReminderDaysBefore {
+				dueAnniversaries = append(dueAnniversaries, anniversary)
+			}
+		}
+
+		if !isSpecialDay && len(dueAnniversaries) == 0 {
 			continue
 		}
 
@@ -104,63 +205,138 @@ func (s *coupleReminderService) CheckAndSendAnniversaryReminders(ctx context.Con
 			continue
 		}
 
-		// 发送纪念日邮件给两个用户
-		dateStr := today.Format("2006-01-02")
-
-		// 给第一个用户发送邮件
-		err = s.emailSvc.SendAnniversaryEmail(
-			ctx,
-			users[0].Email,
-			users[0].Username,
-			users[1].Username,
-			days,
-			dateStr,
-		)
-		if err != nil {
-			s.log.Error(err, "发送纪念日邮件失败", "userID", users[0].ID)
-		}
-
-		// 给第二个用户发送邮件
-		err = s.emailSvc.SendAnniversaryEmail(
-			ctx,
-			users[1].Email,
-			users[1].Username,
-			users[0].Username,
-			days,
-			dateStr,
-		)
-		if err != nil {
-			s.log.Error(err, "发送纪念日邮件失败", "userID", users[1].ID)
+		if isSpecialDay {
+			occurrence := reminderOccurrence{
+				CoupleID: couple.ID,
+				Kind:     models.ReminderKindCoupleDays,
+				Key:      strconv.Itoa(days),
+				Date:     today,
+				Title:    fmt.Sprintf(coupleDaysMilestoneTitle, days),
+			}
+			s.sendToCouple(ctx, users, email.EmailTypeAnniversary, occurrence, func(user, partner *models.User) error {
+				return s.emailSvc.SendAnniversaryEmail(ctx, coupleEmailRecipient(user, couple), partner.Username, days, dateStr)
+			})
+			s.log.Info("已发送纪念日邮件", "coupleID", couple.ID, "days", days)
 		}
 
-		s.log.Info("已发送纪念日邮件", "coupleID", couple.ID, "days", days)
+		for _, anniversary := range dueAnniversaries {
+			next, _ := anniversary.NextOccurrence(today)
+			daysUntil := models.DaysBetween(today, next)
+			occurrence := reminderOccurrence{
+				CoupleID: couple.ID,
+				Kind:     models.ReminderKindAnniversary,
+				Key:      strconv.FormatInt(anniversary.ID, 10),
+				Date:     next,
+				Title:    anniversary.Title,
+			}
+			s.sendToCouple(ctx, users, email.EmailTypeCustomAnniversary, occurrence, func(user, partner *models.User) error {
+				return s.emailSvc.SendCustomAnniversaryEmail(ctx, coupleEmailRecipient(user, couple), partner.Username,
+					anniversary.Title, next.Format("2006-01-02"), daysUntil)
+			})
+			s.log.Info("已发送自定义纪念日邮件", "coupleID", couple.ID, "anniversaryID", anniversary.ID, "daysUntil", daysUntil)
+		}
 	}
 
 	s.log.Info("情侣纪念日检查完成")
 	return nil
 }
 
-// CheckAndSendFestivalReminders 检查并发送节日邮件
-func (s *coupleReminderService) CheckAndSendFestivalReminders(ctx context.Context) error {
-	s.log.Info("开始检查节日提醒")
+// reminderOccurrence 某一次提醒，情侣、类型、Key 和日期相同的提醒对每个收件人只发送一次
+type reminderOccurrence struct {
+	CoupleID int64
+	Kind     string
+	Key      string
+	Date     time.Time
+	Title    string
+}
 
-	today := time.Now()
-	monthDay := fmt.Sprintf("%02d-%02d", today.Month(), today.Day())
+// sendToCouple 给情侣双方分别发送邮件，send 的第二个参数是收件人的另一半。
+// 退订了这类邮件的用户不发送；发送前先在发送记录中占用，已经发送过或正在由其他实例发送的提醒会跳过
+func (s *coupleReminderService) sendToCouple(ctx context.Context, users []*models.User, emailType email.EmailType, occurrence reminderOccurrence, send func(user, partner *models.User) error) {
+	for i, user := range users {
+		if !canReceiveReminder(s.log, user, s.requireVerifiedEmail) || !wantsEmail(ctx, s.preferenceSvc, s.log, user, emailType) {
+			continue
+		}
+		partner := users[1-i]
+		deliverOnce(ctx, s.deliveryRepo, s.log, occurrence, user, func() error {
+			return send(user, partner)
+		})
+	}
+}
 
-	festivalName, isFestival := festivals[monthDay]
-	if !isFestival {
-		s.log.Info("今天不是特殊节日", "date", monthDay)
-		return nil
+// deliverOnce 在发送记录中占用这次提醒后发送，已经发送过或正在由其他实例发送时跳过
+func deliverOnce(ctx context.Context, deliveryRepo repository.ReminderDeliveryRepository, log logger.Logger, occurrence reminderOccurrence, recipient *models.User, send func() error) {
+	delivery := &models.ReminderDelivery{
+		CoupleID:    occurrence.CoupleID,
+		Kind:        occurrence.Kind,
+		ReminderKey: occurrence.Key,
+		// 只保留日期，避免数据库按会话时区换算后落到前一天
+		OccurrenceDate: time.Date(occurrence.Date.Year(), occurrence.Date.Month(), occurrence.Date.Day(), 0, 0, 0, 0, time.UTC),
+		RecipientID:    recipient.ID,
+		Title:          occurrence.Title,
 	}
+	claimed, err := deliveryRepo.Claim(ctx, delivery, reminderDeliveryStaleAfter)
+	if err != nil {
+		log.Error(err, "占用提醒发送记录失败", "userID", recipient.ID, "kind", occurrence.Kind)
+		return
+	}
+	if !claimed {
+		log.Info("提醒已发送过，跳过", "userID", recipient.ID, "kind", occurrence.Kind, "key", occurrence.Key)
+		return
+	}
+
+	if err := send(); err != nil {
+		log.Error(err, "发送提醒邮件失败", "userID", recipient.ID, "kind", occurrence.Kind)
+		if err := deliveryRepo.MarkFailed(ctx, delivery.ID, err.Error()); err != nil {
+			log.Error(err, "更新提醒发送记录失败", "deliveryID", delivery.ID)
+		}
+		return
+	}
+	if err := deliveryRepo.MarkSent(ctx, delivery.ID, time.Now()); err != nil {
+		log.Error(err, "更新提醒发送记录失败", "deliveryID", delivery.ID)
+	}
+}
+
+// CheckAndSendFestivalReminders 检查并发送节日邮件，按情侣的时区判断今天是什么节日
+func (s *coupleReminderService) CheckAndSendFestivalReminders(ctx context.Context) error {
+	s.log.Info("开始检查节日提醒")
 
-	// 获取所有情侣关系
-	couples, _, err := s.coupleRepo.List(ctx, 0, 1000)
+	// 获取当地时间已到提醒时刻的情侣
+	now := time.Now()
+	couples, err := dueCouples(ctx, s.coupleRepo, s.log, now)
 	if err != nil {
 		s.log.Error(err, "获取情侣列表失败")
 		return err
 	}
 
 	for _, couple := range couples {
+		if !s.coupleWantsReminders(couple) {
+			continue
+		}
+
+		today := now.In(couple.Location())
+		todayFestivals := festival.On(today)
+		if len(todayFestivals) == 0 {
+			continue
+		}
+
+		disabled, err := s.disabledFestivals(ctx, couple.ID)
+		if err != nil {
+			s.log.Error(err, "获取节日提醒开关失败", "coupleID", couple.ID)
+			continue
+		}
+
+		var enabled []festival.Festival
+		for _, f := range todayFestivals {
+			if !disabled[f.Key] {
+				enabled = append(enabled, f)
+			}
+		}
+		if len(enabled) == 0 {
+			continue
+		}
+		festivalName := festivalNames(enabled, email.LocaleZhCN)
+
 		// 获取情侣用户
 		users, err := s.userRepo.ListByCoupleID(ctx, couple.ID)
 		if err != nil {
@@ -173,29 +349,17 @@ func (s *coupleReminderService) CheckAndSendFestivalReminders(ctx context.Contex
 			continue
 		}
 
-		// 给第一个用户发送节日邮件
-		err = s.emailSvc.SendFestivalEmail(
-			ctx,
-			users[0].Email,
-			users[0].Username,
-			users[1].Username,
-			festivalName,
-		)
-		if err != nil {
-			s.log.Error(err, "发送节日邮件失败", "userID", users[0].ID)
-		}
-
-		// 给第二个用户发送节日邮件
-		err = s.emailSvc.SendFestivalEmail(
-			ctx,
-			users[1].Email,
-			users[1].Username,
-			users[0].Username,
-			festivalName,
-		)
-		if err != nil {
-			s.log.Error(err, "发送节日邮件失败", "userID", users[1].ID)
+		// 同一天的节日合并为一封邮件，每天最多发送一次
+		occurrence := reminderOccurrence{
+			CoupleID: couple.ID,
+			Kind:     models.ReminderKindFestival,
+			Date:     today,
+			Title:    festivalName,
 		}
+		s.sendToCouple(ctx, users, email.EmailTypeFestival, occurrence, func(user, partner *models.User) error {
+			to := coupleEmailRecipient(user, couple)
+			return s.emailSvc.SendFestivalEmail(ctx, to, partner.Username, festivalNames(enabled, to.Locale))
+		})
 
 		s.log.Info("已发送节日邮件", "coupleID", couple.ID, "festival", festivalName)
 	}
@@ -204,10 +368,109 @@ func (s *coupleReminderService) CheckAndSendFestivalReminders(ctx context.Contex
 	return nil
 }
 
-// CalculateCoupleDays 计算恋爱天数
-func (s *coupleReminderService) CalculateCoupleDays(anniversaryDate time.Time) int {
-	now := time.Now()
-	duration := now.Sub(anniversaryDate)
-	days := int(duration.Hours() / 24)
-	return days
+// ListFestivals 获取所有节日及情侣的提醒开关
+func (s *coupleReminderService) ListFestivals(ctx context.Context, coupleID int64) ([]dto.CoupleFestivalDTO, error) {
+	couple, err := s.coupleRepo.GetByID(ctx, coupleID)
+	if err != nil {
+		return nil, err
+	}
+	disabled, err := s.disabledFestivals(ctx, coupleID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().In(couple.Location())
+	result := make([]dto.CoupleFestivalDTO, 0, len(festival.Festivals))
+	for _, f := range festival.Festivals {
+		item := dto.CoupleFestivalDTO{
+			Key:     f.Key,
+			Name:    f.Name,
+			Kind:    string(f.Kind),
+			Enabled: !disabled[f.Key],
+		}
+		if next, err := f.Next(now); err == nil {
+			item.NextDate = next.Format("2006-01-02")
+		}
+		result = append(result, item)
+	}
+	return result, nil
+}
+
+// SetFestivalEnabled 开关情侣某个节日的提醒
+func (s *coupleReminderService) SetFestivalEnabled(ctx context.Context, coupleID int64, festivalKey string, enabled bool) error {
+	if _, ok := festival.Lookup(festivalKey); !ok {
+		return ErrFestivalNotFound
+	}
+	disabled, err := s.disabledFestivals(ctx, coupleID)
+	if err != nil {
+		return err
+	}
+	if err := s.festivalRepo.Upsert(ctx, coupleID, festivalKey, enabled); err != nil {
+		return fmt.Errorf("保存节日提醒开关失败: %w", err)
+	}
+
+	s.auditSvc.Record(ctx, AuditEntry{
+		CoupleID:   coupleID,
+		Action:     models.AuditActionUpdate,
+		EntityType: models.AuditEntityCouple,
+		EntityID:   AuditEntityID(coupleID),
+		Before:     map[string]bool{"festival." + festivalKey: !disabled[festivalKey]},
+		After:      map[string]bool{"festival." + festivalKey: enabled},
+	})
+	return nil
+}
+
+// disabledFestivals 获取情侣关闭了提醒的节日
+func (s *coupleReminderService) disabledFestivals(ctx context.Context, coupleID int64) (map[string]bool, error) {
+	settings, err := s.festivalRepo.ListByCoupleID(ctx, coupleID)
+	if err != nil {
+		return nil, err
+	}
+	disabled := make(map[string]bool, len(settings))
+	for _, setting := range settings {
+		if !setting.Enabled {
+			disabled[setting.FestivalKey] = true
+		}
+	}
+	return disabled, nil
+}
+
+// ListDeliveries 分页获取情侣的提醒发送记录
+func (s *coupleReminderService) ListDeliveries(ctx context.Context, coupleID int64, offset, limit int) ([]*models.ReminderDelivery, int64, error) {
+	deliveries, total, err := s.deliveryRepo.ListByCoupleID(ctx, coupleID, offset, limit)
+	if err != nil {
+		return nil, 0, fmt.Errorf("查询提醒发送记录失败: %w", err)
+	}
+	return deliveries, total, nil
+}
+
+// CalculateCoupleDays 按情侣时区计算在一起的天数
+func (s *coupleReminderService) CalculateCoupleDays(ctx context.Context, coupleID int64) (int, error) {
+	couple, err := s.coupleRepo.GetByID(ctx, coupleID)
+	if err != nil {
+		return 0, err
+	}
+	anniversaries, err := s.anniversaryRepo.ListByCoupleID(ctx, coupleID)
+	if err != nil {
+		return 0, fmt.Errorf("查询纪念日失败: %w", err)
+	}
+	days, _ := coupleDaysAt(couple, anniversaries, time.Now())
+	return days, nil
+}
+
+// festivalNames 把同一天的多个节日按收件人的语言合并成一个名称
+func festivalNames(festivals []festival.Festival, locale string) string {
+	english := email.NormalizeLocale(locale) == email.LocaleEnUS
+	names := make([]string, 0, len(festivals))
+	for _, f := range festivals {
+		if english && f.EnglishName != "" {
+			names = append(names, f.EnglishName)
+		} else {
+			names = append(names, f.Name)
+		}
+	}
+	if english {
+		return strings.Join(names, " & ")
+	}
+	return strings.Join(names, "、")
 }
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"memoir-api/internal/api/dto"
+	"memoir-api/internal/email"
 	"memoir-api/internal/models"
 	"memoir-api/internal/repository"
 )
@@ -20,13 +21,25 @@ type CoupleService interface {
 	ListCouples(ctx context.Context, offset, limit int) ([]*models.Couple, int64, error)
 	GetCoupleUsers(ctx context.Context, coupleID int64) ([]*models.User, error)
 	GetCoupleInfo(ctx context.Context, userId int64) (*dto.CoupleInfoDTO, error)
+	// GetSettings 获取用户所在情侣关系的设置
+	GetSettings(ctx context.Context, userID int64) (*dto.CoupleSettingsResponse, error)
+	// UpdateSettings 更新情侣设置，提醒邮件开关只对当前用户生效
+	UpdateSettings(ctx context.Context, userID int64, req *dto.UpdateCoupleSettingsRequest) (*dto.CoupleSettingsResponse, error)
 }
 
+var (
+	ErrInvalidTimezone        = errors.New("无效的时区")
+	ErrInvalidAnniversaryDate = errors.New("纪念日不能晚于今天")
+)
+
 // coupleService 情侣关系服务实现
 type coupleService struct {
 	*BaseService
-	coupleRepo repository.CoupleRepository
-	userRepo   repository.UserRepository
+	coupleRepo      repository.CoupleRepository
+	userRepo        repository.UserRepository
+	anniversaryRepo repository.CoupleAnniversaryRepository
+	preferenceSvc   EmailPreferenceService
+	auditSvc        AuditService
 }
 
 func (s *coupleService) GetCoupleInfo(ctx context.Context, userId int64) (*dto.CoupleInfoDTO, error) {
@@ -54,22 +67,23 @@ func (s *coupleService) GetCoupleInfo(ctx context.Context, userId int64) (*dto.C
 	} else {
 		coupleName = coupleUsers[0].Username
 	}
-	var coupleDays int
-	if !couple.AnniversaryDate.IsZero() {
-		coupleDays = int(time.Since(couple.AnniversaryDate).Hours() / 24)
-		if coupleDays < 0 {
-			coupleDays = 0
-		}
+	// 与仪表盘使用同一套计算：按情侣时区的日历日期，没有设置 AnniversaryDate 时以 together 纪念日为起点
+	anniversaries, err := s.anniversaryRepo.ListByCoupleID(ctx, couple.ID)
+	if err != nil {
+		return nil, err
 	}
+	coupleDays, _ := coupleDaysAt(couple, anniversaries, time.Now())
 	var anniversaryDate string
 	if !couple.AnniversaryDate.IsZero() {
 		anniversaryDate = couple.AnniversaryDate.Format("2006-01-02")
 	}
 	return &dto.CoupleInfoDTO{
-		couple.ID,
-		coupleName,
-		coupleDays,
-		anniversaryDate,
+		CoupleId:        couple.ID,
+		CoupleName:      coupleName,
+		CoupleDays:      coupleDays,
+		AnniversaryDate: anniversaryDate,
+		Status:          couple.Status,
+		DissolveAt:      couple.DissolveAt,
 	}, nil
 
 }
@@ -78,11 +92,17 @@ func (s *coupleService) GetCoupleInfo(ctx context.Context, userId int64) (*dto.C
 func NewCoupleService(
 	coupleRepo repository.CoupleRepository,
 	userRepo repository.UserRepository,
+	anniversaryRepo repository.CoupleAnniversaryRepository,
+	preferenceSvc EmailPreferenceService,
+	auditSvc AuditService,
 ) CoupleService {
 	return &coupleService{
-		BaseService: NewBaseService(coupleRepo),
-		coupleRepo:  coupleRepo,
-		userRepo:    userRepo,
+		BaseService:     NewBaseService(coupleRepo),
+		coupleRepo:      coupleRepo,
+		userRepo:        userRepo,
+		anniversaryRepo: anniversaryRepo,
+		preferenceSvc:   preferenceSvc,
+		auditSvc:        auditSvc,
 	}
 }
 
@@ -108,25 +128,40 @@ func (s *coupleService) CreateCouple(ctx context.Context, req *dto.CreateCoupleR
 		}
 	}
 
-	// 创建情侣关系
-	if err := s.coupleRepo.Create(ctx, &couple); err != nil {
-		return nil, err
-	}
-
 	//获取用户id
 	userID, exists := ctx.Value("user_id").(int64)
 	if !exists {
 		return nil, errors.New("user_id not found")
 	}
-	//更新用户情侣id
 	user, err := s.userRepo.GetByID(ctx, userID)
 	if err != nil {
 		return nil, err
 	}
-	user.CoupleID = couple.ID
-	if err := s.userRepo.Update(ctx, user); err != nil {
+	if user.CoupleID != 0 {
+		return nil, ErrAlreadyInCouple
+	}
+
+	// 创建和加入在同一个事务中完成，用户已配对时不会留下空的情侣关系
+	if err := s.userRepo.CreateCoupleAndJoin(ctx, userID, &couple); err != nil {
+		if errors.Is(err, repository.ErrUserAlreadyPaired) {
+			return nil, ErrAlreadyInCouple
+		}
 		return nil, err
 	}
+
+	s.auditSvc.Record(ctx, AuditEntry{
+		ActorID:    userID,
+		CoupleID:   couple.ID,
+		Action:     models.AuditActionCreate,
+		EntityType: models.AuditEntityCouple,
+		EntityID:   AuditEntityID(couple.ID),
+		// 配对口令是加入情侣关系的凭据，不写入审计日志
+		After: map[string]interface{}{
+			"anniversary_date":       couple.AnniversaryDate,
+			"auto_generate_video":    couple.AutoGenerateVideo,
+			"reminder_notifications": couple.ReminderNotifications,
+		},
+	})
 	return &couple, nil
 }
 
@@ -146,26 +181,22 @@ func (s *coupleService) UpdateCouple(ctx context.Context, couple *models.Couple)
 	return s.coupleRepo.Update(ctx, couple)
 }
 
-// DeleteCouple 删除情侣关系
+// DeleteCouple 立即解除情侣关系，跳过冷静期；用户账号和共享数据都会保留（归档）
 func (s *coupleService) DeleteCouple(ctx context.Context, id int64) error {
-	// 获取该情侣关系下的用户
-	users, err := s.userRepo.ListByCoupleID(ctx, id)
-	if err != nil {
+	if err := s.coupleRepo.Dissolve(ctx, id, time.Now()); err != nil {
+		if errors.Is(err, repository.ErrCoupleStatusConflict) {
+			return nil
+		}
 		return err
 	}
 
-	// 开启事务
-	return s.WithTx(ctx, func(ctx context.Context) error {
-		// 删除所有用户
-		for _, user := range users {
-			if err := s.userRepo.Delete(ctx, user.ID); err != nil {
-				return err
-			}
-		}
-
-		// 删除情侣关系
-		return s.coupleRepo.Delete(ctx, id)
+	s.auditSvc.Record(ctx, AuditEntry{
+		CoupleID:   id,
+		Action:     models.AuditActionCoupleDissolve,
+		EntityType: models.AuditEntityCouple,
+		EntityID:   AuditEntityID(id),
 	})
+	return nil
 }
 
 // ListCouples 获取情侣关系列表
@@ -183,3 +214,146 @@ func (s *coupleService) GetCoupleUsers(ctx context.Context, coupleID int64) ([]*
 
 	return s.userRepo.ListByCoupleID(ctx, coupleID)
 }
+
+// GetSettings 获取用户所在情侣关系的设置
+func (s *coupleService) GetSettings(ctx context.Context, userID int64) (*dto.CoupleSettingsResponse, error) {
+	couple, err := s.coupleOfUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.settingsResponse(ctx, couple)
+}
+
+// UpdateSettings 更新情侣设置
+func (s *coupleService) UpdateSettings(ctx context.Context, userID int64, req *dto.UpdateCoupleSettingsRequest) (*dto.CoupleSettingsResponse, error) {
+	couple, err := s.coupleOfUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	before := *couple
+
+	if req.AutoGenerateVideo != nil {
+		couple.AutoGenerateVideo = *req.AutoGenerateVideo
+	}
+	if req.ReminderNotifications != nil {
+		couple.ReminderNotifications = *req.ReminderNotifications
+	}
+	if req.AnniversaryDate != nil {
+		// 纪念日只保存日期，是否晚于今天按情侣时区判断
+		date, err := time.Parse("2006-01-02", *req.AnniversaryDate)
+		if err != nil || models.DaysBetween(time.Now().In(couple.Location()), date) > 0 {
+			return nil, ErrInvalidAnniversaryDate
+		}
+		couple.AnniversaryDate = date
+	}
+	if req.Timezone != nil {
+		// 空字符串和 Local 会被 LoadLocation 解析成UTC或服务器时区，不接受
+		if *req.Timezone == "" || *req.Timezone == "Local" {
+			return nil, ErrInvalidTimezone
+		}
+		if _, err := time.LoadLocation(*req.Timezone); err != nil {
+			return nil, ErrInvalidTimezone
+		}
+		couple.Timezone = *req.Timezone
+	}
+	if req.ReminderHour != nil {
+		couple.ReminderHour = *req.ReminderHour
+	}
+	if req.Language != nil {
+		couple.Language = *req.Language
+	}
+
+	if err := s.coupleRepo.UpdateSettings(ctx, couple); err != nil {
+		return nil, err
+	}
+	s.auditSvc.Record(ctx, AuditEntry{
+		ActorID:    userID,
+		CoupleID:   couple.ID,
+		Action:     models.AuditActionUpdate,
+		EntityType: models.AuditEntityCouple,
+		EntityID:   AuditEntityID(couple.ID),
+		Before:     coupleSettingsAuditFields(&before),
+		After:      coupleSettingsAuditFields(couple),
+	})
+
+	// 提醒邮件开关是各可退订邮件类型的总开关，逐个类型保存，审计日志由邮件偏好服务记录
+	if req.ReminderEmailOptIn != nil {
+		for _, emailType := range email.OptionalTypes {
+			if err := s.preferenceSvc.SetEnabled(ctx, userID, string(emailType), *req.ReminderEmailOptIn); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return s.settingsResponse(ctx, couple)
+}
+
+// coupleOfUser 获取用户当前所在的情侣关系
+func (s *coupleService) coupleOfUser(ctx context.Context, userID int64) (*models.Couple, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.CoupleID == 0 {
+		return nil, ErrNotInCouple
+	}
+	return s.coupleRepo.GetByID(ctx, user.CoupleID)
+}
+
+// settingsResponse 组装情侣设置，包含双方各自的提醒邮件开关
+func (s *coupleService) settingsResponse(ctx context.Context, couple *models.Couple) (*dto.CoupleSettingsResponse, error) {
+	users, err := s.userRepo.ListByCoupleID(ctx, couple.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &dto.CoupleSettingsResponse{
+		AutoGenerateVideo:     couple.AutoGenerateVideo,
+		ReminderNotifications: couple.ReminderNotifications,
+		Timezone:              couple.Timezone,
+		ReminderHour:          couple.ReminderHour,
+		Language:              couple.Language,
+		Members:               make([]dto.CoupleMemberSettings, 0, len(users)),
+	}
+	if !couple.AnniversaryDate.IsZero() {
+		resp.AnniversaryDate = couple.AnniversaryDate.Format("2006-01-02")
+	}
+	for _, user := range users {
+		optIn, err := s.reminderEmailOptIn(ctx, user.ID)
+		if err != nil {
+			return nil, err
+		}
+		resp.Members = append(resp.Members, dto.CoupleMemberSettings{
+			UserID:             user.ID,
+			Username:           user.Username,
+			ReminderEmailOptIn: optIn,
+		})
+	}
+	return resp, nil
+}
+
+// reminderEmailOptIn 用户是否接收全部可退订的提醒邮件，关闭了任意一类即视为未开启
+func (s *coupleService) reminderEmailOptIn(ctx context.Context, userID int64) (bool, error) {
+	preferences, err := s.preferenceSvc.List(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	for _, preference := range preferences {
+		if !preference.Mandatory && !preference.Enabled {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// coupleSettingsAuditFields 审计日志中记录的设置字段，不包含配对口令
+func coupleSettingsAuditFields(couple *models.Couple) map[string]interface{} {
+	return map[string]interface{}{
+		"auto_generate_video":    couple.AutoGenerateVideo,
+		"reminder_notifications": couple.ReminderNotifications,
+		"anniversary_date":       couple.AnniversaryDate,
+		"timezone":               couple.Timezone,
+		"reminder_hour":          couple.ReminderHour,
+		"language":               couple.Language,
+	}
+}
@@ -3,7 +3,6 @@ package service
 import (
 	"context"
 	"memoir-api/internal/api/dto"
-	"time"
 )
 
 type DashboardService interface {
@@ -18,8 +17,12 @@ type dashboardService struct {
 	photoVideoService    PhotoVideoService
 	timelineEventService TimelineEventService
 	locationService      LocationService
+	anniversaryService   CoupleAnniversaryService
 }
 
+// dashboardMilestoneLimit 仪表盘展示的即将到来的里程碑数量
+const dashboardMilestoneLimit = 5
+
 func (d dashboardService) GetDashboardData(ctx context.Context, userId int64) (*dto.DashboardDTO, error) {
 	// 获取情侣ID
 	coupleID, err := d.userService.GetCoupleID(ctx, userId)
@@ -50,21 +53,23 @@ func (d dashboardService) GetDashboardData(ctx context.Context, userId int64) (*
 	}
 	dashboard.AlbumCount = int(total)
 
-	// 获取情侣天数
-	couple, err := d.coupleService.GetCoupleByID(ctx, coupleID)
+	// 获取纪念日
+	anniversaries, err := d.anniversaryService.List(ctx, coupleID)
 	if err != nil {
 		return nil, err
 	}
+	dashboard.Anniversaries = anniversaries
+
+	// 按情侣时区计算情侣天数，没有设置 AnniversaryDate 时以 together 纪念日为起点
+	if dashboard.CoupleDays, err = d.anniversaryService.CoupleDays(ctx, coupleID); err != nil {
+		return nil, err
+	}
 
-	// 计算情侣天数
-	// AnniversaryDate是time.Time类型，不是指针
-	if !couple.AnniversaryDate.IsZero() {
-		days := int(time.Since(couple.AnniversaryDate).Hours() / 24)
-		if days < 0 {
-			days = 0
-		}
-		dashboard.CoupleDays = days
+	milestones, err := d.anniversaryService.UpcomingMilestones(ctx, coupleID, dashboardMilestoneLimit)
+	if err != nil {
+		return nil, err
 	}
+	dashboard.UpcomingMilestones = milestones
 
 	locations, _, err := d.locationService.ListLocationsByCoupleID(ctx, coupleID, -1, -1)
 	if err != nil {
@@ -77,7 +82,8 @@ func (d dashboardService) GetDashboardData(ctx context.Context, userId int64) (*
 func NewDashboardService(service UserService,
 	albumService CoupleAlbumService, coupleService CoupleService,
 	photoVideoService PhotoVideoService,
-	timelineEventService TimelineEventService, locationService LocationService) DashboardService {
+	timelineEventService TimelineEventService, locationService LocationService,
+	anniversaryService CoupleAnniversaryService) DashboardService {
 	return &dashboardService{
 		BaseService:          NewBaseService(nil),
 		userService:          service,
@@ -86,5 +92,6 @@ func NewDashboardService(service UserService,
 		photoVideoService:    photoVideoService,
 		timelineEventService: timelineEventService,
 		locationService:      locationService,
+		anniversaryService:   anniversaryService,
 	}
 }
@@ -0,0 +1,41 @@
+package service
+
+import (
+	"context"
+
+	"memoir-api/internal/models"
+	"memoir-api/internal/repository"
+)
+
+// EmailLogService 邮件投递记录服务，记录由邮件队列写入，这里只负责查询
+type EmailLogService interface {
+	Service
+	// ListByUser 分页获取发给用户的邮件，按时间倒序
+	ListByUser(ctx context.Context, userID int64, offset, limit int) ([]*models.EmailLog, int64, error)
+	// Query 按条件查询投递记录（管理员）
+	Query(ctx context.Context, filter repository.EmailLogFilter, offset, limit int) ([]*models.EmailLog, int64, error)
+}
+
+// emailLogService 邮件投递记录服务实现
+type emailLogService struct {
+	*BaseService
+	emailLogRepo repository.EmailLogRepository
+}
+
+// NewEmailLogService 创建邮件投递记录服务
+func NewEmailLogService(emailLogRepo repository.EmailLogRepository) EmailLogService {
+	return &emailLogService{
+		BaseService:  NewBaseService(emailLogRepo),
+		emailLogRepo: emailLogRepo,
+	}
+}
+
+// ListByUser 分页获取发给用户的邮件
+func (s *emailLogService) ListByUser(ctx context.Context, userID int64, offset, limit int) ([]*models.EmailLog, int64, error) {
+	return s.emailLogRepo.Query(ctx, repository.EmailLogFilter{UserID: userID}, offset, limit)
+}
+
+// Query 按条件查询投递记录
+func (s *emailLogService) Query(ctx context.Context, filter repository.EmailLogFilter, offset, limit int) ([]*models.EmailLog, int64, error) {
+	return s.emailLogRepo.Query(ctx, filter, offset, limit)
+}
@@ -0,0 +1,157 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"memoir-api/internal/api/dto"
+	"memoir-api/internal/email"
+	"memoir-api/internal/models"
+	"memoir-api/internal/repository"
+)
+
+var (
+	ErrEmailTypeNotOptional    = errors.New("该类型的邮件不能退订")
+	ErrInvalidUnsubscribeToken = email.ErrInvalidUnsubscribeToken
+)
+
+// EmailPreferenceService 邮件偏好服务，用户可以关闭纪念日、节日、提醒等邮件，验证码等必要邮件总是发送
+type EmailPreferenceService interface {
+	Service
+	// List 获取所有邮件类型及用户的接收开关
+	List(ctx context.Context, userID int64) ([]dto.EmailPreferenceDTO, error)
+	// SetEnabled 开关用户某类邮件
+	SetEnabled(ctx context.Context, userID int64, emailType string, enabled bool) error
+	// VerifyUnsubscribeToken 校验退订令牌，返回对应的邮件类型及是否已退订
+	VerifyUnsubscribeToken(ctx context.Context, token string) (*dto.UnsubscribeDTO, error)
+	// Unsubscribe 校验退订令牌并关闭对应类型的邮件，不需要登录
+	Unsubscribe(ctx context.Context, token string) (*dto.UnsubscribeDTO, error)
+	// IsEnabled 用户是否接收某类邮件
+	IsEnabled(ctx context.Context, userID int64, emailType email.EmailType) (bool, error)
+}
+
+// emailPreferenceService 邮件偏好服务实现
+type emailPreferenceService struct {
+	*BaseService
+	preferenceRepo repository.EmailPreferenceRepository
+	auditSvc       AuditService
+	// unsubscribeSecret 退订令牌的签名密钥，为空时退订链接不可用
+	unsubscribeSecret string
+}
+
+// NewEmailPreferenceService 创建邮件偏好服务
+func NewEmailPreferenceService(preferenceRepo repository.EmailPreferenceRepository, auditSvc AuditService, unsubscribeSecret string) EmailPreferenceService {
+	return &emailPreferenceService{
+		BaseService:       NewBaseService(preferenceRepo),
+		preferenceRepo:    preferenceRepo,
+		auditSvc:          auditSvc,
+		unsubscribeSecret: unsubscribeSecret,
+	}
+}
+
+// List 获取所有邮件类型及用户的接收开关
+func (s *emailPreferenceService) List(ctx context.Context, userID int64) ([]dto.EmailPreferenceDTO, error) {
+	disabled, err := s.disabledTypes(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]dto.EmailPreferenceDTO, 0, len(email.OptionalTypes)+len(email.MandatoryTypes))
+	for _, emailType := range email.OptionalTypes {
+		result = append(result, dto.EmailPreferenceDTO{
+			Type:    string(emailType),
+			Enabled: !disabled[string(emailType)],
+		})
+	}
+	for _, emailType := range email.MandatoryTypes {
+		result = append(result, dto.EmailPreferenceDTO{
+			Type:      string(emailType),
+			Enabled:   true,
+			Mandatory: true,
+		})
+	}
+	return result, nil
+}
+
+// SetEnabled 开关用户某类邮件，必要邮件和未知类型返回 ErrEmailTypeNotOptional
+func (s *emailPreferenceService) SetEnabled(ctx context.Context, userID int64, emailType string, enabled bool) error {
+	if !email.EmailType(emailType).IsOptional() {
+		return ErrEmailTypeNotOptional
+	}
+	return s.update(ctx, userID, emailType, enabled)
+}
+
+// VerifyUnsubscribeToken 校验退订令牌，返回对应的邮件类型及是否已退订
+func (s *emailPreferenceService) VerifyUnsubscribeToken(ctx context.Context, token string) (*dto.UnsubscribeDTO, error) {
+	userID, emailType, err := email.ParseUnsubscribeToken(s.unsubscribeSecret, token)
+	if err != nil {
+		return nil, err
+	}
+	enabled, err := s.IsEnabled(ctx, userID, emailType)
+	if err != nil {
+		return nil, err
+	}
+	return &dto.UnsubscribeDTO{Type: string(emailType), Unsubscribed: !enabled}, nil
+}
+
+// Unsubscribe 校验退订令牌并关闭对应类型的邮件。令牌由用户ID和邮件类型签名得到，
+// 持有令牌即视为用户本人操作，重复退订不会报错
+func (s *emailPreferenceService) Unsubscribe(ctx context.Context, token string) (*dto.UnsubscribeDTO, error) {
+	userID, emailType, err := email.ParseUnsubscribeToken(s.unsubscribeSecret, token)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.update(ctx, userID, string(emailType), false); err != nil {
+		return nil, err
+	}
+	return &dto.UnsubscribeDTO{Type: string(emailType), Unsubscribed: true}, nil
+}
+
+// IsEnabled 用户是否接收某类邮件，必要邮件总是接收
+func (s *emailPreferenceService) IsEnabled(ctx context.Context, userID int64, emailType email.EmailType) (bool, error) {
+	if !emailType.IsOptional() {
+		return true, nil
+	}
+	disabled, err := s.disabledTypes(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	return !disabled[string(emailType)], nil
+}
+
+// update 保存开关并记录审计日志，操作人是用户本人
+func (s *emailPreferenceService) update(ctx context.Context, userID int64, emailType string, enabled bool) error {
+	disabled, err := s.disabledTypes(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if err := s.preferenceRepo.Upsert(ctx, userID, emailType, enabled); err != nil {
+		return fmt.Errorf("保存邮件偏好失败: %w", err)
+	}
+
+	s.auditSvc.Record(ctx, AuditEntry{
+		ActorID:    userID,
+		Action:     models.AuditActionUpdate,
+		EntityType: models.AuditEntityUser,
+		EntityID:   AuditEntityID(userID),
+		Before:     map[string]bool{"email." + emailType: !disabled[emailType]},
+		After:      map[string]bool{"email." + emailType: enabled},
+	})
+	return nil
+}
+
+// disabledTypes 获取用户关闭了的邮件类型
+func (s *emailPreferenceService) disabledTypes(ctx context.Context, userID int64) (map[string]bool, error) {
+	preferences, err := s.preferenceRepo.ListByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	disabled := make(map[string]bool, len(preferences))
+	for _, preference := range preferences {
+		if !preference.Enabled {
+			disabled[preference.EmailType] = true
+		}
+	}
+	return disabled, nil
+}
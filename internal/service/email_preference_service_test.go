@@ -0,0 +1,162 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"memoir-api/internal/email"
+	"memoir-api/internal/models"
+	"memoir-api/internal/repository"
+)
+
+const testUnsubscribeSecret = "test-unsubscribe-secret"
+
+// fakePreferenceRepo 用 map 模拟邮件偏好表，键为 用户ID/邮件类型
+type fakePreferenceRepo struct {
+	repository.EmailPreferenceRepository
+	rows map[int64]map[string]bool
+}
+
+func newFakePreferenceRepo() *fakePreferenceRepo {
+	return &fakePreferenceRepo{rows: make(map[int64]map[string]bool)}
+}
+
+func (r *fakePreferenceRepo) ListByUserID(ctx context.Context, userID int64) ([]*models.EmailPreference, error) {
+	var preferences []*models.EmailPreference
+	for emailType, enabled := range r.rows[userID] {
+		preferences = append(preferences, &models.EmailPreference{UserID: userID, EmailType: emailType, Enabled: enabled})
+	}
+	return preferences, nil
+}
+
+func (r *fakePreferenceRepo) Upsert(ctx context.Context, userID int64, emailType string, enabled bool) error {
+	if r.rows[userID] == nil {
+		r.rows[userID] = make(map[string]bool)
+	}
+	r.rows[userID][emailType] = enabled
+	return nil
+}
+
+// fakeAudit 记录收到的审计事件
+type fakeAudit struct {
+	AuditService
+	entries []AuditEntry
+}
+
+func (a *fakeAudit) Record(ctx context.Context, entry AuditEntry) {
+	a.entries = append(a.entries, entry)
+}
+
+func TestEmailPreferenceSetEnabled(t *testing.T) {
+	repo := newFakePreferenceRepo()
+	audit := &fakeAudit{}
+	svc := NewEmailPreferenceService(repo, audit, testUnsubscribeSecret)
+	ctx := context.Background()
+
+	if err := svc.SetEnabled(ctx, 1, string(email.EmailTypeFestival), false); err != nil {
+		t.Fatalf("SetEnabled: %v", err)
+	}
+	enabled, err := svc.IsEnabled(ctx, 1, email.EmailTypeFestival)
+	if err != nil || enabled {
+		t.Fatalf("IsEnabled(festival) = %v, %v, want false", enabled, err)
+	}
+	// 其他类型和其他用户不受影响
+	if enabled, _ := svc.IsEnabled(ctx, 1, email.EmailTypeAnniversary); !enabled {
+		t.Error("anniversary should stay enabled")
+	}
+	if enabled, _ := svc.IsEnabled(ctx, 2, email.EmailTypeFestival); !enabled {
+		t.Error("other user's festival emails should stay enabled")
+	}
+
+	if len(audit.entries) != 1 {
+		t.Fatalf("audit entries = %d, want 1", len(audit.entries))
+	}
+	after := audit.entries[0].After.(map[string]bool)
+	if after["email.festival"] {
+		t.Errorf("audit after = %v, want email.festival=false", after)
+	}
+
+	preferences, err := svc.List(ctx, 1)
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(preferences) != len(email.OptionalTypes)+len(email.MandatoryTypes) {
+		t.Fatalf("List returned %d types", len(preferences))
+	}
+	for _, preference := range preferences {
+		wantEnabled := preference.Type != string(email.EmailTypeFestival)
+		if preference.Enabled != wantEnabled {
+			t.Errorf("%s enabled = %v, want %v", preference.Type, preference.Enabled, wantEnabled)
+		}
+	}
+}
+
+func TestEmailPreferenceRejectsMandatoryTypes(t *testing.T) {
+	repo := newFakePreferenceRepo()
+	audit := &fakeAudit{}
+	svc := NewEmailPreferenceService(repo, audit, testUnsubscribeSecret)
+	ctx := context.Background()
+
+	types := []string{"unknown"}
+	for _, emailType := range email.MandatoryTypes {
+		types = append(types, string(emailType))
+	}
+	for _, emailType := range types {
+		if err := svc.SetEnabled(ctx, 1, emailType, false); !errors.Is(err, ErrEmailTypeNotOptional) {
+			t.Errorf("SetEnabled(%s) error = %v, want ErrEmailTypeNotOptional", emailType, err)
+		}
+	}
+	for _, emailType := range email.MandatoryTypes {
+		if enabled, _ := svc.IsEnabled(ctx, 1, emailType); !enabled {
+			t.Errorf("mandatory type %s should always be enabled", emailType)
+		}
+	}
+	if len(repo.rows) != 0 || len(audit.entries) != 0 {
+		t.Errorf("rejected updates must not be saved or audited: rows=%v audit=%d", repo.rows, len(audit.entries))
+	}
+}
+
+func TestEmailPreferenceUnsubscribe(t *testing.T) {
+	repo := newFakePreferenceRepo()
+	svc := NewEmailPreferenceService(repo, &fakeAudit{}, testUnsubscribeSecret)
+	ctx := context.Background()
+	token := email.NewUnsubscribeToken(testUnsubscribeSecret, 1, email.EmailTypeAnniversary)
+
+	result, err := svc.VerifyUnsubscribeToken(ctx, token)
+	if err != nil {
+		t.Fatalf("VerifyUnsubscribeToken: %v", err)
+	}
+	if result.Type != string(email.EmailTypeAnniversary) || result.Unsubscribed {
+		t.Errorf("before unsubscribe = %+v", result)
+	}
+
+	// 重复退订不报错
+	for i := 0; i < 2; i++ {
+		result, err = svc.Unsubscribe(ctx, token)
+		if err != nil {
+			t.Fatalf("Unsubscribe: %v", err)
+		}
+		if !result.Unsubscribed {
+			t.Errorf("after unsubscribe = %+v", result)
+		}
+	}
+	if enabled, _ := svc.IsEnabled(ctx, 1, email.EmailTypeAnniversary); enabled {
+		t.Error("anniversary should be disabled after unsubscribe")
+	}
+
+	invalid := []string{
+		"",
+		"garbage",
+		email.NewUnsubscribeToken("other-secret", 1, email.EmailTypeAnniversary),
+		email.NewUnsubscribeToken(testUnsubscribeSecret, 1, email.EmailTypeResetPassword),
+	}
+	for _, token := range invalid {
+		if _, err := svc.Unsubscribe(ctx, token); !errors.Is(err, ErrInvalidUnsubscribeToken) {
+			t.Errorf("Unsubscribe(%q) error = %v, want ErrInvalidUnsubscribeToken", token, err)
+		}
+	}
+	if enabled, _ := svc.IsEnabled(ctx, 1, email.EmailTypeResetPassword); !enabled {
+		t.Error("mandatory type must not be unsubscribed")
+	}
+}
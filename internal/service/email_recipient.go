@@ -0,0 +1,25 @@
+package service
+
+import (
+	"memoir-api/internal/email"
+	"memoir-api/internal/models"
+)
+
+// emailRecipient 用户作为收件人，使用用户自己设置的语言
+func emailRecipient(user *models.User) email.Recipient {
+	return email.Recipient{
+		UserID:  user.ID,
+		Address: user.Email,
+		Name:    user.Username,
+		Locale:  user.Language,
+	}
+}
+
+// coupleEmailRecipient 情侣提醒的收件人，用户没有设置语言时使用情侣设置的语言
+func coupleEmailRecipient(user *models.User, couple *models.Couple) email.Recipient {
+	to := emailRecipient(user)
+	if to.Locale == "" && couple != nil {
+		to.Locale = couple.Language
+	}
+	return to
+}
@@ -1,6 +1,8 @@
 package service
 
 import (
+	"context"
+
 	"memoir-api/internal/cache"
 	"memoir-api/internal/config"
 	"memoir-api/internal/email"
@@ -12,7 +14,13 @@ import (
 type Factory interface {
 	User() UserService
 	Couple() CoupleService
+	CoupleInvite() CoupleInviteService
+	CoupleDissolution() CoupleDissolutionService
+	CoupleAnniversary() CoupleAnniversaryService
 	JWT() JWTService
+	Session() SessionService
+	LoginGuard() LoginGuardService
+	AccessToken() AccessTokenService
 	Location() LocationService
 	TimelineEvent() TimelineEventService
 	PhotoVideo() PhotoVideoService
@@ -23,13 +31,25 @@ type Factory interface {
 	Attachment() AttachmentService
 	Email() EmailService
 	CoupleReminder() CoupleReminderService
+	Reminder() ReminderService
+	CoupleGuard() CoupleGuardService
+	Audit() AuditService
+	Scheduler() SchedulerService
+	EmailLog() EmailLogService
+	EmailPreference() EmailPreferenceService
 }
 
 // factory 服务工厂实现
 type factory struct {
 	userService           UserService
 	coupleService         CoupleService
+	coupleInviteService   CoupleInviteService
+	coupleDissolution     CoupleDissolutionService
+	coupleAnniversary     CoupleAnniversaryService
 	jwtService            JWTService
+	sessionService        SessionService
+	loginGuardService     LoginGuardService
+	accessTokenService    AccessTokenService
 	locationService       LocationService
 	timelineEventService  TimelineEventService
 	photoVideoService     PhotoVideoService
@@ -40,6 +60,12 @@ type factory struct {
 	attachmentService     AttachmentService
 	emailService          EmailService
 	coupleReminderService CoupleReminderService
+	reminderService       ReminderService
+	coupleGuardService    CoupleGuardService
+	auditService          AuditService
+	schedulerService      SchedulerService
+	emailLogService       EmailLogService
+	emailPreference       EmailPreferenceService
 }
 
 // NewFactory 创建服务工厂
@@ -53,22 +79,49 @@ func NewFactory(repoFactory repository.Factory) Factory {
 
 	// 创建邮件服务
 	cfg := config.New()
-	emailService, err := email.NewEmailService(cfg, redisClient)
+	emailService, err := email.NewEmailService(cfg, redisClient, repoFactory.EmailLog())
 	if err != nil {
 		logger.Fatal(err, "Failed to create email service")
 	}
 
+	// 创建审计日志服务，其他服务的写操作都依赖它
+	auditService := NewAuditService(repoFactory.AuditLog())
+
+	// 创建邮件投递记录服务
+	emailLogService := NewEmailLogService(repoFactory.EmailLog())
+
+	// 创建邮件偏好服务，提醒邮件发送前检查用户是否退订
+	emailPreference := NewEmailPreferenceService(repoFactory.EmailPreference(), auditService, cfg.Email.UnsubscribeSecret)
+
+	// 创建会话注册表服务
+	sessionService := NewSessionService(redisClient)
+
 	// 创建用户服务
-	userService := NewUserService(userRepo, coupleRepo, emailService)
+	userService := NewUserService(userRepo, coupleRepo, emailService, sessionService, repoFactory.MFARecoveryCode(), repoFactory.PersonalAccessToken(), auditService, cfg.Auth.RequireEmailVerification)
+
+	// 创建登录防暴力破解服务
+	loginGuardService := NewLoginGuardService(redisClient, userRepo, emailService, cfg.Auth)
+
+	// 创建个人访问令牌服务
+	accessTokenService := NewAccessTokenService(repoFactory.PersonalAccessToken(), repoFactory.User(), auditService)
 
 	// 创建JWT服务
-	jwtService := NewJWTService()
+	jwtService, err := NewJWTService(cfg, sessionService, redisClient)
+	if err != nil {
+		logger.Fatal(err, "Failed to create JWT service")
+	}
 
 	// 创建情侣服务
-	coupleService := NewCoupleService(coupleRepo, userRepo)
+	coupleService := NewCoupleService(coupleRepo, userRepo, repoFactory.CoupleAnniversary(), emailPreference, auditService)
+
+	// 创建情侣配对邀请服务
+	coupleInviteService := NewCoupleInviteService(redisClient, userRepo, coupleRepo, emailService, auditService, cfg.Email.AppURL)
+
+	// 创建情侣关系解除服务
+	coupleDissolution := NewCoupleDissolutionService(repoFactory, emailService, auditService)
 
 	// 创建位置服务
-	locationService := NewLocationService(repoFactory.Location())
+	locationService := NewLocationService(repoFactory.Location(), auditService)
 
 	// 创建时间线事件服务
 	timelineEventService := NewTimelineEventService(
@@ -77,6 +130,7 @@ func NewFactory(repoFactory repository.Factory) Factory {
 		repoFactory.PhotoVideo(),
 		repoFactory.TimelineEventLocation(),
 		repoFactory.TimelineEventPhotoVideo(),
+		auditService,
 	)
 
 	// 创建照片视频服务
@@ -84,6 +138,10 @@ func NewFactory(repoFactory repository.Factory) Factory {
 		repoFactory.PhotoVideo(),
 		userRepo,
 		repoFactory.CoupleAlbum(),
+		repoFactory.TimelineEvent(),
+		repoFactory.Location(),
+		repoFactory.TimelineEventPhotoVideo(),
+		auditService,
 	)
 
 	// 创建心愿单服务
@@ -91,16 +149,18 @@ func NewFactory(repoFactory repository.Factory) Factory {
 		repoFactory.Wishlist(),
 		repoFactory.WishlistAttachment(),
 		repoFactory.Attachment(),
+		auditService,
 	)
 
 	// 创建个人媒体服务
-	personalMediaService := NewPersonalMediaService(repoFactory.PersonalMedia())
+	personalMediaService := NewPersonalMediaService(repoFactory.PersonalMedia(), auditService)
 
 	// 创建情侣相册服务
 	coupleAlbumService := NewCoupleAlbumService(
 		repoFactory.CoupleAlbum(),
 		userService,
 		photoVideoService,
+		auditService,
 	)
 
 	// 创建附件服务
@@ -108,8 +168,12 @@ func NewFactory(repoFactory repository.Factory) Factory {
 		repoFactory.Attachment(),
 		userRepo,
 		coupleRepo,
+		auditService,
 	)
 
+	// 创建情侣纪念日服务
+	coupleAnniversary := NewCoupleAnniversaryService(repoFactory.CoupleAnniversary(), coupleRepo, auditService)
+
 	// 创建仪表盘服务
 	dashboardService := NewDashboardService(
 		userService,
@@ -118,19 +182,52 @@ func NewFactory(repoFactory repository.Factory) Factory {
 		photoVideoService,
 		timelineEventService,
 		locationService,
+		coupleAnniversary,
 	)
 
 	// 创建情侣纪念日提醒服务
 	coupleReminderService := NewCoupleReminderService(
 		coupleRepo,
 		userRepo,
+		repoFactory.CoupleAnniversary(),
+		repoFactory.CoupleFestivalSetting(),
+		repoFactory.ReminderDelivery(),
+		emailService,
+		emailPreference,
+		auditService,
+		cfg.Auth.RequireEmailVerification,
+	)
+
+	// 创建用户自定义提醒服务
+	reminderService := NewReminderService(
+		repoFactory.Reminder(),
+		coupleRepo,
+		userRepo,
+		repoFactory.ReminderDelivery(),
+		wishlistService,
 		emailService,
+		emailPreference,
+		auditService,
+		cfg.Auth.RequireEmailVerification,
 	)
 
+	// 创建情侣资源授权服务
+	coupleGuardService := NewCoupleGuardService(repoFactory)
+
+	// 创建定时任务调度服务并注册任务
+	schedulerService := NewSchedulerService(redisClient, repoFactory.JobRun(), auditService, cfg.Scheduler)
+	registerJobs(schedulerService, cfg, coupleReminderService, reminderService, coupleDissolution)
+
 	return &factory{
 		userService:           userService,
 		coupleService:         coupleService,
+		coupleInviteService:   coupleInviteService,
+		coupleDissolution:     coupleDissolution,
+		coupleAnniversary:     coupleAnniversary,
 		jwtService:            jwtService,
+		sessionService:        sessionService,
+		loginGuardService:     loginGuardService,
+		accessTokenService:    accessTokenService,
 		locationService:       locationService,
 		timelineEventService:  timelineEventService,
 		photoVideoService:     photoVideoService,
@@ -141,6 +238,59 @@ func NewFactory(repoFactory repository.Factory) Factory {
 		attachmentService:     attachmentService,
 		emailService:          emailService,
 		coupleReminderService: coupleReminderService,
+		reminderService:       reminderService,
+		coupleGuardService:    coupleGuardService,
+		auditService:          auditService,
+		schedulerService:      schedulerService,
+		emailLogService:       emailLogService,
+		emailPreference:       emailPreference,
+	}
+}
+
+// registerJobs 注册定时任务。邮件未启用时提醒任务不按调度表执行，但仍可手动触发
+func registerJobs(scheduler SchedulerService, cfg *config.Config, reminders CoupleReminderService, userReminders ReminderService, dissolution CoupleDissolutionService) {
+	anniversarySchedule, festivalSchedule := cfg.Scheduler.AnniversarySchedule, cfg.Scheduler.FestivalSchedule
+	reminderSchedule := cfg.Scheduler.ReminderSchedule
+	if !cfg.Email.Enabled {
+		anniversarySchedule, festivalSchedule, reminderSchedule = "", "", ""
+	}
+
+	jobs := []Job{
+		{
+			Name:        JobAnniversaryReminders,
+			Description: "检查并发送恋爱天数里程碑和自定义纪念日提醒",
+			Schedule:    anniversarySchedule,
+			Run:         reminders.CheckAndSendAnniversaryReminders,
+		},
+		{
+			Name:        JobFestivalReminders,
+			Description: "检查并发送节日提醒",
+			Schedule:    festivalSchedule,
+			Run:         reminders.CheckAndSendFestivalReminders,
+		},
+		{
+			Name:        JobUserReminders,
+			Description: "检查并发送自定义提醒和心愿单提醒",
+			Schedule:    reminderSchedule,
+			Run:         userReminders.CheckAndSendReminders,
+		},
+		{
+			Name:        JobCoupleDissolutions,
+			Description: "完成冷静期已结束的情侣关系解除",
+			Schedule:    cfg.Scheduler.DissolutionSchedule,
+			Run: func(ctx context.Context) error {
+				count, err := dissolution.FinalizeDueDissolutions(ctx)
+				if count > 0 {
+					logger.Info("完成情侣关系解除", "count", count)
+				}
+				return err
+			},
+		},
+	}
+	for _, job := range jobs {
+		if err := scheduler.Register(job); err != nil {
+			logger.Fatal(err, "Failed to register scheduled job")
+		}
 	}
 }
 
@@ -154,11 +304,41 @@ func (f *factory) Couple() CoupleService {
 	return f.coupleService
 }
 
+// CoupleInvite 获取情侣配对邀请服务
+func (f *factory) CoupleInvite() CoupleInviteService {
+	return f.coupleInviteService
+}
+
+// CoupleDissolution 获取情侣关系解除服务
+func (f *factory) CoupleDissolution() CoupleDissolutionService {
+	return f.coupleDissolution
+}
+
+// CoupleAnniversary 获取情侣纪念日服务
+func (f *factory) CoupleAnniversary() CoupleAnniversaryService {
+	return f.coupleAnniversary
+}
+
 // JWT 获取JWT服务
 func (f *factory) JWT() JWTService {
 	return f.jwtService
 }
 
+// Session 获取会话注册表服务
+func (f *factory) Session() SessionService {
+	return f.sessionService
+}
+
+// LoginGuard 获取登录防暴力破解服务
+func (f *factory) LoginGuard() LoginGuardService {
+	return f.loginGuardService
+}
+
+// AccessToken 获取个人访问令牌服务
+func (f *factory) AccessToken() AccessTokenService {
+	return f.accessTokenService
+}
+
 // Location 获取地点服务
 func (f *factory) Location() LocationService {
 	return f.locationService
@@ -204,3 +384,33 @@ func (f *factory) Email() EmailService {
 func (f *factory) CoupleReminder() CoupleReminderService {
 	return f.coupleReminderService
 }
+
+// Reminder 获取用户自定义提醒服务
+func (f *factory) Reminder() ReminderService {
+	return f.reminderService
+}
+
+// CoupleGuard 获取情侣资源授权服务
+func (f *factory) CoupleGuard() CoupleGuardService {
+	return f.coupleGuardService
+}
+
+// Audit 获取审计日志服务
+func (f *factory) Audit() AuditService {
+	return f.auditService
+}
+
+// Scheduler 获取定时任务调度服务
+func (f *factory) Scheduler() SchedulerService {
+	return f.schedulerService
+}
+
+// EmailLog 获取邮件投递记录服务
+func (f *factory) EmailLog() EmailLogService {
+	return f.emailLogService
+}
+
+// EmailPreference 获取邮件偏好服务
+func (f *factory) EmailPreference() EmailPreferenceService {
+	return f.emailPreference
+}
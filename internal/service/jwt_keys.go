@@ -0,0 +1,290 @@
+package service
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+
+	"memoir-api/internal/config"
+	"memoir-api/internal/logger"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// developmentSecret 仅在非release模式且未配置密钥时使用的开发密钥
+const developmentSecret = "memoir-api-development-secret-key"
+
+var (
+	ErrNoSigningKey      = errors.New("release模式下必须通过 JWT_PRIVATE_KEY_FILE 配置RS256或EdDSA签名私钥")
+	ErrUnknownSigningKey = errors.New("未知的签名密钥")
+)
+
+// JWK JSON Web Key（只包含公钥部分）
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+// JWKSet JSON Web Key Set
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// jwtKey 单个签名/验证密钥
+type jwtKey struct {
+	kid     string
+	method  jwt.SigningMethod
+	signKey interface{} // 私钥，仅当前签名密钥有
+	public  interface{} // 验证用的公钥（HS256时为密钥本身）
+}
+
+// jwtKeySet 当前签名密钥和轮换期间仍然有效的验证密钥
+type jwtKeySet struct {
+	signing      *jwtKey
+	verification map[string]*jwtKey
+}
+
+// loadJWTKeySet 根据配置加载密钥，release模式下不允许退回开发密钥
+func loadJWTKeySet(cfg config.JWTKeyConfig, releaseMode bool) (*jwtKeySet, error) {
+	set := &jwtKeySet{verification: make(map[string]*jwtKey)}
+
+	if cfg.PrivateKeyFile == "" {
+		if releaseMode {
+			return nil, ErrNoSigningKey
+		}
+
+		secret := cfg.Secret
+		if secret == "" {
+			logger.GetLogger("jwt").Warn("未配置JWT签名密钥，使用开发密钥签名，切勿用于生产环境")
+			secret = developmentSecret
+		}
+		set.signing = &jwtKey{
+			method:  jwt.SigningMethodHS256,
+			signKey: []byte(secret),
+			public:  []byte(secret),
+		}
+		return set, nil
+	}
+
+	signing, err := loadPrivateKey(cfg.PrivateKeyFile, cfg.KeyID)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Algorithm != "" && !strings.EqualFold(cfg.Algorithm, signing.method.Alg()) {
+		return nil, fmt.Errorf("JWT_ALGORITHM=%s 与私钥类型(%s)不匹配", cfg.Algorithm, signing.method.Alg())
+	}
+	set.signing = signing
+	set.verification[signing.kid] = signing
+
+	// 轮换期间旧密钥签发的令牌仍需能够验证
+	for _, entry := range strings.Split(cfg.VerificationKeys, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		kid, path, found := strings.Cut(entry, "=")
+		if !found {
+			kid, path = "", entry
+		}
+		key, err := loadPublicKey(strings.TrimSpace(path), strings.TrimSpace(kid))
+		if err != nil {
+			return nil, err
+		}
+		if _, exists := set.verification[key.kid]; exists {
+			return nil, fmt.Errorf("JWT验证密钥kid重复: %s", key.kid)
+		}
+		set.verification[key.kid] = key
+	}
+
+	return set, nil
+}
+
+// loadPrivateKey 从PEM文件加载签名私钥
+func loadPrivateKey(path, kid string) (*jwtKey, error) {
+	block, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var parsed interface{}
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("不支持的私钥PEM类型 %q: %s", block.Type, path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("解析私钥失败 %s: %w", path, err)
+	}
+
+	signer, ok := parsed.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("不支持的私钥类型: %s", path)
+	}
+
+	key, err := newPublicJWTKey(signer.Public(), kid)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	key.signKey = signer
+	return key, nil
+}
+
+// loadPublicKey 从PEM文件加载验证公钥，也接受私钥文件
+func loadPublicKey(path, kid string) (*jwtKey, error) {
+	block, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if block.Type != "PUBLIC KEY" {
+		key, err := loadPrivateKey(path, kid)
+		if err != nil {
+			return nil, err
+		}
+		// 旧私钥只用于验证
+		key.signKey = nil
+		return key, nil
+	}
+
+	parsed, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("解析公钥失败 %s: %w", path, err)
+	}
+	key, err := newPublicJWTKey(parsed, kid)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return key, nil
+}
+
+// newPublicJWTKey 根据公钥类型确定签名算法，未指定kid时使用RFC 7638指纹
+func newPublicJWTKey(public crypto.PublicKey, kid string) (*jwtKey, error) {
+	key := &jwtKey{kid: kid, public: public}
+	switch public.(type) {
+	case *rsa.PublicKey:
+		key.method = jwt.SigningMethodRS256
+	case ed25519.PublicKey:
+		key.method = jwt.SigningMethodEdDSA
+	default:
+		return nil, errors.New("仅支持RSA和Ed25519密钥")
+	}
+
+	if key.kid == "" {
+		key.kid = key.thumbprint()
+	}
+	return key, nil
+}
+
+func readPEM(path string) (*pem.Block, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取密钥文件失败: %w", err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("密钥文件不是有效的PEM格式: %s", path)
+	}
+	return block, nil
+}
+
+// jwk 导出公钥的JWK表示
+func (k *jwtKey) jwk() JWK {
+	jwk := JWK{Kid: k.kid, Use: "sig", Alg: k.method.Alg()}
+	switch pub := k.public.(type) {
+	case *rsa.PublicKey:
+		jwk.Kty = "RSA"
+		jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+		jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+	case ed25519.PublicKey:
+		jwk.Kty = "OKP"
+		jwk.Crv = "Ed25519"
+		jwk.X = base64.RawURLEncoding.EncodeToString(pub)
+	}
+	return jwk
+}
+
+// thumbprint 计算RFC 7638 JWK指纹
+func (k *jwtKey) thumbprint() string {
+	jwk := k.jwk()
+	var canonical []byte
+	switch jwk.Kty {
+	case "RSA":
+		canonical, _ = json.Marshal(struct {
+			E   string `json:"e"`
+			Kty string `json:"kty"`
+			N   string `json:"n"`
+		}{jwk.E, jwk.Kty, jwk.N})
+	case "OKP":
+		canonical, _ = json.Marshal(struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+		}{jwk.Crv, jwk.Kty, jwk.X})
+	}
+	sum := sha256.Sum256(canonical)
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// sign 使用当前签名密钥签发令牌，非对称密钥会写入kid头
+func (s *jwtKeySet) sign(claims jwt.MapClaims) (string, error) {
+	token := jwt.NewWithClaims(s.signing.method, claims)
+	if s.signing.kid != "" {
+		token.Header["kid"] = s.signing.kid
+	}
+	return token.SignedString(s.signing.signKey)
+}
+
+// keyFunc 根据令牌头中的kid选择验证密钥
+func (s *jwtKeySet) keyFunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+
+	key := s.signing
+	if kid != "" || s.signing.kid != "" {
+		var ok bool
+		if key, ok = s.verification[kid]; !ok {
+			return nil, fmt.Errorf("%w: %q", ErrUnknownSigningKey, kid)
+		}
+	}
+
+	// 算法必须与密钥匹配，防止算法混淆攻击
+	if token.Method.Alg() != key.method.Alg() {
+		return nil, fmt.Errorf("意外的签名方法: %v", token.Header["alg"])
+	}
+	return key.public, nil
+}
+
+// jwks 导出所有可公开的验证公钥
+func (s *jwtKeySet) jwks() *JWKSet {
+	set := &JWKSet{Keys: make([]JWK, 0, len(s.verification))}
+	// 当前签名密钥排在最前
+	if s.signing.kid != "" {
+		set.Keys = append(set.Keys, s.signing.jwk())
+	}
+	for kid, key := range s.verification {
+		if kid == s.signing.kid {
+			continue
+		}
+		set.Keys = append(set.Keys, key.jwk())
+	}
+	return set
+}
@@ -1,11 +1,18 @@
 package service
 
 import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
-	"os"
 	"time"
 
+	"memoir-api/internal/config"
+	"memoir-api/internal/logger"
+
+	"github.com/go-redis/redis/v8"
 	"github.com/golang-jwt/jwt/v5"
 )
 
@@ -17,11 +24,27 @@ const (
 	AccessToken TokenType = "access"
 	// RefreshToken 刷新令牌
 	RefreshToken TokenType = "refresh"
+	// MFAChallengeToken 二次验证挑战令牌，只能用于 /auth/mfa/verify
+	MFAChallengeToken TokenType = "mfa"
+)
+
+const (
+	// MFAChallengeKeyPrefix 二次验证挑战键前缀，值为剩余可尝试次数
+	MFAChallengeKeyPrefix = "auth:mfa_challenge:"
+	// mfaChallengeExpiry 二次验证挑战有效期
+	mfaChallengeExpiry = 5 * time.Minute
+	// mfaChallengeMaxAttempts 每个挑战允许的验证码尝试次数
+	mfaChallengeMaxAttempts = 5
+)
+
+var (
+	ErrInvalidTokenType = errors.New("无效的令牌类型")
+	ErrTokenRevoked     = errors.New("令牌已被注销")
+	ErrMFAChallenge     = errors.New("二次验证已过期或尝试次数过多，请重新登录")
 )
 
 // JWTConfig JWT配置
 type JWTConfig struct {
-	SecretKey     string
 	AccessExpiry  time.Duration
 	RefreshExpiry time.Duration
 	Issuer        string
@@ -35,49 +58,145 @@ type TokenDetails struct {
 	RefreshExpiry int64
 	AccessUUID    string
 	RefreshUUID   string
+	SessionID     string
 	ExpiresIn     int64
 }
 
+// AccessClaims 访问令牌中与会话相关的声明
+type AccessClaims struct {
+	UserID    int64
+	SessionID string
+	TokenID   string
+}
+
 // JWTService JWT服务接口
 type JWTService interface {
 	Service
-	// GenerateTokens 生成访问令牌和刷新令牌
-	GenerateTokens(userID int64) (*TokenDetails, error)
+	// GenerateTokens 生成访问令牌和刷新令牌，并登记新的会话
+	GenerateTokens(ctx context.Context, userID int64, meta SessionMeta) (*TokenDetails, error)
+	// RefreshTokens 使用刷新令牌轮换出新的令牌对
+	RefreshTokens(ctx context.Context, refreshToken string) (*TokenDetails, error)
 	// ValidateToken 验证令牌
 	ValidateToken(tokenString string) (*jwt.Token, jwt.MapClaims, error)
+	// ValidateAccessToken 验证访问令牌并检查其会话是否仍然有效
+	ValidateAccessToken(ctx context.Context, tokenString string) (*AccessClaims, error)
 	// ExtractUserID 从令牌中提取用户ID
 	ExtractUserID(tokenString string) (int64, error)
+	// JWKS 返回可公开的验证公钥集合
+	JWKS() *JWKSet
+	// GenerateMFAChallenge 为通过密码校验、但开启了二次验证的用户签发短期挑战令牌
+	GenerateMFAChallenge(ctx context.Context, userID int64) (string, int64, error)
+	// ParseMFAChallenge 校验挑战令牌，返回用户ID和挑战ID
+	ParseMFAChallenge(ctx context.Context, challengeToken string) (int64, string, error)
+	// FailMFAChallenge 记录一次失败的验证码尝试，超过次数后挑战作废
+	FailMFAChallenge(ctx context.Context, challengeID string) error
+	// ConsumeMFAChallenge 使挑战令牌失效，每个挑战只能成功使用一次
+	ConsumeMFAChallenge(ctx context.Context, challengeID string) error
+	// RevokeSession 注销单个会话
+	RevokeSession(ctx context.Context, userID int64, sessionID string) error
+	// RevokeAllSessions 注销用户的全部会话
+	RevokeAllSessions(ctx context.Context, userID int64) error
 }
 
 // jwtService JWT服务实现
 type jwtService struct {
 	*BaseService
-	config JWTConfig
+	config   JWTConfig
+	keys     *jwtKeySet
+	sessions SessionService
+	redis    *redis.Client
 }
 
-// NewJWTService 创建JWT服务
-func NewJWTService() JWTService {
-	// 从环境变量获取密钥
-	secretKey := os.Getenv("JWT_SECRET")
-	if secretKey == "" {
-		// 默认开发密钥
-		secretKey = "memoir-api-development-secret-key"
+// NewJWTService 创建JWT服务，release模式下未配置签名私钥时返回错误
+func NewJWTService(cfg *config.Config, sessions SessionService, redisClient *redis.Client) (JWTService, error) {
+	keys, err := loadJWTKeySet(cfg.JWT, cfg.Server.Mode == "release")
+	if err != nil {
+		return nil, err
 	}
 
 	return &jwtService{
 		BaseService: NewBaseService(nil),
 		config: JWTConfig{
-			SecretKey:     secretKey,
 			AccessExpiry:  time.Hour * 24,     // 访问令牌有效期24小时
 			RefreshExpiry: time.Hour * 24 * 7, // 刷新令牌有效期7天
 			Issuer:        "memoir-api",
 		},
+		keys:     keys,
+		sessions: sessions,
+		redis:    redisClient,
+	}, nil
+}
+
+// GenerateTokens 生成访问令牌和刷新令牌，并登记新的会话
+func (s *jwtService) GenerateTokens(ctx context.Context, userID int64, meta SessionMeta) (*TokenDetails, error) {
+	sessionID, err := newTokenID()
+	if err != nil {
+		return nil, err
 	}
+
+	td, err := s.signTokens(userID, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	session := &Session{
+		ID:         sessionID,
+		UserID:     userID,
+		AccessJTI:  td.AccessUUID,
+		RefreshJTI: td.RefreshUUID,
+		DeviceName: meta.DeviceName,
+		IP:         meta.IP,
+		UserAgent:  meta.UserAgent,
+		CreatedAt:  now,
+		LastSeenAt: now,
+		ExpiresAt:  time.Unix(td.RefreshExpiry, 0),
+	}
+	if err := s.sessions.Create(ctx, session); err != nil {
+		return nil, err
+	}
+
+	return td, nil
 }
 
-// GenerateTokens 生成访问令牌和刷新令牌
-func (s *jwtService) GenerateTokens(userID int64) (*TokenDetails, error) {
-	td := &TokenDetails{}
+// RefreshTokens 使用刷新令牌轮换出新的令牌对
+// 每个刷新令牌只能使用一次，重复使用会注销整个会话（令牌家族）
+func (s *jwtService) RefreshTokens(ctx context.Context, refreshToken string) (*TokenDetails, error) {
+	_, claims, err := s.ValidateToken(refreshToken)
+	if err != nil {
+		return nil, err
+	}
+
+	if tokenType, _ := claims["typ"].(string); tokenType != string(RefreshToken) {
+		return nil, ErrInvalidTokenType
+	}
+
+	userID, sessionID, tokenID, err := parseSessionClaims(claims)
+	if err != nil {
+		return nil, err
+	}
+
+	td, err := s.signTokens(userID, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.sessions.Rotate(ctx, sessionID, tokenID, td.AccessUUID, td.RefreshUUID, time.Unix(td.RefreshExpiry, 0))
+	if err != nil {
+		if errors.Is(err, ErrRefreshTokenReused) {
+			if revokeErr := s.sessions.Revoke(ctx, userID, sessionID); revokeErr != nil && !errors.Is(revokeErr, ErrSessionNotFound) {
+				return nil, fmt.Errorf("注销被盗用的会话失败: %w", revokeErr)
+			}
+		}
+		return nil, err
+	}
+
+	return td, nil
+}
+
+// signTokens 为指定会话签发一对新的令牌
+func (s *jwtService) signTokens(userID int64, sessionID string) (*TokenDetails, error) {
+	td := &TokenDetails{SessionID: sessionID}
 	now := time.Now()
 
 	// 设置过期时间
@@ -88,6 +207,14 @@ func (s *jwtService) GenerateTokens(userID int64) (*TokenDetails, error) {
 	td.RefreshExpiry = refreshExpiry.Unix()
 	td.ExpiresIn = accessExpiry.Unix() - now.Unix()
 
+	var err error
+	if td.AccessUUID, err = newTokenID(); err != nil {
+		return nil, err
+	}
+	if td.RefreshUUID, err = newTokenID(); err != nil {
+		return nil, err
+	}
+
 	// 创建访问令牌
 	accessClaims := jwt.MapClaims{
 		"sub": userID,
@@ -95,11 +222,11 @@ func (s *jwtService) GenerateTokens(userID int64) (*TokenDetails, error) {
 		"iat": now.Unix(),
 		"iss": s.config.Issuer,
 		"typ": string(AccessToken),
+		"jti": td.AccessUUID,
+		"sid": sessionID,
 	}
 
-	accessToken := jwt.NewWithClaims(jwt.SigningMethodHS256, accessClaims)
-	var err error
-	td.AccessToken, err = accessToken.SignedString([]byte(s.config.SecretKey))
+	td.AccessToken, err = s.keys.sign(accessClaims)
 	if err != nil {
 		return nil, fmt.Errorf("创建访问令牌失败: %w", err)
 	}
@@ -111,10 +238,11 @@ func (s *jwtService) GenerateTokens(userID int64) (*TokenDetails, error) {
 		"iat": now.Unix(),
 		"iss": s.config.Issuer,
 		"typ": string(RefreshToken),
+		"jti": td.RefreshUUID,
+		"sid": sessionID,
 	}
 
-	refreshToken := jwt.NewWithClaims(jwt.SigningMethodHS256, refreshClaims)
-	td.RefreshToken, err = refreshToken.SignedString([]byte(s.config.SecretKey))
+	td.RefreshToken, err = s.keys.sign(refreshClaims)
 	if err != nil {
 		return nil, fmt.Errorf("创建刷新令牌失败: %w", err)
 	}
@@ -125,13 +253,9 @@ func (s *jwtService) GenerateTokens(userID int64) (*TokenDetails, error) {
 // ValidateToken 验证令牌
 func (s *jwtService) ValidateToken(tokenString string) (*jwt.Token, jwt.MapClaims, error) {
 	// 解析令牌
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		// 验证签名方法
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("意外的签名方法: %v", token.Header["alg"])
-		}
-		return []byte(s.config.SecretKey), nil
-	})
+	// 使用json.Number解析数字声明，避免雪花ID在float64下丢失精度
+	// 按kid选择验证密钥并校验签名方法
+	token, err := jwt.Parse(tokenString, s.keys.keyFunc, jwt.WithJSONNumber(), jwt.WithIssuer(s.config.Issuer))
 
 	if err != nil {
 		return nil, nil, err
@@ -145,6 +269,145 @@ func (s *jwtService) ValidateToken(tokenString string) (*jwt.Token, jwt.MapClaim
 	return token, claims, nil
 }
 
+// ValidateAccessToken 验证访问令牌并检查其会话是否仍然有效
+func (s *jwtService) ValidateAccessToken(ctx context.Context, tokenString string) (*AccessClaims, error) {
+	_, claims, err := s.ValidateToken(tokenString)
+	if err != nil {
+		return nil, err
+	}
+
+	if tokenType, _ := claims["typ"].(string); tokenType != string(AccessToken) {
+		return nil, ErrInvalidTokenType
+	}
+
+	userID, sessionID, tokenID, err := parseSessionClaims(claims)
+	if err != nil {
+		return nil, err
+	}
+
+	session, err := s.sessions.Get(ctx, sessionID)
+	if err != nil {
+		if errors.Is(err, ErrSessionNotFound) {
+			return nil, ErrTokenRevoked
+		}
+		return nil, err
+	}
+
+	// 会话轮换后，旧的访问令牌随之失效
+	if session.UserID != userID || session.AccessJTI != tokenID {
+		return nil, ErrTokenRevoked
+	}
+
+	if err := s.sessions.Touch(ctx, session); err != nil {
+		// 活跃时间只用于展示，更新失败不影响鉴权
+		logger.FromContext(ctx).WithComponent("jwt").Error(err, "更新会话活跃时间失败", "session_id", sessionID)
+	}
+
+	return &AccessClaims{
+		UserID:    userID,
+		SessionID: sessionID,
+		TokenID:   tokenID,
+	}, nil
+}
+
+// GenerateMFAChallenge 为通过密码校验、但开启了二次验证的用户签发短期挑战令牌
+func (s *jwtService) GenerateMFAChallenge(ctx context.Context, userID int64) (string, int64, error) {
+	challengeID, err := newTokenID()
+	if err != nil {
+		return "", 0, err
+	}
+
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"sub": userID,
+		"exp": now.Add(mfaChallengeExpiry).Unix(),
+		"iat": now.Unix(),
+		"iss": s.config.Issuer,
+		"typ": string(MFAChallengeToken),
+		"jti": challengeID,
+	}
+
+	token, err := s.keys.sign(claims)
+	if err != nil {
+		return "", 0, fmt.Errorf("创建二次验证令牌失败: %w", err)
+	}
+
+	key := MFAChallengeKeyPrefix + challengeID
+	if err := s.redis.Set(ctx, key, mfaChallengeMaxAttempts, mfaChallengeExpiry).Err(); err != nil {
+		return "", 0, fmt.Errorf("保存二次验证挑战失败: %w", err)
+	}
+
+	return token, int64(mfaChallengeExpiry.Seconds()), nil
+}
+
+// ParseMFAChallenge 校验挑战令牌，返回用户ID和挑战ID
+func (s *jwtService) ParseMFAChallenge(ctx context.Context, challengeToken string) (int64, string, error) {
+	_, claims, err := s.ValidateToken(challengeToken)
+	if err != nil {
+		return 0, "", err
+	}
+
+	if tokenType, _ := claims["typ"].(string); tokenType != string(MFAChallengeToken) {
+		return 0, "", ErrInvalidTokenType
+	}
+
+	userID, err := claimUserID(claims)
+	if err != nil {
+		return 0, "", err
+	}
+	challengeID, _ := claims["jti"].(string)
+
+	exists, err := s.redis.Exists(ctx, MFAChallengeKeyPrefix+challengeID).Result()
+	if err != nil {
+		return 0, "", fmt.Errorf("查询二次验证挑战失败: %w", err)
+	}
+	if exists == 0 {
+		return 0, "", ErrMFAChallenge
+	}
+
+	return userID, challengeID, nil
+}
+
+// FailMFAChallenge 记录一次失败的验证码尝试，超过次数后挑战作废
+func (s *jwtService) FailMFAChallenge(ctx context.Context, challengeID string) error {
+	key := MFAChallengeKeyPrefix + challengeID
+	remaining, err := s.redis.Decr(ctx, key).Result()
+	if err != nil {
+		return fmt.Errorf("更新二次验证挑战失败: %w", err)
+	}
+	if remaining <= 0 {
+		s.redis.Del(ctx, key)
+	}
+	return nil
+}
+
+// ConsumeMFAChallenge 使挑战令牌失效，每个挑战只能成功使用一次
+func (s *jwtService) ConsumeMFAChallenge(ctx context.Context, challengeID string) error {
+	deleted, err := s.redis.Del(ctx, MFAChallengeKeyPrefix+challengeID).Result()
+	if err != nil {
+		return fmt.Errorf("更新二次验证挑战失败: %w", err)
+	}
+	if deleted == 0 {
+		return ErrMFAChallenge
+	}
+	return nil
+}
+
+// RevokeSession 注销单个会话
+func (s *jwtService) RevokeSession(ctx context.Context, userID int64, sessionID string) error {
+	return s.sessions.Revoke(ctx, userID, sessionID)
+}
+
+// RevokeAllSessions 注销用户的全部会话
+func (s *jwtService) RevokeAllSessions(ctx context.Context, userID int64) error {
+	return s.sessions.RevokeAll(ctx, userID)
+}
+
+// JWKS 返回可公开的验证公钥集合
+func (s *jwtService) JWKS() *JWKSet {
+	return s.keys.jwks()
+}
+
 // ExtractUserID 从令牌中提取用户ID
 func (s *jwtService) ExtractUserID(tokenString string) (int64, error) {
 	_, claims, err := s.ValidateToken(tokenString)
@@ -152,10 +415,44 @@ func (s *jwtService) ExtractUserID(tokenString string) (int64, error) {
 		return 0, err
 	}
 
-	userID, ok := claims["sub"].(float64)
-	if !ok {
-		return 0, errors.New("无法获取用户ID")
+	return claimUserID(claims)
+}
+
+// parseSessionClaims 从令牌声明中解析用户ID、会话ID和令牌ID
+func parseSessionClaims(claims jwt.MapClaims) (int64, string, string, error) {
+	userID, err := claimUserID(claims)
+	if err != nil {
+		return 0, "", "", err
+	}
+
+	sessionID, _ := claims["sid"].(string)
+	tokenID, _ := claims["jti"].(string)
+	if sessionID == "" || tokenID == "" {
+		// 旧版本签发的令牌没有会话信息，无法校验是否已被注销
+		return 0, "", "", ErrTokenRevoked
 	}
 
-	return int64(userID), nil
+	return userID, sessionID, tokenID, nil
+}
+
+// claimUserID 从sub声明中解析用户ID
+func claimUserID(claims jwt.MapClaims) (int64, error) {
+	switch sub := claims["sub"].(type) {
+	case json.Number:
+		if userID, err := sub.Int64(); err == nil {
+			return userID, nil
+		}
+	case float64:
+		return int64(sub), nil
+	}
+	return 0, errors.New("无法获取用户ID")
+}
+
+// newTokenID 生成随机的令牌/会话标识
+func newTokenID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("生成令牌标识失败: %w", err)
+	}
+	return hex.EncodeToString(b), nil
 }
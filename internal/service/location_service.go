@@ -10,30 +10,33 @@ import (
 )
 
 var (
-	ErrLocationNotFound = errors.New("地点不存在")
+	ErrLocationNotFound = resourceNotFound("地点不存在")
 )
 
 // LocationService 地点服务接口
 type LocationService interface {
 	Service
 	CreateLocation(ctx context.Context, location *models.Location) (*models.Location, error)
-	GetLocationByID(ctx context.Context, id int64) (*models.Location, error)
+	// GetLocationByID 获取情侣的地点，不属于该情侣时返回 ErrLocationNotFound
+	GetLocationByID(ctx context.Context, coupleID, id int64) (*models.Location, error)
 	ListLocationsByCoupleID(ctx context.Context, coupleID int64, offset, limit int) ([]*models.Location, int64, error)
-	UpdateLocation(ctx context.Context, location *models.Location) error
-	DeleteLocation(ctx context.Context, id int64) error
+	UpdateLocation(ctx context.Context, coupleID int64, location *models.Location) error
+	DeleteLocation(ctx context.Context, coupleID, id int64) error
 }
 
 // locationService 地点服务实现
 type locationService struct {
 	*BaseService
 	locationRepo repository.LocationRepository
+	auditSvc     AuditService
 }
 
 // NewLocationService 创建地点服务
-func NewLocationService(locationRepo repository.LocationRepository) LocationService {
+func NewLocationService(locationRepo repository.LocationRepository, auditSvc AuditService) LocationService {
 	return &locationService{
 		BaseService:  NewBaseService(locationRepo),
 		locationRepo: locationRepo,
+		auditSvc:     auditSvc,
 	}
 }
 
@@ -42,12 +45,20 @@ func (s *locationService) CreateLocation(ctx context.Context, location *models.L
 	if err := s.locationRepo.Create(ctx, location); err != nil {
 		return nil, fmt.Errorf("创建地点失败: %w", err)
 	}
+
+	s.auditSvc.Record(ctx, AuditEntry{
+		CoupleID:   location.CoupleID,
+		Action:     models.AuditActionCreate,
+		EntityType: models.AuditEntityLocation,
+		EntityID:   AuditEntityID(location.ID),
+		After:      location,
+	})
 	return location, nil
 }
 
-// GetLocationByID 通过ID获取地点
-func (s *locationService) GetLocationByID(ctx context.Context, id int64) (*models.Location, error) {
-	location, err := s.locationRepo.GetByID(ctx, id)
+// GetLocationByID 通过ID获取情侣的地点
+func (s *locationService) GetLocationByID(ctx context.Context, coupleID, id int64) (*models.Location, error) {
+	location, err := s.locationRepo.GetByIDInCouple(ctx, coupleID, id)
 	if err != nil {
 		if errors.Is(err, repository.ErrLocationNotFound) {
 			return nil, ErrLocationNotFound
@@ -63,27 +74,37 @@ func (s *locationService) ListLocationsByCoupleID(ctx context.Context, coupleID
 }
 
 // UpdateLocation 更新地点
-func (s *locationService) UpdateLocation(ctx context.Context, location *models.Location) error {
-	// 检查地点是否存在
-	_, err := s.locationRepo.GetByID(ctx, location.ID)
+func (s *locationService) UpdateLocation(ctx context.Context, coupleID int64, location *models.Location) error {
+	// 检查地点是否存在且属于该情侣
+	existing, err := s.locationRepo.GetByIDInCouple(ctx, coupleID, location.ID)
 	if err != nil {
 		if errors.Is(err, repository.ErrLocationNotFound) {
 			return ErrLocationNotFound
 		}
 		return fmt.Errorf("查询地点失败: %w", err)
 	}
+	location.CoupleID = existing.CoupleID
 
 	// 更新地点
 	if err := s.locationRepo.Update(ctx, location); err != nil {
 		return fmt.Errorf("更新地点失败: %w", err)
 	}
+
+	s.auditSvc.Record(ctx, AuditEntry{
+		CoupleID:   existing.CoupleID,
+		Action:     models.AuditActionUpdate,
+		EntityType: models.AuditEntityLocation,
+		EntityID:   AuditEntityID(location.ID),
+		Before:     existing,
+		After:      location,
+	})
 	return nil
 }
 
 // DeleteLocation 删除地点
-func (s *locationService) DeleteLocation(ctx context.Context, id int64) error {
-	// 检查地点是否存在
-	_, err := s.locationRepo.GetByID(ctx, id)
+func (s *locationService) DeleteLocation(ctx context.Context, coupleID, id int64) error {
+	// 检查地点是否存在且属于该情侣
+	existing, err := s.locationRepo.GetByIDInCouple(ctx, coupleID, id)
 	if err != nil {
 		if errors.Is(err, repository.ErrLocationNotFound) {
 			return ErrLocationNotFound
@@ -95,5 +116,13 @@ func (s *locationService) DeleteLocation(ctx context.Context, id int64) error {
 	if err := s.locationRepo.Delete(ctx, id); err != nil {
 		return fmt.Errorf("删除地点失败: %w", err)
 	}
+
+	s.auditSvc.Record(ctx, AuditEntry{
+		CoupleID:   existing.CoupleID,
+		Action:     models.AuditActionDelete,
+		EntityType: models.AuditEntityLocation,
+		EntityID:   AuditEntityID(id),
+		Before:     existing,
+	})
 	return nil
 }
@@ -0,0 +1,336 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"memoir-api/internal/config"
+	"memoir-api/internal/logger"
+	"memoir-api/internal/models"
+	"memoir-api/internal/repository"
+
+	"github.com/go-redis/redis/v8"
+)
+
+const (
+	// LoginFailPrefix 登录失败计数键前缀
+	LoginFailPrefix = "auth:login_fail:"
+	// LoginDelayPrefix 登录退避等待键前缀
+	LoginDelayPrefix = "auth:login_delay:"
+	// LoginLockPrefix 登录锁定键前缀
+	LoginLockPrefix = "auth:login_lock:"
+
+	// LockoutKindAccount 按账号锁定
+	LockoutKindAccount = "account"
+	// LockoutKindIP 按IP锁定
+	LockoutKindIP = "ip"
+
+	// loginBackoffThreshold 连续失败达到该次数后开始退避
+	loginBackoffThreshold = 3
+	// loginMaxBackoff 单次退避的最长等待时间
+	loginMaxBackoff = 5 * time.Minute
+)
+
+var (
+	ErrLockoutNotFound    = errors.New("锁定记录不存在")
+	ErrInvalidLockoutKind = errors.New("无效的锁定类型")
+)
+
+// LoginAttempt 一次登录尝试的标识信息
+type LoginAttempt struct {
+	Email    string
+	Username string
+	// UserID 已确认身份的用户，二次验证时填写；为0时按邮箱或用户名查找
+	UserID int64
+	IP     string
+}
+
+// identifier 未注册账号的计数标识，邮箱和用户名分别计数
+func (a LoginAttempt) identifier() string {
+	if a.Email != "" {
+		return "email:" + strings.ToLower(strings.TrimSpace(a.Email))
+	}
+	return "username:" + strings.ToLower(strings.TrimSpace(a.Username))
+}
+
+// LoginBlock 登录被限制的原因和需要等待的时间
+type LoginBlock struct {
+	Locked     bool          // true为锁定，false为退避等待
+	Kind       string        // 触发限制的维度：account 或 ip
+	RetryAfter time.Duration // 距离可以再次尝试的时间
+}
+
+// LoginLockout 登录锁定记录
+type LoginLockout struct {
+	Kind       string    `json:"kind"`
+	Identifier string    `json:"identifier"`
+	Failures   int64     `json:"failures"`
+	LastIP     string    `json:"last_ip"`
+	LockedAt   time.Time `json:"locked_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
+
+// LoginGuardService 登录防暴力破解服务接口
+type LoginGuardService interface {
+	Service
+	// Check 检查本次登录是否被退避或锁定
+	Check(ctx context.Context, attempt LoginAttempt) (*LoginBlock, error)
+	// RecordFailure 记录一次失败的登录
+	RecordFailure(ctx context.Context, attempt LoginAttempt) error
+	// RecordSuccess 登录完成（包括二次验证）后清除账号维度的失败记录
+	RecordSuccess(ctx context.Context, attempt LoginAttempt) error
+	// ListLockouts 列出当前所有锁定记录
+	ListLockouts(ctx context.Context) ([]*LoginLockout, error)
+	// ClearLockout 解除锁定并清空失败计数
+	ClearLockout(ctx context.Context, kind, identifier string) error
+}
+
+// loginGuardService 基于Redis计数器的实现
+type loginGuardService struct {
+	*BaseService
+	redis    *redis.Client
+	userRepo repository.UserRepository
+	emailSvc EmailService
+	cfg      config.AuthConfig
+	log      logger.Logger
+}
+
+// NewLoginGuardService 创建登录防暴力破解服务
+func NewLoginGuardService(redisClient *redis.Client, userRepo repository.UserRepository, emailSvc EmailService, cfg config.AuthConfig) LoginGuardService {
+	return &loginGuardService{
+		BaseService: NewBaseService(userRepo),
+		redis:       redisClient,
+		userRepo:    userRepo,
+		emailSvc:    emailSvc,
+		cfg:         cfg,
+		log:         logger.GetLogger("login-guard"),
+	}
+}
+
+func loginKey(prefix, kind, identifier string) string {
+	return prefix + kind + ":" + identifier
+}
+
+// account 账号维度的计数标识。已注册的账号按用户ID计数，用邮箱和用户名登录、二次验证共用同一个计数；
+// 账号不存在时按输入的邮箱或用户名计数。返回的用户ID为0表示账号不存在
+func (s *loginGuardService) account(ctx context.Context, attempt LoginAttempt) (string, int64, error) {
+	userID := attempt.UserID
+	if userID == 0 {
+		var user *models.User
+		var err error
+		if attempt.Email != "" {
+			user, err = s.userRepo.GetByEmail(ctx, attempt.Email)
+		} else {
+			user, err = s.userRepo.GetByUsername(ctx, attempt.Username)
+		}
+		if err != nil {
+			if errors.Is(err, repository.ErrUserNotFound) {
+				return attempt.identifier(), 0, nil
+			}
+			return "", 0, fmt.Errorf("查询登录账号失败: %w", err)
+		}
+		userID = user.ID
+	}
+	return "user:" + strconv.FormatInt(userID, 10), userID, nil
+}
+
+// Check 检查本次登录是否被退避或锁定
+func (s *loginGuardService) Check(ctx context.Context, attempt LoginAttempt) (*LoginBlock, error) {
+	account, _, err := s.account(ctx, attempt)
+	if err != nil {
+		return nil, err
+	}
+	dimensions := []struct{ kind, identifier string }{
+		{LockoutKindAccount, account},
+		{LockoutKindIP, attempt.IP},
+	}
+
+	// 先检查锁定，再检查退避
+	for _, prefix := range []string{LoginLockPrefix, LoginDelayPrefix} {
+		for _, d := range dimensions {
+			ttl, err := s.redis.TTL(ctx, loginKey(prefix, d.kind, d.identifier)).Result()
+			if err != nil {
+				return nil, fmt.Errorf("检查登录限制失败: %w", err)
+			}
+			if ttl > 0 {
+				return &LoginBlock{
+					Locked:     prefix == LoginLockPrefix,
+					Kind:       d.kind,
+					RetryAfter: ttl,
+				}, nil
+			}
+		}
+	}
+	return nil, nil
+}
+
+// RecordFailure 记录一次失败的登录
+func (s *loginGuardService) RecordFailure(ctx context.Context, attempt LoginAttempt) error {
+	window := time.Duration(s.cfg.LoginFailureWindowMinutes) * time.Minute
+
+	// 账号维度：失败次数增长后指数退避，达到阈值后锁定并通知账号所有者
+	account, userID, err := s.account(ctx, attempt)
+	if err != nil {
+		return err
+	}
+	failures, err := s.incrFailures(ctx, LockoutKindAccount, account, window)
+	if err != nil {
+		return err
+	}
+	if failures >= loginBackoffThreshold {
+		delay := time.Second << uint(failures-loginBackoffThreshold)
+		if delay > loginMaxBackoff || delay <= 0 {
+			delay = loginMaxBackoff
+		}
+		if err := s.redis.Set(ctx, loginKey(LoginDelayPrefix, LockoutKindAccount, account), failures, delay).Err(); err != nil {
+			return fmt.Errorf("记录登录退避失败: %w", err)
+		}
+	}
+	if failures >= int64(s.cfg.LoginMaxFailures) {
+		locked, err := s.lock(ctx, LockoutKindAccount, account, failures, attempt.IP)
+		if err != nil {
+			return err
+		}
+		if locked && userID != 0 {
+			s.notifyOwner(ctx, userID, attempt.IP)
+		}
+	}
+
+	// IP维度：同一IP对多个账号的尝试，只做锁定
+	if attempt.IP != "" {
+		ipFailures, err := s.incrFailures(ctx, LockoutKindIP, attempt.IP, window)
+		if err != nil {
+			return err
+		}
+		if ipFailures >= int64(s.cfg.LoginIPMaxFailures) {
+			if _, err := s.lock(ctx, LockoutKindIP, attempt.IP, ipFailures, attempt.IP); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// incrFailures 失败计数加一，首次计数时设置统计窗口
+func (s *loginGuardService) incrFailures(ctx context.Context, kind, identifier string, window time.Duration) (int64, error) {
+	key := loginKey(LoginFailPrefix, kind, identifier)
+	count, err := s.redis.Incr(ctx, key).Result()
+	if err != nil {
+		return 0, fmt.Errorf("记录登录失败次数失败: %w", err)
+	}
+
+	if count == 1 {
+		s.redis.Expire(ctx, key, window)
+	}
+	return count, nil
+}
+
+// lock 写入锁定记录，返回是否为新产生的锁定
+func (s *loginGuardService) lock(ctx context.Context, kind, identifier string, failures int64, ip string) (bool, error) {
+	duration := time.Duration(s.cfg.LoginLockoutMinutes) * time.Minute
+	now := time.Now()
+	data, err := json.Marshal(&LoginLockout{
+		Kind:       kind,
+		Identifier: identifier,
+		Failures:   failures,
+		LastIP:     ip,
+		LockedAt:   now,
+		ExpiresAt:  now.Add(duration),
+	})
+	if err != nil {
+		return false, fmt.Errorf("序列化锁定记录失败: %w", err)
+	}
+
+	locked, err := s.redis.SetNX(ctx, loginKey(LoginLockPrefix, kind, identifier), data, duration).Result()
+	if err != nil {
+		return false, fmt.Errorf("写入锁定记录失败: %w", err)
+	}
+	if locked {
+		s.log.Warn("登录失败次数过多，已临时锁定", "kind", kind, "identifier", identifier, "failures", failures, "ip", ip)
+	}
+	return locked, nil
+}
+
+// notifyOwner 通知账号所有者账号已被临时锁定
+func (s *loginGuardService) notifyOwner(ctx context.Context, userID int64, ip string) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		s.log.Error(err, "获取被锁定的账号失败", "user_id", userID)
+		return
+	}
+
+	message := fmt.Sprintf("您的账号在短时间内多次登录失败（最近一次来自IP %s），为保护账号安全已临时锁定 %d 分钟。如果这不是您本人的操作，建议您尽快修改密码并开启二次验证。",
+		ip, s.cfg.LoginLockoutMinutes)
+	if err := s.emailSvc.SendNotificationEmail(ctx, emailRecipient(user), message); err != nil {
+		s.log.Error(err, "发送账号锁定通知失败", "user_id", user.ID)
+	}
+}
+
+// RecordSuccess 登录完成后清除账号维度的失败记录
+func (s *loginGuardService) RecordSuccess(ctx context.Context, attempt LoginAttempt) error {
+	account, _, err := s.account(ctx, attempt)
+	if err != nil {
+		return err
+	}
+	err = s.redis.Del(ctx,
+		loginKey(LoginFailPrefix, LockoutKindAccount, account),
+		loginKey(LoginDelayPrefix, LockoutKindAccount, account),
+	).Err()
+	if err != nil {
+		return fmt.Errorf("清除登录失败记录失败: %w", err)
+	}
+	return nil
+}
+
+// ListLockouts 列出当前所有锁定记录
+func (s *loginGuardService) ListLockouts(ctx context.Context) ([]*LoginLockout, error) {
+	lockouts := make([]*LoginLockout, 0)
+	iter := s.redis.Scan(ctx, 0, LoginLockPrefix+"*", 100).Iterator()
+	for iter.Next(ctx) {
+		data, err := s.redis.Get(ctx, iter.Val()).Bytes()
+		if err != nil {
+			if errors.Is(err, redis.Nil) {
+				// 扫描期间已过期
+				continue
+			}
+			return nil, fmt.Errorf("获取锁定记录失败: %w", err)
+		}
+
+		var lockout LoginLockout
+		if err := json.Unmarshal(data, &lockout); err != nil {
+			s.log.Error(err, "解析锁定记录失败", "key", iter.Val())
+			continue
+		}
+		lockouts = append(lockouts, &lockout)
+	}
+	if err := iter.Err(); err != nil {
+		return nil, fmt.Errorf("扫描锁定记录失败: %w", err)
+	}
+	return lockouts, nil
+}
+
+// ClearLockout 解除锁定并清空失败计数
+func (s *loginGuardService) ClearLockout(ctx context.Context, kind, identifier string) error {
+	if kind != LockoutKindAccount && kind != LockoutKindIP {
+		return ErrInvalidLockoutKind
+	}
+
+	deleted, err := s.redis.Del(ctx,
+		loginKey(LoginLockPrefix, kind, identifier),
+		loginKey(LoginFailPrefix, kind, identifier),
+		loginKey(LoginDelayPrefix, kind, identifier),
+	).Result()
+	if err != nil {
+		return fmt.Errorf("解除锁定失败: %w", err)
+	}
+	if deleted == 0 {
+		return ErrLockoutNotFound
+	}
+	return nil
+}
@@ -2,10 +2,17 @@ package service
 
 import (
 	"context"
+	"errors"
 
 	"memoir-api/internal/api/dto"
 	"memoir-api/internal/models"
 	"memoir-api/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrPersonalMediaNotFound = resourceNotFound("个人媒体不存在")
 )
 
 // PersonalMediaService 个人媒体服务接口
@@ -14,23 +21,42 @@ type PersonalMediaService interface {
 	CreateWithURL(ctx context.Context, request dto.CreatePersonalMediaWithURLRequest) (*models.PersonalMedia, error)
 	// 分页查询个人媒体
 	PageQuery(ctx context.Context, pageRequest dto.QueryPersonalMediaRequest) (*dto.PageResult, error)
-	// 删除个人媒体
-	Delete(ctx context.Context, id int64) error
+	// 删除用户本人的个人媒体，不属于该用户时返回 ErrPersonalMediaNotFound
+	Delete(ctx context.Context, userID, id int64) error
 }
 
 // DefaultPersonalMediaService 个人媒体服务的默认实现
 type DefaultPersonalMediaService struct {
-	repo repository.PersonalMediaRepository
+	repo     repository.PersonalMediaRepository
+	auditSvc AuditService
 }
 
-func (s *DefaultPersonalMediaService) Delete(ctx context.Context, id int64) error {
-	return s.repo.Delete(ctx, id)
+func (s *DefaultPersonalMediaService) Delete(ctx context.Context, userID, id int64) error {
+	media, err := s.repo.FindByIDForUser(ctx, userID, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrPersonalMediaNotFound
+		}
+		return err
+	}
+	if err := s.repo.Delete(ctx, id); err != nil {
+		return err
+	}
+
+	s.auditSvc.Record(ctx, AuditEntry{
+		Action:     models.AuditActionDelete,
+		EntityType: models.AuditEntityPersonalMedia,
+		EntityID:   AuditEntityID(id),
+		Before:     media,
+	})
+	return nil
 }
 
 // NewPersonalMediaService 创建个人媒体服务实例
-func NewPersonalMediaService(repo repository.PersonalMediaRepository) PersonalMediaService {
+func NewPersonalMediaService(repo repository.PersonalMediaRepository, auditSvc AuditService) PersonalMediaService {
 	return &DefaultPersonalMediaService{
-		repo: repo,
+		repo:     repo,
+		auditSvc: auditSvc,
 	}
 }
 
@@ -53,6 +79,12 @@ func (s *DefaultPersonalMediaService) CreateWithURL(ctx context.Context, request
 		return nil, err
 	}
 
+	s.auditSvc.Record(ctx, AuditEntry{
+		Action:     models.AuditActionCreate,
+		EntityType: models.AuditEntityPersonalMedia,
+		EntityID:   AuditEntityID(media.ID),
+		After:      media,
+	})
 	return media, nil
 }
 
@@ -8,34 +8,70 @@ import (
 	"memoir-api/internal/api/dto"
 	"memoir-api/internal/models"
 	"memoir-api/internal/repository"
+
+	"gorm.io/gorm"
 )
 
 var (
-	ErrPhotoVideoNotFound = errors.New("照片/视频不存在")
+	ErrPhotoVideoNotFound = resourceNotFound("照片/视频不存在")
 )
 
 // PhotoVideoService 照片和视频服务接口
 type PhotoVideoService interface {
 	Service
+	// CreatePhotoVideo 创建照片/视频，相册、事件和地点都必须属于用户所在的情侣
 	CreatePhotoVideo(ctx context.Context, dto *dto.CreatePhotoVideoRequest) (*models.PhotoVideo, error)
 	GetPhotoVideoByID(ctx context.Context, id int64) (*models.PhotoVideo, error)
 	Query(ctx context.Context, params *dto.PhotoVideoQueryParams) (*dto.PageResult, error)
 	UpdatePhotoVideo(ctx context.Context, photoVideo *models.PhotoVideo) error
 	DeletePhotoVideo(ctx context.Context, id int64) error
-	BatchDeletePhotoVideo(ctx context.Context, ids []int64) error
+	// BatchDeletePhotoVideo 批量删除情侣的照片/视频，有任何一个不属于该情侣时不删除并返回 ErrPhotoVideoNotFound
+	BatchDeletePhotoVideo(ctx context.Context, coupleID int64, ids []int64) error
 	CountByCoupleID(ctx context.Context, coupleID int64) (int64, error)
 }
 
 // photoVideoService 照片和视频服务实现
 type photoVideoService struct {
 	*BaseService
-	photoVideoRepo repository.PhotoVideoRepository
-	userRepo       repository.UserRepository
-	ablumRepo      repository.CoupleAlbumRepository
+	photoVideoRepo      repository.PhotoVideoRepository
+	userRepo            repository.UserRepository
+	ablumRepo           repository.CoupleAlbumRepository
+	timelineEventRepo   repository.TimelineEventRepository
+	locationRepo        repository.LocationRepository
+	eventPhotoVideoRepo repository.TimelineEventPhotoVideoRepository
+	auditSvc            AuditService
 }
 
-func (s *photoVideoService) BatchDeletePhotoVideo(ctx context.Context, ids []int64) error {
-	return s.photoVideoRepo.BatchDelete(ctx, ids)
+func (s *photoVideoService) BatchDeletePhotoVideo(ctx context.Context, coupleID int64, ids []int64) error {
+	ids = uniqueIDs(ids)
+	if len(ids) == 0 {
+		return nil
+	}
+
+	// 删除前先查出原始记录，审计日志中保留被删除的内容
+	photosVideos, err := s.photoVideoRepo.FindByIDsInCouple(ctx, coupleID, ids)
+	if err != nil {
+		return fmt.Errorf("查询照片/视频失败: %w", err)
+	}
+	if len(photosVideos) != len(ids) {
+		return ErrPhotoVideoNotFound
+	}
+
+	if err := s.photoVideoRepo.BatchDelete(ctx, ids); err != nil {
+		return err
+	}
+
+	for i := range photosVideos {
+		photoVideo := &photosVideos[i]
+		s.auditSvc.Record(ctx, AuditEntry{
+			CoupleID:   photoVideo.CoupleID,
+			Action:     models.AuditActionDelete,
+			EntityType: models.AuditEntityPhotoVideo,
+			EntityID:   AuditEntityID(photoVideo.ID),
+			Before:     photoVideo,
+		})
+	}
+	return nil
 }
 
 func (s *photoVideoService) CountByCoupleID(ctx context.Context, coupleID int64) (int64, error) {
@@ -52,12 +88,24 @@ func (s *photoVideoService) Query(ctx context.Context, params *dto.PhotoVideoQue
 }
 
 // NewPhotoVideoService 创建照片和视频服务
-func NewPhotoVideoService(photoVideoRepo repository.PhotoVideoRepository, userRepo repository.UserRepository, ablumRepo repository.CoupleAlbumRepository) PhotoVideoService {
+func NewPhotoVideoService(
+	photoVideoRepo repository.PhotoVideoRepository,
+	userRepo repository.UserRepository,
+	ablumRepo repository.CoupleAlbumRepository,
+	timelineEventRepo repository.TimelineEventRepository,
+	locationRepo repository.LocationRepository,
+	eventPhotoVideoRepo repository.TimelineEventPhotoVideoRepository,
+	auditSvc AuditService,
+) PhotoVideoService {
 	return &photoVideoService{
-		BaseService:    NewBaseService(photoVideoRepo),
-		photoVideoRepo: photoVideoRepo,
-		userRepo:       userRepo,
-		ablumRepo:      ablumRepo,
+		BaseService:         NewBaseService(photoVideoRepo),
+		photoVideoRepo:      photoVideoRepo,
+		userRepo:            userRepo,
+		ablumRepo:           ablumRepo,
+		timelineEventRepo:   timelineEventRepo,
+		locationRepo:        locationRepo,
+		eventPhotoVideoRepo: eventPhotoVideoRepo,
+		auditSvc:            auditSvc,
 	}
 }
 
@@ -72,14 +120,53 @@ func (s *photoVideoService) CreatePhotoVideo(ctx context.Context, dto *dto.Creat
 		return nil, fmt.Errorf("用户没有情侣关系")
 	}
 	photoVideo.CoupleID = user.CoupleID
+
+	// 相册、事件和地点都只在当前情侣的数据中查找，写入之前完成校验
+	ablum, err := s.ablumRepo.GetByIDInCouple(ctx, photoVideo.CoupleID, photoVideo.AlbumID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrAlbumNotFound
+		}
+		return nil, fmt.Errorf("查询相册失败：%w", err)
+	}
+	if dto.EventID != nil {
+		if _, err := s.timelineEventRepo.FindByIDInCouple(ctx, photoVideo.CoupleID, *dto.EventID); err != nil {
+			if errors.Is(err, repository.ErrTimelineEventNotFound) {
+				return nil, ErrTimelineEventNotFound
+			}
+			return nil, fmt.Errorf("查询时间轴事件失败：%w", err)
+		}
+	}
+	// 照片/视频没有单独的地点字段，地点只校验归属
+	if dto.LocationID != nil {
+		if _, err := s.locationRepo.GetByIDInCouple(ctx, photoVideo.CoupleID, *dto.LocationID); err != nil {
+			if errors.Is(err, repository.ErrLocationNotFound) {
+				return nil, ErrLocationNotFound
+			}
+			return nil, fmt.Errorf("查询地点失败：%w", err)
+		}
+	}
+
 	if err := s.photoVideoRepo.Create(ctx, photoVideo); err != nil {
 		return nil, fmt.Errorf("创建照片/视频失败: %w", err)
 	}
-	//对应相册 照片数量+1
-	ablum, err := s.ablumRepo.GetByID(ctx, photoVideo.AlbumID)
-	if err != nil {
-		return nil, fmt.Errorf("查询相册失败：%w", err)
+	if dto.EventID != nil {
+		eventPhotoVideo := &models.TimelineEventPhotoVideo{
+			TimelineEventID: *dto.EventID,
+			PhotoVideoID:    photoVideo.ID,
+		}
+		if err := s.eventPhotoVideoRepo.Create(ctx, eventPhotoVideo); err != nil {
+			return nil, fmt.Errorf("关联时间轴事件失败：%w", err)
+		}
 	}
+	s.auditSvc.Record(ctx, AuditEntry{
+		CoupleID:   photoVideo.CoupleID,
+		Action:     models.AuditActionCreate,
+		EntityType: models.AuditEntityPhotoVideo,
+		EntityID:   AuditEntityID(photoVideo.ID),
+		After:      photoVideo,
+	})
+	//对应相册 照片数量+1
 	ablum.Count = ablum.Count + 1
 	err = s.ablumRepo.Update(ctx, ablum)
 	if err != nil {
@@ -103,7 +190,7 @@ func (s *photoVideoService) GetPhotoVideoByID(ctx context.Context, id int64) (*m
 // UpdatePhotoVideo 更新照片/视频
 func (s *photoVideoService) UpdatePhotoVideo(ctx context.Context, photoVideo *models.PhotoVideo) error {
 	// 检查照片/视频是否存在
-	_, err := s.photoVideoRepo.GetByID(ctx, photoVideo.ID)
+	existing, err := s.photoVideoRepo.GetByID(ctx, photoVideo.ID)
 	if err != nil {
 		if errors.Is(err, repository.ErrPhotoVideoNotFound) {
 			return ErrPhotoVideoNotFound
@@ -115,13 +202,22 @@ func (s *photoVideoService) UpdatePhotoVideo(ctx context.Context, photoVideo *mo
 	if err := s.photoVideoRepo.Update(ctx, photoVideo); err != nil {
 		return fmt.Errorf("更新照片/视频失败: %w", err)
 	}
+
+	s.auditSvc.Record(ctx, AuditEntry{
+		CoupleID:   existing.CoupleID,
+		Action:     models.AuditActionUpdate,
+		EntityType: models.AuditEntityPhotoVideo,
+		EntityID:   AuditEntityID(photoVideo.ID),
+		Before:     existing,
+		After:      photoVideo,
+	})
 	return nil
 }
 
 // DeletePhotoVideo 删除照片/视频
 func (s *photoVideoService) DeletePhotoVideo(ctx context.Context, id int64) error {
 	// 检查照片/视频是否存在
-	_, err := s.photoVideoRepo.GetByID(ctx, id)
+	existing, err := s.photoVideoRepo.GetByID(ctx, id)
 	if err != nil {
 		if errors.Is(err, repository.ErrPhotoVideoNotFound) {
 			return ErrPhotoVideoNotFound
@@ -133,5 +229,13 @@ func (s *photoVideoService) DeletePhotoVideo(ctx context.Context, id int64) erro
 	if err := s.photoVideoRepo.Delete(ctx, id); err != nil {
 		return fmt.Errorf("删除照片/视频失败: %w", err)
 	}
+
+	s.auditSvc.Record(ctx, AuditEntry{
+		CoupleID:   existing.CoupleID,
+		Action:     models.AuditActionDelete,
+		EntityType: models.AuditEntityPhotoVideo,
+		EntityID:   AuditEntityID(id),
+		Before:     existing,
+	})
 	return nil
 }
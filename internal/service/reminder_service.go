@@ -0,0 +1,388 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"memoir-api/internal/api/dto"
+	"memoir-api/internal/email"
+	"memoir-api/internal/logger"
+	"memoir-api/internal/models"
+	"memoir-api/internal/repository"
+	"memoir-api/internal/rrule"
+)
+
+var (
+	ErrReminderNotFound = resourceNotFound("提醒不存在")
+	ErrInvalidRRule     = errors.New("无效的重复规则")
+)
+
+// 倒计时列表默认查看的天数
+const defaultUpcomingDays = 30
+
+// ReminderService 用户自定义提醒服务接口
+type ReminderService interface {
+	Service
+	Create(ctx context.Context, coupleID, userID int64, req *dto.CreateReminderRequest) (*dto.ReminderDTO, error)
+	List(ctx context.Context, coupleID int64) ([]dto.ReminderDTO, error)
+	// Update 更新情侣的提醒，不属于该情侣时返回 ErrReminderNotFound
+	Update(ctx context.Context, coupleID, id int64, req *dto.UpdateReminderRequest) (*dto.ReminderDTO, error)
+	Delete(ctx context.Context, coupleID, id int64) error
+	// Upcoming 获取之后 days 天内的自定义提醒和心愿单提醒，按倒计时排序
+	Upcoming(ctx context.Context, coupleID int64, days int) ([]dto.UpcomingReminderDTO, error)
+	// CheckAndSendReminders 检查并发送自定义提醒和心愿单提醒邮件
+	CheckAndSendReminders(ctx context.Context) error
+}
+
+// reminderService 用户自定义提醒服务实现
+type reminderService struct {
+	*BaseService
+	reminderRepo  repository.ReminderRepository
+	coupleRepo    repository.CoupleRepository
+	userRepo      repository.UserRepository
+	deliveryRepo  repository.ReminderDeliveryRepository
+	wishlistSvc   WishlistService
+	emailSvc      EmailService
+	preferenceSvc EmailPreferenceService
+	auditSvc      AuditService
+	log           logger.Logger
+	// requireVerifiedEmail 为true时，未验证邮箱的用户不接收提醒邮件
+	requireVerifiedEmail bool
+}
+
+// NewReminderService 创建用户自定义提醒服务
+func NewReminderService(
+	reminderRepo repository.ReminderRepository,
+	coupleRepo repository.CoupleRepository,
+	userRepo repository.UserRepository,
+	deliveryRepo repository.ReminderDeliveryRepository,
+	wishlistSvc WishlistService,
+	emailSvc EmailService,
+	preferenceSvc EmailPreferenceService,
+	auditSvc AuditService,
+	requireVerifiedEmail bool,
+) ReminderService {
+	return &reminderService{
+		BaseService:          NewBaseService(reminderRepo),
+		reminderRepo:         reminderRepo,
+		coupleRepo:           coupleRepo,
+		userRepo:             userRepo,
+		deliveryRepo:         deliveryRepo,
+		wishlistSvc:          wishlistSvc,
+		emailSvc:             emailSvc,
+		preferenceSvc:        preferenceSvc,
+		auditSvc:             auditSvc,
+		log:                  logger.GetLogger("reminder-service"),
+		requireVerifiedEmail: requireVerifiedEmail,
+	}
+}
+
+// Create 创建提醒
+func (s *reminderService) Create(ctx context.Context, coupleID, userID int64, req *dto.CreateReminderRequest) (*dto.ReminderDTO, error) {
+	reminder, err := req.ToModel(coupleID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateRRule(reminder.RRule); err != nil {
+		return nil, err
+	}
+	if err := s.reminderRepo.Create(ctx, reminder); err != nil {
+		return nil, fmt.Errorf("创建提醒失败: %w", err)
+	}
+
+	s.auditSvc.Record(ctx, AuditEntry{
+		CoupleID:   coupleID,
+		Action:     models.AuditActionCreate,
+		EntityType: models.AuditEntityReminder,
+		EntityID:   AuditEntityID(reminder.ID),
+		After:      reminder,
+	})
+	return s.toDTO(ctx, reminder)
+}
+
+// List 获取情侣的所有提醒
+func (s *reminderService) List(ctx context.Context, coupleID int64) ([]dto.ReminderDTO, error) {
+	reminders, err := s.reminderRepo.ListByCoupleID(ctx, coupleID)
+	if err != nil {
+		return nil, fmt.Errorf("查询提醒失败: %w", err)
+	}
+	now, err := s.coupleNow(ctx, coupleID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]dto.ReminderDTO, len(reminders))
+	for i, reminder := range reminders {
+		result[i] = reminderToDTO(reminder, now)
+	}
+	return result, nil
+}
+
+// Update 更新提醒
+func (s *reminderService) Update(ctx context.Context, coupleID, id int64, req *dto.UpdateReminderRequest) (*dto.ReminderDTO, error) {
+	reminder, err := s.reminderRepo.GetByIDInCouple(ctx, coupleID, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrReminderNotFound) {
+			return nil, ErrReminderNotFound
+		}
+		return nil, fmt.Errorf("查询提醒失败: %w", err)
+	}
+	before := *reminder
+
+	if err := req.ApplyToModel(reminder); err != nil {
+		return nil, fmt.Errorf("更新提醒参数无效: %w", err)
+	}
+	if err := validateRRule(reminder.RRule); err != nil {
+		return nil, err
+	}
+	if err := s.reminderRepo.Update(ctx, reminder); err != nil {
+		return nil, fmt.Errorf("更新提醒失败: %w", err)
+	}
+
+	s.auditSvc.Record(ctx, AuditEntry{
+		CoupleID:   reminder.CoupleID,
+		Action:     models.AuditActionUpdate,
+		EntityType: models.AuditEntityReminder,
+		EntityID:   AuditEntityID(reminder.ID),
+		Before:     &before,
+		After:      reminder,
+	})
+	return s.toDTO(ctx, reminder)
+}
+
+// Delete 删除提醒
+func (s *reminderService) Delete(ctx context.Context, coupleID, id int64) error {
+	reminder, err := s.reminderRepo.GetByIDInCouple(ctx, coupleID, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrReminderNotFound) {
+			return ErrReminderNotFound
+		}
+		return fmt.Errorf("查询提醒失败: %w", err)
+	}
+	if err := s.reminderRepo.Delete(ctx, id); err != nil {
+		return fmt.Errorf("删除提醒失败: %w", err)
+	}
+
+	s.auditSvc.Record(ctx, AuditEntry{
+		CoupleID:   reminder.CoupleID,
+		Action:     models.AuditActionDelete,
+		EntityType: models.AuditEntityReminder,
+		EntityID:   AuditEntityID(reminder.ID),
+		Before:     reminder,
+	})
+	return nil
+}
+
+// Upcoming 获取即将到来的提醒，重复的提醒只列出最近的一次
+func (s *reminderService) Upcoming(ctx context.Context, coupleID int64, days int) ([]dto.UpcomingReminderDTO, error) {
+	if days <= 0 {
+		days = defaultUpcomingDays
+	}
+	now, err := s.coupleNow(ctx, coupleID)
+	if err != nil {
+		return nil, err
+	}
+
+	reminders, err := s.reminderRepo.ListByCoupleID(ctx, coupleID)
+	if err != nil {
+		return nil, fmt.Errorf("查询提醒失败: %w", err)
+	}
+	wishlists, err := s.wishlistSvc.ListUpcomingReminders(ctx, coupleID, now, days)
+	if err != nil {
+		return nil, fmt.Errorf("查询心愿提醒失败: %w", err)
+	}
+
+	result := make([]dto.UpcomingReminderDTO, 0, len(reminders)+len(wishlists))
+	for _, reminder := range reminders {
+		next, ok := reminder.NextOccurrence(now)
+		if !ok {
+			continue
+		}
+		daysUntil := models.DaysBetween(now, next)
+		if daysUntil > days {
+			continue
+		}
+		result = append(result, dto.UpcomingReminderDTO{
+			Source:    dto.UpcomingSourceReminder,
+			ID:        reminder.ID,
+			Title:     reminder.Title,
+			Date:      next.Format("2006-01-02"),
+			DaysUntil: daysUntil,
+		})
+	}
+	for _, wishlist := range wishlists {
+		result = append(result, dto.UpcomingReminderDTO{
+			Source:    dto.UpcomingSourceWishlist,
+			ID:        wishlist.ID,
+			Title:     wishlist.Title,
+			Date:      wishlist.ReminderDate.Format("2006-01-02"),
+			DaysUntil: models.DaysBetween(now, *wishlist.ReminderDate),
+		})
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].DaysUntil < result[j].DaysUntil
+	})
+	return result, nil
+}
+
+// CheckAndSendReminders 检查并发送自定义提醒和心愿单提醒邮件，按情侣的时区和提醒时刻发送
+func (s *reminderService) CheckAndSendReminders(ctx context.Context) error {
+	s.log.Info("开始检查自定义提醒")
+
+	// 获取当地时间已到提醒时刻的情侣
+	now := time.Now()
+	couples, err := dueCouples(ctx, s.coupleRepo, s.log, now)
+	if err != nil {
+		s.log.Error(err, "获取情侣列表失败")
+		return err
+	}
+
+	for _, couple := range couples {
+		if !couple.ReminderNotifications || !couple.IsActive() {
+			continue
+		}
+		today := now.In(couple.Location())
+
+		reminders, err := s.reminderRepo.ListByCoupleID(ctx, couple.ID)
+		if err != nil {
+			s.log.Error(err, "获取提醒失败", "coupleID", couple.ID)
+			continue
+		}
+		wishlists, err := s.wishlistSvc.ListUpcomingReminders(ctx, couple.ID, today, 0)
+		if err != nil {
+			s.log.Error(err, "获取心愿提醒失败", "coupleID", couple.ID)
+			continue
+		}
+		if len(reminders) == 0 && len(wishlists) == 0 {
+			continue
+		}
+
+		users, err := s.userRepo.ListByCoupleID(ctx, couple.ID)
+		if err != nil {
+			s.log.Error(err, "获取情侣用户失败", "coupleID", couple.ID)
+			continue
+		}
+
+		for _, reminder := range reminders {
+			if !reminder.HasChannel(models.ReminderChannelEmail) {
+				continue
+			}
+			for _, lead := range reminder.LeadDays {
+				date := today.AddDate(0, 0, lead)
+				if !reminder.OccursOn(date) {
+					continue
+				}
+				occurrence := reminderOccurrence{
+					CoupleID: couple.ID,
+					Kind:     models.ReminderKindCustom,
+					Key:      strconv.FormatInt(reminder.ID, 10) + ":" + strconv.Itoa(lead),
+					Date:     date,
+					Title:    reminder.Title,
+				}
+				for _, user := range reminderRecipients(reminder, users) {
+					s.send(ctx, couple, occurrence, user, reminder.Note, lead)
+				}
+				s.log.Info("已发送自定义提醒", "coupleID", couple.ID, "reminderID", reminder.ID, "daysUntil", lead)
+			}
+		}
+
+		for _, wishlist := range wishlists {
+			occurrence := reminderOccurrence{
+				CoupleID: couple.ID,
+				Kind:     models.ReminderKindWishlist,
+				Key:      strconv.FormatInt(wishlist.ID, 10),
+				Date:     *wishlist.ReminderDate,
+				Title:    wishlist.Title,
+			}
+			for _, user := range users {
+				s.send(ctx, couple, occurrence, user, wishlist.Description, 0)
+			}
+			s.log.Info("已发送心愿提醒", "coupleID", couple.ID, "wishlistID", wishlist.ID)
+		}
+	}
+
+	s.log.Info("自定义提醒检查完成")
+	return nil
+}
+
+// send 给一个收件人发送一次提醒，退订了提醒邮件或已发送过的跳过
+func (s *reminderService) send(ctx context.Context, couple *models.Couple, occurrence reminderOccurrence, user *models.User, note string, daysUntil int) {
+	if !canReceiveReminder(s.log, user, s.requireVerifiedEmail) || !wantsEmail(ctx, s.preferenceSvc, s.log, user, email.EmailTypeReminder) {
+		return
+	}
+	deliverOnce(ctx, s.deliveryRepo, s.log, occurrence, user, func() error {
+		return s.emailSvc.SendReminderEmail(ctx, coupleEmailRecipient(user, couple), occurrence.Title, note,
+			occurrence.Date.Format("2006-01-02"), daysUntil)
+	})
+}
+
+// reminderRecipients 按提醒对象选出收件人
+func reminderRecipients(reminder *models.Reminder, users []*models.User) []*models.User {
+	if reminder.Target == models.ReminderTargetBoth {
+		return users
+	}
+	var recipients []*models.User
+	for _, user := range users {
+		isCreator := user.ID == reminder.CreatedBy
+		if isCreator == (reminder.Target == models.ReminderTargetCreator) {
+			recipients = append(recipients, user)
+		}
+	}
+	return recipients
+}
+
+// coupleNow 按情侣设置的时区获取当前时间
+func (s *reminderService) coupleNow(ctx context.Context, coupleID int64) (time.Time, error) {
+	couple, err := s.coupleRepo.GetByID(ctx, coupleID)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Now().In(couple.Location()), nil
+}
+
+// toDTO 转换为响应，按情侣时区计算倒计时
+func (s *reminderService) toDTO(ctx context.Context, reminder *models.Reminder) (*dto.ReminderDTO, error) {
+	now, err := s.coupleNow(ctx, reminder.CoupleID)
+	if err != nil {
+		return nil, err
+	}
+	result := reminderToDTO(reminder, now)
+	return &result, nil
+}
+
+// reminderToDTO 转换为响应，附带下一次的倒计时
+func reminderToDTO(reminder *models.Reminder, now time.Time) dto.ReminderDTO {
+	result := dto.ReminderDTO{
+		ID:        reminder.ID,
+		CreatedBy: reminder.CreatedBy,
+		Title:     reminder.Title,
+		Note:      reminder.Note,
+		Date:      reminder.Date.Format("2006-01-02"),
+		RRule:     reminder.RRule,
+		LeadDays:  reminder.LeadDays,
+		Target:    reminder.Target,
+		Channels:  reminder.Channels,
+	}
+	if next, ok := reminder.NextOccurrence(now); ok {
+		daysUntil := models.DaysBetween(now, next)
+		result.NextDate = next.Format("2006-01-02")
+		result.DaysUntil = &daysUntil
+	}
+	return result
+}
+
+// validateRRule 校验重复规则，为空表示只有一次
+func validateRRule(s string) error {
+	if s == "" {
+		return nil
+	}
+	if _, err := rrule.Parse(s); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidRRule, err)
+	}
+	return nil
+}
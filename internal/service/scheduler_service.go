@@ -0,0 +1,386 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"memoir-api/internal/config"
+	"memoir-api/internal/logger"
+	"memoir-api/internal/models"
+	"memoir-api/internal/repository"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/robfig/cron/v3"
+)
+
+const (
+	// SchedulerLockPrefix 任务执行锁键前缀，值为持有锁的执行标识
+	SchedulerLockPrefix = "scheduler:lock:"
+	// SchedulerSlotPrefix 调度时间点占用键前缀，同一时间点只有一个实例执行
+	SchedulerSlotPrefix = "scheduler:slot:"
+
+	// schedulerSlotTTL 调度时间点占用标记的保留时间，需要覆盖各实例之间的时钟偏差
+	schedulerSlotTTL = 10 * time.Minute
+	// defaultSchedulerLockTTL 未配置时任务锁的过期时间
+	defaultSchedulerLockTTL = 5 * time.Minute
+)
+
+// 定时任务名称
+const (
+	JobAnniversaryReminders = "anniversary_reminders"
+	JobFestivalReminders    = "festival_reminders"
+	JobUserReminders        = "user_reminders"
+	JobCoupleDissolutions   = "couple_dissolutions"
+)
+
+var (
+	ErrJobNotFound  = errors.New("定时任务不存在")
+	ErrJobRunning   = errors.New("定时任务正在执行")
+	ErrJobDuplicate = errors.New("定时任务已注册")
+)
+
+// releaseLockScript 只释放自己持有的锁，避免锁过期后误删其他实例的锁
+var releaseLockScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0`)
+
+// renewLockScript 只续期自己持有的锁
+var renewLockScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0`)
+
+// JobFunc 定时任务的执行函数
+type JobFunc func(ctx context.Context) error
+
+// Job 定时任务定义
+type Job struct {
+	Name        string
+	Description string
+	// Schedule 5段cron表达式，为空时只能手动触发
+	Schedule string
+	Run      JobFunc
+}
+
+// JobStatus 定时任务及其最近一次执行情况
+type JobStatus struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	Schedule    string         `json:"schedule"`
+	NextRunAt   *time.Time     `json:"next_run_at,omitempty"`
+	LastRun     *models.JobRun `json:"last_run,omitempty"`
+}
+
+// SchedulerService 定时任务调度服务接口。每个实例都按调度表触发，
+// 通过Redis锁保证同一个任务同一时间只在一个实例上执行
+type SchedulerService interface {
+	Service
+	// Register 注册任务，需要在 Start 之前调用
+	Register(job Job) error
+	// Start 启动调度，配置关闭调度时只允许手动触发
+	Start()
+	// Stop 停止调度，返回的上下文在正在执行的任务结束后完成
+	Stop() context.Context
+	// ListJobs 获取所有任务及最近一次执行情况
+	ListJobs(ctx context.Context) ([]JobStatus, error)
+	// ListRuns 分页获取任务的执行记录
+	ListRuns(ctx context.Context, name string, offset, limit int) ([]*models.JobRun, int64, error)
+	// Trigger 手动执行任务并等待完成，任务正在其他实例执行时返回 ErrJobRunning
+	Trigger(ctx context.Context, name string, actorID int64) (*models.JobRun, error)
+}
+
+// registeredJob 已注册的任务
+type registeredJob struct {
+	Job
+	entryID cron.EntryID
+}
+
+// schedulerService 基于robfig/cron和Redis锁的调度实现
+type schedulerService struct {
+	*BaseService
+	redis    *redis.Client
+	runRepo  repository.JobRunRepository
+	auditSvc AuditService
+	cron     *cron.Cron
+	enabled  bool
+	lockTTL  time.Duration
+	node     string
+	log      logger.Logger
+
+	mu   sync.RWMutex
+	jobs map[string]*registeredJob
+	// names 按注册顺序保存任务名，用于列表展示
+	names []string
+}
+
+// NewSchedulerService 创建定时任务调度服务
+func NewSchedulerService(
+	redisClient *redis.Client,
+	runRepo repository.JobRunRepository,
+	auditSvc AuditService,
+	cfg config.SchedulerConfig,
+) SchedulerService {
+	log := logger.GetLogger("scheduler")
+
+	loc, err := time.LoadLocation(cfg.Timezone)
+	if err != nil {
+		log.Warn("调度时区无效，使用服务器时区", "timezone", cfg.Timezone, "error", err)
+		loc = time.Local
+	}
+	lockTTL := time.Duration(cfg.LockTTLSeconds) * time.Second
+	if lockTTL <= 0 {
+		lockTTL = defaultSchedulerLockTTL
+	}
+
+	return &schedulerService{
+		BaseService: NewBaseService(runRepo),
+		redis:       redisClient,
+		runRepo:     runRepo,
+		auditSvc:    auditSvc,
+		cron:        cron.New(cron.WithLocation(loc)),
+		enabled:     cfg.Enabled,
+		lockTTL:     lockTTL,
+		node:        schedulerNodeName(),
+		log:         log,
+		jobs:        make(map[string]*registeredJob),
+	}
+}
+
+// schedulerNodeName 当前实例的标识，记录在执行记录中
+func schedulerNodeName() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+	return fmt.Sprintf("%s-%d", host, os.Getpid())
+}
+
+// Register 注册任务
+func (s *schedulerService) Register(job Job) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.jobs[job.Name]; ok {
+		return fmt.Errorf("%w: %s", ErrJobDuplicate, job.Name)
+	}
+
+	registered := &registeredJob{Job: job}
+	if job.Schedule != "" {
+		entryID, err := s.cron.AddFunc(job.Schedule, func() {
+			s.runScheduled(registered)
+		})
+		if err != nil {
+			return fmt.Errorf("定时任务 %s 的调度表无效: %w", job.Name, err)
+		}
+		registered.entryID = entryID
+	}
+
+	s.jobs[job.Name] = registered
+	s.names = append(s.names, job.Name)
+	return nil
+}
+
+// Start 启动调度
+func (s *schedulerService) Start() {
+	if !s.enabled {
+		s.log.Info("本实例未启用定时任务调度，任务只能手动触发")
+		return
+	}
+	s.log.Info("启动定时任务调度", "node", s.node, "jobs", len(s.names))
+	s.cron.Start()
+}
+
+// Stop 停止调度
+func (s *schedulerService) Stop() context.Context {
+	return s.cron.Stop()
+}
+
+// ListJobs 获取所有任务及最近一次执行情况
+func (s *schedulerService) ListJobs(ctx context.Context) ([]JobStatus, error) {
+	s.mu.RLock()
+	names := append([]string(nil), s.names...)
+	s.mu.RUnlock()
+
+	latest, err := s.runRepo.LatestByJobNames(ctx, names)
+	if err != nil {
+		return nil, fmt.Errorf("查询任务执行记录失败: %w", err)
+	}
+
+	result := make([]JobStatus, 0, len(names))
+	for _, name := range names {
+		job, _ := s.lookup(name)
+		status := JobStatus{
+			Name:        job.Name,
+			Description: job.Description,
+			Schedule:    job.Schedule,
+			LastRun:     latest[name],
+		}
+		if s.enabled && job.entryID != 0 {
+			if next := s.cron.Entry(job.entryID).Next; !next.IsZero() {
+				status.NextRunAt = &next
+			}
+		}
+		result = append(result, status)
+	}
+	return result, nil
+}
+
+// ListRuns 分页获取任务的执行记录
+func (s *schedulerService) ListRuns(ctx context.Context, name string, offset, limit int) ([]*models.JobRun, int64, error) {
+	if _, ok := s.lookup(name); !ok {
+		return nil, 0, ErrJobNotFound
+	}
+	runs, total, err := s.runRepo.ListByJobName(ctx, name, offset, limit)
+	if err != nil {
+		return nil, 0, fmt.Errorf("查询任务执行记录失败: %w", err)
+	}
+	return runs, total, nil
+}
+
+// Trigger 手动执行任务并等待完成，任务执行失败时同时返回执行记录和错误
+func (s *schedulerService) Trigger(ctx context.Context, name string, actorID int64) (*models.JobRun, error) {
+	job, ok := s.lookup(name)
+	if !ok {
+		return nil, ErrJobNotFound
+	}
+
+	run, err := s.execute(ctx, job, models.JobTriggerManual, actorID)
+	if run != nil {
+		s.auditSvc.Record(ctx, AuditEntry{
+			ActorID:    actorID,
+			Action:     models.AuditActionJobTrigger,
+			EntityType: models.AuditEntityJob,
+			EntityID:   name,
+			After:      map[string]interface{}{"run_id": run.ID, "status": run.Status},
+		})
+	}
+	return run, err
+}
+
+// lookup 按名称查找任务
+func (s *schedulerService) lookup(name string) (*registeredJob, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	job, ok := s.jobs[name]
+	return job, ok
+}
+
+// runScheduled 按调度表执行。各实例在同一时间点都会触发，先占用这个时间点的实例执行，
+// 其他实例跳过；任务很快结束时，时钟稍慢的实例也不会再执行一次
+func (s *schedulerService) runScheduled(job *registeredJob) {
+	ctx := context.Background()
+	slot := time.Now().Truncate(time.Minute)
+	slotKey := fmt.Sprintf("%s%s:%d", SchedulerSlotPrefix, job.Name, slot.Unix())
+
+	claimed, err := s.redis.SetNX(ctx, slotKey, s.node, schedulerSlotTTL).Result()
+	if err != nil {
+		s.log.Error(err, "占用定时任务时间点失败", "job", job.Name)
+		return
+	}
+	if !claimed {
+		s.log.Debug("定时任务已由其他实例执行", "job", job.Name)
+		return
+	}
+
+	if _, err := s.execute(ctx, job, models.JobTriggerSchedule, 0); err != nil {
+		if errors.Is(err, ErrJobRunning) {
+			s.log.Warn("上一次执行尚未结束，跳过本次调度", "job", job.Name)
+		}
+	}
+}
+
+// execute 获取任务锁后执行任务，并记录执行状态、耗时和错误
+func (s *schedulerService) execute(ctx context.Context, job *registeredJob, trigger string, actorID int64) (*models.JobRun, error) {
+	token, err := newTokenID()
+	if err != nil {
+		return nil, err
+	}
+	lockKey := SchedulerLockPrefix + job.Name
+	acquired, err := s.redis.SetNX(ctx, lockKey, token, s.lockTTL).Result()
+	if err != nil {
+		return nil, fmt.Errorf("获取任务锁失败: %w", err)
+	}
+	if !acquired {
+		return nil, ErrJobRunning
+	}
+	// 任务执行期间定期续期，实例崩溃时锁在过期后自动释放
+	stopRenew := s.keepLock(lockKey, token)
+	defer func() {
+		stopRenew()
+		if err := releaseLockScript.Run(context.Background(), s.redis, []string{lockKey}, token).Err(); err != nil {
+			s.log.Error(err, "释放任务锁失败", "job", job.Name)
+		}
+	}()
+
+	run := &models.JobRun{
+		JobName:     job.Name,
+		Trigger:     trigger,
+		TriggeredBy: actorID,
+		Node:        s.node,
+		Status:      models.JobRunRunning,
+		StartedAt:   time.Now(),
+	}
+	// 执行记录保存失败不影响任务执行
+	if err := s.runRepo.Create(ctx, run); err != nil {
+		s.log.Error(err, "保存任务执行记录失败", "job", job.Name)
+	}
+
+	s.log.Info("开始执行定时任务", "job", job.Name, "trigger", trigger)
+	jobErr := s.safeRun(ctx, job)
+
+	finishedAt := time.Now()
+	run.FinishedAt = &finishedAt
+	run.DurationMs = finishedAt.Sub(run.StartedAt).Milliseconds()
+	run.Status = models.JobRunSucceeded
+	if jobErr != nil {
+		run.Status = models.JobRunFailed
+		run.Error = jobErr.Error()
+		s.log.Error(jobErr, "定时任务执行失败", "job", job.Name, "duration_ms", run.DurationMs)
+	} else {
+		s.log.Info("定时任务执行完成", "job", job.Name, "duration_ms", run.DurationMs)
+	}
+	if run.ID != 0 {
+		if err := s.runRepo.Finish(context.Background(), run); err != nil {
+			s.log.Error(err, "保存任务执行结果失败", "job", job.Name)
+		}
+	}
+	return run, jobErr
+}
+
+// safeRun 执行任务，panic 作为执行失败处理，不影响其他任务
+func (s *schedulerService) safeRun(ctx context.Context, job *registeredJob) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("定时任务 panic: %v", r)
+		}
+	}()
+	return job.Run(ctx)
+}
+
+// keepLock 定期续期任务锁，返回停止续期的函数
+func (s *schedulerService) keepLock(lockKey, token string) func() {
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(s.lockTTL / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				err := renewLockScript.Run(context.Background(), s.redis, []string{lockKey}, token, s.lockTTL.Milliseconds()).Err()
+				if err != nil {
+					s.log.Error(err, "续期任务锁失败", "lock", lockKey)
+				}
+			}
+		}
+	}()
+	return func() { close(done) }
+}
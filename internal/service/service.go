@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"memoir-api/internal/email"
 	"memoir-api/internal/repository"
 
 	"gorm.io/gorm"
@@ -12,29 +13,64 @@ type Service interface {
 	Repository() repository.Repository
 }
 
+var (
+	ErrDeadLetterNotFound = email.ErrDeadLetterNotFound
+	ErrTemplateNotFound   = email.ErrTemplateNotFound
+)
+
 // EmailService 邮件服务接口
 type EmailService interface {
 	// 发送验证邮件
-	SendVerificationEmail(ctx context.Context, toAddress, username, verificationCode string) error
+	SendVerificationEmail(ctx context.Context, to email.Recipient, verificationCode string) error
 
 	// 发送密码重置邮件
-	SendPasswordResetEmail(ctx context.Context, toAddress, resetToken string) error
+	SendPasswordResetEmail(ctx context.Context, to email.Recipient, resetToken string) error
 
 	// 发送通知邮件
-	SendNotificationEmail(ctx context.Context, toAddress, username, message string) error
+	SendNotificationEmail(ctx context.Context, to email.Recipient, message string) error
 
 	// 发送欢迎邮件
-	SendWelcomeEmail(ctx context.Context, toAddress, username string) error
+	SendWelcomeEmail(ctx context.Context, to email.Recipient) error
 
 	// 发送纪念日邮件
-	SendAnniversaryEmail(ctx context.Context, toAddress, username, partnerName string, days int, date string) error
+	SendAnniversaryEmail(ctx context.Context, to email.Recipient, partnerName string, days int, date string) error
 
 	// 发送节日邮件
-	SendFestivalEmail(ctx context.Context, toAddress, username, partnerName, festivalName string) error
+	SendFestivalEmail(ctx context.Context, to email.Recipient, partnerName, festivalName string) error
+
+	// 发送自定义纪念日提醒邮件，daysUntil 为0表示纪念日就是今天
+	SendCustomAnniversaryEmail(ctx context.Context, to email.Recipient, partnerName, title, date string, daysUntil int) error
+
+	// 发送自定义提醒邮件，daysUntil 为0表示就是今天
+	SendReminderEmail(ctx context.Context, to email.Recipient, title, note, date string, daysUntil int) error
+
+	// 发送情侣配对邀请邮件
+	SendCoupleInviteEmail(ctx context.Context, to email.Recipient, inviterName, inviteCode string, expireHours int) error
+
+	// 用示例数据渲染模板，用于开发环境预览
+	RenderPreview(emailType email.EmailType, locale string) (*email.RenderedEmail, error)
 
-	// 处理邮件队列
+	// 已加载模板的邮件类型
+	TemplateTypes() []email.EmailType
+
+	// 处理邮件队列，阻塞到 ctx 取消
 	ProcessEmailQueue(ctx context.Context)
 
+	// 获取邮件队列各部分的任务数
+	QueueStats(ctx context.Context) (*email.QueueStats, error)
+
+	// 分页获取死信
+	ListDeadLetters(ctx context.Context, offset, limit int) ([]email.EmailTask, int64, error)
+
+	// 把死信重新放回待发送队列
+	RequeueDeadLetter(ctx context.Context, id string) error
+
+	// 删除一条死信
+	DeleteDeadLetter(ctx context.Context, id string) error
+
+	// 清空死信队列
+	PurgeDeadLetters(ctx context.Context) (int64, error)
+
 	// 存储验证码到Redis
 	StoreVerificationCode(ctx context.Context, email, code string) error
 
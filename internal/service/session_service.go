@@ -0,0 +1,284 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+const (
+	// SessionKeyPrefix 会话数据键前缀
+	SessionKeyPrefix = "auth:session:"
+	// UserSessionsKeyPrefix 用户会话集合键前缀
+	UserSessionsKeyPrefix = "auth:user_sessions:"
+	// sessionTouchInterval 最近活跃时间的刷新间隔，避免每个请求都写Redis
+	sessionTouchInterval = time.Minute
+)
+
+var (
+	ErrSessionNotFound    = errors.New("会话不存在或已失效")
+	ErrRefreshTokenReused = errors.New("刷新令牌已被使用，会话已被注销")
+)
+
+// Session 服务端登录会话，一个会话对应一个刷新令牌家族
+type Session struct {
+	ID         string    `json:"id"`
+	UserID     int64     `json:"user_id,string"`
+	AccessJTI  string    `json:"access_jti"`
+	RefreshJTI string    `json:"refresh_jti"`
+	DeviceName string    `json:"device_name"`
+	IP         string    `json:"ip"`
+	UserAgent  string    `json:"user_agent"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
+
+// SessionMeta 登录时采集的设备信息
+type SessionMeta struct {
+	DeviceName string
+	IP         string
+	UserAgent  string
+}
+
+// SessionService 会话注册表服务接口
+type SessionService interface {
+	// Create 登记新会话
+	Create(ctx context.Context, session *Session) error
+	// Get 获取会话
+	Get(ctx context.Context, sessionID string) (*Session, error)
+	// Rotate 轮换会话的令牌标识，presentedRefreshJTI 与当前记录不一致时视为刷新令牌重用
+	Rotate(ctx context.Context, sessionID, presentedRefreshJTI, accessJTI, refreshJTI string, expiresAt time.Time) error
+	// Touch 更新会话的最近活跃时间
+	Touch(ctx context.Context, session *Session) error
+	// Revoke 注销用户的单个会话
+	Revoke(ctx context.Context, userID int64, sessionID string) error
+	// RevokeAll 注销用户的全部会话
+	RevokeAll(ctx context.Context, userID int64) error
+	// ListByUser 列出用户的有效会话
+	ListByUser(ctx context.Context, userID int64) ([]*Session, error)
+}
+
+// sessionService 基于Redis的会话注册表实现
+type sessionService struct {
+	*BaseService
+	redis *redis.Client
+}
+
+// NewSessionService 创建会话服务
+func NewSessionService(redisClient *redis.Client) SessionService {
+	return &sessionService{
+		BaseService: NewBaseService(nil),
+		redis:       redisClient,
+	}
+}
+
+func sessionKey(sessionID string) string {
+	return SessionKeyPrefix + sessionID
+}
+
+func userSessionsKey(userID int64) string {
+	return fmt.Sprintf("%s%d", UserSessionsKeyPrefix, userID)
+}
+
+// Create 登记新会话
+func (s *sessionService) Create(ctx context.Context, session *Session) error {
+	data, err := json.Marshal(session)
+	if err != nil {
+		return fmt.Errorf("序列化会话失败: %w", err)
+	}
+
+	ttl := time.Until(session.ExpiresAt)
+	pipe := s.redis.TxPipeline()
+	pipe.Set(ctx, sessionKey(session.ID), data, ttl)
+	pipe.SAdd(ctx, userSessionsKey(session.UserID), session.ID)
+	pipe.Expire(ctx, userSessionsKey(session.UserID), ttl)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("保存会话失败: %w", err)
+	}
+	return nil
+}
+
+// Get 获取会话
+func (s *sessionService) Get(ctx context.Context, sessionID string) (*Session, error) {
+	data, err := s.redis.Get(ctx, sessionKey(sessionID)).Bytes()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, ErrSessionNotFound
+		}
+		return nil, fmt.Errorf("获取会话失败: %w", err)
+	}
+
+	var session Session
+	if err := json.Unmarshal(data, &session); err != nil {
+		return nil, fmt.Errorf("解析会话失败: %w", err)
+	}
+	return &session, nil
+}
+
+// Rotate 轮换会话的令牌标识
+func (s *sessionService) Rotate(ctx context.Context, sessionID, presentedRefreshJTI, accessJTI, refreshJTI string, expiresAt time.Time) error {
+	key := sessionKey(sessionID)
+	err := s.redis.Watch(ctx, func(tx *redis.Tx) error {
+		data, err := tx.Get(ctx, key).Bytes()
+		if err != nil {
+			if errors.Is(err, redis.Nil) {
+				return ErrSessionNotFound
+			}
+			return err
+		}
+
+		var session Session
+		if err := json.Unmarshal(data, &session); err != nil {
+			return err
+		}
+
+		// 已被轮换过的刷新令牌再次出现，说明令牌可能被盗用
+		if session.RefreshJTI != presentedRefreshJTI {
+			return ErrRefreshTokenReused
+		}
+
+		session.AccessJTI = accessJTI
+		session.RefreshJTI = refreshJTI
+		session.LastSeenAt = time.Now()
+		session.ExpiresAt = expiresAt
+		updated, err := json.Marshal(&session)
+		if err != nil {
+			return err
+		}
+
+		ttl := time.Until(expiresAt)
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.Set(ctx, key, updated, ttl)
+			pipe.Expire(ctx, userSessionsKey(session.UserID), ttl)
+			return nil
+		})
+		return err
+	}, key)
+
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, ErrSessionNotFound), errors.Is(err, ErrRefreshTokenReused):
+		return err
+	case errors.Is(err, redis.TxFailedErr):
+		// 并发刷新同一个令牌，只有一个请求能成功，其余按重用处理
+		return ErrRefreshTokenReused
+	default:
+		return fmt.Errorf("轮换会话失败: %w", err)
+	}
+}
+
+// Touch 更新会话的最近活跃时间，距上次更新不足 sessionTouchInterval 时跳过
+func (s *sessionService) Touch(ctx context.Context, session *Session) error {
+	now := time.Now()
+	if now.Sub(session.LastSeenAt) < sessionTouchInterval {
+		return nil
+	}
+
+	// 在 WATCH 事务中重新读取会话，只改最近活跃时间。调用方持有的会话可能已经过时，
+	// 直接写回会把并发轮换后的令牌标识改回旧值
+	key := sessionKey(session.ID)
+	err := s.redis.Watch(ctx, func(tx *redis.Tx) error {
+		data, err := tx.Get(ctx, key).Bytes()
+		if err != nil {
+			// 会话已被注销或过期，不再复活
+			if errors.Is(err, redis.Nil) {
+				return nil
+			}
+			return err
+		}
+
+		var current Session
+		if err := json.Unmarshal(data, &current); err != nil {
+			return err
+		}
+		current.LastSeenAt = now
+		updated, err := json.Marshal(&current)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.Set(ctx, key, updated, redis.KeepTTL)
+			return nil
+		})
+		return err
+	}, key)
+
+	switch {
+	case err == nil:
+		session.LastSeenAt = now
+		return nil
+	case errors.Is(err, redis.TxFailedErr):
+		// 会话在读写之间被修改（轮换或注销），这次活跃时间不写入，下次请求再更新
+		return nil
+	default:
+		return fmt.Errorf("更新会话活跃时间失败: %w", err)
+	}
+}
+
+// Revoke 注销用户的单个会话
+func (s *sessionService) Revoke(ctx context.Context, userID int64, sessionID string) error {
+	isMember, err := s.redis.SIsMember(ctx, userSessionsKey(userID), sessionID).Result()
+	if err != nil {
+		return fmt.Errorf("查询会话失败: %w", err)
+	}
+	if !isMember {
+		return ErrSessionNotFound
+	}
+
+	pipe := s.redis.TxPipeline()
+	pipe.Del(ctx, sessionKey(sessionID))
+	pipe.SRem(ctx, userSessionsKey(userID), sessionID)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("注销会话失败: %w", err)
+	}
+	return nil
+}
+
+// RevokeAll 注销用户的全部会话
+func (s *sessionService) RevokeAll(ctx context.Context, userID int64) error {
+	sessionIDs, err := s.redis.SMembers(ctx, userSessionsKey(userID)).Result()
+	if err != nil {
+		return fmt.Errorf("查询会话失败: %w", err)
+	}
+
+	keys := make([]string, 0, len(sessionIDs)+1)
+	for _, id := range sessionIDs {
+		keys = append(keys, sessionKey(id))
+	}
+	keys = append(keys, userSessionsKey(userID))
+
+	if err := s.redis.Del(ctx, keys...).Err(); err != nil {
+		return fmt.Errorf("注销全部会话失败: %w", err)
+	}
+	return nil
+}
+
+// ListByUser 列出用户的有效会话
+func (s *sessionService) ListByUser(ctx context.Context, userID int64) ([]*Session, error) {
+	sessionIDs, err := s.redis.SMembers(ctx, userSessionsKey(userID)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("查询会话失败: %w", err)
+	}
+
+	sessions := make([]*Session, 0, len(sessionIDs))
+	for _, id := range sessionIDs {
+		session, err := s.Get(ctx, id)
+		if err != nil {
+			if errors.Is(err, ErrSessionNotFound) {
+				// 会话已过期，顺便清理集合中的残留
+				s.redis.SRem(ctx, userSessionsKey(userID), id)
+				continue
+			}
+			return nil, err
+		}
+		sessions = append(sessions, session)
+	}
+	return sessions, nil
+}
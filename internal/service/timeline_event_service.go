@@ -11,17 +11,18 @@ import (
 )
 
 var (
-	ErrTimelineEventNotFound = errors.New("时间轴事件不存在")
+	ErrTimelineEventNotFound = resourceNotFound("时间轴事件不存在")
 )
 
 // TimelineEventService 时间轴事件服务接口
 type TimelineEventService interface {
 	Service
 	CreateTimelineEvent(ctx context.Context, createReq *dto.CreateTimelineEventRequest) (bool, error)
-	GetTimelineEventByID(ctx context.Context, id int64) (*models.TimelineEvent, error)
+	// GetTimelineEventByID 获取情侣的时间轴事件，不属于该情侣时返回 ErrTimelineEventNotFound
+	GetTimelineEventByID(ctx context.Context, coupleID, id int64) (*models.TimelineEvent, error)
 	ListTimelineEventsByCoupleID(ctx context.Context, coupleID int64, offset, limit int) ([]*models.TimelineEvent, int64, error)
-	UpdateTimelineEvent(ctx context.Context, event *models.TimelineEvent, locationIDs, photoVideoIDs []int64) (*models.TimelineEvent, error)
-	DeleteTimelineEvent(ctx context.Context, id int64) error
+	UpdateTimelineEvent(ctx context.Context, coupleID int64, event *models.TimelineEvent, locationIDs, photoVideoIDs []int64) (*models.TimelineEvent, error)
+	DeleteTimelineEvent(ctx context.Context, coupleID, id int64) error
 	CountByCoupleID(ctx context.Context, coupleID int64) (int64, error)
 }
 
@@ -33,6 +34,7 @@ type timelineEventService struct {
 	photoVideoRepo      repository.PhotoVideoRepository
 	eventLocationRepo   repository.TimelineEventLocationRepository
 	eventPhotoVideoRepo repository.TimelineEventPhotoVideoRepository
+	auditSvc            AuditService
 }
 
 func (s *timelineEventService) CountByCoupleID(ctx context.Context, coupleID int64) (int64, error) {
@@ -46,6 +48,7 @@ func NewTimelineEventService(
 	photoVideoRepo repository.PhotoVideoRepository,
 	eventLocationRepo repository.TimelineEventLocationRepository,
 	eventPhotoVideoRepo repository.TimelineEventPhotoVideoRepository,
+	auditSvc AuditService,
 ) TimelineEventService {
 	return &timelineEventService{
 		BaseService:         NewBaseService(timelineEventRepo),
@@ -54,6 +57,7 @@ func NewTimelineEventService(
 		photoVideoRepo:      photoVideoRepo,
 		eventLocationRepo:   eventLocationRepo,
 		eventPhotoVideoRepo: eventPhotoVideoRepo,
+		auditSvc:            auditSvc,
 	}
 }
 
@@ -63,6 +67,9 @@ func (s *timelineEventService) CreateTimelineEvent(ctx context.Context, createRe
 	if err != nil {
 		return false, fmt.Errorf("换成实体对象失败：%w", err)
 	}
+	if err := s.checkAssociations(ctx, model.CoupleID, createReq.LocationIDs, createReq.PhotoVideoIDs); err != nil {
+		return false, err
+	}
 	if err := s.timelineEventRepo.Create(ctx, model); err != nil {
 		return false, fmt.Errorf("创建时间轴事件失败: %w", err)
 	}
@@ -75,12 +82,19 @@ func (s *timelineEventService) CreateTimelineEvent(ctx context.Context, createRe
 		return false, fmt.Errorf("关联照片/视频失败: %w", err)
 	}
 
+	s.auditSvc.Record(ctx, AuditEntry{
+		CoupleID:   model.CoupleID,
+		Action:     models.AuditActionCreate,
+		EntityType: models.AuditEntityTimelineEvent,
+		EntityID:   AuditEntityID(model.ID),
+		After:      timelineEventAuditState(model, createReq.LocationIDs, createReq.PhotoVideoIDs),
+	})
 	return true, nil
 }
 
-// GetTimelineEventByID 通过ID获取时间轴事件
-func (s *timelineEventService) GetTimelineEventByID(ctx context.Context, id int64) (*models.TimelineEvent, error) {
-	event, err := s.timelineEventRepo.FindByID(ctx, id)
+// GetTimelineEventByID 通过ID获取情侣的时间轴事件
+func (s *timelineEventService) GetTimelineEventByID(ctx context.Context, coupleID, id int64) (*models.TimelineEvent, error) {
+	event, err := s.timelineEventRepo.FindByIDInCouple(ctx, coupleID, id)
 	if err != nil {
 		if errors.Is(err, repository.ErrTimelineEventNotFound) {
 			return nil, ErrTimelineEventNotFound
@@ -101,7 +115,17 @@ func (s *timelineEventService) ListTimelineEventsByCoupleID(ctx context.Context,
 }
 
 // UpdateTimelineEvent 更新时间轴事件
-func (s *timelineEventService) UpdateTimelineEvent(ctx context.Context, event *models.TimelineEvent, locationIDs, photoVideoIDs []int64) (*models.TimelineEvent, error) {
+func (s *timelineEventService) UpdateTimelineEvent(ctx context.Context, coupleID int64, event *models.TimelineEvent, locationIDs, photoVideoIDs []int64) (*models.TimelineEvent, error) {
+	before, err := s.GetTimelineEventByID(ctx, coupleID, event.ID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkAssociations(ctx, coupleID, locationIDs, photoVideoIDs); err != nil {
+		return nil, err
+	}
+	// 事件不能转移到其他情侣
+	event.CoupleID = before.CoupleID
+
 	if err := s.timelineEventRepo.Update(ctx, event); err != nil {
 		return nil, fmt.Errorf("更新时间轴事件失败: %w", err)
 	}
@@ -126,11 +150,29 @@ func (s *timelineEventService) UpdateTimelineEvent(ctx context.Context, event *m
 		}
 	}
 
-	return s.GetTimelineEventByID(ctx, event.ID)
+	after, err := s.GetTimelineEventByID(ctx, coupleID, event.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.auditSvc.Record(ctx, AuditEntry{
+		CoupleID:   before.CoupleID,
+		Action:     models.AuditActionUpdate,
+		EntityType: models.AuditEntityTimelineEvent,
+		EntityID:   AuditEntityID(event.ID),
+		Before:     timelineEventAuditState(before, nil, nil),
+		After:      timelineEventAuditState(after, nil, nil),
+	})
+	return after, nil
 }
 
 // DeleteTimelineEvent 删除时间轴事件
-func (s *timelineEventService) DeleteTimelineEvent(ctx context.Context, id int64) error {
+func (s *timelineEventService) DeleteTimelineEvent(ctx context.Context, coupleID, id int64) error {
+	before, err := s.GetTimelineEventByID(ctx, coupleID, id)
+	if err != nil {
+		return err
+	}
+
 	if err := s.timelineEventRepo.Delete(ctx, id); err != nil {
 		return fmt.Errorf("删除时间轴事件失败: %w", err)
 	}
@@ -143,11 +185,43 @@ func (s *timelineEventService) DeleteTimelineEvent(ctx context.Context, id int64
 		return fmt.Errorf("删除照片/视频关联失败: %w", err)
 	}
 
+	s.auditSvc.Record(ctx, AuditEntry{
+		CoupleID:   before.CoupleID,
+		Action:     models.AuditActionDelete,
+		EntityType: models.AuditEntityTimelineEvent,
+		EntityID:   AuditEntityID(id),
+		Before:     timelineEventAuditState(before, nil, nil),
+	})
 	return nil
 }
 
 // 私有辅助方法
 
+// timelineEventAuditState 审计日志中记录的事件状态，关联的地点和照片/视频只记录ID。
+// 传入的ID列表为空时使用事件上已加载的关联
+func timelineEventAuditState(event *models.TimelineEvent, locationIDs, photoVideoIDs []int64) map[string]interface{} {
+	if locationIDs == nil {
+		for _, location := range event.Locations {
+			locationIDs = append(locationIDs, location.ID)
+		}
+	}
+	if photoVideoIDs == nil {
+		for _, photoVideo := range event.PhotosVideos {
+			photoVideoIDs = append(photoVideoIDs, photoVideo.ID)
+		}
+	}
+
+	return map[string]interface{}{
+		"title":           event.Title,
+		"content":         event.Content,
+		"cover_url":       event.CoverURL,
+		"start_date":      event.StartDate,
+		"end_date":        event.EndDate,
+		"location_ids":    locationIDs,
+		"photo_video_ids": photoVideoIDs,
+	}
+}
+
 func (s *timelineEventService) loadTimelineEventAssociations(ctx context.Context, event *models.TimelineEvent) error {
 	eventLocations, err := s.eventLocationRepo.FindByEventID(ctx, event.ID)
 	if err != nil {
@@ -160,7 +234,7 @@ func (s *timelineEventService) loadTimelineEventAssociations(ctx context.Context
 	}
 
 	if len(locationIDs) > 0 {
-		locations, err := s.locationRepo.FindByIDs(ctx, locationIDs)
+		locations, err := s.locationRepo.FindByIDsInCouple(ctx, event.CoupleID, locationIDs)
 		if err != nil {
 			return err
 		}
@@ -178,7 +252,7 @@ func (s *timelineEventService) loadTimelineEventAssociations(ctx context.Context
 	}
 
 	if len(photoVideoIDs) > 0 {
-		photosVideos, err := s.photoVideoRepo.FindByIDs(ctx, photoVideoIDs)
+		photosVideos, err := s.photoVideoRepo.FindByIDsInCouple(ctx, event.CoupleID, photoVideoIDs)
 		if err != nil {
 			return err
 		}
@@ -188,21 +262,33 @@ func (s *timelineEventService) loadTimelineEventAssociations(ctx context.Context
 	return nil
 }
 
-func (s *timelineEventService) associateLocations(ctx context.Context, eventID int64, locationIDs []int64) error {
-	if len(locationIDs) == 0 {
-		return nil
-	}
-
-	locations, err := s.locationRepo.FindByIDs(ctx, locationIDs)
-	if err != nil {
-		return err
+// checkAssociations 校验要关联的地点和照片/视频都属于该情侣，必须在写入任何数据之前调用
+func (s *timelineEventService) checkAssociations(ctx context.Context, coupleID int64, locationIDs, photoVideoIDs []int64) error {
+	if ids := uniqueIDs(locationIDs); len(ids) > 0 {
+		locations, err := s.locationRepo.FindByIDsInCouple(ctx, coupleID, ids)
+		if err != nil {
+			return fmt.Errorf("查询地点失败: %w", err)
+		}
+		if len(locations) != len(ids) {
+			return ErrLocationNotFound
+		}
 	}
 
-	if len(locations) != len(locationIDs) {
-		return errors.New("some locations do not exist")
+	if ids := uniqueIDs(photoVideoIDs); len(ids) > 0 {
+		photosVideos, err := s.photoVideoRepo.FindByIDsInCouple(ctx, coupleID, ids)
+		if err != nil {
+			return fmt.Errorf("查询照片/视频失败: %w", err)
+		}
+		if len(photosVideos) != len(ids) {
+			return ErrPhotoVideoNotFound
+		}
 	}
+	return nil
+}
 
-	for _, locationID := range locationIDs {
+// associateLocations 关联地点，调用前需要先通过 checkAssociations 校验归属
+func (s *timelineEventService) associateLocations(ctx context.Context, eventID int64, locationIDs []int64) error {
+	for _, locationID := range uniqueIDs(locationIDs) {
 		eventLocation := &models.TimelineEventLocation{
 			TimelineEventID: eventID,
 			LocationID:      locationID,
@@ -215,21 +301,9 @@ func (s *timelineEventService) associateLocations(ctx context.Context, eventID i
 	return nil
 }
 
+// associatePhotosVideos 关联照片/视频，调用前需要先通过 checkAssociations 校验归属
 func (s *timelineEventService) associatePhotosVideos(ctx context.Context, eventID int64, photoVideoIDs []int64) error {
-	if len(photoVideoIDs) == 0 {
-		return nil
-	}
-
-	photosVideos, err := s.photoVideoRepo.FindByIDs(ctx, photoVideoIDs)
-	if err != nil {
-		return err
-	}
-
-	if len(photosVideos) != len(photoVideoIDs) {
-		return errors.New("some photos/videos do not exist")
-	}
-
-	for _, photoVideoID := range photoVideoIDs {
+	for _, photoVideoID := range uniqueIDs(photoVideoIDs) {
 		eventPhotoVideo := &models.TimelineEventPhotoVideo{
 			TimelineEventID: eventID,
 			PhotoVideoID:    photoVideoID,
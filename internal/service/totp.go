@@ -0,0 +1,120 @@
+package service
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// TOTP 参数（RFC 6238 默认值，兼容主流验证器App）
+const (
+	totpIssuer      = "Memoir"
+	totpPeriod      = 30 // 时间步长(秒)
+	totpDigits      = 6
+	totpSkew        = 1 // 允许前后各偏移一个时间步，容忍客户端时钟误差
+	totpSecretBytes = 20
+
+	recoveryCodeCount = 10
+)
+
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// generateTOTPSecret 生成Base32编码的随机TOTP密钥
+func generateTOTPSecret() (string, error) {
+	b := make([]byte, totpSecretBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("生成TOTP密钥失败: %w", err)
+	}
+	return totpEncoding.EncodeToString(b), nil
+}
+
+// totpURI 生成验证器App可识别的 otpauth URI
+func totpURI(account, secret string) string {
+	label := url.PathEscape(totpIssuer + ":" + account)
+	params := url.Values{}
+	params.Set("secret", secret)
+	params.Set("issuer", totpIssuer)
+	params.Set("algorithm", "SHA1")
+	params.Set("digits", fmt.Sprintf("%d", totpDigits))
+	params.Set("period", fmt.Sprintf("%d", totpPeriod))
+	return fmt.Sprintf("otpauth://totp/%s?%s", label, params.Encode())
+}
+
+// hotp 按 RFC 4226 计算指定计数器的一次性密码
+func hotp(key []byte, counter uint64) string {
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], counter)
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+
+	// 动态截断
+	offset := sum[len(sum)-1] & 0x0f
+	code := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	mod := uint32(1)
+	for i := 0; i < totpDigits; i++ {
+		mod *= 10
+	}
+	return fmt.Sprintf("%0*d", totpDigits, code%mod)
+}
+
+// validateTOTP 校验验证码，成功时返回匹配的时间步；lastStep 及之前的时间步视为已使用
+func validateTOTP(secret, code string, now time.Time, lastStep int64) (int64, bool) {
+	code = strings.TrimSpace(code)
+	if len(code) != totpDigits {
+		return 0, false
+	}
+
+	key, err := totpEncoding.DecodeString(strings.ToUpper(secret))
+	if err != nil {
+		return 0, false
+	}
+
+	current := now.Unix() / totpPeriod
+	for offset := -totpSkew; offset <= totpSkew; offset++ {
+		step := current + int64(offset)
+		if step <= lastStep {
+			continue
+		}
+		if subtle.ConstantTimeCompare([]byte(hotp(key, uint64(step))), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+	return 0, false
+}
+
+// generateRecoveryCodes 生成一组恢复码，格式为 xxxxx-xxxxx
+func generateRecoveryCodes() ([]string, error) {
+	const charset = "abcdefghjkmnpqrstuvwxyz23456789"
+
+	codes := make([]string, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		b := make([]byte, 10)
+		if _, err := rand.Read(b); err != nil {
+			return nil, fmt.Errorf("生成恢复码失败: %w", err)
+		}
+		for j := range b {
+			b[j] = charset[int(b[j])%len(charset)]
+		}
+		codes = append(codes, string(b[:5])+"-"+string(b[5:]))
+	}
+	return codes, nil
+}
+
+// hashRecoveryCode 计算恢复码哈希，忽略大小写和分隔符
+func hashRecoveryCode(code string) string {
+	normalized := strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
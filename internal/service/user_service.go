@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"memoir-api/internal/api/dto"
+	"memoir-api/internal/logger"
 	"memoir-api/internal/models"
 	"memoir-api/internal/repository"
 
@@ -23,8 +24,27 @@ var (
 	ErrInvalidVerificationCode = errors.New("验证码无效")
 	ErrInvalidResetToken       = errors.New("重置令牌无效")
 	ErrEmailNotFound           = errors.New("邮箱不存在")
+	ErrMFAAlreadyEnabled       = errors.New("二次验证已开启")
+	ErrMFANotEnabled           = errors.New("二次验证未开启")
+	ErrMFANotEnrolled          = errors.New("请先生成二次验证密钥")
+	ErrInvalidMFACode          = errors.New("二次验证码无效")
+	ErrUserDisabled            = errors.New("账号已被停用")
+	ErrEmailChangeNotAllowed   = errors.New("请通过修改邮箱接口更换邮箱")
+	ErrEmailUnchanged          = errors.New("新邮箱与当前邮箱相同")
 )
 
+// MFAEnrollment 二次验证绑定信息
+type MFAEnrollment struct {
+	Secret     string `json:"secret"`
+	OTPAuthURI string `json:"otpauth_uri"`
+}
+
+// MFAStatus 二次验证状态
+type MFAStatus struct {
+	Enabled                bool  `json:"enabled"`
+	RecoveryCodesRemaining int64 `json:"recovery_codes_remaining"`
+}
+
 // UserService 用户服务接口
 type UserService interface {
 	Service
@@ -40,25 +60,66 @@ type UserService interface {
 	GetCoupleID(ctx context.Context, userID int64) (int64, error)
 	VerifyEmail(ctx context.Context, email, code string) error
 	ForgotPassword(ctx context.Context, email string) (string, error)
+	ResetPassword(ctx context.Context, email, token, newPassword string) error
+	// RequestEmailChange 校验当前密码后向新邮箱发送验证码
+	RequestEmailChange(ctx context.Context, userID int64, newEmail, password string) error
+	// ConfirmEmailChange 使用新邮箱收到的验证码完成修改
+	ConfirmEmailChange(ctx context.Context, userID int64, newEmail, code string) error
+	// GetMFAStatus 获取二次验证状态
+	GetMFAStatus(ctx context.Context, userID int64) (*MFAStatus, error)
+	// EnrollTOTP 生成TOTP密钥，确认前不会生效
+	EnrollTOTP(ctx context.Context, userID int64) (*MFAEnrollment, error)
+	// ConfirmTOTP 用第一个验证码确认绑定，返回明文恢复码（仅此一次）
+	ConfirmTOTP(ctx context.Context, userID int64, code string) ([]string, error)
+	// DisableTOTP 关闭二次验证，需要提供有效的验证码或恢复码
+	DisableTOTP(ctx context.Context, userID int64, code string) error
+	// VerifyMFACode 校验TOTP验证码或恢复码
+	VerifyMFACode(ctx context.Context, userID int64, code string) error
 	GenerateVerificationCode() string
 	ResendVerificationCode(ctx context.Context, email string) (string, error)
+	// ListUsers 分页获取全部用户（管理员）
+	ListUsers(ctx context.Context, offset, limit int) ([]*models.User, int64, error)
+	// DisableUser 停用账号，并注销全部会话和访问令牌（管理员）
+	DisableUser(ctx context.Context, userID int64) error
+	// EnableUser 恢复启用账号（管理员）
+	EnableUser(ctx context.Context, userID int64) error
 }
 
 // userService 用户服务实现
 type userService struct {
 	*BaseService
-	userRepo   repository.UserRepository
-	coupleRepo repository.CoupleRepository
-	emailSvc   EmailService // 邮件服务依赖
+	userRepo     repository.UserRepository
+	coupleRepo   repository.CoupleRepository
+	emailSvc     EmailService   // 邮件服务依赖
+	sessionSvc   SessionService // 会话注册表，用于重置密码后注销已登录设备
+	recoveryRepo repository.MFARecoveryCodeRepository
+	tokenRepo    repository.PersonalAccessTokenRepository // 停用账号时撤销个人访问令牌
+	auditSvc     AuditService
+	// requireVerifiedEmail 为true时，注册时的配对令牌在邮箱验证通过后才生效
+	requireVerifiedEmail bool
 }
 
 // NewUserService 创建用户服务
-func NewUserService(userRepo repository.UserRepository, coupleRepo repository.CoupleRepository, emailSvc EmailService) UserService {
+func NewUserService(
+	userRepo repository.UserRepository,
+	coupleRepo repository.CoupleRepository,
+	emailSvc EmailService,
+	sessionSvc SessionService,
+	recoveryRepo repository.MFARecoveryCodeRepository,
+	tokenRepo repository.PersonalAccessTokenRepository,
+	auditSvc AuditService,
+	requireVerifiedEmail bool,
+) UserService {
 	return &userService{
-		BaseService: NewBaseService(userRepo),
-		userRepo:    userRepo,
-		coupleRepo:  coupleRepo,
-		emailSvc:    emailSvc,
+		BaseService:          NewBaseService(userRepo),
+		userRepo:             userRepo,
+		coupleRepo:           coupleRepo,
+		emailSvc:             emailSvc,
+		sessionSvc:           sessionSvc,
+		recoveryRepo:         recoveryRepo,
+		tokenRepo:            tokenRepo,
+		auditSvc:             auditSvc,
+		requireVerifiedEmail: requireVerifiedEmail,
 	}
 }
 
@@ -86,28 +147,11 @@ func (s *userService) Register(ctx context.Context, username, email, password, p
 		return nil, fmt.Errorf("密码哈希失败: %w", err)
 	}
 
-	// 如果pair_token不为空，查询是否有情侣记录，如果有的话返回情侣主键id；没用的创建记录然后返回
-	var coupleID int64
+	// 配对令牌已被使用时先检查能否加入，避免注册后才发现情侣关系已满
 	if pairToken != "" {
-		// 查找是否存在使用此配对令牌的情侣关系
-		couple, err := s.coupleRepo.GetByPairToken(ctx, pairToken)
-		if err != nil {
-			if !errors.Is(err, repository.ErrCoupleNotFound) {
-				return nil, fmt.Errorf("查询情侣记录时发生错误: %w", err)
-			}
-		}
-		// 如果不存在,创建新的情侣关系
-		if couple == nil {
-			couple = &models.Couple{
-				PairToken:             pairToken,
-				AutoGenerateVideo:     true, // 默认开启
-				ReminderNotifications: true, // 默认开启
-			}
-			if err := s.coupleRepo.Create(ctx, couple); err != nil {
-				return nil, fmt.Errorf("创建情侣关系失败: %w", err)
-			}
+		if err := s.checkPairToken(ctx, pairToken); err != nil {
+			return nil, err
 		}
-		coupleID = couple.ID
 	}
 
 	// 创建用户
@@ -115,17 +159,35 @@ func (s *userService) Register(ctx context.Context, username, email, password, p
 		Username:     username,
 		Email:        email,
 		PasswordHash: string(hashedPassword),
-		CoupleID:     coupleID,
 		DarkMode:     false, // 默认不开启
 	}
+	// 要求验证邮箱时先记下配对令牌，验证通过后再加入情侣关系
+	if pairToken != "" && s.requireVerifiedEmail {
+		user.PendingPairToken = pairToken
+	}
 
 	if err := s.userRepo.Create(ctx, user); err != nil {
 		return nil, fmt.Errorf("创建用户失败: %w", err)
 	}
 
+	s.auditSvc.Record(ctx, AuditEntry{
+		ActorID:    user.ID,
+		Action:     models.AuditActionCreate,
+		EntityType: models.AuditEntityUser,
+		EntityID:   AuditEntityID(user.ID),
+		After:      user,
+	})
+
+	if pairToken != "" && !s.requireVerifiedEmail {
+		// 注册已经成功，配对失败（例如并发加入导致成员已满）只记录日志，用户可以之后通过邀请码配对
+		if err := s.joinByPairToken(ctx, user, pairToken); err != nil {
+			logger.FromContext(ctx).WithComponent("user_service").Error(err, "注册时配对失败", "user_id", user.ID)
+		}
+	}
+
 	// 生成并发送验证码
 	verificationCode := s.GenerateVerificationCode()
-	err = s.emailSvc.SendVerificationEmail(ctx, email, username, verificationCode)
+	err = s.emailSvc.SendVerificationEmail(ctx, emailRecipient(user), verificationCode)
 	if err != nil {
 		// 记录错误但不影响注册流程
 		fmt.Printf("发送验证邮件失败: %v", err)
@@ -134,6 +196,69 @@ func (s *userService) Register(ctx context.Context, username, email, password, p
 	return user, nil
 }
 
+// checkPairToken 检查配对令牌对应的情侣关系是否还能加入，令牌还没有被使用时可以加入
+func (s *userService) checkPairToken(ctx context.Context, pairToken string) error {
+	couple, err := s.coupleRepo.GetByPairToken(ctx, pairToken)
+	if err != nil {
+		if errors.Is(err, repository.ErrCoupleNotFound) {
+			return nil
+		}
+		return fmt.Errorf("查询情侣记录时发生错误: %w", err)
+	}
+	if couple.IsDissolving() {
+		return ErrCoupleDissolving
+	}
+	members, err := s.userRepo.ListByCoupleID(ctx, couple.ID)
+	if err != nil {
+		return fmt.Errorf("查询情侣成员失败: %w", err)
+	}
+	if len(members) >= repository.MaxCoupleMembers {
+		return ErrCoupleFull
+	}
+	return nil
+}
+
+// joinByPairToken 加入配对令牌对应的情侣关系，令牌还没有被使用时创建新的情侣关系。
+// 和邀请码一样通过 userRepo.JoinCouple 加入，由它在锁内检查成员数和关系状态
+func (s *userService) joinByPairToken(ctx context.Context, user *models.User, pairToken string) error {
+	couple, err := s.coupleRepo.GetByPairToken(ctx, pairToken)
+	if err != nil {
+		if !errors.Is(err, repository.ErrCoupleNotFound) {
+			return fmt.Errorf("查询情侣记录时发生错误: %w", err)
+		}
+		couple = &models.Couple{
+			PairToken:             pairToken,
+			AutoGenerateVideo:     true, // 默认开启
+			ReminderNotifications: true, // 默认开启
+		}
+		if err := s.coupleRepo.Create(ctx, couple); err != nil {
+			return fmt.Errorf("创建情侣关系失败: %w", err)
+		}
+	}
+
+	if err := s.userRepo.JoinCouple(ctx, user.ID, couple.ID); err != nil {
+		switch {
+		case errors.Is(err, repository.ErrCoupleFull):
+			return ErrCoupleFull
+		case errors.Is(err, repository.ErrUserAlreadyPaired):
+			return ErrAlreadyInCouple
+		case errors.Is(err, repository.ErrCoupleStatusConflict):
+			return ErrCoupleDissolving
+		}
+		return fmt.Errorf("加入情侣关系失败: %w", err)
+	}
+	user.CoupleID = couple.ID
+
+	s.auditSvc.Record(ctx, AuditEntry{
+		ActorID:    user.ID,
+		CoupleID:   couple.ID,
+		Action:     models.AuditActionCoupleJoin,
+		EntityType: models.AuditEntityCouple,
+		EntityID:   AuditEntityID(couple.ID),
+	})
+	return nil
+}
+
 // Login 用户登录
 func (s *userService) Login(ctx context.Context, email, password string) (*models.User, error) {
 	user, err := s.userRepo.GetByEmail(ctx, email)
@@ -149,6 +274,11 @@ func (s *userService) Login(ctx context.Context, email, password string) (*model
 		return nil, ErrInvalidPassword
 	}
 
+	// 密码正确后再提示账号已停用，避免泄露账号状态
+	if user.IsDisabled() {
+		return nil, ErrUserDisabled
+	}
+
 	return user, nil
 }
 
@@ -167,6 +297,11 @@ func (s *userService) LoginByUsername(ctx context.Context, username, password st
 		return nil, ErrInvalidPassword
 	}
 
+	// 密码正确后再提示账号已停用，避免泄露账号状态
+	if user.IsDisabled() {
+		return nil, ErrUserDisabled
+	}
+
 	return user, nil
 }
 
@@ -186,8 +321,25 @@ func (s *userService) UpdateUser(ctx context.Context, updateUserRequest *dto.Upd
 	if err != nil {
 		return err
 	}
+	// 邮箱需要验证新地址后才能修改，不允许直接改
+	if updateUserRequest.Email != "" && updateUserRequest.Email != user.Email {
+		return ErrEmailChangeNotAllowed
+	}
+	before := *user
 	updateUserRequest.ApplyUpdates(user)
-	return s.userRepo.Update(ctx, user)
+	if err := s.userRepo.Update(ctx, user, "username", "dark_mode", "language"); err != nil {
+		return err
+	}
+
+	s.auditSvc.Record(ctx, AuditEntry{
+		CoupleID:   user.CoupleID,
+		Action:     models.AuditActionUpdate,
+		EntityType: models.AuditEntityUser,
+		EntityID:   AuditEntityID(user.ID),
+		Before:     &before,
+		After:      user,
+	})
+	return nil
 }
 
 // UpdatePassword 更新用户密码
@@ -210,7 +362,18 @@ func (s *userService) UpdatePassword(ctx context.Context, dto *dto.UpdateUserPas
 
 	// 更新密码
 	user.PasswordHash = string(hashedPassword)
-	return s.userRepo.Update(ctx, user)
+	if err := s.userRepo.Update(ctx, user, "password_hash"); err != nil {
+		return err
+	}
+
+	s.auditSvc.Record(ctx, AuditEntry{
+		ActorID:    user.ID,
+		CoupleID:   user.CoupleID,
+		Action:     models.AuditActionPasswordChange,
+		EntityType: models.AuditEntityUser,
+		EntityID:   AuditEntityID(user.ID),
+	})
+	return nil
 }
 
 // DeleteUser 删除用户
@@ -266,7 +429,7 @@ func (s *userService) GetCoupleID(ctx context.Context, userID int64) (int64, err
 // VerifyEmail 验证用户邮箱
 func (s *userService) VerifyEmail(ctx context.Context, email, code string) error {
 	// 检查用户是否存在
-	_, err := s.userRepo.GetByEmail(ctx, email)
+	user, err := s.userRepo.GetByEmail(ctx, email)
 	if err != nil {
 		if errors.Is(err, repository.ErrUserNotFound) {
 			return ErrEmailNotFound
@@ -284,13 +447,46 @@ func (s *userService) VerifyEmail(ctx context.Context, email, code string) error
 		return ErrInvalidVerificationCode
 	}
 
+	// 已验证过的账号不重复记录，也不重复发送欢迎邮件
+	if user.IsEmailVerified() {
+		return nil
+	}
+
+	now := time.Now()
+	user.EmailVerifiedAt = &now
+	pendingPairToken := user.PendingPairToken
+	user.PendingPairToken = ""
+	if err := s.userRepo.Update(ctx, user, "email_verified_at", "pending_pair_token"); err != nil {
+		return fmt.Errorf("保存邮箱验证状态失败: %w", err)
+	}
+
+	s.auditSvc.Record(ctx, AuditEntry{
+		ActorID:    user.ID,
+		CoupleID:   user.CoupleID,
+		Action:     models.AuditActionEmailVerify,
+		EntityType: models.AuditEntityUser,
+		EntityID:   AuditEntityID(user.ID),
+	})
+
+	// 注册时提交的配对令牌在验证通过后生效，配对失败不影响验证结果，用户可以之后通过邀请码配对
+	if pendingPairToken != "" {
+		if err := s.joinByPairToken(ctx, user, pendingPairToken); err != nil {
+			logger.FromContext(ctx).WithComponent("user_service").Error(err, "验证邮箱后配对失败", "user_id", user.ID)
+		}
+	}
+
+	// 验证成功后发送欢迎邮件，发送失败不影响验证结果
+	if err := s.emailSvc.SendWelcomeEmail(ctx, emailRecipient(user)); err != nil {
+		logger.FromContext(ctx).WithComponent("user_service").Error(err, "发送欢迎邮件失败", "user_id", user.ID)
+	}
+
 	return nil
 }
 
 // ForgotPassword 处理忘记密码请求
 func (s *userService) ForgotPassword(ctx context.Context, email string) (string, error) {
 	// 检查用户是否存在
-	_, err := s.userRepo.GetByEmail(ctx, email)
+	user, err := s.userRepo.GetByEmail(ctx, email)
 	if err != nil {
 		if errors.Is(err, repository.ErrUserNotFound) {
 			return "", ErrEmailNotFound
@@ -302,7 +498,7 @@ func (s *userService) ForgotPassword(ctx context.Context, email string) (string,
 	resetToken := generateResetToken()
 
 	// 发送密码重置邮件
-	err = s.emailSvc.SendPasswordResetEmail(ctx, email, resetToken)
+	err = s.emailSvc.SendPasswordResetEmail(ctx, emailRecipient(user), resetToken)
 	if err != nil {
 		return "", fmt.Errorf("发送密码重置邮件失败: %w", err)
 	}
@@ -310,6 +506,138 @@ func (s *userService) ForgotPassword(ctx context.Context, email string) (string,
 	return resetToken, nil
 }
 
+// ResetPassword 使用一次性重置令牌设置新密码
+func (s *userService) ResetPassword(ctx context.Context, email, token, newPassword string) error {
+	user, err := s.userRepo.GetByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, repository.ErrUserNotFound) {
+			return ErrInvalidResetToken
+		}
+		return fmt.Errorf("查询用户时发生错误: %w", err)
+	}
+
+	// 验证重置令牌，验证成功后令牌即被消费
+	valid, err := s.emailSvc.VerifyPasswordResetToken(ctx, email, token)
+	if err != nil {
+		return fmt.Errorf("验证重置令牌时发生错误: %w", err)
+	}
+	if !valid {
+		return ErrInvalidResetToken
+	}
+
+	// 对新密码进行哈希
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return fmt.Errorf("密码哈希失败: %w", err)
+	}
+
+	user.PasswordHash = string(hashedPassword)
+	if err := s.userRepo.Update(ctx, user, "password_hash"); err != nil {
+		return fmt.Errorf("更新密码失败: %w", err)
+	}
+
+	s.auditSvc.Record(ctx, AuditEntry{
+		ActorID:    user.ID,
+		CoupleID:   user.CoupleID,
+		Action:     models.AuditActionPasswordReset,
+		EntityType: models.AuditEntityUser,
+		EntityID:   AuditEntityID(user.ID),
+	})
+
+	// 密码已重置，之前登录的设备全部下线
+	if err := s.sessionSvc.RevokeAll(ctx, user.ID); err != nil {
+		return fmt.Errorf("注销已登录设备失败: %w", err)
+	}
+
+	// 发送确认通知，通知失败不影响重置结果
+	message := "您的密码已重置成功，所有已登录的设备均已退出。如果这不是您本人的操作，请立即联系我们。"
+	if err := s.emailSvc.SendNotificationEmail(ctx, emailRecipient(user), message); err != nil {
+		logger.FromContext(ctx).WithComponent("user_service").Error(err, "发送密码重置确认邮件失败", "user_id", user.ID)
+	}
+
+	return nil
+}
+
+// RequestEmailChange 校验当前密码后向新邮箱发送验证码，确认前邮箱保持不变
+func (s *userService) RequestEmailChange(ctx context.Context, userID int64, newEmail, password string) error {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)); err != nil {
+		return ErrInvalidPassword
+	}
+	if newEmail == user.Email {
+		return ErrEmailUnchanged
+	}
+	if err := s.checkEmailAvailable(ctx, newEmail); err != nil {
+		return err
+	}
+
+	to := emailRecipient(user)
+	to.Address = newEmail
+	if err := s.emailSvc.SendVerificationEmail(ctx, to, s.GenerateVerificationCode()); err != nil {
+		return fmt.Errorf("发送验证码失败: %w", err)
+	}
+	return nil
+}
+
+// ConfirmEmailChange 使用新邮箱收到的验证码完成修改，新邮箱视为已验证，并通知旧邮箱
+func (s *userService) ConfirmEmailChange(ctx context.Context, userID int64, newEmail, code string) error {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if newEmail == user.Email {
+		return ErrEmailUnchanged
+	}
+
+	verified, err := s.emailSvc.VerifyCode(ctx, newEmail, code)
+	if err != nil {
+		return fmt.Errorf("验证验证码时发生错误: %w", err)
+	}
+	if !verified {
+		return ErrInvalidVerificationCode
+	}
+	// 申请之后邮箱可能已被其他账号注册
+	if err := s.checkEmailAvailable(ctx, newEmail); err != nil {
+		return err
+	}
+
+	before := *user
+	now := time.Now()
+	user.Email = newEmail
+	user.EmailVerifiedAt = &now
+	if err := s.userRepo.Update(ctx, user, "email", "email_verified_at"); err != nil {
+		return fmt.Errorf("更新邮箱失败: %w", err)
+	}
+
+	s.auditSvc.Record(ctx, AuditEntry{
+		ActorID:    user.ID,
+		CoupleID:   user.CoupleID,
+		Action:     models.AuditActionEmailChange,
+		EntityType: models.AuditEntityUser,
+		EntityID:   AuditEntityID(user.ID),
+		Before:     map[string]string{"email": before.Email},
+		After:      map[string]string{"email": user.Email},
+	})
+
+	s.notifySecurityChange(ctx, &before, fmt.Sprintf("您的账号邮箱已修改为 %s。如果这不是您本人的操作，请立即修改密码。", newEmail))
+	return nil
+}
+
+// checkEmailAvailable 检查邮箱是否未被其他账号使用
+func (s *userService) checkEmailAvailable(ctx context.Context, email string) error {
+	_, err := s.userRepo.GetByEmail(ctx, email)
+	if err == nil {
+		return ErrUserExists
+	}
+	if !errors.Is(err, repository.ErrUserNotFound) {
+		return fmt.Errorf("检查用户邮箱时发生错误: %w", err)
+	}
+	return nil
+}
+
 // GenerateVerificationCode 生成6位验证码
 func (s *userService) GenerateVerificationCode() string {
 	const codeLength = 6
@@ -344,7 +672,7 @@ func (s *userService) ResendVerificationCode(ctx context.Context, email string)
 	verificationCode := s.GenerateVerificationCode()
 
 	// 发送验证码
-	err = s.emailSvc.SendVerificationEmail(ctx, email, user.Username, verificationCode)
+	err = s.emailSvc.SendVerificationEmail(ctx, emailRecipient(user), verificationCode)
 	if err != nil {
 		return "", fmt.Errorf("发送验证码失败: %w", err)
 	}
@@ -361,3 +689,215 @@ func generateResetToken() string {
 	}
 	return hex.EncodeToString(b)
 }
+
+// GetMFAStatus 获取二次验证状态
+func (s *userService) GetMFAStatus(ctx context.Context, userID int64) (*MFAStatus, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &MFAStatus{Enabled: user.MFAEnabled}
+	if user.MFAEnabled {
+		if status.RecoveryCodesRemaining, err = s.recoveryRepo.CountUnused(ctx, userID); err != nil {
+			return nil, fmt.Errorf("查询恢复码失败: %w", err)
+		}
+	}
+	return status, nil
+}
+
+// EnrollTOTP 生成TOTP密钥，确认前不会生效
+func (s *userService) EnrollTOTP(ctx context.Context, userID int64) (*MFAEnrollment, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.MFAEnabled {
+		return nil, ErrMFAAlreadyEnabled
+	}
+
+	secret, err := generateTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	// 重新生成会覆盖之前未确认的密钥
+	user.TOTPSecret = secret
+	user.TOTPLastStep = 0
+	if err := s.userRepo.Update(ctx, user, "totp_secret", "totp_last_step"); err != nil {
+		return nil, fmt.Errorf("保存TOTP密钥失败: %w", err)
+	}
+
+	return &MFAEnrollment{
+		Secret:     secret,
+		OTPAuthURI: totpURI(user.Email, secret),
+	}, nil
+}
+
+// ConfirmTOTP 用第一个验证码确认绑定，返回明文恢复码（仅此一次）
+func (s *userService) ConfirmTOTP(ctx context.Context, userID int64, code string) ([]string, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.MFAEnabled {
+		return nil, ErrMFAAlreadyEnabled
+	}
+	if user.TOTPSecret == "" {
+		return nil, ErrMFANotEnrolled
+	}
+
+	step, ok := validateTOTP(user.TOTPSecret, code, time.Now(), user.TOTPLastStep)
+	if !ok {
+		return nil, ErrInvalidMFACode
+	}
+
+	recoveryCodes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	hashes := make([]string, 0, len(recoveryCodes))
+	for _, rc := range recoveryCodes {
+		hashes = append(hashes, hashRecoveryCode(rc))
+	}
+
+	// 先保存恢复码再开启二次验证，避免开启后没有可用的恢复码
+	if err := s.recoveryRepo.ReplaceForUser(ctx, userID, hashes); err != nil {
+		return nil, fmt.Errorf("保存恢复码失败: %w", err)
+	}
+
+	user.MFAEnabled = true
+	user.TOTPLastStep = step
+	if err := s.userRepo.Update(ctx, user, "mfa_enabled", "totp_last_step"); err != nil {
+		return nil, fmt.Errorf("开启二次验证失败: %w", err)
+	}
+
+	s.auditSvc.Record(ctx, AuditEntry{
+		ActorID:    user.ID,
+		CoupleID:   user.CoupleID,
+		Action:     models.AuditActionMFAEnable,
+		EntityType: models.AuditEntityUser,
+		EntityID:   AuditEntityID(user.ID),
+	})
+
+	s.notifySecurityChange(ctx, user, "您的账号已开启二次验证。如果这不是您本人的操作，请立即修改密码。")
+	return recoveryCodes, nil
+}
+
+// DisableTOTP 关闭二次验证，需要提供有效的验证码或恢复码
+func (s *userService) DisableTOTP(ctx context.Context, userID int64, code string) error {
+	if err := s.VerifyMFACode(ctx, userID, code); err != nil {
+		return err
+	}
+
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	user.MFAEnabled = false
+	user.TOTPSecret = ""
+	user.TOTPLastStep = 0
+	if err := s.userRepo.Update(ctx, user, "mfa_enabled", "totp_secret", "totp_last_step"); err != nil {
+		return fmt.Errorf("关闭二次验证失败: %w", err)
+	}
+	if err := s.recoveryRepo.DeleteByUserID(ctx, userID); err != nil {
+		return fmt.Errorf("删除恢复码失败: %w", err)
+	}
+
+	s.auditSvc.Record(ctx, AuditEntry{
+		ActorID:    user.ID,
+		CoupleID:   user.CoupleID,
+		Action:     models.AuditActionMFADisable,
+		EntityType: models.AuditEntityUser,
+		EntityID:   AuditEntityID(user.ID),
+	})
+
+	s.notifySecurityChange(ctx, user, "您的账号已关闭二次验证。如果这不是您本人的操作，请立即修改密码。")
+	return nil
+}
+
+// VerifyMFACode 校验TOTP验证码或恢复码
+func (s *userService) VerifyMFACode(ctx context.Context, userID int64, code string) error {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user.IsDisabled() {
+		return ErrUserDisabled
+	}
+	if !user.MFAEnabled {
+		return ErrMFANotEnabled
+	}
+
+	if step, ok := validateTOTP(user.TOTPSecret, code, time.Now(), user.TOTPLastStep); ok {
+		// 记录已使用的时间步，同一个验证码不能再次使用
+		advanced, err := s.userRepo.AdvanceTOTPStep(ctx, userID, step)
+		if err != nil {
+			return fmt.Errorf("更新二次验证状态失败: %w", err)
+		}
+		if !advanced {
+			return ErrInvalidMFACode
+		}
+		return nil
+	}
+
+	// 不是有效的TOTP验证码时尝试作为恢复码使用
+	used, err := s.recoveryRepo.Consume(ctx, userID, hashRecoveryCode(code))
+	if err != nil {
+		return fmt.Errorf("校验恢复码失败: %w", err)
+	}
+	if !used {
+		return ErrInvalidMFACode
+	}
+	return nil
+}
+
+// ListUsers 分页获取全部用户（管理员）
+func (s *userService) ListUsers(ctx context.Context, offset, limit int) ([]*models.User, int64, error) {
+	return s.userRepo.List(ctx, offset, limit)
+}
+
+// DisableUser 停用账号，并注销全部会话和访问令牌（管理员）
+func (s *userService) DisableUser(ctx context.Context, userID int64) error {
+	now := time.Now()
+	if err := s.userRepo.SetDisabledAt(ctx, userID, &now); err != nil {
+		return err
+	}
+
+	// 已签发的访问令牌依赖会话校验，注销会话后立即失效
+	if err := s.sessionSvc.RevokeAll(ctx, userID); err != nil {
+		return fmt.Errorf("注销用户会话失败: %w", err)
+	}
+	if err := s.tokenRepo.RevokeAllByUserID(ctx, userID); err != nil {
+		return fmt.Errorf("撤销访问令牌失败: %w", err)
+	}
+
+	s.auditSvc.Record(ctx, AuditEntry{
+		Action:     models.AuditActionUserDisable,
+		EntityType: models.AuditEntityUser,
+		EntityID:   AuditEntityID(userID),
+	})
+	return nil
+}
+
+// EnableUser 恢复启用账号（管理员）
+func (s *userService) EnableUser(ctx context.Context, userID int64) error {
+	if err := s.userRepo.SetDisabledAt(ctx, userID, nil); err != nil {
+		return err
+	}
+
+	s.auditSvc.Record(ctx, AuditEntry{
+		Action:     models.AuditActionUserEnable,
+		EntityType: models.AuditEntityUser,
+		EntityID:   AuditEntityID(userID),
+	})
+	return nil
+}
+
+// notifySecurityChange 发送账号安全变更通知，发送失败只记录日志
+func (s *userService) notifySecurityChange(ctx context.Context, user *models.User, message string) {
+	if err := s.emailSvc.SendNotificationEmail(ctx, emailRecipient(user), message); err != nil {
+		logger.FromContext(ctx).WithComponent("user_service").Error(err, "发送安全通知邮件失败", "user_id", user.ID)
+	}
+}
@@ -8,29 +8,33 @@ import (
 
 	"memoir-api/internal/models"
 	"memoir-api/internal/repository"
+	"time"
 )
 
 var (
-	ErrWishlistNotFound = errors.New("心愿不存在")
+	ErrWishlistNotFound = resourceNotFound("心愿不存在")
 )
 
 // WishlistService 心愿清单服务接口
 type WishlistService interface {
 	Service
-	CreateWishlist(ctx context.Context, wishlistDTO *dto.CreateWishlistRequest) (*models.Wishlist, error)
-	GetWishlistByID(ctx context.Context, id int64) (*models.Wishlist, error)
+	// CreateWishlist 创建心愿，关联的附件必须是 userID 本人或同一情侣空间的
+	CreateWishlist(ctx context.Context, userID int64, wishlistDTO *dto.CreateWishlistRequest) (*models.Wishlist, error)
+	// GetWishlistByID 获取情侣的心愿，不属于该情侣时返回 ErrWishlistNotFound
+	GetWishlistByID(ctx context.Context, coupleID, id int64) (*models.Wishlist, error)
 	ListWishlistsByCoupleID(ctx context.Context, coupleID int64) ([]dto.WishlistDTO, error)
 	ListWishlistsByStatus(ctx context.Context, coupleID int64, status string) ([]*models.Wishlist, error)
 	ListWishlistsByPriority(ctx context.Context, coupleID int64, priority int) ([]*models.Wishlist, error)
-	ListUpcomingReminders(ctx context.Context, daysAhead int) ([]*models.Wishlist, error)
-	UpdateWishlist(ctx context.Context, wishlist *models.Wishlist) error
-	UpdateWishlistStatus(ctx context.Context, id int64, status string) error
-	DeleteWishlist(ctx context.Context, id int64) error
-	UpdateWishlistByRequest(ctx context.Context, req *dto.UpdateWishlistRequest) (*models.Wishlist, error)
+	// ListUpcomingReminders 获取情侣提醒日期在 from 当天到之后 daysAhead 天之间的未完成心愿
+	ListUpcomingReminders(ctx context.Context, coupleID int64, from time.Time, daysAhead int) ([]*models.Wishlist, error)
+	UpdateWishlist(ctx context.Context, coupleID int64, wishlist *models.Wishlist) error
+	UpdateWishlistStatus(ctx context.Context, coupleID, id int64, status string) error
+	DeleteWishlist(ctx context.Context, coupleID, id int64) error
+	UpdateWishlistByRequest(ctx context.Context, userID, coupleID int64, req *dto.UpdateWishlistRequest) (*models.Wishlist, error)
 
 	// 附件关联管理
-	AssociateAttachments(ctx context.Context, wishlistID int64, attachmentIDs []int64) error
-	RemoveAttachment(ctx context.Context, wishlistID int64, attachmentID int64) error
+	AssociateAttachments(ctx context.Context, userID, coupleID, wishlistID int64, attachmentIDs []int64) error
+	RemoveAttachment(ctx context.Context, coupleID, wishlistID int64, attachmentID int64) error
 	GetAttachments(ctx context.Context, wishlistID int64) ([]models.Attachment, error)
 }
 
@@ -40,6 +44,7 @@ type wishlistService struct {
 	wishlistRepo           repository.WishlistRepository
 	wishlistAttachmentRepo repository.WishlistAttachmentRepository
 	attachmentRepo         repository.AttachmentRepository
+	auditSvc               AuditService
 }
 
 // NewWishlistService 创建心愿清单服务
@@ -47,38 +52,52 @@ func NewWishlistService(
 	wishlistRepo repository.WishlistRepository,
 	wishlistAttachmentRepo repository.WishlistAttachmentRepository,
 	attachmentRepo repository.AttachmentRepository,
+	auditSvc AuditService,
 ) WishlistService {
 	return &wishlistService{
 		BaseService:            NewBaseService(wishlistRepo),
 		wishlistRepo:           wishlistRepo,
 		wishlistAttachmentRepo: wishlistAttachmentRepo,
 		attachmentRepo:         attachmentRepo,
+		auditSvc:               auditSvc,
 	}
 }
 
 // CreateWishlist 创建心愿
-func (s *wishlistService) CreateWishlist(ctx context.Context, wishlistDTO *dto.CreateWishlistRequest) (*models.Wishlist, error) {
+func (s *wishlistService) CreateWishlist(ctx context.Context, userID int64, wishlistDTO *dto.CreateWishlistRequest) (*models.Wishlist, error) {
 	wishlist, err := wishlistDTO.ToModel()
 	if err != nil {
 		return nil, err
 	}
+	if err := s.checkAttachments(ctx, userID, wishlist.CoupleID, wishlistDTO.AttachmentIDs); err != nil {
+		return nil, err
+	}
 	if err := s.wishlistRepo.Create(ctx, wishlist); err != nil {
 		return nil, fmt.Errorf("创建心愿失败: %w", err)
 	}
 
 	// 处理附件关联
 	if wishlistDTO.AttachmentIDs != nil && len(wishlistDTO.AttachmentIDs) > 0 {
-		if err := s.AssociateAttachments(ctx, wishlist.ID, wishlistDTO.AttachmentIDs); err != nil {
+		if err := s.associateAttachments(ctx, wishlist.ID, wishlistDTO.AttachmentIDs); err != nil {
 			return nil, fmt.Errorf("关联附件失败: %w", err)
 		}
 	}
 
+	after := wishlistAuditState(wishlist)
+	after["attachment_ids"] = []int64(wishlistDTO.AttachmentIDs)
+	s.auditSvc.Record(ctx, AuditEntry{
+		CoupleID:   wishlist.CoupleID,
+		Action:     models.AuditActionCreate,
+		EntityType: models.AuditEntityWishlist,
+		EntityID:   AuditEntityID(wishlist.ID),
+		After:      after,
+	})
 	return wishlist, nil
 }
 
-// GetWishlistByID 通过ID获取心愿
-func (s *wishlistService) GetWishlistByID(ctx context.Context, id int64) (*models.Wishlist, error) {
-	wishlist, err := s.wishlistRepo.GetByID(ctx, id)
+// GetWishlistByID 通过ID获取情侣的心愿
+func (s *wishlistService) GetWishlistByID(ctx context.Context, coupleID, id int64) (*models.Wishlist, error) {
+	wishlist, err := s.wishlistRepo.GetByIDInCouple(ctx, coupleID, id)
 	if err != nil {
 		if errors.Is(err, repository.ErrWishlistNotFound) {
 			return nil, ErrWishlistNotFound
@@ -130,35 +149,48 @@ func (s *wishlistService) ListWishlistsByPriority(ctx context.Context, coupleID
 }
 
 // ListUpcomingReminders 获取即将到期的提醒
-func (s *wishlistService) ListUpcomingReminders(ctx context.Context, daysAhead int) ([]*models.Wishlist, error) {
-	return s.wishlistRepo.ListUpcomingReminders(ctx, daysAhead)
+func (s *wishlistService) ListUpcomingReminders(ctx context.Context, coupleID int64, from time.Time, daysAhead int) ([]*models.Wishlist, error) {
+	return s.wishlistRepo.ListUpcomingReminders(ctx, coupleID, from, daysAhead)
 }
 
 // UpdateWishlist 更新心愿
-func (s *wishlistService) UpdateWishlist(ctx context.Context, wishlist *models.Wishlist) error {
-	// 检查心愿是否存在
-	_, err := s.wishlistRepo.GetByID(ctx, wishlist.ID)
+func (s *wishlistService) UpdateWishlist(ctx context.Context, coupleID int64, wishlist *models.Wishlist) error {
+	// 检查心愿是否存在且属于该情侣
+	existing, err := s.wishlistRepo.GetByIDInCouple(ctx, coupleID, wishlist.ID)
 	if err != nil {
 		if errors.Is(err, repository.ErrWishlistNotFound) {
 			return ErrWishlistNotFound
 		}
 		return fmt.Errorf("查询心愿失败: %w", err)
 	}
+	wishlist.CoupleID = existing.CoupleID
 
 	// 更新心愿
 	if err := s.wishlistRepo.Update(ctx, wishlist); err != nil {
 		return fmt.Errorf("更新心愿失败: %w", err)
 	}
+
+	s.auditSvc.Record(ctx, AuditEntry{
+		CoupleID:   existing.CoupleID,
+		Action:     models.AuditActionUpdate,
+		EntityType: models.AuditEntityWishlist,
+		EntityID:   AuditEntityID(wishlist.ID),
+		Before:     wishlistAuditState(existing),
+		After:      wishlistAuditState(wishlist),
+	})
 	return nil
 }
 
 // UpdateWishlistByRequest 根据请求更新心愿
-func (s *wishlistService) UpdateWishlistByRequest(ctx context.Context, req *dto.UpdateWishlistRequest) (*models.Wishlist, error) {
+func (s *wishlistService) UpdateWishlistByRequest(ctx context.Context, userID, coupleID int64, req *dto.UpdateWishlistRequest) (*models.Wishlist, error) {
 	// 获取现有的心愿项
-	existingWishlist, err := s.GetWishlistByID(ctx, req.ID)
+	existingWishlist, err := s.GetWishlistByID(ctx, coupleID, req.ID)
 	if err != nil {
 		return nil, err // GetWishlistByID 已经处理了错误包装
 	}
+	if err := s.checkAttachments(ctx, userID, coupleID, req.AttachmentIDs); err != nil {
+		return nil, err
+	}
 
 	// 将请求中的更新应用到现有心愿项
 	if err := req.ApplyToModel(existingWishlist); err != nil {
@@ -166,13 +198,13 @@ func (s *wishlistService) UpdateWishlistByRequest(ctx context.Context, req *dto.
 	}
 
 	// 调用更新方法
-	if err := s.UpdateWishlist(ctx, existingWishlist); err != nil {
+	if err := s.UpdateWishlist(ctx, coupleID, existingWishlist); err != nil {
 		return nil, err // UpdateWishlist 已经处理了错误包装
 	}
 
 	// 处理附件关联
 	if req.AttachmentIDs != nil {
-		if err := s.AssociateAttachments(ctx, existingWishlist.ID, req.AttachmentIDs); err != nil {
+		if err := s.AssociateAttachments(ctx, userID, coupleID, existingWishlist.ID, req.AttachmentIDs); err != nil {
 			return nil, fmt.Errorf("关联附件失败: %w", err)
 		}
 	}
@@ -181,9 +213,9 @@ func (s *wishlistService) UpdateWishlistByRequest(ctx context.Context, req *dto.
 }
 
 // UpdateWishlistStatus 更新心愿状态
-func (s *wishlistService) UpdateWishlistStatus(ctx context.Context, id int64, status string) error {
-	// 检查心愿是否存在
-	_, err := s.wishlistRepo.GetByID(ctx, id)
+func (s *wishlistService) UpdateWishlistStatus(ctx context.Context, coupleID, id int64, status string) error {
+	// 检查心愿是否存在且属于该情侣
+	existing, err := s.wishlistRepo.GetByIDInCouple(ctx, coupleID, id)
 	if err != nil {
 		if errors.Is(err, repository.ErrWishlistNotFound) {
 			return ErrWishlistNotFound
@@ -195,13 +227,22 @@ func (s *wishlistService) UpdateWishlistStatus(ctx context.Context, id int64, st
 	if err := s.wishlistRepo.UpdateStatus(ctx, id, status); err != nil {
 		return fmt.Errorf("更新心愿状态失败: %w", err)
 	}
+
+	s.auditSvc.Record(ctx, AuditEntry{
+		CoupleID:   existing.CoupleID,
+		Action:     models.AuditActionUpdate,
+		EntityType: models.AuditEntityWishlist,
+		EntityID:   AuditEntityID(id),
+		Before:     map[string]string{"status": existing.Status},
+		After:      map[string]string{"status": status},
+	})
 	return nil
 }
 
 // DeleteWishlist 删除心愿
-func (s *wishlistService) DeleteWishlist(ctx context.Context, id int64) error {
-	// 检查心愿是否存在
-	_, err := s.wishlistRepo.GetByID(ctx, id)
+func (s *wishlistService) DeleteWishlist(ctx context.Context, coupleID, id int64) error {
+	// 检查心愿是否存在且属于该情侣
+	existing, err := s.wishlistRepo.GetByIDInCouple(ctx, coupleID, id)
 	if err != nil {
 		if errors.Is(err, repository.ErrWishlistNotFound) {
 			return ErrWishlistNotFound
@@ -213,16 +254,65 @@ func (s *wishlistService) DeleteWishlist(ctx context.Context, id int64) error {
 	if err := s.wishlistRepo.Delete(ctx, id); err != nil {
 		return fmt.Errorf("删除心愿失败: %w", err)
 	}
+
+	s.auditSvc.Record(ctx, AuditEntry{
+		CoupleID:   existing.CoupleID,
+		Action:     models.AuditActionDelete,
+		EntityType: models.AuditEntityWishlist,
+		EntityID:   AuditEntityID(id),
+		Before:     wishlistAuditState(existing),
+	})
 	return nil
 }
 
 // AssociateAttachments 将附件关联到心愿清单
-func (s *wishlistService) AssociateAttachments(ctx context.Context, wishlistID int64, attachmentIDs []int64) error {
+func (s *wishlistService) AssociateAttachments(ctx context.Context, userID, coupleID, wishlistID int64, attachmentIDs []int64) error {
 	// 首先验证心愿是否存在
-	_, err := s.GetWishlistByID(ctx, wishlistID)
+	wishlist, err := s.GetWishlistByID(ctx, coupleID, wishlistID)
 	if err != nil {
 		return err // GetWishlistByID 已经包装了错误
 	}
+	if err := s.checkAttachments(ctx, userID, coupleID, attachmentIDs); err != nil {
+		return err
+	}
+
+	if err := s.associateAttachments(ctx, wishlistID, attachmentIDs); err != nil {
+		return err
+	}
+
+	beforeIDs := make([]int64, 0, len(wishlist.Attachments))
+	for _, attachment := range wishlist.Attachments {
+		beforeIDs = append(beforeIDs, attachment.ID)
+	}
+	s.auditSvc.Record(ctx, AuditEntry{
+		CoupleID:   wishlist.CoupleID,
+		Action:     models.AuditActionUpdate,
+		EntityType: models.AuditEntityWishlist,
+		EntityID:   AuditEntityID(wishlistID),
+		Before:     map[string][]int64{"attachment_ids": beforeIDs},
+		After:      map[string][]int64{"attachment_ids": attachmentIDs},
+	})
+	return nil
+}
+
+// checkAttachments 校验附件都是用户本人或同一情侣空间的，必须在修改关联之前调用
+func (s *wishlistService) checkAttachments(ctx context.Context, userID, coupleID int64, attachmentIDs []int64) error {
+	ids := uniqueIDs(attachmentIDs)
+	if len(ids) == 0 {
+		return nil
+	}
+	attachments, err := s.attachmentRepo.FindAccessibleByIDs(ctx, userID, coupleID, ids)
+	if err != nil {
+		return fmt.Errorf("查询附件失败: %w", err)
+	}
+	if len(attachments) != len(ids) {
+		return ErrAttachmentNotFound
+	}
+	return nil
+}
+
+// associateAttachments 替换心愿清单的附件关联，调用前需要先通过 checkAttachments 校验归属
+func (s *wishlistService) associateAttachments(ctx context.Context, wishlistID int64, attachmentIDs []int64) error {
 
 	// 删除现有关联
 	if err := s.wishlistAttachmentRepo.DeleteByWishlistID(ctx, wishlistID); err != nil {
@@ -230,13 +320,7 @@ func (s *wishlistService) AssociateAttachments(ctx context.Context, wishlistID i
 	}
 
 	// 创建新的关联
-	for _, attachmentID := range attachmentIDs {
-		// 验证附件是否存在
-		_, err := s.attachmentRepo.GetByID(ctx, attachmentID)
-		if err != nil {
-			return fmt.Errorf("附件 %d 不存在: %w", attachmentID, err)
-		}
-
+	for _, attachmentID := range uniqueIDs(attachmentIDs) {
 		// 创建关联
 		wishlistAttachment := &models.WishlistAttachment{
 			WishlistID:   wishlistID,
@@ -251,9 +335,9 @@ func (s *wishlistService) AssociateAttachments(ctx context.Context, wishlistID i
 }
 
 // RemoveAttachment 从心愿清单中移除单个附件
-func (s *wishlistService) RemoveAttachment(ctx context.Context, wishlistID int64, attachmentID int64) error {
+func (s *wishlistService) RemoveAttachment(ctx context.Context, coupleID, wishlistID int64, attachmentID int64) error {
 	// 首先验证心愿是否存在
-	_, err := s.GetWishlistByID(ctx, wishlistID)
+	_, err := s.GetWishlistByID(ctx, coupleID, wishlistID)
 	if err != nil {
 		return err // GetWishlistByID 已经包装了错误
 	}
@@ -270,3 +354,15 @@ func (s *wishlistService) RemoveAttachment(ctx context.Context, wishlistID int64
 func (s *wishlistService) GetAttachments(ctx context.Context, wishlistID int64) ([]models.Attachment, error) {
 	return s.wishlistRepo.GetAttachments(ctx, wishlistID)
 }
+
+// wishlistAuditState 审计日志中记录的心愿状态，附件关联单独记录
+func wishlistAuditState(wishlist *models.Wishlist) map[string]interface{} {
+	return map[string]interface{}{
+		"title":         wishlist.Title,
+		"description":   wishlist.Description,
+		"priority":      wishlist.Priority,
+		"status":        wishlist.Status,
+		"type":          wishlist.Type,
+		"reminder_date": wishlist.ReminderDate,
+	}
+}